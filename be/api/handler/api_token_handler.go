@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	service "BE_Manage_device/internal/service/api_token"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ApiTokenHandler struct {
+	service *service.ApiTokenService
+}
+
+func NewApiTokenHandler(service *service.ApiTokenService) *ApiTokenHandler {
+	return &ApiTokenHandler{service: service}
+}
+
+func parseApiTokenId(c *gin.Context) int64 {
+	tokenId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert tokenId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert tokenId to int64")
+	}
+	return tokenId
+}
+
+// ApiToken godoc
+// @Summary      Create API token
+// @Description  Create a personal access token with an expiry and a subset of the role's permissions. The token is only returned once
+// @Tags         ApiToken
+// @Accept       json
+// @Produce      json
+// @Param        token   body    dto.CreateApiTokenRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/api-tokens [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *ApiTokenHandler) Create(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.CreateApiTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	token, rawToken, err := h.service.Create(auth, request.Name, request.Scopes, request.ExpiresInDays)
+	if err != nil {
+		log.Error("Happened error when create api token. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when create api token. Error: "+err.Error())
+	}
+	res := dto.CreateApiTokenResponse{ApiTokenResponse: utils.ConvertApiTokenToResponse(token), Token: rawToken}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, res))
+}
+
+// ApiToken godoc
+// @Summary      Get my API tokens
+// @Description  Get the API tokens of the current user
+// @Tags         ApiToken
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/api-tokens [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *ApiTokenHandler) GetMyTokens(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	tokens, err := h.service.GetMyTokens(userId)
+	if err != nil {
+		log.Error("Happened error when get api tokens. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get api tokens")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertApiTokensToResponses(tokens)))
+}
+
+// ApiToken godoc
+// @Summary      Revoke my API token
+// @Description  Revoke one API token of the current user
+// @Tags         ApiToken
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/api-tokens/{id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *ApiTokenHandler) RevokeMyToken(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	tokenId := parseApiTokenId(c)
+	if err := h.service.RevokeMyToken(auth, tokenId); err != nil {
+		log.Error("Happened error when revoke api token. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when revoke api token. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// ApiToken godoc
+// @Summary      Get company API tokens
+// @Description  Admin view of the API tokens of every user in the company
+// @Tags         ApiToken
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/api-tokens [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *ApiTokenHandler) GetCompanyTokens(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	tokens, err := h.service.GetCompanyTokens(auth)
+	if err != nil {
+		log.Error("Happened error when get api tokens. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get api tokens")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertApiTokensToResponses(tokens)))
+}
+
+// ApiToken godoc
+// @Summary      Revoke company API token
+// @Description  Admin revokes any API token of the company
+// @Tags         ApiToken
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/api-tokens/{id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *ApiTokenHandler) RevokeCompanyToken(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	tokenId := parseApiTokenId(c)
+	if err := h.service.RevokeCompanyToken(auth, tokenId); err != nil {
+		log.Error("Happened error when revoke api token. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when revoke api token. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
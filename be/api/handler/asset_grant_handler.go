@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Asset godoc
+// @Summary Get asset grants
+// @Description Get the explicit per-asset permission grants
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router /api/assets/{id}/grants [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetsHandler) GetAssetGrants(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert assetId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert assetId to int64")
+	}
+	grants, err := h.service.GetAssetGrants(userId, assetId)
+	if err != nil {
+		log.Error("Happened error when get asset grants. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset grants. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetGrantsToResponses(grants)))
+}
+
+// Asset godoc
+// @Summary Create asset grant
+// @Description Grant a user a permission (default view-assets) on one asset
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Param		id	path		string				true	"id"
+// @Param        grant   body    dto.CreateAssetGrantRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router /api/assets/{id}/grants [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetsHandler) CreateAssetGrant(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert assetId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert assetId to int64")
+	}
+	var request dto.CreateAssetGrantRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	grants, err := h.service.CreateAssetGrant(userId, assetId, request.UserId, request.Permission)
+	if err != nil {
+		log.Error("Happened error when create asset grant. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when create asset grant. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetGrantsToResponses(grants)))
+}
+
+// Asset godoc
+// @Summary Delete asset grant
+// @Description Remove an explicit per-asset permission grant
+// @Tags Assets
+// @Accept json
+// @Produce json
+// @Param		id	path		string				true	"id"
+// @Param		grant_id	path		string				true	"grant_id"
+// @param Authorization header string true "Authorization"
+// @Router /api/assets/{id}/grants/{grant_id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetsHandler) DeleteAssetGrant(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert assetId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert assetId to int64")
+	}
+	grantId, err := strconv.ParseInt(c.Param("grant_id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert grantId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert grantId to int64")
+	}
+	if err := h.service.DeleteAssetGrant(userId, assetId, grantId); err != nil {
+		log.Error("Happened error when delete asset grant. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when delete asset grant. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
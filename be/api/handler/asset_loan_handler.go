@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/filter"
+	service "BE_Manage_device/internal/service/asset_loan"
+
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type AssetLoanHandler struct {
+	service *service.AssetLoanService
+}
+
+func NewAssetLoanHandler(service *service.AssetLoanService) *AssetLoanHandler {
+	return &AssetLoanHandler{service: service}
+}
+
+// AssetLoan godoc
+// @Summary      Check out asset
+// @Description  Lend an asset to a borrower until the due date
+// @Tags         AssetLoans
+// @Accept       json
+// @Produce      json
+// @Param        loan   body    dto.CheckOutAssetRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/asset-loans/check-out [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetLoanHandler) CheckOut(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.CheckOutAssetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	loan, err := h.service.CheckOut(auth, request.AssetId, request.BorrowerId, request.DueDate, request.Condition, request.Note)
+	if err != nil {
+		log.Error("Happened error when check out asset. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when check out asset: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetLoanToResponse(loan)))
+}
+
+// AssetLoan godoc
+// @Summary      Check in asset
+// @Description  Return a borrowed asset and restore the previous owner. Only asset managers of the asset department can check in
+// @Tags         AssetLoans
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"loan id"
+// @Param        loan   body    dto.CheckInAssetRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/asset-loans/{id}/check-in [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetLoanHandler) CheckIn(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	idStr := c.Param("id")
+	loanId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert loan id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert loan id to int64")
+	}
+	var request dto.CheckInAssetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	loan, err := h.service.CheckIn(auth, loanId, request.Condition)
+	if err != nil {
+		log.Error("Happened error when check in asset. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when check in asset: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetLoanToResponse(loan)))
+}
+
+// AssetLoan godoc
+// @Summary      Get asset loans with filter
+// @Description  Get asset loans with filter
+// @Tags         AssetLoans
+// @Accept       json
+// @Produce      json
+// @Param        loan   query    filter.AssetLoanFilter   false  "filter loan"
+// @param Authorization header string true "Authorization"
+// @Router       /api/asset-loans [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetLoanHandler) FilterAssetLoans(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var loanFilter filter.AssetLoanFilter
+	if err := c.ShouldBindQuery(&loanFilter); err != nil {
+		log.Error("Happened error when mapping query to filter. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping query to filter")
+	}
+	loans, err := h.service.Filter(auth, loanFilter)
+	if err != nil {
+		log.Error("Happened error when filter asset loans. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when filter asset loans.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetLoansToResponses(loans)))
+}
+
+// AssetLoan godoc
+// @Summary      Get asset loan
+// @Description  Get asset loan
+// @Tags         AssetLoans
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"loan id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/asset-loans/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetLoanHandler) GetAssetLoanById(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	idStr := c.Param("id")
+	loanId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert loan id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert loan id to int64")
+	}
+	loan, err := h.service.GetLoanById(auth, loanId)
+	if err != nil {
+		log.Error("Happened error when get asset loan. Error", err)
+		pkg.PanicExeption(constant.DataNotFound, "Happened error when get asset loan.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertAssetLoanToResponse(loan)))
+}
@@ -38,6 +38,7 @@ func NewAssetLogHandler(service *service.AssetLogService) *AssetLogHandler {
 func (h *AssetLogHandler) GetLogByAssetId(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	id := c.Param("id")
 	assetId, err := strconv.ParseInt(id, 10, 64)
 	if err != nil {
@@ -54,12 +55,12 @@ func (h *AssetLogHandler) GetLogByAssetId(c *gin.Context) {
 		log.Error("Happened error when get asset by id. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset by id")
 	}
-	err = h.service.CheckPermissionForManager(userId, asset.DepartmentId)
+	err = h.service.CheckPermissionForManager(auth, asset.DepartmentId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 		return
 	}
-	assetLogs, err := h.service.Filter(userId, assetId, filter.Action, filter.StartTime, filter.EndTime)
+	assetLogs, err := h.service.Filter(auth, assetId, filter.Action, filter.StartTime, filter.EndTime)
 	if err != nil {
 		log.Error("Happened error when get asset log. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset log")
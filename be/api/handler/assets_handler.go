@@ -6,12 +6,16 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
+	"BE_Manage_device/internal/domain/policy"
 	service "BE_Manage_device/internal/service/asset"
+	vendorS "BE_Manage_device/internal/service/vendor"
 
 	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/storage"
 	"BE_Manage_device/pkg/utils"
 	"bytes"
 	"encoding/csv"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -49,6 +53,7 @@ func NewAssetsHandler(service *service.AssetsService) *AssetsHandler {
 // @Param categoryId formData int64 true "Category ID"
 // @Param departmentId formData int64 true "Department ID"
 // @Param redirectUrl formData string true "redirect url"
+// @Param vendorId formData int64 false "Vendor ID (purchased from)"
 // @Param file formData file true "File to upload"
 // @Param image formData file true "Image to upload"
 // @param Authorization header string true "Authorization"
@@ -61,6 +66,7 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 
 	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 
 	assetName := c.PostForm("assetName")
 	purchaseDateStr := c.PostForm("purchaseDate")
@@ -70,6 +76,10 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 	categoryIdStr := c.PostForm("categoryId")
 	departmentIdStr := c.PostForm("departmentId")
 	url := c.PostForm("redirectUrl")
+	vendorId, err := utils.ParseOptionalInt64(c.PostForm("vendorId"))
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid vendor_id format")
+	}
 
 	purchaseDate, err := time.Parse(time.RFC3339, purchaseDateStr)
 	if err != nil {
@@ -111,7 +121,7 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 		return
 	}
 
-	err = h.service.CheckPermissionForManager(userId, departmentId)
+	err = h.service.CheckPermissionForManager(auth, departmentId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 		return
@@ -129,20 +139,23 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 		departmentId,
 		url,
 		cost,
+		vendorId,
 	)
-
+	if errors.Is(err, vendorS.ErrVendorNotFound) {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
 	if err != nil {
 		log.Error("Failed to create asset. Error", err.Error())
 		pkg.PanicExeption(constant.InvalidRequest, "Failed to create asset")
 	}
-	asset, err := h.service.GetAssetById(userId, assetCreate.Id)
+	asset, err := h.service.GetAssetById(auth, assetCreate.Id)
 	if err != nil {
 		log.Error("Happened error when get asset by id. Error", err.Error())
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset by id")
 	}
 	var qrURL string
 	if asset.QrUrl != nil {
-		qrURL = *asset.QrUrl
+		qrURL = storage.FileURL(*asset.QrUrl)
 	}
 
 	assetResponse := dto.AssetResponse{
@@ -153,9 +166,10 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 		WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 		Status:         asset.Status,
 		SerialNumber:   asset.SerialNumber,
-		FileAttachment: *asset.FileAttachment,
-		ImageUpload:    *asset.ImageUpload,
+		FileAttachment: storage.FileURL(*asset.FileAttachment),
+		ImageUpload:    storage.FileURL(*asset.ImageUpload),
 		QrURL:          qrURL,
+		VendorId:       asset.VendorId,
 		Category: dto.CategoryResponse{
 			ID:           asset.Category.Id,
 			CategoryName: asset.Category.CategoryName,
@@ -194,6 +208,7 @@ func (h *AssetsHandler) Create(c *gin.Context) {
 // @Param warrantExpiry formData string true "Warranty Expiry (RFC3339 format, e.g. 2023-12-31T23:59:59Z)"
 // @Param serialNumber formData string true "Serial Number"
 // @Param categoryId formData int64 true "Category ID"
+// @Param vendorId formData int64 false "Vendor ID (purchased from), empty keeps the current vendor"
 // @Param file formData file true "File to upload"
 // @Param image formData file true "Image to upload"
 // @param Authorization header string true "Authorization"
@@ -212,6 +227,7 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 	}
 
 	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 
 	assetName := c.PostForm("assetName")
 	purchaseDateStr := c.PostForm("purchaseDate")
@@ -219,6 +235,10 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 	warrantExpiryStr := c.PostForm("warrantExpiry")
 	serialNumber := c.PostForm("serialNumber")
 	categoryIdStr := c.PostForm("categoryId")
+	vendorId, err := utils.ParseOptionalInt64(c.PostForm("vendorId"))
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid vendor_id format")
+	}
 
 	purchaseDate, err := time.Parse(time.RFC3339, purchaseDateStr)
 	if err != nil {
@@ -252,11 +272,11 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 		pkg.PanicExeption(constant.InvalidRequest, "Image upload missing")
 		return
 	}
-	assetCheck, err := h.service.GetAssetById(userId, assetId)
+	assetCheck, err := h.service.GetAssetById(auth, assetId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, "Failed to update asset")
 	}
-	err = h.service.CheckPermissionForManager(userId, assetCheck.DepartmentId)
+	err = h.service.CheckPermissionForManager(auth, assetCheck.DepartmentId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 		return
@@ -272,11 +292,12 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 		file,
 		categoryId,
 		cost,
+		vendorId,
 	)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 	}
-	asset, err := h.service.GetAssetById(userId, assetUpdate.Id)
+	asset, err := h.service.GetAssetById(auth, assetUpdate.Id)
 	if err != nil {
 		log.Error("Happened error when get asset by id. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset by id")
@@ -289,9 +310,10 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 		WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 		Status:         asset.Status,
 		SerialNumber:   asset.SerialNumber,
-		FileAttachment: *asset.FileAttachment,
-		ImageUpload:    *asset.ImageUpload,
-		QrURL:          *asset.QrUrl,
+		FileAttachment: storage.FileURL(*asset.FileAttachment),
+		ImageUpload:    storage.FileURL(*asset.ImageUpload),
+		QrURL:          storage.FileURL(*asset.QrUrl),
+		VendorId:       asset.VendorId,
 		Category: dto.CategoryResponse{
 			ID:           asset.Category.Id,
 			CategoryName: asset.Category.CategoryName,
@@ -331,16 +353,19 @@ func (h *AssetsHandler) Update(c *gin.Context) {
 // @Security JWT
 func (h *AssetsHandler) GetAssetById(c *gin.Context) {
 	defer pkg.PanicHandler(c)
-	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	idStr := c.Param("id")
 	assetId, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		log.Error("Happened error when convert assetId to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert assetId to int64")
 	}
-	asset, err := h.service.GetAssetById(userId, assetId)
+	asset, err := h.service.GetAssetById(auth, assetId)
 	if err != nil {
 		log.Error("Happened error when get asset by id. Error", err.Error())
+		if errors.Is(err, service.ErrAssetNotVisible) {
+			pkg.PanicExeption(constant.StatusForbidden, err.Error())
+		}
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get asset by id")
 	}
 	assetResponse := dto.AssetResponse{
@@ -351,9 +376,10 @@ func (h *AssetsHandler) GetAssetById(c *gin.Context) {
 		WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 		Status:         asset.Status,
 		SerialNumber:   asset.SerialNumber,
-		FileAttachment: *asset.FileAttachment,
-		ImageUpload:    *asset.ImageUpload,
-		QrURL:          *asset.QrUrl,
+		FileAttachment: storage.FileURL(*asset.FileAttachment),
+		ImageUpload:    storage.FileURL(*asset.ImageUpload),
+		QrURL:          storage.FileURL(*asset.QrUrl),
+		VendorId:       asset.VendorId,
 		Category: dto.CategoryResponse{
 			ID:           asset.Category.Id,
 			CategoryName: asset.Category.CategoryName,
@@ -393,8 +419,8 @@ func (h *AssetsHandler) GetAssetById(c *gin.Context) {
 func (h *AssetsHandler) GetAllAsset(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	var assets []*entity.Assets
-	userID := utils.GetUserIdFromContext(c)
-	assets, err := h.service.GetAllAsset(userID, cacheKey)
+	auth := utils.GetAuthContextFromContext(c)
+	assets, err := h.service.GetAllAsset(auth, cacheKey)
 	if err != nil {
 		log.Error("Happened error when get all assets. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get all assets")
@@ -410,9 +436,10 @@ func (h *AssetsHandler) GetAllAsset(c *gin.Context) {
 			WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 			Status:         asset.Status,
 			SerialNumber:   asset.SerialNumber,
-			FileAttachment: *asset.FileAttachment,
-			ImageUpload:    *asset.ImageUpload,
-			QrURL:          *asset.QrUrl,
+			FileAttachment: storage.FileURL(*asset.FileAttachment),
+			ImageUpload:    storage.FileURL(*asset.ImageUpload),
+			QrURL:          storage.FileURL(*asset.QrUrl),
+			VendorId:       asset.VendorId,
 			Category: dto.CategoryResponse{
 				ID:           asset.Category.Id,
 				CategoryName: asset.Category.CategoryName,
@@ -455,17 +482,18 @@ func (h *AssetsHandler) GetAllAsset(c *gin.Context) {
 func (h *AssetsHandler) DeleteAsset(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	idStr := c.Param("id")
 	assetId, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		log.Error("Happened error when convert assetId to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert assetId to int64")
 	}
-	assetCheck, err := h.service.GetAssetById(userId, assetId)
+	assetCheck, err := h.service.GetAssetById(auth, assetId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, "Failed to update asset")
 	}
-	err = h.service.CheckPermissionForManager(userId, assetCheck.DepartmentId)
+	err = h.service.CheckPermissionForManager(auth, assetCheck.DepartmentId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 		return
@@ -493,6 +521,7 @@ func (h *AssetsHandler) DeleteAsset(c *gin.Context) {
 func (h *AssetsHandler) UpdateAssetRetired(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	idStr := c.Param("id")
 	assetId, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
@@ -504,11 +533,11 @@ func (h *AssetsHandler) UpdateAssetRetired(c *gin.Context) {
 		log.Error("Happened error when mapping request from FE. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest)
 	}
-	assetCheck, err := h.service.GetAssetById(userId, assetId)
+	assetCheck, err := h.service.GetAssetById(auth, assetId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, "Failed to update asset")
 	}
-	err = h.service.CheckPermissionForManager(userId, assetCheck.DepartmentId)
+	err = h.service.CheckPermissionForManager(auth, assetCheck.DepartmentId)
 	if err != nil {
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
 		return
@@ -536,12 +565,12 @@ func (h *AssetsHandler) UpdateAssetRetired(c *gin.Context) {
 func (h *AssetsHandler) FilterAsset(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	var filter filter.AssetFilter
-	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		log.Error("Happened error when mapping query to filter. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping query to filter")
 	}
-	data, err := h.service.Filter(userId, filter.AssetName, filter.Status, filter.CategoryId, filter.Cost, filter.SerialNumber, filter.Email, filter.DepartmentId)
+	data, err := h.service.Filter(auth, filter.AssetName, filter.Status, filter.CategoryId, filter.Cost, filter.SerialNumber, filter.Email, filter.DepartmentId)
 	if err != nil {
 		log.Error("Happened error when filter asset. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when filter asset")
@@ -565,48 +594,30 @@ func (h *AssetsHandler) FilterAsset(c *gin.Context) {
 func (h *AssetsHandler) FilterAssetDashboard(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	var filter filter.AssetFilterDashboard
-	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		log.Error("Happened error when mapping query to filter. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping query to filter")
 	}
-	summary, assets, err := h.service.ApplyFilterDashBoard(userId, filter.CategoryId, filter.DepartmentId, filter.Status, filter.Export)
+	summary, assets, err := h.service.ApplyFilterDashBoard(auth, filter.CategoryId, filter.DepartmentId, filter.Status)
 	if err != nil {
 		log.Error("Happened error when filter asset. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when filter asset")
 	}
 	if filter.Export != nil {
-		user, err := h.service.GetUserById(userId)
-		if err != nil {
-			log.Error("Happened error when filter asset. Error", err)
-			pkg.PanicExeption(constant.UnknownError, "Happened error when filter asset")
+		if !auth.Allows(policy.PermissionExportReports, policy.OpRead) {
+			pkg.PanicExeption(constant.StatusForbidden, "You are not allowed to export reports")
 		}
-		if user.Role.Slug != "employee" {
-			if *filter.Export == "csv" {
-				data, _ := GenerateCSV(assets)
-				c.Header("Content-Disposition", "attachment; filename=assets.csv")
-				c.Data(http.StatusOK, "text/csv", data)
-				return
-			} else if *filter.Export == "pdf" {
-				data, _ := GeneratePDF(assets)
-				c.Header("Content-Disposition", "attachment; filename=assets.pdf")
-				c.Data(http.StatusOK, "application/pdf", data)
-				return
-			}
-		} else {
-			if user.CanExport {
-				if *filter.Export == "csv" {
-					data, _ := GenerateCSV(assets)
-					c.Header("Content-Disposition", "attachment; filename=assets.csv")
-					c.Data(http.StatusOK, "text/csv", data)
-					return
-				} else if *filter.Export == "pdf" {
-					data, _ := GeneratePDF(assets)
-					c.Header("Content-Disposition", "attachment; filename=assets.pdf")
-					c.Data(http.StatusOK, "application/pdf", data)
-					return
-				}
-			}
+		if *filter.Export == "csv" {
+			data, _ := GenerateCSV(assets)
+			c.Header("Content-Disposition", "attachment; filename=assets.csv")
+			c.Data(http.StatusOK, "text/csv", data)
+			return
+		} else if *filter.Export == "pdf" {
+			data, _ := GeneratePDF(assets)
+			c.Header("Content-Disposition", "attachment; filename=assets.pdf")
+			c.Data(http.StatusOK, "application/pdf", data)
+			return
 		}
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, summary))
@@ -694,9 +705,10 @@ func (h *AssetsHandler) GetAssetsByCateOfDepartment(c *gin.Context) {
 			WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 			Status:         asset.Status,
 			SerialNumber:   asset.SerialNumber,
-			FileAttachment: *asset.FileAttachment,
-			ImageUpload:    *asset.ImageUpload,
-			QrURL:          *asset.QrUrl,
+			FileAttachment: storage.FileURL(*asset.FileAttachment),
+			ImageUpload:    storage.FileURL(*asset.ImageUpload),
+			QrURL:          storage.FileURL(*asset.QrUrl),
+			VendorId:       asset.VendorId,
 			Category: dto.CategoryResponse{
 				ID:           asset.Category.Id,
 				CategoryName: asset.Category.CategoryName,
@@ -753,9 +765,10 @@ func (h *AssetsHandler) GetAllAssetNotHaveMaintenance(c *gin.Context) {
 			WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 			Status:         asset.Status,
 			SerialNumber:   asset.SerialNumber,
-			FileAttachment: *asset.FileAttachment,
-			ImageUpload:    *asset.ImageUpload,
-			QrURL:          *asset.QrUrl,
+			FileAttachment: storage.FileURL(*asset.FileAttachment),
+			ImageUpload:    storage.FileURL(*asset.ImageUpload),
+			QrURL:          storage.FileURL(*asset.QrUrl),
+			VendorId:       asset.VendorId,
 			Category: dto.CategoryResponse{
 				ID:           asset.Category.Id,
 				CategoryName: asset.Category.CategoryName,
@@ -781,3 +794,48 @@ func (h *AssetsHandler) GetAllAssetNotHaveMaintenance(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, assetsResponse))
 }
+
+// Asset godoc
+// @Summary Import assets from CSV/XLSX
+// @Description Validate and import assets from a CSV or XLSX file. Columns: assetName, serialNumber, purchaseDate, warrantExpiry, cost, category, department, owner (optional, email or full name). Valid rows are imported and invalid rows are listed in the report. With dryRun=true only the validation report is returned.
+// @Tags Assets
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dryRun formData bool false "Only validate, do not import (default true)"
+// @Param redirectUrl formData string true "redirect url"
+// @param Authorization header string true "Authorization"
+// @Router /api/assets/import [post]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *AssetsHandler) ImportAssets(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	file, err := c.FormFile("file")
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, "File upload missing")
+	}
+	dryRun := true
+	if dryRunStr := c.PostForm("dryRun"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			pkg.PanicExeption(constant.InvalidRequest, "Invalid dryRun format")
+		}
+	}
+	url := c.PostForm("redirectUrl")
+	report, err := h.service.ImportAssets(auth, file, dryRun, url)
+	if err != nil {
+		log.Error("Happened error when import assets. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	if !dryRun && !report.Imported {
+		c.JSON(http.StatusBadRequest, pkg.BuildReponseSuccess(http.StatusBadRequest, constant.InvalidRequest, report))
+		return
+	}
+	if report.Imported {
+		config.Rdb.Del(config.Ctx, "assets:all")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, report))
+}
@@ -100,12 +100,12 @@ func (h *AssignmentHandler) Update(c *gin.Context) {
 func (h *AssignmentHandler) FilterAssignment(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	var filter filter.AssignmentFilter
-	userId := utils.GetUserIdFromContext(c)
+	auth := utils.GetAuthContextFromContext(c)
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		log.Error("Happened error when mapping query to filter. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping query to filter")
 	}
-	data, err := h.service.Filter(userId, filter.EmailAssigned, filter.EmailAssign, filter.AssetName)
+	data, err := h.service.Filter(auth, filter.EmailAssigned, filter.EmailAssign, filter.AssetName)
 	if err != nil {
 		log.Error("Happened error when filter assignment. Error: ", err.Error())
 		pkg.PanicExeption(constant.UnknownError, "Happened error when filter assignment. Error: "+err.Error())
@@ -2,16 +2,22 @@ package handler
 
 import (
 	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
 	service "BE_Manage_device/internal/service/bill"
+	vendorS "BE_Manage_device/internal/service/vendor"
 	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type BillsHandler struct {
@@ -28,7 +34,11 @@ func NewBillHandler(service *service.BillsService) *BillsHandler {
 // @Tags         Bills
 // @Accept       json
 // @Produce      json
-// @Param assetId formData []string true "Asset ID"
+// @Param assetId formData []string false "Asset ID, tài sản chưa có trong lineItems được thêm 1 dòng theo giá tài sản"
+// @Param lineItems formData string false "JSON array [{assetId, description, quantity, unitPrice, taxRate, discount}]"
+// @Param currency formData string false "ISO 4217 currency code, default VND"
+// @Param dueDate formData string false "Due date YYYY-MM-DD, default BILL_DEFAULT_DUE_DAYS after today"
+// @Param paymentMethod formData string false "cash, bank_transfer, card or other, used when statusBill is Paid"
 // @Param description formData string false "Description"
 // @Param statusBill formData string true "Paid or Unpaid"
 // @Param file formData file false "File to upload"
@@ -37,6 +47,7 @@ func NewBillHandler(service *service.BillsService) *BillsHandler {
 // @Param buyerPhone formData string true "Buyer Phone"
 // @Param buyerEmail formData string true "Buyer Email"
 // @Param buyerAddress formData string true "BuyerAddress"
+// @Param vendorId formData int64 false "Vendor ID (counterparty)"
 // @param Authorization header string true "Authorization"
 // @Router       /api/bills [POST]
 // @securityDefinitions.apiKey token
@@ -53,11 +64,27 @@ func (h *BillsHandler) Create(c *gin.Context) {
 	buyerPhone := c.PostForm("buyerPhone")
 	buyerEmail := c.PostForm("buyerEmail")
 	buyerAddress := c.PostForm("buyerAddress")
+	currency := c.PostForm("currency")
+	dueDate := c.PostForm("dueDate")
+	paymentMethod := c.PostForm("paymentMethod")
+	vendorId, err := utils.ParseOptionalInt64(c.PostForm("vendorId"))
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid vendorId format")
+	}
+	var lineItems []dto.BillLineItemRequest
+	if raw := strings.TrimSpace(c.PostForm("lineItems")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &lineItems); err != nil {
+			pkg.PanicExeption(constant.InvalidRequest, "Invalid lineItems format")
+		}
+	}
 	var assetIds []int64
 	if len(assetIdStrs) == 1 && strings.Contains(assetIdStrs[0], ",") {
 		assetIdStrs = strings.Split(assetIdStrs[0], ",")
 	}
 	for _, idStr := range assetIdStrs {
+		if strings.TrimSpace(idStr) == "" {
+			continue
+		}
 		id, err := utils.ParseStrToInt64(idStr)
 		if err != nil {
 			pkg.PanicExeption(constant.InvalidRequest, "Invalid assetId format")
@@ -66,7 +93,7 @@ func (h *BillsHandler) Create(c *gin.Context) {
 	}
 
 	// handler status string
-	if statusStr == "Unpaid" || statusStr == "Paid" {
+	if statusStr == entity.BillStatusUnpaid || statusStr == entity.BillStatusPaid {
 		status = statusStr
 	} else {
 		log.Info(fmt.Errorf("Error: %v", statusStr))
@@ -82,7 +109,11 @@ func (h *BillsHandler) Create(c *gin.Context) {
 		image = nil
 	}
 	userId := utils.GetUserIdFromContext(c)
-	bill, err := h.service.Create(userId, assetIds, description, image, file, status, buyerName, buyerPhone, buyerEmail, buyerAddress)
+	bill, err := h.service.Create(userId, assetIds, lineItems, currency, dueDate, paymentMethod, description, image, file, status, buyerName, buyerPhone, buyerEmail, buyerAddress, vendorId)
+	if errors.Is(err, service.ErrInvalidCurrency) || errors.Is(err, service.ErrInvalidLineItem) || errors.Is(err, service.ErrEmptyBill) ||
+		errors.Is(err, service.ErrInvalidDueDate) || errors.Is(err, service.ErrInvalidPayment) || errors.Is(err, vendorS.ErrVendorNotFound) {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
 	if err != nil {
 		log.Error("Happened error when create bill. Error", err.Error())
 		pkg.PanicExeption(constant.UnknownError, "Happened error when create bill. Error: "+err.Error())
@@ -116,6 +147,34 @@ func (h BillsHandler) GetByBillNumber(c *gin.Context) {
 	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, billResponse))
 }
 
+// Bill godoc
+// @Summary Download bill invoice as PDF
+// @Description Render hoá đơn PDF gồm thông tin công ty, người mua, các dòng hàng, tổng tiền, watermark Paid/Unpaid và QR về trang bill
+// @Tags Bills
+// @Produce application/pdf
+// @Param		billNumber	path		string				true	"billNumber"
+// @param Authorization header string true "Authorization"
+// @Router /api/bills/{billNumber}/pdf [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *BillsHandler) DownloadPDF(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	billNumber := c.Param("billNumber")
+	data, err := h.service.RenderInvoicePDF(userId, billNumber)
+	if errors.Is(err, service.ErrBillNotFound) {
+		pkg.PanicExeption(constant.DataNotFound, "Bill not found")
+	}
+	if err != nil {
+		log.Error("Happened error when render bill pdf. Error", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happened error when render bill pdf. Error: "+err.Error())
+	}
+	c.Header("Content-Disposition", "attachment; filename="+billNumber+".pdf")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
 // Bill godoc
 // @Summary Get all bill with filter
 // @Description Get all bill have permission
@@ -169,9 +228,95 @@ func (h *BillsHandler) GetAllBillUnpaid(c *gin.Context) {
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, billsRes))
 }
 
+// Bill godoc
+// @Summary Record a bill payment
+// @Description Ghi 1 lần thanh toán (có thể 1 phần), trạng thái bill được suy ra lại từ tổng đã trả và hạn thanh toán
+// @Tags Bills
+// @Accept json
+// @Produce json
+// @Param		billNumber	path		string				true	"billNumber"
+// @Param        payment   body    dto.CreateBillPaymentRequest   true  "payment"
+// @param Authorization header string true "Authorization"
+// @Router /api/bills/{billNumber}/payments [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *BillsHandler) RecordPayment(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.CreateBillPaymentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE")
+	}
+	bill, err := h.service.RecordPayment(userId, c.Param("billNumber"), request)
+	if errors.Is(err, service.ErrBillNotFound) {
+		pkg.PanicExeption(constant.DataNotFound, "Bill not found")
+	}
+	if errors.Is(err, service.ErrInvalidPayment) || errors.Is(err, service.ErrPaymentExceedsOutstanding) || errors.Is(err, service.ErrBillAlreadyPaid) {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	if err != nil {
+		log.Error("Happened error when record bill payment. Error", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happened error when record bill payment. Error: "+err.Error())
+	}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, utils.ConvertBillToResponse(bill)))
+}
+
+// Bill godoc
+// @Summary Get bill payments
+// @Description Các lần thanh toán của bill theo thứ tự ngày trả
+// @Tags Bills
+// @Accept json
+// @Produce json
+// @Param		billNumber	path		string				true	"billNumber"
+// @param Authorization header string true "Authorization"
+// @Router /api/bills/{billNumber}/payments [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *BillsHandler) GetPayments(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	payments, err := h.service.GetPayments(userId, c.Param("billNumber"))
+	if errors.Is(err, service.ErrBillNotFound) {
+		pkg.PanicExeption(constant.DataNotFound, "Bill not found")
+	}
+	if err != nil {
+		log.Error("Happened error when get bill payments. Error", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get bill payments. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertBillPaymentsToResponses(payments)))
+}
+
+// Bill godoc
+// @Summary Bill aging report
+// @Description Tuổi nợ các bill chưa trả đủ của công ty theo nhóm 0-30/31-60/61-90/90+ ngày quá hạn, tách theo đồng tiền
+// @Tags Bills
+// @Accept json
+// @Produce json
+// @param Authorization header string true "Authorization"
+// @Router /api/bills/aging [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *BillsHandler) GetAgingReport(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	report, err := h.service.GetAgingReport(userId)
+	if err != nil {
+		log.Error("Happened error when get bill aging report. Error", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get bill aging report. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, report))
+}
+
 // Bill godoc
 // @Summary Update paid bill
-// @Description Update paid bill
+// @Description Đánh dấu đã trả đủ: ghi 1 lần thanh toán cho toàn bộ số tiền còn nợ
 // @Tags Bills
 // @Accept json
 // @Produce json
@@ -185,7 +330,11 @@ func (h *BillsHandler) GetAllBillUnpaid(c *gin.Context) {
 func (h *BillsHandler) UpdatePaid(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	billNumber := c.Param("billNumber")
-	err := h.service.UpdatePaid(billNumber)
+	userId := utils.GetUserIdFromContext(c)
+	err := h.service.UpdatePaid(userId, billNumber)
+	if errors.Is(err, service.ErrBillNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
+		pkg.PanicExeption(constant.DataNotFound, "Bill not found")
+	}
 	if err != nil {
 		log.Error("Happened error when update paid. Error", err.Error())
 		pkg.PanicExeption(constant.UnknownError, "Happened error when update paid"+err.Error())
@@ -5,6 +5,7 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	service "BE_Manage_device/internal/service/company"
 	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
 	"net/http"
 	"strconv"
 
@@ -76,3 +77,32 @@ func (h *CompanyHandler) GetCompanyById(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, company))
 }
+
+// Company godoc
+// @Summary      Update MFA policy
+// @Description  Require MFA for admin and assetManager users of the current company
+// @Tags         Company
+// @Accept       json
+// @Produce      json
+// @Param        policy   body    dto.UpdateCompanyMfaPolicyRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/company/mfa-policy [PATCH]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *CompanyHandler) UpdateMfaPolicy(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.UpdateCompanyMfaPolicyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happen error when mapping request from FE. Error: ", err.Error())
+		pkg.PanicExeption(constant.InvalidRequest, "Happen error when mapping request from FE. Error: "+err.Error())
+	}
+	company, err := h.service.UpdateMfaPolicy(userId, *request.RequireMfa)
+	if err != nil {
+		log.Error("Happen error when update mfa policy Error: ", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happen error when update mfa policy Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, company))
+}
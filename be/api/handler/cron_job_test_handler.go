@@ -2,10 +2,10 @@ package handler
 
 import (
 	"BE_Manage_device/constant"
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
 	asset "BE_Manage_device/internal/repository/assets"
 	user "BE_Manage_device/internal/repository/user"
-	emailS "BE_Manage_device/internal/service/email"
 	notificationS "BE_Manage_device/internal/service/notification"
 	"net/http"
 
@@ -18,15 +18,15 @@ import (
 
 type CronJobTestHandler struct {
 	db                   *gorm.DB
-	emailService         *emailS.EmailService
 	assetsRepository     asset.AssetsRepository
 	userRepository       user.UserRepository
 	notificationsService *notificationS.NotificationService
 	assetsLogRepository  asset_log.AssetsLogRepository
+	assetLoanRepository  assetLoan.AssetLoansRepository
 }
 
-func NewCronJobTestHandler(db *gorm.DB, emailService *emailS.EmailService, assetsRepository asset.AssetsRepository, userRepository user.UserRepository, notificationsService *notificationS.NotificationService, assetsLogRepository asset_log.AssetsLogRepository) *CronJobTestHandler {
-	return &CronJobTestHandler{db: db, emailService: emailService, assetsRepository: assetsRepository, userRepository: userRepository, notificationsService: notificationsService, assetsLogRepository: assetsLogRepository}
+func NewCronJobTestHandler(db *gorm.DB, assetsRepository asset.AssetsRepository, userRepository user.UserRepository, notificationsService *notificationS.NotificationService, assetsLogRepository asset_log.AssetsLogRepository, assetLoanRepository assetLoan.AssetLoansRepository) *CronJobTestHandler {
+	return &CronJobTestHandler{db: db, assetsRepository: assetsRepository, userRepository: userRepository, notificationsService: notificationsService, assetsLogRepository: assetsLogRepository, assetLoanRepository: assetLoanRepository}
 }
 
 // Cron godoc
@@ -38,7 +38,7 @@ func NewCronJobTestHandler(db *gorm.DB, emailService *emailS.EmailService, asset
 // @Router       /api/CheckAndSenMaintenanceNotification [GET]
 func (h *CronJobTestHandler) CheckAndSenMaintenanceNotification(c *gin.Context) {
 	defer pkg.PanicHandler(c)
-	utils.CheckAndSenMaintenanceNotification(h.db, h.emailService, h.assetsRepository, h.userRepository, h.notificationsService, h.assetsLogRepository)
+	utils.CheckAndSenMaintenanceNotification(h.db, h.assetsRepository, h.userRepository, h.notificationsService, h.assetsLogRepository)
 	c.JSON(http.StatusCreated, pkg.BuildReponseSuccessNoData(http.StatusCreated, constant.Success))
 }
 
@@ -51,7 +51,7 @@ func (h *CronJobTestHandler) CheckAndSenMaintenanceNotification(c *gin.Context)
 // @Router       /api/SendEmailsForWarrantyExpiry [GET]
 func (h *CronJobTestHandler) SendEmailsForWarrantyExpiry(c *gin.Context) {
 	defer pkg.PanicHandler(c)
-	utils.SendEmailsForWarrantyExpiry(h.db, h.emailService, h.notificationsService, h.assetsRepository, h.userRepository)
+	utils.SendEmailsForWarrantyExpiry(h.db, h.notificationsService, h.assetsRepository, h.userRepository)
 	c.JSON(http.StatusCreated, pkg.BuildReponseSuccessNoData(http.StatusCreated, constant.Success))
 }
 
@@ -67,3 +67,17 @@ func (h *CronJobTestHandler) UpdateStatusWhenFinishMaintenance(c *gin.Context) {
 	utils.UpdateStatusWhenFinishMaintenance(h.db, h.assetsRepository, h.userRepository, h.notificationsService, h.assetsLogRepository)
 	c.JSON(http.StatusCreated, pkg.BuildReponseSuccessNoData(http.StatusCreated, constant.Success))
 }
+
+// Cron godoc
+// @Summary      SendOverdueLoanNotifications
+// @Description  SendOverdueLoanNotifications
+// @Tags         Cron
+// @Accept       json
+// @Produce      json
+// @Security JWT
+// @Router       /api/SendOverdueLoanNotifications [GET]
+func (h *CronJobTestHandler) SendOverdueLoanNotifications(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	utils.SendOverdueLoanNotifications(h.notificationsService, h.assetLoanRepository, h.userRepository)
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccessNoData(http.StatusCreated, constant.Success))
+}
@@ -118,6 +118,7 @@ func (h *DepartmentsHandler) GetAll(c *gin.Context) {
 		departmentResponse := dto.DepartmentResponse{}
 		departmentResponse.ID = department.Id
 		departmentResponse.DepartmentName = department.DepartmentName
+		departmentResponse.HeadId = department.HeadId
 		departmentResponse.Location.ID = department.Location.Id
 		departmentResponse.Location.LocationName = department.Location.LocationName
 		departmentResponses = append(departmentResponses, departmentResponse)
@@ -165,3 +166,40 @@ func (h *DepartmentsHandler) Delete(c *gin.Context) {
 	config.Rdb.Del(config.Ctx, cacheKeyDepartmentCompanyId)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
 }
+
+// Department godoc
+// @Summary      Set department head
+// @Description  Set the head of a department, who approves the requester_department_head step of request transfers. headId = null removes the head
+// @Tags         Departments
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        request   body    dto.UpdateDepartmentHeadRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/departments/{id}/head [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *DepartmentsHandler) UpdateHead(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when get id via path. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when get id via path")
+	}
+	var request dto.UpdateDepartmentHeadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	department, err := h.service.UpdateHead(userId, id, request.HeadId)
+	if err != nil {
+		log.Error("Happened error when update department head. Error", err)
+		pkg.PanicExeption(constant.UnknownError, err.Error())
+	}
+	cacheKeyDepartmentCompanyId := fmt.Sprintf("%v:%v", cacheKeyDepartment, department.CompanyId)
+	config.Rdb.Del(config.Ctx, cacheKeyDepartmentCompanyId)
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, department))
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	service "BE_Manage_device/internal/service/depreciation"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type DepreciationHandler struct {
+	service *service.DepreciationService
+}
+
+func NewDepreciationHandler(service *service.DepreciationService) *DepreciationHandler {
+	return &DepreciationHandler{service: service}
+}
+
+// Depreciation godoc
+// @Summary      Get depreciation schedule of asset
+// @Description  Period-by-period depreciation schedule (opening value, charge, accumulated depreciation, closing book value)
+// @Tags         Depreciation
+// @Accept       json
+// @Produce      json
+// @Param		id	path		int				true	"asset_id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/assets/{id}/depreciation [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *DepreciationHandler) GetSchedule(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert asset id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid asset id.")
+	}
+	schedule, err := h.service.GetSchedule(userId, assetId)
+	if err != nil {
+		log.Error("Happened error when get depreciation schedule. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, schedule))
+}
+
+// Depreciation godoc
+// @Summary      Override depreciation settings of asset
+// @Description  Override the category depreciation method, useful life, residual value and total units for one asset. Omitted fields fall back to the category settings.
+// @Tags         Depreciation
+// @Accept       json
+// @Produce      json
+// @Param		id	path		int				true	"asset_id"
+// @Param        Depreciation   body    dto.UpdateAssetDepreciationRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/assets/{id}/depreciation [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *DepreciationHandler) UpdateAssetDepreciation(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert asset id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid asset id.")
+	}
+	var request dto.UpdateAssetDepreciationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	schedule, err := h.service.UpdateAssetDepreciation(userId, assetId, request)
+	if err != nil {
+		log.Error("Happened error when update asset depreciation. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	if companyId, err := h.service.GetCompanyId(userId); err == nil {
+		config.Rdb.Del(config.Ctx, fmt.Sprintf("%v:%v", cacheKey, companyId))
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, schedule))
+}
+
+// Depreciation godoc
+// @Summary      Record asset usage
+// @Description  Record the units produced in a depreciation period (1-based year since acquisition), used by the units-of-production method
+// @Tags         Depreciation
+// @Accept       json
+// @Produce      json
+// @Param		id	path		int				true	"asset_id"
+// @Param        Usage   body    dto.RecordAssetUsageRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/assets/{id}/depreciation/usage [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *DepreciationHandler) RecordUsage(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	assetId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert asset id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid asset id.")
+	}
+	var request dto.RecordAssetUsageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	usage, err := h.service.RecordUsage(userId, assetId, request.Period, request.Units)
+	if err != nil {
+		log.Error("Happened error when record asset usage. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, usage))
+}
+
+// Depreciation godoc
+// @Summary      Configure depreciation of category
+// @Description  Default depreciation method, useful life (years), residual rate (fraction of cost) and declining factor for assets of the category
+// @Tags         Depreciation
+// @Accept       json
+// @Produce      json
+// @Param		id	path		int				true	"category_id"
+// @Param        Depreciation   body    dto.UpdateCategoryDepreciationRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/categories/{id}/depreciation [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *DepreciationHandler) UpdateCategoryDepreciation(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	categoryId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert category id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid category id.")
+	}
+	var request dto.UpdateCategoryDepreciationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	category, err := h.service.UpdateCategoryDepreciation(userId, categoryId, request)
+	if err != nil {
+		log.Error("Happened error when update category depreciation. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	config.Rdb.Del(config.Ctx, fmt.Sprintf("%v:%v", cacheKeyCategories, category.CompanyId))
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, category))
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/storage"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	storage storage.Storage
+}
+
+func NewFileHandler(storage storage.Storage) *FileHandler {
+	return &FileHandler{storage: storage}
+}
+
+// File godoc
+// @Summary      Download file
+// @Description  Download a file stored by the local storage driver. When the driver is private, expires and signature from a signed URL are required.
+// @Tags         Files
+// @Produce      octet-stream
+// @Param		path	path		string				true	"object path"
+// @Param		expires	query		string				false	"expires (unix time)"
+// @Param		signature	query		string				false	"signature"
+// @Router       /api/files/{path} [GET]
+func (h *FileHandler) Download(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	local, ok := h.storage.(*storage.LocalStorage)
+	if !ok {
+		pkg.PanicExeption(constant.DataNotFound, "File storage is not local")
+	}
+	objectPath := strings.TrimPrefix(c.Param("path"), "/")
+	signature := c.Query("signature")
+	if !local.Public || signature != "" {
+		if !local.VerifySignature(objectPath, c.Query("expires"), signature) {
+			pkg.PanicExeption(constant.StatusForbidden, "Invalid or expired signature")
+		}
+	}
+	fullPath, err := local.FilePath(objectPath)
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	if _, err := os.Stat(fullPath); errors.Is(err, os.ErrNotExist) {
+		pkg.PanicExeption(constant.DataNotFound, "File not found")
+	}
+	c.File(fullPath)
+}
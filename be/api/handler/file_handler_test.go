@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"BE_Manage_device/pkg/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newFileRouter(t *testing.T, public bool) (*gin.Engine, *storage.LocalStorage) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	local, err := storage.NewLocalStorage(t.TempDir(), "http://localhost:8080", "signing-secret", public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/api/files/*path", NewFileHandler(local).Download)
+	return r, local
+}
+
+// Upload rồi tải lại qua handler bằng đúng link mà response trả cho client
+func TestUploadThenDownload(t *testing.T) {
+	tests := []struct {
+		name     string
+		public   bool
+		link     func(key, signed string) string
+		wantCode int
+	}{
+		{"private signed url", false, func(key, signed string) string { return signed }, http.StatusOK},
+		{"private without signature", false, func(key, signed string) string { return "/api/files/" + key }, http.StatusForbidden},
+		{"private tampered signature", false, func(key, signed string) string { return strings.Replace(signed, "signature=", "signature=0", 1) }, http.StatusForbidden},
+		{"public url", true, func(key, signed string) string { return signed }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, local := newFileRouter(t, tt.public)
+			storage.SetDefault(local)
+			defer storage.SetDefault(nil)
+
+			key, err := local.UploadReader("assets/1/file.txt", strings.NewReader("hello"), "text/plain")
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := url.Parse(tt.link(key, storage.FileURL(key)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET %s = %d, want %d: %s", u.RequestURI(), w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode == http.StatusOK {
+				body, _ := io.ReadAll(w.Body)
+				if string(body) != "hello" {
+					t.Errorf("body = %q", body)
+				}
+			}
+		})
+	}
+}
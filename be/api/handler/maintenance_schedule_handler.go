@@ -4,9 +4,11 @@ import (
 	"BE_Manage_device/constant"
 	"BE_Manage_device/internal/domain/dto"
 	service "BE_Manage_device/internal/service/maintenance_schedules"
+	vendorS "BE_Manage_device/internal/service/vendor"
 
 	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -25,7 +27,7 @@ func NewMaintenanceSchedulesHandler(service *service.MaintenanceSchedulesService
 
 // Maintenance Schedules godoc
 // @Summary      Create maintenanceSchedules
-// @Description  Create maintenanceSchedules
+// @Description  Create maintenanceSchedules. Overlaps with the other schedules of the asset are checked for the next 2 years only when the series has no end. A series can't expand to more than 10000 occurrences
 // @Tags         MaintenanceSchedules
 // @Accept       json
 // @Produce      json
@@ -53,7 +55,10 @@ func (h *MaintenanceSchedulesHandler) Create(c *gin.Context) {
 		log.Error("Happened error start date >= end date .")
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error start date > end date.")
 	}
-	maintenance, err := h.service.Create(userId, request.AssetId, request.StartDate, request.EndDate)
+	maintenance, err := h.service.Create(userId, request.AssetId, request.StartDate, request.EndDate, request.Recurrence, request.VendorId)
+	if errors.Is(err, vendorS.ErrVendorNotFound) {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
 	if err != nil {
 		log.Error("Happened error when create maintenance. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when create maintenance.")
@@ -94,7 +99,7 @@ func (h *MaintenanceSchedulesHandler) GetAllMaintenanceSchedulesByAssetId(c *gin
 
 // Maintenance Schedules godoc
 // @Summary      Update maintenanceSchedules by id
-// @Description  Update maintenanceSchedules by id
+// @Description  Update maintenanceSchedules by id. For recurring schedules, scope "this" changes only the occurrence starting at occurrenceStart and "following" splits the series at that occurrence. Overlaps are checked for the next 2 years only when the series has no end.
 // @Tags         MaintenanceSchedules
 // @Accept       json
 // @Produce      json
@@ -129,7 +134,10 @@ func (h *MaintenanceSchedulesHandler) Update(c *gin.Context) {
 		log.Error("End date must be after start date.")
 		pkg.PanicExeption(constant.InvalidRequest, "End date must be after start date.")
 	}
-	maintenance, err := h.service.Update(userId, id, request.StartDate, request.EndDate)
+	maintenance, err := h.service.Update(userId, id, request.StartDate, request.EndDate, request.Scope, request.OccurrenceStart, request.Recurrence, request.VendorId)
+	if errors.Is(err, vendorS.ErrVendorNotFound) {
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
 	if err != nil {
 		log.Error("Happened error when create maintenance. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when update maintenance.")
@@ -140,11 +148,13 @@ func (h *MaintenanceSchedulesHandler) Update(c *gin.Context) {
 
 // Maintenance Schedules godoc
 // @Summary      Delete maintenanceSchedules by id
-// @Description  Delete maintenanceSchedules by id
+// @Description  Delete maintenanceSchedules by id. For recurring schedules, scope "this" cancels only the occurrence starting at occurrenceStart and "following" ends the series before it.
 // @Tags         MaintenanceSchedules
 // @Accept       json
 // @Produce      json
 // @Param		id	path		int				true	"maintenance_id"
+// @Param		scope	query		string				false	"all | this | following"
+// @Param		occurrenceStart	query		string				false	"occurrence start (RFC3339)"
 // @param Authorization header string true "Authorization"
 // @Router       /api/maintenance-schedules/{id} [DELETE]
 // @securityDefinitions.apiKey token
@@ -160,12 +170,69 @@ func (h *MaintenanceSchedulesHandler) Delete(c *gin.Context) {
 		log.Error("Happened error when convert project id to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest)
 	}
-	err = h.service.Delete(userId, id)
+	scope := c.DefaultQuery("scope", "all")
+	if scope != "all" && scope != "this" && scope != "following" {
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid scope.")
+	}
+	var occurrenceStart *time.Time
+	if occurrenceStartStr := c.Query("occurrenceStart"); occurrenceStartStr != "" {
+		t, err := time.Parse(time.RFC3339, occurrenceStartStr)
+		if err != nil {
+			log.Error("Happened error when parse occurrenceStart. Error", err)
+			pkg.PanicExeption(constant.InvalidRequest, "Invalid occurrenceStart.")
+		}
+		occurrenceStart = &t
+	}
+	err = h.service.Delete(userId, id, scope, occurrenceStart)
 	if err != nil {
-		log.Error("Happened error when create maintenance. Error", err)
+		log.Error("Happened error when delete maintenance. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when delete maintenance.")
-		c.JSON(http.StatusCreated, pkg.BuildReponseSuccessNoData(http.StatusCreated, constant.Success))
 	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// Maintenance Schedules godoc
+// @Summary      Get maintenance occurrences by assetId
+// @Description  Expand the maintenance schedules of an asset into concrete occurrences between from and to (default: next 90 days)
+// @Tags         MaintenanceSchedules
+// @Accept       json
+// @Produce      json
+// @Param		id	path		int				true	"asset_id"
+// @Param		from	query		string				false	"from (RFC3339)"
+// @Param		to	query		string				false	"to (RFC3339)"
+// @param Authorization header string true "Authorization"
+// @Router       /api/maintenance-schedules/{id}/occurrences [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *MaintenanceSchedulesHandler) GetOccurrencesByAssetId(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert asset id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest)
+	}
+	from := time.Now()
+	to := from.AddDate(0, 0, 90)
+	if fromStr := c.Query("from"); fromStr != "" {
+		if from, err = time.Parse(time.RFC3339, fromStr); err != nil {
+			pkg.PanicExeption(constant.InvalidRequest, "Invalid from.")
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if to, err = time.Parse(time.RFC3339, toStr); err != nil {
+			pkg.PanicExeption(constant.InvalidRequest, "Invalid to.")
+		}
+	}
+	occurrences, err := h.service.GetOccurrencesByAssetId(userId, id, from, to)
+	if err != nil {
+		log.Error("Happened error when get maintenance occurrences. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertMaintenanceOccurrencesToResponses(occurrences)))
 }
 
 // Maintenance Schedules godoc
@@ -189,3 +256,38 @@ func (h *MaintenanceSchedulesHandler) GetAllMaintenanceSchedules(c *gin.Context)
 	MaintenanceScheduleRes := utils.ConvertMaintenanceSchedulesToResponsesArray(maintenances)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, MaintenanceScheduleRes))
 }
+
+// Maintenance Schedules godoc
+// @Summary      Record maintenance handover and return
+// @Description  Record when the asset was actually handed over to the vendor and returned for the occurrence starting at occurrenceStart. Vendor turnaround is computed from these times
+// @Tags         MaintenanceSchedules
+// @Accept       json
+// @Produce      json
+// @Param        request   body    dto.RecordMaintenanceJobRequest   true  "Data"
+// @Param		id	path		int				true	"maintenance_id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/maintenance-schedules/{id}/job [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *MaintenanceSchedulesHandler) RecordJob(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert maintenance id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest)
+	}
+	var request dto.RecordMaintenanceJobRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	job, err := h.service.RecordJob(userId, id, request.OccurrenceStart, request.HandedOverAt, request.ReturnedAt)
+	if err != nil {
+		log.Error("Happened error when record maintenance job. Error", err)
+		pkg.PanicExeption(constant.UnknownError, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, job))
+}
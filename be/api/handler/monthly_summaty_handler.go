@@ -2,6 +2,7 @@ package handler
 
 import (
 	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/filter"
 	service "BE_Manage_device/internal/service/monthly_summary"
 	"BE_Manage_device/pkg"
@@ -50,3 +51,32 @@ func (h *MonthlySummaryHandler) Filter(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, data))
 }
+
+// Bill godoc
+// @Summary Regenerate monthly summary
+// @Description Regenerate or backfill monthly summaries of the company for a range of months
+// @Tags Monthly Summary
+// @Accept json
+// @Produce json
+// @Param        summary   body    dto.RegenerateMonthlySummaryRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router /api/monthly-summary/regenerate [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *MonthlySummaryHandler) Regenerate(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	var request dto.RegenerateMonthlySummaryRequest
+	userId := utils.GetUserIdFromContext(c)
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request regenerate monthly summary. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request regenerate monthly summary")
+	}
+	data, err := h.service.Regenerate(userId, request.FromMonth, request.FromYear, request.ToMonth, request.ToYear, request.OnlyMissing)
+	if err != nil {
+		log.Error("Happened error when regenerate monthly summary. Error: ", err.Error())
+		pkg.PanicExeption(constant.UnknownError, "Happened error when regenerate monthly summary. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, data))
+}
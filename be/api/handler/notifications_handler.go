@@ -2,10 +2,13 @@ package handler
 
 import (
 	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/filter"
 	notificationS "BE_Manage_device/internal/service/notification"
 
 	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -22,11 +25,17 @@ func NewNotificationHandler(service *notificationS.NotificationService) *Notific
 }
 
 // Notification godoc
-// @Summary      Get notifications by user id
-// @Description  Get notifications by user id
+// @Summary      Get notification inbox
+// @Description  Cursor-paginated inbox of the current user, newest first. Pass nextCursor of the previous page as cursor
 // @Tags         Notification
 // @Accept       json
 // @Produce      json
+// @Param        type      query    string  false  "Notification type, e.g. maintenance.started"
+// @Param        assetId   query    int     false  "Asset id"
+// @Param        status    query    string  false  "pending (unread) or seen"
+// @Param        archived  query    bool    false  "List archived notifications instead of the inbox"
+// @Param        cursor    query    int     false  "Cursor"
+// @Param        limit     query    int     false  "Page size, default 20, max 100"
 // @param Authorization header string true "Authorization"
 // @Router       /api/notifications [GET]
 // @securityDefinitions.apiKey token
@@ -34,18 +43,47 @@ func NewNotificationHandler(service *notificationS.NotificationService) *Notific
 // @name Authorization
 // @Security JWT
 func (h *NotificationHandler) GetNotificationsByUserId(c *gin.Context) {
-	userId := utils.GetUserIdFromContext(c)
-	notifications, err := h.service.GetNotificationsByUserId(userId)
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var notificationFilter filter.NotificationFilter
+	if err := c.ShouldBindQuery(&notificationFilter); err != nil {
+		log.Error("Happened error when mapping query to filter. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping query to filter. Error: "+err.Error())
+	}
+	page, err := h.service.GetInbox(auth.UserId, notificationFilter)
 	if err != nil {
-		log.Error("Happened error when get notification.")
+		log.Error("Happened error when get notification. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when get notification.")
 	}
-	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, notifications))
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, page))
+}
+
+// Notification godoc
+// @Summary      Get unread notification count
+// @Description  Number of unread, not archived notifications of the current user
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/notifications/unread-count [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.GetUnreadCount(auth.UserId)
+	if err != nil {
+		log.Error("Happened error when count unread notifications. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when count unread notifications.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.NotificationCountResponse{Count: count}))
 }
 
 // Notification godoc
-// @Summary      Update notification
-// @Description  Update notification
+// @Summary      Mark notification as read
+// @Description  Mark one notification of the current user as read
 // @Tags         Notification
 // @Accept       json
 // @Produce      json
@@ -57,16 +95,206 @@ func (h *NotificationHandler) GetNotificationsByUserId(c *gin.Context) {
 // @name Authorization
 // @Security JWT
 func (h *NotificationHandler) UpdateStatusToSeen(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
 	idStr := c.Param("id")
 	NotificationId, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
 		log.Error("Happened error when convert notification to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert notification to int64")
 	}
-	err = h.service.UpdateStatusToSeen(NotificationId)
+	err = h.service.UpdateStatusToSeen(auth.UserId, NotificationId)
+	if errors.Is(err, notificationS.ErrNotificationNotFound) {
+		pkg.PanicExeption(constant.DataNotFound, "Notification not found")
+	}
 	if err != nil {
-		log.Error("Happened error when update notification.")
+		log.Error("Happened error when update notification. Error", err)
 		pkg.PanicExeption(constant.UnknownError, "Happened error when update notification.")
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
 }
+
+func bindNotificationIds(c *gin.Context) []int64 {
+	var request dto.NotificationIdsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	return request.Ids
+}
+
+// Notification godoc
+// @Summary      Mark notifications as read
+// @Description  Mark a list of notifications as read. Ids that do not belong to the current user are ignored, count is the number actually updated
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        ids   body    dto.NotificationIdsRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/notifications/read [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) MarkSeen(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.MarkSeen(auth.UserId, bindNotificationIds(c))
+	if err != nil {
+		log.Error("Happened error when mark notifications as read. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when mark notifications as read.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.NotificationCountResponse{Count: count}))
+}
+
+// Notification godoc
+// @Summary      Mark all notifications as read
+// @Description  Mark every unread notification of the current user as read
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/notifications/read-all [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) MarkAllSeen(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.MarkAllSeen(auth.UserId)
+	if err != nil {
+		log.Error("Happened error when mark all notifications as read. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when mark all notifications as read.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.NotificationCountResponse{Count: count}))
+}
+
+// Notification godoc
+// @Summary      Archive notifications
+// @Description  Move notifications of the current user out of the inbox. Archived notifications are also marked as read
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        ids   body    dto.NotificationIdsRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/notifications/archive [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) Archive(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.Archive(auth.UserId, bindNotificationIds(c))
+	if err != nil {
+		log.Error("Happened error when archive notifications. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when archive notifications.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.NotificationCountResponse{Count: count}))
+}
+
+// Notification godoc
+// @Summary      Delete notifications
+// @Description  Delete notifications of the current user, ids of other users are ignored
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        ids   body    dto.NotificationIdsRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/notifications [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) Delete(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.Delete(auth.UserId, bindNotificationIds(c))
+	if err != nil {
+		log.Error("Happened error when delete notifications. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when delete notifications.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.NotificationCountResponse{Count: count}))
+}
+
+// Notification godoc
+// @Summary      Get notification preferences
+// @Description  Get the full category x channel matrix (immediate, digest or off) and the notification settings of the current user
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/notification-preferences [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	res, err := h.service.GetPreferences(auth.UserId)
+	if err != nil {
+		log.Error("Happened error when get notification preferences. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get notification preferences.")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, res))
+}
+
+// Notification godoc
+// @Summary      Update notification preferences
+// @Description  Override cells of the category x channel matrix, cells not sent keep their value
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        preferences   body    dto.UpdateNotificationPreferencesRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/notification-preferences [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	res, err := h.service.UpdatePreferences(auth.UserId, request.Preferences)
+	if err != nil {
+		log.Error("Happened error when update notification preferences. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when update notification preferences. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, res))
+}
+
+// Notification godoc
+// @Summary      Update notification settings
+// @Description  Update timezone, quiet hours, digest hour and personal webhook. The webhook secret is only returned when the url is set or changed
+// @Tags         Notification
+// @Accept       json
+// @Produce      json
+// @Param        settings   body    dto.NotificationSettingsRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/notification-settings [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *NotificationHandler) UpdateSettings(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.NotificationSettingsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	res, err := h.service.UpdateSettings(auth.UserId, request)
+	if err != nil {
+		log.Error("Happened error when update notification settings. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when update notification settings. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, res))
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	service "BE_Manage_device/internal/service/outbox"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type OutboxHandler struct {
+	service *service.OutboxService
+}
+
+func NewOutboxHandler(service *service.OutboxService) *OutboxHandler {
+	return &OutboxHandler{service: service}
+}
+
+func parseOutboxId(c *gin.Context) int64 {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert id to int64")
+	}
+	return id
+}
+
+// Outbox godoc
+// @Summary      Get outbox messages
+// @Description  Get the latest outbox messages of the company, filter by status (pending, sent, dead) and channel (notification, email, webhook)
+// @Tags         Outbox
+// @Accept       json
+// @Produce      json
+// @Param        status    query    string  false  "status"
+// @Param        channel   query    string  false  "channel"
+// @param Authorization header string true "Authorization"
+// @Router       /api/outbox [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *OutboxHandler) GetMessages(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	messages, err := h.service.GetMessages(auth, c.Query("status"), c.Query("channel"))
+	if err != nil {
+		log.Error("Happened error when get outbox messages. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when get outbox messages. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, messages))
+}
+
+// Outbox godoc
+// @Summary      Get outbox message
+// @Description  Get outbox message by id, including payload and last error
+// @Tags         Outbox
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/outbox/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *OutboxHandler) GetMessage(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	message, err := h.service.GetMessage(auth, parseOutboxId(c))
+	if err != nil {
+		log.Error("Happened error when get outbox message. Error", err)
+		pkg.PanicExeption(constant.DataNotFound, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, message))
+}
+
+// Outbox godoc
+// @Summary      Replay outbox message
+// @Description  Put a dead-lettered message back to pending with its attempts reset
+// @Tags         Outbox
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/outbox/{id}/replay [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *OutboxHandler) Replay(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	message, err := h.service.Replay(auth, parseOutboxId(c))
+	if err != nil {
+		log.Error("Happened error when replay outbox message. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when replay outbox message. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, message))
+}
+
+// Outbox godoc
+// @Summary      Replay dead outbox messages
+// @Description  Replay every dead-lettered message of the company, optionally only one channel
+// @Tags         Outbox
+// @Accept       json
+// @Produce      json
+// @Param        channel   query    string  false  "channel"
+// @param Authorization header string true "Authorization"
+// @Router       /api/outbox/replay [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *OutboxHandler) ReplayDead(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	count, err := h.service.ReplayDead(auth, c.Query("channel"))
+	if err != nil {
+		log.Error("Happened error when replay outbox messages. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when replay outbox messages. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, gin.H{"replayed": count}))
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	service "BE_Manage_device/internal/service/purchase_order"
+	vendorS "BE_Manage_device/internal/service/vendor"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type PurchaseOrderHandler struct {
+	service *service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+func parsePurchaseOrderId(c *gin.Context) int64 {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert id to int64")
+	}
+	return id
+}
+
+func purchaseOrderError(err error) constant.ResponseStatus {
+	switch {
+	case errors.Is(err, service.ErrPurchaseOrderNotFound):
+		return constant.DataNotFound
+	case errors.Is(err, service.ErrInvalidPurchaseOrder), errors.Is(err, service.ErrPurchaseOrderForbidden),
+		errors.Is(err, service.ErrPurchaseOrderStatus), errors.Is(err, service.ErrInvalidGoodsReceipt),
+		errors.Is(err, service.ErrNoAssetManager), errors.Is(err, utils.ErrInvalidRange),
+		errors.Is(err, vendorS.ErrVendorNotFound):
+		return constant.InvalidRequest
+	}
+	return constant.UnknownError
+}
+
+// bindDecision đọc ghi chú duyệt/từ chối, body có thể để trống
+func bindDecision(c *gin.Context) dto.PurchaseOrderDecisionRequest {
+	var request dto.PurchaseOrderDecisionRequest
+	if c.Request.ContentLength == 0 {
+		return request
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	return request
+}
+
+// PurchaseOrder godoc
+// @Summary      Create purchase order
+// @Description  Request items per category for a department. The order starts as Pending and needs approval before goods can be received
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param        purchaseOrder   body    dto.PurchaseOrderRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) Create(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	order, err := h.service.Create(auth, request)
+	if err != nil {
+		log.Error("Happened error when create purchase order. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when create purchase order. Error: "+err.Error())
+	}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Get purchase orders
+// @Description  Get the purchase orders the user can see: approvers see the whole company, department managers their own department
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		status	query		string				false	"Pending, Approved, Rejected, Partially Received, Received or Cancelled"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) GetPurchaseOrders(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	orders, err := h.service.GetPurchaseOrders(auth, c.Query("status"))
+	if err != nil {
+		log.Error("Happened error when get purchase orders. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get purchase orders")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, orders))
+}
+
+// PurchaseOrder godoc
+// @Summary      Get purchase order
+// @Description  Get purchase order by id with its items, goods receipts and the assets created on receipt
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parsePurchaseOrderId(c)
+	order, err := h.service.GetPurchaseOrder(auth, id)
+	if err != nil {
+		log.Error("Happened error when get purchase order. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when get purchase order. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Approve purchase order
+// @Description  Approve a Pending purchase order, its estimated cost becomes committed budget
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        decision   body    dto.PurchaseOrderDecisionRequest   false  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/{id}/approve [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) Approve(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parsePurchaseOrderId(c)
+	request := bindDecision(c)
+	order, err := h.service.Approve(auth, id, request.Note)
+	if err != nil {
+		log.Error("Happened error when approve purchase order. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when approve purchase order. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Reject purchase order
+// @Description  Reject a Pending purchase order
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        decision   body    dto.PurchaseOrderDecisionRequest   false  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/{id}/reject [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) Reject(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parsePurchaseOrderId(c)
+	request := bindDecision(c)
+	order, err := h.service.Reject(auth, id, request.Note)
+	if err != nil {
+		log.Error("Happened error when reject purchase order. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when reject purchase order. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Cancel purchase order
+// @Description  Cancel an order that is not fully received. Quantities already received stay as spent, the rest is no longer committed
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/{id}/cancel [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) Cancel(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parsePurchaseOrderId(c)
+	order, err := h.service.Cancel(auth, id)
+	if err != nil {
+		log.Error("Happened error when cancel purchase order. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when cancel purchase order. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Receive goods
+// @Description  Receive some or all remaining quantities of an approved order. Each unit becomes an asset of the ordering department assigned to its asset manager. Serial numbers are generated when omitted
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        receipt   body    dto.GoodsReceiptRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/{id}/receipts [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) Receive(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parsePurchaseOrderId(c)
+	var request dto.GoodsReceiptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	order, err := h.service.Receive(auth, id, request)
+	if err != nil {
+		log.Error("Happened error when receive goods. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when receive goods. Error: "+err.Error())
+	}
+	config.Rdb.Del(config.Ctx, "assets:all")
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, order))
+}
+
+// PurchaseOrder godoc
+// @Summary      Purchase budget
+// @Description  Committed (remaining quantities of the approved orders still open at "to", or now when "to" is empty, at estimated cost) versus spent (actual cost of goods received in the range) per department. from/to are RFC3339 and optional, the range can't be longer than 2 years
+// @Tags         PurchaseOrder
+// @Accept       json
+// @Produce      json
+// @Param		from	query		string				false	"from"
+// @Param		to	query		string				false	"to"
+// @param Authorization header string true "Authorization"
+// @Router       /api/purchase-orders/budget [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *PurchaseOrderHandler) GetBudget(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	budget, err := h.service.GetBudget(auth, parseVendorTime(c, "from"), parseVendorTime(c, "to"))
+	if err != nil {
+		log.Error("Happened error when get purchase budget. Error", err)
+		pkg.PanicExeption(purchaseOrderError(err), "Happened error when get purchase budget. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, budget))
+}
@@ -8,6 +8,8 @@ import (
 
 	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -55,7 +57,7 @@ func (h *RequestTransferHandler) Create(c *gin.Context) {
 
 // Request Transfer godoc
 // @Summary      Accept Request Transfer
-// @Description  Accept Request Transfer
+// @Description  Approve the current step of the request transfer. The asset is selected at the first step that needs it, the asset is transferred when the last step is approved
 // @Tags         RequestTransfer
 // @Accept       json
 // @Produce      json
@@ -81,10 +83,10 @@ func (h *RequestTransferHandler) Accept(c *gin.Context) {
 		log.Error("Happened error when convert id to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest)
 	}
-	requestTransfer, err := h.service.Accept(userId, id, request.AssetId)
+	requestTransfer, err := h.service.Accept(userId, id, request.AssetId, request.Comment)
 	if err != nil {
 		log.Error("Happened error when accept request transfer. Error", err)
-		pkg.PanicExeption(constant.UnknownError, "Happened error when accept request transfer")
+		pkg.PanicExeption(constant.UnknownError, "Happened error when accept request transfer. Error: "+err.Error())
 	}
 	requestTransferResponse := utils.ConvertRequestTransferToResponse(requestTransfer)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, requestTransferResponse))
@@ -92,10 +94,11 @@ func (h *RequestTransferHandler) Accept(c *gin.Context) {
 
 // Request Transfer godoc
 // @Summary      Deny Request Transfer
-// @Description  Deny Request Transfer
+// @Description  Deny the current step of the request transfer
 // @Tags         RequestTransfer
 // @Accept       json
 // @Produce      json
+// @Param        Decision   body    dto.DecisionRequestTransferRequest   false  "Data"
 // @Param		id	path		int				true	"request_transfer_id"
 // @param Authorization header string true "Authorization"
 // @Router       /api/request-transfer/deny/{id} [PATCH]
@@ -112,10 +115,15 @@ func (h *RequestTransferHandler) Deny(c *gin.Context) {
 		log.Error("Happened error when convert id to int64. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest)
 	}
-	requestTransfer, err := h.service.Deny(userId, id)
+	var request dto.DecisionRequestTransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest)
+	}
+	requestTransfer, err := h.service.Deny(userId, id, request.Comment)
 	if err != nil {
 		log.Error("Happened error when deny request transfer. Error", err)
-		pkg.PanicExeption(constant.UnknownError, "Happened error when deny request transfer")
+		pkg.PanicExeption(constant.UnknownError, "Happened error when deny request transfer. Error: "+err.Error())
 	}
 	requestTransferResponse := utils.ConvertRequestTransferToResponse(requestTransfer)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, requestTransferResponse))
@@ -180,3 +188,92 @@ func (h *RequestTransferHandler) FilterRequestTransfer(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, data))
 }
+
+// Request Transfer godoc
+// @Summary      Cancel Request Transfer
+// @Description  Cancel a pending request transfer, only the requester can cancel
+// @Tags         RequestTransfer
+// @Accept       json
+// @Produce      json
+// @Param        Decision   body    dto.DecisionRequestTransferRequest   false  "Data"
+// @Param		id	path		int				true	"request_transfer_id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/request-transfer/cancel/{id} [PATCH]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RequestTransferHandler) Cancel(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert id to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest)
+	}
+	var request dto.DecisionRequestTransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest)
+	}
+	requestTransfer, err := h.service.Cancel(userId, id, request.Comment)
+	if err != nil {
+		log.Error("Happened error when cancel request transfer. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when cancel request transfer. Error: "+err.Error())
+	}
+	requestTransferResponse := utils.ConvertRequestTransferToResponse(requestTransfer)
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, requestTransferResponse))
+}
+
+// Request Transfer godoc
+// @Summary      Get approval chain
+// @Description  Get the request transfer approval chain of the company
+// @Tags         RequestTransfer
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/request-transfer/approval-chain [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RequestTransferHandler) GetApprovalChain(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	steps, err := h.service.GetApprovalChain(userId)
+	if err != nil {
+		log.Error("Happened error when get approval chain. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get approval chain")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertTransferApprovalStepsToResponses(steps)))
+}
+
+// Request Transfer godoc
+// @Summary      Update approval chain
+// @Description  Replace the request transfer approval chain of the company. Approver types: requester_department_head, source_department_manager, admin. minAssetCost makes a step apply only to assets costing at least that amount
+// @Tags         RequestTransfer
+// @Accept       json
+// @Produce      json
+// @Param        Chain   body    dto.UpdateTransferApprovalChainRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/request-transfer/approval-chain [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RequestTransferHandler) UpdateApprovalChain(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.UpdateTransferApprovalChainRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	steps, err := h.service.UpdateApprovalChain(userId, request.Steps)
+	if err != nil {
+		log.Error("Happened error when update approval chain. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when update approval chain. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertTransferApprovalStepsToResponses(steps)))
+}
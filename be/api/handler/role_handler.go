@@ -2,12 +2,15 @@ package handler
 
 import (
 	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
 	service "BE_Manage_device/internal/service/role"
 	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 type RoleHandler struct {
@@ -20,7 +23,7 @@ func NewRoleHandler(service *service.RoleService) *RoleHandler {
 
 // User godoc
 // @Summary      GetRole
-// @Description  GetRole
+// @Description  Get system roles and the custom roles of the current company
 // @Tags         Roles
 // @Accept       json
 // @Produce      json
@@ -32,7 +35,212 @@ func NewRoleHandler(service *service.RoleService) *RoleHandler {
 // @Security JWT
 func (h *RoleHandler) GetAllRole(c *gin.Context) {
 	defer pkg.PanicHandler(c)
-	roles := h.service.GetAllRole()
+	userId := utils.GetUserIdFromContext(c)
+	roles, err := h.service.GetAllRole(userId)
+	if err != nil {
+		log.Error("Happened error when get roles. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get roles")
+	}
 	rolesResponse := utils.ConvertRolesToResponsesArray(roles)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, rolesResponse))
 }
+
+// Role godoc
+// @Summary      Get role by id
+// @Description  Get role with its permissions and access levels
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) GetRoleById(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	roleId := parseRoleId(c, "id")
+	roles, userCount, err := h.service.GetRoleById(userId, roleId)
+	if err != nil {
+		log.Error("Happened error when get role by id. Error", err)
+		pkg.PanicExeption(constant.DataNotFound, "Happened error when get role by id. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertRoleToDetailResponse(roles, userCount)))
+}
+
+// Role godoc
+// @Summary      Get permissions
+// @Description  Get all permissions that can be attached to a role
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/permissions [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) GetAllPermissions(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	permissions, err := h.service.GetAllPermissions()
+	if err != nil {
+		log.Error("Happened error when get permissions. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get permissions")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertPermissionsToResponses(permissions)))
+}
+
+// Role godoc
+// @Summary      Create role
+// @Description  Create a custom role for the current company
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        role   body    dto.CreateRoleRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) Create(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	roles, err := h.service.Create(userId, request.Title, request.Slug, request.Description)
+	if err != nil {
+		log.Error("Happened error when create role. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when create role. Error: "+err.Error())
+	}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, utils.ConvertRoleToDetailResponse(roles, 0)))
+}
+
+// Role godoc
+// @Summary      Update role
+// @Description  Update title, description or activation of a custom role
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        role   body    dto.UpdateRoleRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles/{id} [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) Update(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	roleId := parseRoleId(c, "id")
+	var request dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	roles, err := h.service.Update(userId, roleId, request.Title, request.Description, request.Activated)
+	if err != nil {
+		log.Error("Happened error when update role. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when update role. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertRoleToDetailResponse(roles, 0)))
+}
+
+// Role godoc
+// @Summary      Delete role
+// @Description  Delete a custom role that is not assigned to any user
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles/{id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) Delete(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	roleId := parseRoleId(c, "id")
+	if err := h.service.Delete(userId, roleId); err != nil {
+		log.Error("Happened error when delete role. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when delete role. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// Role godoc
+// @Summary      Attach permission to role
+// @Description  Attach a permission to a custom role, or change its access level
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		permission_id	path		string				true	"permission_id"
+// @Param        permission   body    dto.SetRolePermissionRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles/{id}/permissions/{permission_id} [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) SetPermission(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	roleId := parseRoleId(c, "id")
+	permissionId := parseRoleId(c, "permission_id")
+	var request dto.SetRolePermissionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	roles, err := h.service.SetPermission(userId, roleId, permissionId, request.AccessLevel)
+	if err != nil {
+		log.Error("Happened error when set role permission. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when set role permission. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertRoleToDetailResponse(roles, 0)))
+}
+
+// Role godoc
+// @Summary      Detach permission from role
+// @Description  Remove a permission from a custom role
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		permission_id	path		string				true	"permission_id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/roles/{id}/permissions/{permission_id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *RoleHandler) RemovePermission(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	roleId := parseRoleId(c, "id")
+	permissionId := parseRoleId(c, "permission_id")
+	roles, err := h.service.RemovePermission(userId, roleId, permissionId)
+	if err != nil {
+		log.Error("Happened error when remove role permission. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when remove role permission. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, utils.ConvertRoleToDetailResponse(roles, 0)))
+}
+
+func parseRoleId(c *gin.Context, param string) int64 {
+	id, err := strconv.ParseInt(c.Param(param), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert "+param+" to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert "+param+" to int64")
+	}
+	return id
+}
@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"BE_Manage_device/constant"
 	service "BE_Manage_device/internal/service/notification"
+	"BE_Manage_device/pkg"
 	"BE_Manage_device/pkg/utils"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 )
 
 type SSEHandler struct {
@@ -20,11 +24,12 @@ func NewSSEHandler(service *service.NotificationService) *SSEHandler {
 }
 
 // User godoc
-// @Summary      GetRole
-// @Description  GetRole
+// @Summary      Subscribe notifications
+// @Description  Server-sent events stream, each frame carries the notification id, its type as event name and a JSON payload. Reconnect with the Last-Event-ID header to replay missed notifications; when more than 200 were missed a single notification.resync event is sent instead and the client should reload its inbox
 // @Tags         Sockets
 // @Accept       json
 // @Produce      json
+// @Param        Last-Event-ID  header  string  false  "id of the last received event"
 // @param Authorization header string true "Authorization"
 // @Router       /api/sse [GET]
 // @securityDefinitions.apiKey token
@@ -32,31 +37,91 @@ func NewSSEHandler(service *service.NotificationService) *SSEHandler {
 // @name Authorization
 // @Security JWT
 func (h *SSEHandler) SSEHandle(c *gin.Context) {
-	userId := utils.GetUserIdFromContext(c)
-	userIdStr := strconv.Itoa(int(userId))
-	msgChan := h.service.Register(userIdStr)
-	defer h.service.UnRegister(userIdStr, msgChan)
+	auth := utils.GetAuthContextFromContext(c)
+	userIdStr := strconv.FormatInt(auth.UserId, 10)
+	// Đăng ký trước khi replay để không lỡ event phát ra trong lúc đọc DB
+	client := h.service.Register(userIdStr, strconv.FormatInt(auth.CompanyId, 10))
+	defer h.service.UnRegister(userIdStr, client)
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
 	c.Writer.Header().Set("Connection", "keep-alive")
 	c.Writer.Flush()
+	var lastId int64
+	if lastEventId := c.GetHeader("Last-Event-ID"); lastEventId != "" {
+		lastId, _ = strconv.ParseInt(lastEventId, 10, 64)
+		missed, resync, err := h.service.GetMissedNotifications(auth.UserId, lastId)
+		if err != nil {
+			log.Error("Happened error when get missed notifications. Error", err)
+		}
+		for _, n := range missed {
+			writeSSEEvent(c, service.NewNotificationSSEEvent(n))
+			lastId = n.Id
+		}
+		if resync != nil {
+			writeSSEEvent(c, *resync)
+			lastId = resync.Id
+		}
+		c.Writer.Flush()
+	}
+	heartbeat := time.NewTicker(service.PresenceHeartbeatInterval)
+	defer heartbeat.Stop()
 	// Giữ connection tới khi client disconnect
 	notify := c.Writer.CloseNotify()
 	for {
 		select {
-		case msg := <-msgChan:
-			fmt.Fprintf(c.Writer, "data: %s\n\n", msg)
+		case event := <-client.Events:
+			// Bỏ qua event đã gửi trong lúc replay
+			if event.Id != 0 && event.Id <= lastId {
+				continue
+			}
+			writeSSEEvent(c, event)
 			c.Writer.Flush()
+		case <-client.Overflow:
+			return // Client quá chậm, kết nối lại sẽ được replay
 		case <-notify:
 			return // Client disconnect
-		case <-time.After(30 * time.Second):
-			// Gửi keep-alive ping (tránh timeout)
+		case <-heartbeat.C:
+			// Gửi keep-alive ping (tránh timeout) và gia hạn presence
 			fmt.Fprintf(c.Writer, ":\n\n")
 			c.Writer.Flush()
+			h.service.Heartbeat(userIdStr, client)
 		}
 	}
 }
 
+func writeSSEEvent(c *gin.Context, event service.SSEEvent) {
+	if event.Id != 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", event.Id)
+	}
+	if event.Event != "" {
+		fmt.Fprintf(c.Writer, "event: %s\n", event.Event)
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", event.Data)
+}
+
+// User godoc
+// @Summary      Get online users
+// @Description  Get ids of the users of the company that currently hold an SSE connection on any instance
+// @Tags         Sockets
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/sse/presence [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *SSEHandler) GetOnlineUsers(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	userIds, err := h.service.OnlineUsers(auth.CompanyId)
+	if err != nil {
+		log.Error("Happened error when get online users. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get online users")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, userIds))
+}
+
 func (h *SSEHandler) SendNotificationHandler(c *gin.Context) {
 	userId := c.Param("userId")
 	var req struct{ Message string }
@@ -64,6 +129,7 @@ func (h *SSEHandler) SendNotificationHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid"})
 		return
 	}
-	h.service.Push(userId, req.Message)
+	data, _ := json.Marshal(gin.H{"content": req.Message})
+	h.service.Push(userId, service.SSEEvent{Event: "message", Data: data})
 	c.JSON(http.StatusOK, gin.H{"status": "sent"})
 }
@@ -50,7 +50,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		log.Error("Happened error when mapping request from FE. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
 	}
-	_, err := h.service.Register(user.FirstName, user.LastName, user.Password, user.Email, user.RedirectUrl)
+	_, err := h.service.Register(user.FirstName, user.LastName, user.Password, user.Email, user.RedirectUrl, user.Language)
 	if err != nil {
 		log.Error("Happened error when saving data to database. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, err.Error())
@@ -60,7 +60,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 // User godoc
 // @Summary      Login
-// @Description  Login
+// @Description  Login. When the user has MFA enabled (or the company requires it for the role) the response contains mfa_required and a short-lived mfa_token instead of the tokens
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -76,11 +76,16 @@ func (h *UserHandler) Login(c *gin.Context) {
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
 	}
 
-	userLogin, accessToken, refreshToken, err := h.service.Login(user.Email, user.Password)
+	userLogin, accessToken, refreshToken, mfaChallenge, err := h.service.Login(user.Email, user.Password, sessionDevice(c, user.DeviceLabel))
 	if err != nil {
 		log.Error("Happened error when login. Error", err)
 		pkg.PanicExeption(constant.Invalidemailorpassword)
 	}
+	// Cần thêm bước MFA, FE gọi /auth/mfa/verify (hoặc /auth/mfa/enroll nếu chưa cài đặt) với mfa_token
+	if mfaChallenge != nil {
+		c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, mfaChallenge))
+		return
+	}
 	dataResponese := map[string]interface{}{}
 	if userLogin.IsActive {
 		dataResponese = map[string]interface{}{
@@ -119,7 +124,7 @@ func (h *UserHandler) Activate(c *gin.Context) {
 
 // User godoc
 // @Summary      Refresh Token
-// @Description  Refresh Token
+// @Description  Refresh Token. The refresh token is single-use, the response contains a new refresh token. Reusing an old one signs out the session
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -136,56 +141,16 @@ func (h *UserHandler) Refresh(c *gin.Context) {
 		log.Error("Happened error when mapping request from FE. Error", err)
 		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE")
 	}
-
-	ok := h.service.CheckRefreshToken(rq.RefreshToken)
-	if !ok {
-		log.Error("Happened error refresh token was invoked")
-		pkg.PanicExeption(constant.Unauthorized, "Refresh token was revoked")
+	accessToken, refreshToken, err := h.service.RefreshSession(rq.RefreshToken, sessionDevice(c, ""))
+	if err != nil {
+		log.Error("Happened error when refresh token. Error", err)
+		pkg.PanicExeption(constant.Unauthorized, err.Error())
 	}
-	refreshToken, err := jwt.Parse(rq.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, http.ErrAbortHandler
-		}
-		return []byte(config.RefreshSecret), nil
-	})
-	if err != nil || !refreshToken.Valid {
-		pkg.PanicExeption(constant.Unauthorized, "Refresh token was expired")
-		return
-	}
-
-	if claims, ok := refreshToken.Claims.(jwt.MapClaims); ok && refreshToken.Valid {
-		exp, ok := claims["exp"].(float64)
-		if !ok {
-			pkg.PanicExeption(constant.Unauthorized, "Invalid refresh token")
-			return
-		}
-		if int64(exp) < time.Now().Unix() {
-			pkg.PanicExeption(constant.StatusForbidden, "Refresh token was expired")
-			return
-		}
-		email := claims["email"].(string)
-		user, err := h.service.FindUserByEmail(email)
-		newAccessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"userId": user.Id,
-			"email":  email,
-			"exp":    time.Now().Add(time.Minute * 1).Unix(),
-		})
-
-		if err != nil {
-			pkg.PanicExeption(constant.UnknownError, "Happened error when create access token")
-		}
-
-		newAccessTokenString, err := newAccessToken.SignedString([]byte(config.AccessSecret))
-		if err != nil {
-			pkg.PanicExeption(constant.UnknownError, "Happened error when create access token")
-		}
-		data := map[string]interface{}{
-			"access_token": newAccessTokenString,
-		}
-		c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, data))
-	} else {
-		pkg.PanicExeption(constant.Unauthorized, "invalid refresh token")
+	data := map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
 	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, data))
 }
 
 // User godoc
@@ -329,13 +294,9 @@ func (h *UserHandler) CheckPasswordReset(c *gin.Context) {
 // @Security JWT
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
 	email := c.Param("email")
-	user, err := h.service.FindUserByEmail(email)
-	if err != nil {
-		log.Error("Happened error: Email is not registered. . Error", err)
-		pkg.PanicExeption(constant.UnknownError, err.Error())
-	}
-	err = h.service.DeleteUser(email)
+	user, err := h.service.DeleteUser(userId, email)
 	if err != nil {
 		log.Error("Happened error when delete user. Error", err)
 		pkg.PanicExeption(constant.UnknownError, err.Error())
@@ -362,12 +323,8 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 func (h *UserHandler) Logout(c *gin.Context) {
 	defer pkg.PanicHandler(c)
 	userId := utils.GetUserIdFromContext(c)
-	userSession, err := h.service.FindSessionById(userId)
-	if err != nil {
-		log.Error("Happened error when logout user. Error", err)
-		pkg.PanicExeption(constant.UnknownError, err.Error())
-	}
-	err = h.service.UpdateInvoked(*userSession)
+	sessionId := utils.GetSessionIdFromContext(c)
+	err := h.service.RevokeSession(userId, sessionId)
 	if err != nil {
 		log.Error("Happened error when logout user. Error", err)
 		pkg.PanicExeption(constant.UnknownError, err.Error())
@@ -406,6 +363,7 @@ func (h *UserHandler) GetAllUser(c *gin.Context) {
 // @Produce      json
 // @Param firstName formData string true "firstName"
 // @Param lastName formData string true "lastName"
+// @Param language formData string false "email language: en or vi"
 // @Param avatar formData file false "avatar to upload"
 // @param Authorization header string true "Authorization"
 // @Router       /api/user/information [PATCH]
@@ -420,12 +378,13 @@ func (h *UserHandler) UpdateInformationUser(c *gin.Context) {
 	userId := utils.GetUserIdFromContext(c)
 	firstName := c.PostForm("firstName")
 	lastName := c.PostForm("lastName")
+	language := c.PostForm("language")
 	image, err := c.FormFile("avatar")
 	var userUpdated *entity.Users
 	if err != nil {
-		userUpdated, err = h.service.UpdateInformation(userId, firstName, lastName, nil)
+		userUpdated, err = h.service.UpdateInformation(userId, firstName, lastName, language, nil)
 	} else {
-		userUpdated, err = h.service.UpdateInformation(userId, firstName, lastName, image)
+		userUpdated, err = h.service.UpdateInformation(userId, firstName, lastName, language, image)
 	}
 	if err != nil {
 		log.Error("Happened error when update information user. Error", err)
@@ -619,3 +578,66 @@ func (h *UserHandler) GetUserNotHaveDep(c *gin.Context) {
 	usersResponses := utils.ConvertUsersToUserResponses(users)
 	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, usersResponses))
 }
+
+// User godoc
+// @Summary      Get sessions
+// @Description   Get the signed-in devices of the current user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/sessions [GET]
+// @Success      200   {object}  dto.ApiResponseSuccessStruct
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	sessionId := utils.GetSessionIdFromContext(c)
+	sessions, err := h.service.GetSessions(userId, sessionId)
+	if err != nil {
+		log.Error("Happened error when get sessions. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get sessions")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, sessions))
+}
+
+// User godoc
+// @Summary      Revoke session
+// @Description   Sign out one device of the current user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/sessions/{id} [DELETE]
+// @Success      200   {object}  dto.ApiResponseSuccessNoData
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	sessionId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert sessionId to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert sessionId to int64")
+	}
+	if err := h.service.RevokeSession(userId, sessionId); err != nil {
+		log.Error("Happened error when revoke session. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when revoke session. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// sessionDevice lấy thông tin thiết bị từ request để gắn vào session
+func sessionDevice(c *gin.Context, deviceLabel string) dto.SessionDevice {
+	return dto.SessionDevice{
+		DeviceLabel: deviceLabel,
+		UserAgent:   c.Request.UserAgent(),
+		IpAddress:   c.ClientIP(),
+	}
+}
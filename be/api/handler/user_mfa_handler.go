@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// User godoc
+// @Summary      Verify MFA login
+// @Description  Second step of login, exchange the mfa_token and a TOTP code (or a recovery code) for the access/refresh tokens
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaVerifyRequest   true  "Data"
+// @Router       /api/auth/mfa/verify [POST]
+// @Success      200   {object}  dto.ApiResponseSuccessStruct
+// @Failure      500   {object}  dto.ApiResponseFail
+func (h *UserHandler) VerifyMfaLogin(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	var request dto.MfaVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	userLogin, accessToken, refreshToken, err := h.service.VerifyMfaLogin(request.MfaToken, request.Code, request.RecoveryCode, sessionDevice(c, request.DeviceLabel))
+	if err != nil {
+		log.Error("Happened error when verify mfa. Error", err)
+		pkg.PanicExeption(constant.Unauthorized, err.Error())
+	}
+	dataResponese := map[string]interface{}{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"is_active":     userLogin.IsActive,
+		"roleSlug":      userLogin.Role.Slug,
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dataResponese))
+}
+
+// User godoc
+// @Summary      Start MFA enrollment during login
+// @Description  For users whose company requires MFA but who have not enrolled yet, start the enrollment with the mfa_token returned by login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaChallengeEnrollRequest   true  "Data"
+// @Router       /api/auth/mfa/enroll [POST]
+// @Success      200   {object}  dto.ApiResponseSuccessStruct
+// @Failure      500   {object}  dto.ApiResponseFail
+func (h *UserHandler) BeginMfaEnrollmentWithChallenge(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	var request dto.MfaChallengeEnrollRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	enrollment, err := h.service.BeginMfaEnrollmentWithChallenge(request.MfaToken)
+	if err != nil {
+		log.Error("Happened error when begin mfa enrollment. Error", err)
+		pkg.PanicExeption(constant.Unauthorized, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, enrollment))
+}
+
+// User godoc
+// @Summary      Confirm MFA enrollment during login
+// @Description  Confirm the enrollment with the first TOTP code, returns the recovery codes together with the access/refresh tokens
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaChallengeConfirmRequest   true  "Data"
+// @Router       /api/auth/mfa/enroll/confirm [POST]
+// @Success      200   {object}  dto.ApiResponseSuccessStruct
+// @Failure      500   {object}  dto.ApiResponseFail
+func (h *UserHandler) ConfirmMfaEnrollmentWithChallenge(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	var request dto.MfaChallengeConfirmRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	userLogin, accessToken, refreshToken, recoveryCodes, err := h.service.ConfirmMfaEnrollmentWithChallenge(request.MfaToken, request.Code, sessionDevice(c, request.DeviceLabel))
+	if err != nil {
+		log.Error("Happened error when confirm mfa enrollment. Error", err)
+		pkg.PanicExeption(constant.Unauthorized, err.Error())
+	}
+	dataResponese := map[string]interface{}{
+		"access_token":   accessToken,
+		"refresh_token":  refreshToken,
+		"is_active":      userLogin.IsActive,
+		"roleSlug":       userLogin.Role.Slug,
+		"recovery_codes": recoveryCodes,
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dataResponese))
+}
+
+// User godoc
+// @Summary      Get MFA status
+// @Description  Get MFA status of the current user
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/mfa [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) GetMfaStatus(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	status, err := h.service.GetMfaStatus(userId)
+	if err != nil {
+		log.Error("Happened error when get mfa status. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get mfa status")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, status))
+}
+
+// User godoc
+// @Summary      Start MFA enrollment
+// @Description  Generate a new TOTP secret, returns the otpauth provisioning URI and a QR code (PNG base64)
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/mfa/enroll [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) BeginMfaEnrollment(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	enrollment, err := h.service.BeginMfaEnrollment(userId)
+	if err != nil {
+		log.Error("Happened error when begin mfa enrollment. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when begin mfa enrollment. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, enrollment))
+}
+
+// User godoc
+// @Summary      Confirm MFA enrollment
+// @Description  Enable MFA with the first TOTP code, returns the recovery codes. They are shown only once
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaCodeRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/mfa/confirm [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) ConfirmMfaEnrollment(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.MfaCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	recoveryCodes, err := h.service.ConfirmMfaEnrollment(userId, request.Code)
+	if err != nil {
+		log.Error("Happened error when confirm mfa enrollment. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when confirm mfa enrollment. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.MfaRecoveryCodesResponse{RecoveryCodes: recoveryCodes}))
+}
+
+// User godoc
+// @Summary      Disable MFA
+// @Description  Disable MFA, requires the password and a TOTP code or a recovery code. Not allowed when the company requires MFA for the role
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaDisableRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/mfa/disable [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) DisableMfa(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.MfaDisableRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	if err := h.service.DisableMfa(userId, request.Password, request.Code, request.RecoveryCode); err != nil {
+		log.Error("Happened error when disable mfa. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when disable mfa. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// User godoc
+// @Summary      Regenerate MFA recovery codes
+// @Description  Replace all recovery codes, requires a TOTP code
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        mfa   body    dto.MfaCodeRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/user/mfa/recovery-codes [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	userId := utils.GetUserIdFromContext(c)
+	var request dto.MfaCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE.")
+	}
+	recoveryCodes, err := h.service.RegenerateRecoveryCodes(userId, request.Code)
+	if err != nil {
+		log.Error("Happened error when regenerate recovery codes. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when regenerate recovery codes. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, dto.MfaRecoveryCodesResponse{RecoveryCodes: recoveryCodes}))
+}
@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	service "BE_Manage_device/internal/service/vendor"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type VendorHandler struct {
+	service *service.VendorService
+}
+
+func NewVendorHandler(service *service.VendorService) *VendorHandler {
+	return &VendorHandler{service: service}
+}
+
+func parseVendorParam(c *gin.Context, name string) int64 {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert "+name+" to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert "+name+" to int64")
+	}
+	return id
+}
+
+// parseVendorTime đọc query RFC3339, bỏ trống trả về nil
+func parseVendorTime(c *gin.Context, name string) *time.Time {
+	value := c.Query(name)
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		pkg.PanicExeption(constant.InvalidRequest, "Invalid "+name+".")
+	}
+	return &t
+}
+
+// parseVendorRange đọc from/to, mặc định là 12 tháng gần nhất tính tới hiện tại
+func parseVendorRange(c *gin.Context) (time.Time, time.Time) {
+	to := time.Now()
+	if t := parseVendorTime(c, "to"); t != nil {
+		to = *t
+	}
+	from := to.AddDate(-1, 0, 0)
+	if f := parseVendorTime(c, "from"); f != nil {
+		from = *f
+	}
+	return from, to
+}
+
+func vendorError(err error) constant.ResponseStatus {
+	switch {
+	case errors.Is(err, service.ErrVendorNotFound):
+		return constant.DataNotFound
+	case errors.Is(err, service.ErrInvalidVendor), errors.Is(err, service.ErrDuplicateTaxId),
+		errors.Is(err, service.ErrVendorInUse), errors.Is(err, utils.ErrInvalidRange):
+		return constant.InvalidRequest
+	}
+	return constant.UnknownError
+}
+
+// Vendor godoc
+// @Summary      Create vendor
+// @Description  Create a supplier / repair shop of the company with its contacts. The first contact is primary unless one is marked
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param        vendor   body    dto.VendorRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) Create(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.VendorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	vendor, err := h.service.Create(auth, request)
+	if err != nil {
+		log.Error("Happened error when create vendor. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when create vendor. Error: "+err.Error())
+	}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, vendor))
+}
+
+// Vendor godoc
+// @Summary      Get vendors
+// @Description  Get the vendors of the company, search matches name, tax id or email
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		search	query		string				false	"search"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetVendors(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	vendors, err := h.service.GetVendors(auth, c.Query("search"))
+	if err != nil {
+		log.Error("Happened error when get vendors. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get vendors")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, vendors))
+}
+
+// Vendor godoc
+// @Summary      Get vendor
+// @Description  Get vendor by id with its contacts
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetVendor(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	vendor, err := h.service.GetVendor(auth, parseVendorParam(c, "id"))
+	if err != nil {
+		log.Error("Happened error when get vendor. Error", err)
+		pkg.PanicExeption(vendorError(err), err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, vendor))
+}
+
+// Vendor godoc
+// @Summary      Update vendor
+// @Description  Update vendor details, the contacts list replaces the current contacts
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        vendor   body    dto.VendorRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id} [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) Update(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parseVendorParam(c, "id")
+	var request dto.VendorRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	vendor, err := h.service.Update(auth, id, request)
+	if err != nil {
+		log.Error("Happened error when update vendor. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when update vendor. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, vendor))
+}
+
+// Vendor godoc
+// @Summary      Delete vendor
+// @Description  Delete a vendor that is not linked to any asset, bill or maintenance schedule
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) Delete(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	if err := h.service.Delete(auth, parseVendorParam(c, "id")); err != nil {
+		log.Error("Happened error when delete vendor. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when delete vendor. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// Vendor godoc
+// @Summary      Get vendor assets
+// @Description  Get the assets purchased from a vendor
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id}/assets [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetAssets(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	assets, err := h.service.GetAssets(auth, parseVendorParam(c, "id"))
+	if err != nil {
+		log.Error("Happened error when get vendor assets. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when get vendor assets. Error: "+err.Error())
+	}
+	res := make([]dto.AssetResponse, 0, len(assets))
+	for _, a := range assets {
+		res = append(res, utils.ConvertAssetToResponse(*a))
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, res))
+}
+
+// Vendor godoc
+// @Summary      Get vendor spend
+// @Description  Total of the bills with the vendor per currency (billed, paid, outstanding) and the purchase cost of its assets, optionally limited to [from, to)
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		from	query		string				false	"from (RFC3339)"
+// @Param		to	query		string				false	"to (RFC3339)"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id}/spend [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetSpend(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parseVendorParam(c, "id")
+	spend, err := h.service.GetSpend(auth, id, parseVendorTime(c, "from"), parseVendorTime(c, "to"))
+	if err != nil {
+		log.Error("Happened error when get vendor spend. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when get vendor spend. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, spend))
+}
+
+// Vendor godoc
+// @Summary      Get vendor maintenance jobs
+// @Description  Expand the maintenance schedules serviced by the vendor into jobs between from and to (default: last 12 months). Completed jobs carry their turnaround in hours
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		from	query		string				false	"from (RFC3339)"
+// @Param		to	query		string				false	"to (RFC3339)"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id}/maintenance [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetMaintenanceJobs(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parseVendorParam(c, "id")
+	from, to := parseVendorRange(c)
+	jobs, err := h.service.GetMaintenanceJobs(auth, id, from, to)
+	if err != nil {
+		log.Error("Happened error when get vendor maintenance jobs. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when get vendor maintenance jobs. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, jobs))
+}
+
+// Vendor godoc
+// @Summary      Get vendor performance
+// @Description  Completed and in-progress jobs, average/min/max repair turnaround in hours and spend of a vendor between from and to (default: last 12 months)
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		from	query		string				false	"from (RFC3339)"
+// @Param		to	query		string				false	"to (RFC3339)"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/{id}/performance [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetPerformance(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parseVendorParam(c, "id")
+	from, to := parseVendorRange(c)
+	performance, err := h.service.GetPerformance(auth, id, from, to)
+	if err != nil {
+		log.Error("Happened error when get vendor performance. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when get vendor performance. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, performance))
+}
+
+// Vendor godoc
+// @Summary      Get vendors performance summary
+// @Description  Performance of every vendor of the company between from and to (default: last 12 months), fastest average turnaround first
+// @Tags         Vendor
+// @Accept       json
+// @Produce      json
+// @Param		from	query		string				false	"from (RFC3339)"
+// @Param		to	query		string				false	"to (RFC3339)"
+// @param Authorization header string true "Authorization"
+// @Router       /api/vendors/performance [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *VendorHandler) GetPerformanceSummary(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	from, to := parseVendorRange(c)
+	summary, err := h.service.GetPerformanceSummary(auth, from, to)
+	if err != nil {
+		log.Error("Happened error when get vendors performance summary. Error", err)
+		pkg.PanicExeption(vendorError(err), "Happened error when get vendors performance summary. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, summary))
+}
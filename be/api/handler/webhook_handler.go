@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/dto"
+	service "BE_Manage_device/internal/service/webhook"
+	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func parseWebhookParam(c *gin.Context, name string) int64 {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		log.Error("Happened error when convert "+name+" to int64. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when convert "+name+" to int64")
+	}
+	return id
+}
+
+// Webhook godoc
+// @Summary      Create webhook
+// @Description  Subscribe a url to asset lifecycle events. The signing secret is only returned once
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        webhook   body    dto.CreateWebhookRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) Create(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	var request dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	hook, err := h.service.Create(auth, request.Name, request.Url, request.Events)
+	if err != nil {
+		log.Error("Happened error when create webhook. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when create webhook. Error: "+err.Error())
+	}
+	res := dto.CreateWebhookResponse{Id: hook.Id, Name: hook.Name, Url: hook.Url, Events: hook.Events, Active: hook.Active, Secret: hook.Secret}
+	c.JSON(http.StatusCreated, pkg.BuildReponseSuccess(http.StatusCreated, constant.Success, res))
+}
+
+// Webhook godoc
+// @Summary      Get webhooks
+// @Description  Get the webhooks of the company
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	hooks, err := h.service.GetWebhooks(auth)
+	if err != nil {
+		log.Error("Happened error when get webhooks. Error", err)
+		pkg.PanicExeption(constant.UnknownError, "Happened error when get webhooks")
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, hooks))
+}
+
+// Webhook godoc
+// @Summary      Get webhook
+// @Description  Get webhook by id
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks/{id} [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	hook, err := h.service.GetWebhook(auth, parseWebhookParam(c, "id"))
+	if err != nil {
+		log.Error("Happened error when get webhook. Error", err)
+		pkg.PanicExeption(constant.DataNotFound, err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, hook))
+}
+
+// Webhook godoc
+// @Summary      Update webhook
+// @Description  Update url, event filter or active flag of a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param        webhook   body    dto.UpdateWebhookRequest   true  "Data"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks/{id} [PUT]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) Update(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	id := parseWebhookParam(c, "id")
+	var request dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		log.Error("Happened error when mapping request from FE. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when mapping request from FE. Error: "+err.Error())
+	}
+	hook, err := h.service.Update(auth, id, request.Name, request.Url, request.Events, *request.Active)
+	if err != nil {
+		log.Error("Happened error when update webhook. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when update webhook. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, hook))
+}
+
+// Webhook godoc
+// @Summary      Delete webhook
+// @Description  Delete a webhook and its delivery log
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks/{id} [DELETE]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	if err := h.service.Delete(auth, parseWebhookParam(c, "id")); err != nil {
+		log.Error("Happened error when delete webhook. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when delete webhook. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccessNoData(http.StatusOK, constant.Success))
+}
+
+// Webhook godoc
+// @Summary      Get webhook deliveries
+// @Description  Get the latest deliveries of a webhook with response code and latency of every attempt
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks/{id}/deliveries [GET]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	deliveries, err := h.service.GetDeliveries(auth, parseWebhookParam(c, "id"))
+	if err != nil {
+		log.Error("Happened error when get webhook deliveries. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when get webhook deliveries. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, deliveries))
+}
+
+// Webhook godoc
+// @Summary      Redeliver webhook delivery
+// @Description  Send the payload of a past delivery again as a new delivery
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param		id	path		string				true	"id"
+// @Param		delivery_id	path		string				true	"delivery id"
+// @param Authorization header string true "Authorization"
+// @Router       /api/webhooks/{id}/deliveries/{delivery_id}/redeliver [POST]
+// @securityDefinitions.apiKey token
+// @in header
+// @name Authorization
+// @Security JWT
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	defer pkg.PanicHandler(c)
+	auth := utils.GetAuthContextFromContext(c)
+	delivery, err := h.service.Redeliver(auth, parseWebhookParam(c, "id"), parseWebhookParam(c, "delivery_id"))
+	if err != nil {
+		log.Error("Happened error when redeliver webhook. Error", err)
+		pkg.PanicExeption(constant.InvalidRequest, "Happened error when redeliver webhook. Error: "+err.Error())
+	}
+	c.JSON(http.StatusOK, pkg.BuildReponseSuccess(http.StatusOK, constant.Success, delivery))
+}
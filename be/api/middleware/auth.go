@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/policy"
+	apiTokenR "BE_Manage_device/internal/repository/api_token"
 	repository "BE_Manage_device/internal/repository/user_session"
 
 	"BE_Manage_device/pkg"
@@ -9,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,7 +22,10 @@ import (
 	"gorm.io/gorm"
 )
 
-func AuthMiddleware(secretKey string, session repository.UsersSessionRepository) gin.HandlerFunc {
+const lastSeenInterval = time.Minute
+
+func AuthMiddleware(secretKey string, session repository.UsersSessionRepository, db *gorm.DB) gin.HandlerFunc {
+	apiTokens := apiTokenR.NewPostgreSQLApiTokensRepository(db)
 	return func(c *gin.Context) {
 		defer pkg.PanicHandler(c)
 		authHeader := c.GetHeader("Authorization")
@@ -28,6 +34,11 @@ func AuthMiddleware(secretKey string, session repository.UsersSessionRepository)
 			c.Abort()
 		}
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if utils.IsApiToken(tokenString) {
+			authenticateApiToken(c, apiTokens, db, tokenString)
+			c.Next()
+			return
+		}
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, http.ErrAbortHandler
@@ -56,16 +67,30 @@ func AuthMiddleware(secretKey string, session repository.UsersSessionRepository)
 
 			userIdConvert, _ := strconv.ParseInt(str, 10, 64)
 
-			if !session.CheckUserInSession(userIdConvert) {
+			userSession, err := session.FindByAccessToken(tokenString)
+			if err != nil || userSession.UserId != userIdConvert {
 				pkg.PanicExeption(constant.Unauthorized, "Unauthorized Access Token")
 				c.Abort()
 				return
 			}
-			if session.CheckTokenWasInVoked(tokenString) {
+			if userSession.IsRevoked {
 				pkg.PanicExeption(constant.Unauthorized, "Access Token was revoked")
 				c.Abort()
 				return
 			}
+			c.Set("sessionID", userSession.Id)
+			// Cập nhật last-seen tối đa 1 lần/phút để không ghi DB ở mọi request
+			now := time.Now()
+			if userSession.LastSeenAt == nil || now.Sub(*userSession.LastSeenAt) > lastSeenInterval {
+				if err := session.UpdateLastSeen(userSession.Id, now, c.ClientIP()); err != nil {
+					logrus.Error("Happened error when update session last seen. Error", err)
+				}
+			}
+			if _, err := utils.ResolveAuthContext(c, db, userIdConvert); err != nil {
+				pkg.PanicExeption(constant.Unauthorized, "Unauthorized Access Token")
+				c.Abort()
+				return
+			}
 		} else {
 			pkg.PanicExeption(constant.Unauthorized, "Unauthorized Access Token")
 			c.Abort()
@@ -76,6 +101,52 @@ func AuthMiddleware(secretKey string, session repository.UsersSessionRepository)
 	}
 }
 
+// authenticateApiToken xác thực personal access token, quyền của request là phần giao giữa scopes của token và role của owner
+func authenticateApiToken(c *gin.Context, apiTokens apiTokenR.ApiTokensRepository, db *gorm.DB, tokenString string) {
+	apiToken, err := apiTokens.FindByHash(utils.HashToken(tokenString))
+	if err != nil {
+		pkg.PanicExeption(constant.Unauthorized, "Invalid API token")
+	}
+	now := time.Now()
+	if !apiToken.IsActive(now) {
+		pkg.PanicExeption(constant.Unauthorized, "API token expired or revoked")
+	}
+	c.Set("userID", apiToken.UserId)
+	c.Set("apiTokenID", apiToken.Id)
+	auth, err := utils.ResolveAuthContext(c, db, apiToken.UserId)
+	if err != nil || !auth.IsActive {
+		pkg.PanicExeption(constant.Unauthorized, "Invalid API token")
+	}
+	auth.RestrictToScopes(apiToken.Id, apiToken.Scopes)
+	checkRouteScope(c, auth)
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > lastSeenInterval {
+		if err := apiTokens.UpdateLastUsed(apiToken.Id, now, c.ClientIP()); err != nil {
+			logrus.Error("Happened error when update api token last used. Error", err)
+		}
+	}
+}
+
+const apiTokenScopesKey = "apiTokenScopes"
+
+// RequireScope khai báo scope API token của nhóm route, phải đứng trước AuthMiddleware để AuthMiddleware đọc được.
+// JWT luôn qua, API token phải có 1 trong các scope. Route không khai báo scope thì API token bị chặn (mặc định là chặn)
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiTokenScopesKey, scopes)
+		c.Next()
+	}
+}
+
+func checkRouteScope(c *gin.Context, auth *policy.AuthContext) {
+	scopes := c.GetStringSlice(apiTokenScopesKey)
+	if len(scopes) == 0 {
+		pkg.PanicExeption(constant.StatusForbidden, "API tokens are not allowed on this endpoint")
+	}
+	if !slices.ContainsFunc(scopes, auth.InScope) {
+		pkg.PanicExeption(constant.StatusForbidden, "API token scope does not allow this endpoint")
+	}
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
@@ -115,12 +186,13 @@ func RequirePermission(permSlug []string, accessLevel []string, db *gorm.DB) gin
 			c.Abort()
 			return
 		}
-		ok, err := utils.UserHasPermission(db, userIdConvert, permSlug, accessLevel)
+		auth, err := utils.ResolveAuthContext(c, db, userIdConvert)
 		if err != nil {
 			pkg.PanicExeption(constant.UnknownError, "Internal server error")
 			c.Abort()
 			return
 		}
+		ok := auth.Granted(permSlug, accessLevel)
 		if !ok {
 			pkg.PanicExeption(constant.StatusForbidden, "Forbidden")
 			c.Abort()
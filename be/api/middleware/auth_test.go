@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"BE_Manage_device/internal/domain/policy"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiTokenAuth giả lập nhánh API token của AuthMiddleware: nạp quyền của owner, giới hạn theo scopes rồi chặn route không khai báo scope
+func apiTokenAuth(scopes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				c.AbortWithStatus(http.StatusForbidden)
+			}
+		}()
+		auth := policy.NewAuthContext(1, 1, nil, map[string]string{
+			policy.PermissionViewAssets:    "full",
+			policy.PermissionManageAssets:  "full",
+			policy.PermissionNotifications: "action",
+		})
+		auth.RestrictToScopes(7, scopes)
+		c.Set("userID", int64(1))
+		c.Set("apiTokenID", int64(7))
+		c.Set("authContext", auth)
+		checkRouteScope(c, auth)
+		c.Next()
+	}
+}
+
+func TestApiTokenScopedToViewAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api := r.Group("/api")
+	auth := apiTokenAuth([]string{policy.PermissionViewAssets})
+	viewAssets := api.Group("", RequireScope("view-assets"), auth)
+	manageAssets := api.Group("", RequireScope("manage-assets"), auth)
+	notifications := api.Group("", RequireScope("notifications"), auth)
+	anyOf := api.Group("", RequireScope("manage-assets", "view-assets"), auth)
+	jwtOnly := api.Group("", auth)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	viewAssets.GET("/permission/view", RequirePermission([]string{"view-assets"}, nil, nil), ok)
+	manageAssets.GET("/permission/manage", RequirePermission([]string{"manage-assets"}, nil, nil), ok)
+	viewAssets.GET("/scope/view", ok)
+	notifications.GET("/scope/notifications", ok)
+	anyOf.GET("/scope/any", ok)
+	jwtOnly.GET("/undeclared", ok)
+	// scope khai báo sau AuthMiddleware thì AuthMiddleware không thấy, vẫn bị chặn
+	api.GET("/late", auth, RequireScope("view-assets"), ok)
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"RequirePermission with scope", "/api/permission/view", http.StatusOK},
+		{"RequirePermission outside scope", "/api/permission/manage", http.StatusForbidden},
+		{"scope allowed", "/api/scope/view", http.StatusOK},
+		{"scope not allowed", "/api/scope/notifications", http.StatusForbidden},
+		{"one of scopes", "/api/scope/any", http.StatusOK},
+		{"route without scope", "/api/undeclared", http.StatusForbidden},
+		{"scope after auth", "/api/late", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerApiTokenRoutes(api *gin.RouterGroup, h *handler.ApiTokenHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
+
+	jwtOnly.GET("/user/api-tokens", h.GetMyTokens)
+	jwtOnly.POST("/user/api-tokens", h.Create)
+	jwtOnly.DELETE("/user/api-tokens/:id", h.RevokeMyToken)
+	jwtOnly.GET("/api-tokens", middleware.RequirePermission([]string{"user-management"}, nil, db), h.GetCompanyTokens)
+	jwtOnly.DELETE("/api-tokens/:id", middleware.RequirePermission([]string{"user-management"}, nil, db), h.RevokeCompanyToken)
+
+}
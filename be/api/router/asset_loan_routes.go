@@ -0,0 +1,24 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerAssetLoanRoutes(api *gin.RouterGroup, h *handler.AssetLoanHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	assignAssets := api.Group("", middleware.RequireScope("assign-assets"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
+
+	assignAssets.POST("/asset-loans/check-out", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.CheckOut)
+	jwtOnly.POST("/asset-loans/:id/check-in", h.CheckIn)
+	jwtOnly.GET("/asset-loans", h.FilterAssetLoans)
+	jwtOnly.GET("/asset-loans/:id", h.GetAssetLoanById)
+
+}
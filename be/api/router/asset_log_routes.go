@@ -11,8 +11,9 @@ import (
 )
 
 func registerAssetLogsRoutes(api *gin.RouterGroup, h *handler.AssetLogHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	auditLogs := api.Group("", middleware.RequireScope("audit-logs"), auth)
 
-	api.GET("/assets-log/:id", middleware.RequirePermission([]string{"audit-logs"}, []string{"full", "partial"}, db), h.GetLogByAssetId) // đã check
+	auditLogs.GET("/assets-log/:id", middleware.RequirePermission([]string{"audit-logs"}, []string{"full", "partial"}, db), h.GetLogByAssetId) // đã check
 
 }
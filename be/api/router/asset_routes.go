@@ -11,17 +11,27 @@ import (
 )
 
 func registerAssetsRoutes(api *gin.RouterGroup, h *handler.AssetsHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageAssets := api.Group("", middleware.RequireScope("manage-assets"), auth)
+	viewAssets := api.Group("", middleware.RequireScope("view-assets"), auth)
+	lifecycleUpdateManageAssets := api.Group("", middleware.RequireScope("lifecycle-update", "manage-assets"), auth)
+	dashboards := api.Group("", middleware.RequireScope("dashboards"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.POST("/assets", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Create)    // đã check
-	api.GET("/assets/:id", h.GetAssetById)                                                                                     // đã check
-	api.GET("/assets", h.GetAllAsset)                                                                                          // đã check
-	api.GET("/assets/filter", h.FilterAsset)                                                                                   // đã check
-	api.PUT("/assets/:id", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Update) // đã check
-	api.DELETE("/assets/:id", middleware.RequirePermission([]string{"manage-assets"}, nil, db), h.DeleteAsset)
-	api.PATCH("/assets-retired/:id", middleware.RequirePermission([]string{"lifecycle-update", "manage-assets"}, nil, db), h.UpdateAssetRetired)      // đã check
-	api.GET("/assets/filter-dashboard", middleware.RequirePermission([]string{"dashboards"}, []string{"full", "scoped"}, db), h.FilterAssetDashboard) // đã check
-	api.GET("/assets/request-transfer", h.GetAssetsByCateOfDepartment)
-	api.GET("/assets/maintenance-schedules", h.GetAllAssetNotHaveMaintenance)
+	manageAssets.POST("/assets", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Create) // đã check
+	manageAssets.POST("/assets/import", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.ImportAssets)
+	viewAssets.GET("/assets/:id", h.GetAssetById)                                                                                       // đã check
+	viewAssets.GET("/assets", h.GetAllAsset)                                                                                            // đã check
+	viewAssets.GET("/assets/filter", h.FilterAsset)                                                                                     // đã check
+	manageAssets.PUT("/assets/:id", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Update) // đã check
+	manageAssets.DELETE("/assets/:id", middleware.RequirePermission([]string{"manage-assets"}, nil, db), h.DeleteAsset)
+	lifecycleUpdateManageAssets.PATCH("/assets-retired/:id", middleware.RequirePermission([]string{"lifecycle-update", "manage-assets"}, nil, db), h.UpdateAssetRetired) // đã check
+	dashboards.GET("/assets/filter-dashboard", middleware.RequirePermission([]string{"dashboards"}, []string{"full", "scoped"}, db), h.FilterAssetDashboard)             // đã check
+	jwtOnly.GET("/assets/request-transfer", h.GetAssetsByCateOfDepartment)
+	jwtOnly.GET("/assets/maintenance-schedules", h.GetAllAssetNotHaveMaintenance)
+	manageAssets.GET("/assets/:id/grants", middleware.RequirePermission([]string{"manage-assets"}, nil, db), h.GetAssetGrants)
+	manageAssets.POST("/assets/:id/grants", middleware.RequirePermission([]string{"manage-assets"}, nil, db), h.CreateAssetGrant)
+	manageAssets.DELETE("/assets/:id/grants/:grant_id", middleware.RequirePermission([]string{"manage-assets"}, nil, db), h.DeleteAssetGrant)
 
 }
@@ -11,11 +11,12 @@ import (
 )
 
 func registerAssignmentRoutes(api *gin.RouterGroup, h *handler.AssignmentHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	assignAssets := api.Group("", middleware.RequireScope("assign-assets"), auth)
 
-	api.POST("/assignments", middleware.RequirePermission([]string{"assign-assets"}, nil, db), h.Create)
-	api.PUT("/assignments/:id", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.Update)              // đã check
-	api.GET("/assignments/filter", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.FilterAssignment) // đã check
-	api.GET("/assignments/:id", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.GetAssignmentById)   // đã check
+	assignAssets.POST("/assignments", middleware.RequirePermission([]string{"assign-assets"}, nil, db), h.Create)
+	assignAssets.PUT("/assignments/:id", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.Update)              // đã check
+	assignAssets.GET("/assignments/filter", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.FilterAssignment) // đã check
+	assignAssets.GET("/assignments/:id", middleware.RequirePermission([]string{"assign-assets"}, []string{"full", "conditional"}, db), h.GetAssignmentById)   // đã check
 
 }
@@ -11,11 +11,16 @@ import (
 )
 
 func registerBillsRoutes(api *gin.RouterGroup, h *handler.BillsHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
 
-	api.POST("/bills", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)
-	api.GET("/bills/:billNumber", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetByBillNumber)
-	api.GET("/bills/filter", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.FilterBill)
-	api.GET("/bills-un-paid", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetAllBillUnpaid)
-	api.PATCH("/bills/:billNumber", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.UpdatePaid)
+	manageTaxonomy.POST("/bills", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)
+	manageTaxonomy.GET("/bills/:billNumber", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetByBillNumber)
+	manageTaxonomy.GET("/bills/:billNumber/pdf", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.DownloadPDF)
+	manageTaxonomy.GET("/bills/aging", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetAgingReport)
+	manageTaxonomy.POST("/bills/:billNumber/payments", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.RecordPayment)
+	manageTaxonomy.GET("/bills/:billNumber/payments", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetPayments)
+	manageTaxonomy.GET("/bills/filter", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.FilterBill)
+	manageTaxonomy.GET("/bills-un-paid", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetAllBillUnpaid)
+	manageTaxonomy.PATCH("/bills/:billNumber", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.UpdatePaid)
 }
@@ -11,10 +11,13 @@ import (
 )
 
 func registerCategoriesRoutes(api *gin.RouterGroup, h *handler.CategoriesHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.POST("/categories", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
-	api.GET("/categories", h.GetAll)                                                                            // đã check
-	api.DELETE("/categories/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
+	manageTaxonomy.POST("/categories", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
+	jwtOnly.GET("/categories", h.GetAll)                                                                                   // đã check
+	manageTaxonomy.DELETE("/categories/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
 
 }
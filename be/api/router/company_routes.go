@@ -11,9 +11,13 @@ import (
 )
 
 func registerCompanyRoutes(api *gin.RouterGroup, h *handler.CompanyHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
+	systemSettings := api.Group("", middleware.RequireScope("system-settings"), auth)
 
-	api.POST("/company", h.Create)            // đã check
-	api.GET("/company/:id", h.GetCompanyById) // đã check
+	jwtOnly.POST("/company", h.Create)            // đã check
+	jwtOnly.GET("/company/:id", h.GetCompanyById) // đã check
+	systemSettings.PATCH("/company/mfa-policy", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.UpdateMfaPolicy)
 
 }
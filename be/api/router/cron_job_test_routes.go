@@ -2,14 +2,20 @@ package api
 
 import (
 	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func registerCronJobTestRoutes(api *gin.RouterGroup, h *handler.CronJobTestHandler) {
+func registerCronJobTestRoutes(api *gin.RouterGroup, h *handler.CronJobTestHandler, session repository.UsersSessionRepository, db *gorm.DB) {
 
 	api.GET("/CheckAndSenMaintenanceNotification", h.CheckAndSenMaintenanceNotification)
 	api.GET("/SendEmailsForWarrantyExpiry", h.SendEmailsForWarrantyExpiry)
 	api.GET("/UpdateStatusWhenFinishMaintenance", h.UpdateStatusWhenFinishMaintenance)
+	// Gửi mail cho người mượn nên chỉ admin hệ thống mới được gọi tay
+	api.GET("/SendOverdueLoanNotifications", middleware.RequireScope("system-settings"), middleware.AuthMiddleware(config.AccessSecret, session, db), middleware.RequirePermission([]string{"system-settings"}, nil, db), h.SendOverdueLoanNotifications)
 
 }
@@ -11,10 +11,14 @@ import (
 )
 
 func registerDepartmentRoutes(api *gin.RouterGroup, h *handler.DepartmentsHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.POST("/departments", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
-	api.GET("/departments", h.GetAll)                                                                            // đã check
-	api.DELETE("/departments/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
+	manageTaxonomy.POST("/departments", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
+	jwtOnly.GET("/departments", h.GetAll)                                                                                   // đã check
+	manageTaxonomy.DELETE("/departments/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
+	manageTaxonomy.PUT("/departments/:id/head", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.UpdateHead)
 
 }
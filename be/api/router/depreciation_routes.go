@@ -0,0 +1,21 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerDepreciationRoutes(api *gin.RouterGroup, h *handler.DepreciationHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	depreciation := api.Group("", middleware.RequireScope("depreciation"), auth)
+
+	depreciation.GET("/assets/:id/depreciation", middleware.RequirePermission([]string{"depreciation"}, []string{"full", "view"}, db), h.GetSchedule)
+	depreciation.PUT("/assets/:id/depreciation", middleware.RequirePermission([]string{"depreciation"}, nil, db), h.UpdateAssetDepreciation)
+	depreciation.PUT("/assets/:id/depreciation/usage", middleware.RequirePermission([]string{"depreciation"}, nil, db), h.RecordUsage)
+	depreciation.PUT("/categories/:id/depreciation", middleware.RequirePermission([]string{"depreciation"}, nil, db), h.UpdateCategoryDepreciation)
+}
@@ -0,0 +1,15 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// File được truy cập trực tiếp qua URL (ảnh, QR) nên không đi qua AuthMiddleware,
+// mặc định local driver là private nên mọi request đều phải có chữ ký của SignedURL
+func registerFileRoutes(api *gin.RouterGroup, h *handler.FileHandler) {
+
+	api.GET("/files/*path", h.Download)
+
+}
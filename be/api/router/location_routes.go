@@ -11,9 +11,12 @@ import (
 )
 
 func registerLocationsRoutes(api *gin.RouterGroup, h *handler.LocationHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.POST("/locations", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
-	api.GET("/locations", h.GetAll)                                                                            // đã check
-	api.DELETE("/locations/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
+	manageTaxonomy.POST("/locations", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)       // đã check
+	jwtOnly.GET("/locations", h.GetAll)                                                                                   // đã check
+	manageTaxonomy.DELETE("/locations/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete) // đã check
 }
@@ -11,12 +11,15 @@ import (
 )
 
 func registerMaintenanceSchedulesRoutes(api *gin.RouterGroup, h *handler.MaintenanceSchedulesHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	maintenanceLogs := api.Group("", middleware.RequireScope("maintenance-logs"), auth)
 
-	api.POST("/maintenance-schedules", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Create)
-	api.GET("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, []string{"full", "view"}, db), h.GetAllMaintenanceSchedulesByAssetId)
-	api.PATCH("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Update)
-	api.DELETE("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Delete)
-	api.GET("/maintenance-schedules", middleware.RequirePermission([]string{"maintenance-logs"}, []string{"full", "view"}, db), h.GetAllMaintenanceSchedules) // đã check
+	maintenanceLogs.POST("/maintenance-schedules", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Create)
+	maintenanceLogs.GET("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, []string{"full", "view"}, db), h.GetAllMaintenanceSchedulesByAssetId)
+	maintenanceLogs.GET("/maintenance-schedules/:id/occurrences", middleware.RequirePermission([]string{"maintenance-logs"}, []string{"full", "view"}, db), h.GetOccurrencesByAssetId)
+	maintenanceLogs.PATCH("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Update)
+	maintenanceLogs.DELETE("/maintenance-schedules/:id", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.Delete)
+	maintenanceLogs.PUT("/maintenance-schedules/:id/job", middleware.RequirePermission([]string{"maintenance-logs"}, nil, db), h.RecordJob)
+	maintenanceLogs.GET("/maintenance-schedules", middleware.RequirePermission([]string{"maintenance-logs"}, []string{"full", "view"}, db), h.GetAllMaintenanceSchedules) // đã check
 
 }
@@ -11,7 +11,10 @@ import (
 )
 
 func registerMonthlySummaryRoutes(api *gin.RouterGroup, h *handler.MonthlySummaryHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+	systemSettings := api.Group("", middleware.RequireScope("system-settings"), auth)
 
-	api.GET("/monthly-summary/filter", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Filter)
+	manageTaxonomy.GET("/monthly-summary/filter", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Filter)
+	systemSettings.POST("/monthly-summary/regenerate", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.Regenerate)
 }
@@ -11,10 +11,22 @@ import (
 )
 
 func registerNotificationsRoutes(api *gin.RouterGroup, h *handler.NotificationHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	notifications := api.Group("", middleware.RequireScope("notifications"), auth)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.GET("/notifications", h.GetNotificationsByUserId)
+	notifications.GET("/notifications", h.GetNotificationsByUserId)
+	notifications.GET("/notifications/unread-count", h.GetUnreadCount)
+	notifications.PUT("/notifications/read", h.MarkSeen)
+	notifications.PUT("/notifications/read-all", h.MarkAllSeen)
+	notifications.PUT("/notifications/archive", h.Archive)
+	notifications.DELETE("/notifications", h.Delete)
 
-	api.PUT("/notifications/:id", h.UpdateStatusToSeen) // đã check
+	notifications.PUT("/notifications/:id", h.UpdateStatusToSeen) // đã check
+
+	jwtOnly.GET("/notification-preferences", h.GetPreferences)
+	jwtOnly.PUT("/notification-preferences", h.UpdatePreferences)
+	jwtOnly.PUT("/notification-settings", h.UpdateSettings)
 
 }
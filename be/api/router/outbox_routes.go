@@ -0,0 +1,22 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerOutboxRoutes(api *gin.RouterGroup, h *handler.OutboxHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	systemSettings := api.Group("", middleware.RequireScope("system-settings"), auth)
+
+	systemSettings.GET("/outbox", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.GetMessages)
+	systemSettings.POST("/outbox/replay", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.ReplayDead)
+	systemSettings.GET("/outbox/:id", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.GetMessage)
+	systemSettings.POST("/outbox/:id/replay", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.Replay)
+
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerPurchaseOrderRoutes(api *gin.RouterGroup, h *handler.PurchaseOrderHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageAssetsManageTaxonomy := api.Group("", middleware.RequireScope("manage-assets", "manage-taxonomy"), auth)
+	manageAssets := api.Group("", middleware.RequireScope("manage-assets"), auth)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+
+	manageAssetsManageTaxonomy.GET("/purchase-orders", middleware.RequirePermission([]string{"manage-assets", "manage-taxonomy"}, []string{"full", "limited"}, db), h.GetPurchaseOrders)
+	manageAssets.POST("/purchase-orders", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Create)
+	manageAssetsManageTaxonomy.GET("/purchase-orders/budget", middleware.RequirePermission([]string{"manage-assets", "manage-taxonomy"}, []string{"full", "limited"}, db), h.GetBudget)
+	manageAssetsManageTaxonomy.GET("/purchase-orders/:id", middleware.RequirePermission([]string{"manage-assets", "manage-taxonomy"}, []string{"full", "limited"}, db), h.GetPurchaseOrder)
+	manageTaxonomy.POST("/purchase-orders/:id/approve", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Approve)
+	manageTaxonomy.POST("/purchase-orders/:id/reject", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Reject)
+	manageAssetsManageTaxonomy.POST("/purchase-orders/:id/cancel", middleware.RequirePermission([]string{"manage-assets", "manage-taxonomy"}, []string{"full", "limited"}, db), h.Cancel)
+	manageAssets.POST("/purchase-orders/:id/receipts", middleware.RequirePermission([]string{"manage-assets"}, []string{"full", "limited"}, db), h.Receive)
+}
@@ -11,12 +11,19 @@ import (
 )
 
 func registerRequestTransferRoutes(api *gin.RouterGroup, h *handler.RequestTransferHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	transferAssets := api.Group("", middleware.RequireScope("transfer-assets"), auth)
+	transferAssetsSystemSettings := api.Group("", middleware.RequireScope("transfer-assets", "system-settings"), auth)
+	systemSettings := api.Group("", middleware.RequireScope("system-settings"), auth)
 
-	api.POST("/request-transfer", middleware.RequirePermission([]string{"transfer-assets"}, []string{"full", "can-request"}, db), h.Create) // đã check
-	api.PATCH("/request-transfer/confirm/:id", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.Accept)                // đã check
-	api.PATCH("/request-transfer/deny/:id", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.Deny)                     // đã check
-	api.GET("/request-transfer/:id", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.GetRequestTransferById)          // đã check
-	api.GET("/request-transfer/filter", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.FilterRequestTransfer)        // đã check
+	transferAssets.POST("/request-transfer", middleware.RequirePermission([]string{"transfer-assets"}, []string{"full", "can-request"}, db), h.Create) // đã check
+	// Admin không có quyền transfer-assets nhưng có thể là 1 bước trong chuỗi duyệt, service kiểm tra người duyệt của từng bước
+	transferAssetsSystemSettings.PATCH("/request-transfer/confirm/:id", middleware.RequirePermission([]string{"transfer-assets", "system-settings"}, nil, db), h.Accept) // đã check
+	transferAssetsSystemSettings.PATCH("/request-transfer/deny/:id", middleware.RequirePermission([]string{"transfer-assets", "system-settings"}, nil, db), h.Deny)      // đã check
+	transferAssets.PATCH("/request-transfer/cancel/:id", middleware.RequirePermission([]string{"transfer-assets"}, []string{"full", "can-request"}, db), h.Cancel)
+	systemSettings.GET("/request-transfer/approval-chain", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.GetApprovalChain)
+	systemSettings.PUT("/request-transfer/approval-chain", middleware.RequirePermission([]string{"system-settings"}, nil, db), h.UpdateApprovalChain)
+	transferAssets.GET("/request-transfer/:id", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.GetRequestTransferById)   // đã check
+	transferAssets.GET("/request-transfer/filter", middleware.RequirePermission([]string{"transfer-assets"}, nil, db), h.FilterRequestTransfer) // đã check
 
 }
@@ -11,8 +11,18 @@ import (
 )
 
 func registerRoleRoutes(api *gin.RouterGroup, h *handler.RoleHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
+	roleAssignment := api.Group("", middleware.RequireScope("role-assignment"), auth)
 
-	api.GET("/roles", h.GetAllRole) // đã check
+	jwtOnly.GET("/roles", h.GetAllRole) // đã check
+	jwtOnly.GET("/roles/:id", h.GetRoleById)
+	jwtOnly.GET("/permissions", h.GetAllPermissions)
+	roleAssignment.POST("/roles", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.Create)
+	roleAssignment.PUT("/roles/:id", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.Update)
+	roleAssignment.DELETE("/roles/:id", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.Delete)
+	roleAssignment.PUT("/roles/:id/permissions/:permission_id", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.SetPermission)
+	roleAssignment.DELETE("/roles/:id/permissions/:permission_id", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.RemovePermission)
 
 }
@@ -11,14 +11,15 @@ import (
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, userHandler *handler.UserHandler, LocationHandler *handler.LocationHandler, CategoriesHandler *handler.CategoriesHandler, DepartmentsHandler *handler.DepartmentsHandler, AssetsHandler *handler.AssetsHandler, RoleHandler *handler.RoleHandler, AssignmentHandler *handler.AssignmentHandler, AssetLogHandler *handler.AssetLogHandler, RequestTransferHandler *handler.RequestTransferHandler, MaintenanceSchedulesHandler *handler.MaintenanceSchedulesHandler, SSEHandler *handler.SSEHandler, NotificationHandler *handler.NotificationHandler, CronJobTestHandler *handler.CronJobTestHandler, CompanyHandler *handler.CompanyHandler, BillsHandler *handler.BillsHandler, MonthlySummaryHandler *handler.MonthlySummaryHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+func SetupRoutes(r *gin.Engine, userHandler *handler.UserHandler, LocationHandler *handler.LocationHandler, CategoriesHandler *handler.CategoriesHandler, DepartmentsHandler *handler.DepartmentsHandler, AssetsHandler *handler.AssetsHandler, RoleHandler *handler.RoleHandler, AssignmentHandler *handler.AssignmentHandler, AssetLogHandler *handler.AssetLogHandler, RequestTransferHandler *handler.RequestTransferHandler, MaintenanceSchedulesHandler *handler.MaintenanceSchedulesHandler, SSEHandler *handler.SSEHandler, NotificationHandler *handler.NotificationHandler, CronJobTestHandler *handler.CronJobTestHandler, CompanyHandler *handler.CompanyHandler, BillsHandler *handler.BillsHandler, MonthlySummaryHandler *handler.MonthlySummaryHandler, FileHandler *handler.FileHandler, AssetLoanHandler *handler.AssetLoanHandler, DepreciationHandler *handler.DepreciationHandler, ApiTokenHandler *handler.ApiTokenHandler, WebhookHandler *handler.WebhookHandler, OutboxHandler *handler.OutboxHandler, VendorHandler *handler.VendorHandler, PurchaseOrderHandler *handler.PurchaseOrderHandler, session repository.UsersSessionRepository, db *gorm.DB) {
 	//users
 	r.Use(middleware.CORSMiddleware())
 	r.Use(middleware.TimeoutMiddleware(5 * time.Second))
 	r.Use(middleware.PrometheusMiddleware())
 	r.GET("/metrics", middleware.PrometheusHandler())
 	api := r.Group("/api")
-	registerCronJobTestRoutes(api, CronJobTestHandler)
+	registerCronJobTestRoutes(api, CronJobTestHandler, session, db)
+	registerFileRoutes(api, FileHandler)
 	registerAuthRoutes(api, userHandler, SSEHandler)
 	registerUserRoutes(api, userHandler, session, db)
 	registerLocationsRoutes(api, LocationHandler, session, db)
@@ -35,4 +36,11 @@ func SetupRoutes(r *gin.Engine, userHandler *handler.UserHandler, LocationHandle
 	registerCompanyRoutes(api, CompanyHandler, session, db)
 	registerBillsRoutes(api, BillsHandler, session, db)
 	registerMonthlySummaryRoutes(api, MonthlySummaryHandler, session, db)
+	registerAssetLoanRoutes(api, AssetLoanHandler, session, db)
+	registerDepreciationRoutes(api, DepreciationHandler, session, db)
+	registerApiTokenRoutes(api, ApiTokenHandler, session, db)
+	registerWebhookRoutes(api, WebhookHandler, session, db)
+	registerOutboxRoutes(api, OutboxHandler, session, db)
+	registerVendorRoutes(api, VendorHandler, session, db)
+	registerPurchaseOrderRoutes(api, PurchaseOrderHandler, session, db)
 }
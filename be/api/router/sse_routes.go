@@ -11,8 +11,11 @@ import (
 )
 
 func registerSSEHandlerRoutes(api *gin.RouterGroup, h *handler.SSEHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
 
-	api.GET("/sse", h.SSEHandle)
+	jwtOnly.GET("/sse", h.SSEHandle)
+	jwtOnly.GET("/sse/presence", h.GetOnlineUsers)
 
 }
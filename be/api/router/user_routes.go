@@ -11,16 +11,28 @@ import (
 )
 
 func registerUserRoutes(api *gin.RouterGroup, h *handler.UserHandler, session repository.UsersSessionRepository, db *gorm.DB) {
-	api.Use(middleware.AuthMiddleware(config.AccessSecret, session))
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	// API token không dùng được ở các route không khai báo scope
+	jwtOnly := api.Group("", auth)
+	roleAssignment := api.Group("", middleware.RequireScope("role-assignment"), auth)
+	userManagement := api.Group("", middleware.RequireScope("user-management"), auth)
 
-	api.GET("/user/department/:department_id", h.GetAllUserOfDepartment)
-	api.GET("/user/session", h.Session)
-	api.POST("/auth/logout", h.Logout)
-	api.GET("/users", h.GetAllUser)
-	api.PATCH("/user/information", h.UpdateInformationUser)
-	api.PATCH("/users/role", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.UpdateRoleUser)
-	api.PATCH("/user/department", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateDepartment)
-	api.PATCH("/user/manager-department/:user_id", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateManagerDep)
-	api.PATCH("/user/can-export/:user_id", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateCanExport)
-	api.GET("/users/not-dep", h.GetUserNotHaveDep)
+	jwtOnly.GET("/user/department/:department_id", h.GetAllUserOfDepartment)
+	jwtOnly.GET("/user/session", h.Session)
+	jwtOnly.GET("/user/sessions", h.GetSessions)
+	jwtOnly.DELETE("/user/sessions/:id", h.RevokeSession)
+	jwtOnly.POST("/auth/logout", h.Logout)
+	jwtOnly.GET("/users", h.GetAllUser)
+	jwtOnly.PATCH("/user/information", h.UpdateInformationUser)
+	roleAssignment.PATCH("/users/role", middleware.RequirePermission([]string{"role-assignment"}, nil, db), h.UpdateRoleUser)
+	userManagement.DELETE("/user/:email", middleware.RequirePermission([]string{"user-management"}, nil, db), h.DeleteUser)
+	userManagement.PATCH("/user/department", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateDepartment)
+	userManagement.PATCH("/user/manager-department/:user_id", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateManagerDep)
+	userManagement.PATCH("/user/can-export/:user_id", middleware.RequirePermission([]string{"user-management"}, nil, db), h.UpdateCanExport)
+	jwtOnly.GET("/users/not-dep", h.GetUserNotHaveDep)
+	jwtOnly.GET("/user/mfa", h.GetMfaStatus)
+	jwtOnly.POST("/user/mfa/enroll", h.BeginMfaEnrollment)
+	jwtOnly.POST("/user/mfa/confirm", h.ConfirmMfaEnrollment)
+	jwtOnly.POST("/user/mfa/disable", h.DisableMfa)
+	jwtOnly.POST("/user/mfa/recovery-codes", h.RegenerateRecoveryCodes)
 }
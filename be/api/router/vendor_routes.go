@@ -0,0 +1,29 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerVendorRoutes(api *gin.RouterGroup, h *handler.VendorHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	manageTaxonomyMaintenanceLogs := api.Group("", middleware.RequireScope("manage-taxonomy", "maintenance-logs"), auth)
+	manageTaxonomy := api.Group("", middleware.RequireScope("manage-taxonomy"), auth)
+	manageTaxonomyDashboards := api.Group("", middleware.RequireScope("manage-taxonomy", "dashboards"), auth)
+
+	manageTaxonomyMaintenanceLogs.GET("/vendors", middleware.RequirePermission([]string{"manage-taxonomy", "maintenance-logs"}, nil, db), h.GetVendors)
+	manageTaxonomy.POST("/vendors", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Create)
+	manageTaxonomyDashboards.GET("/vendors/performance", middleware.RequirePermission([]string{"manage-taxonomy", "dashboards"}, nil, db), h.GetPerformanceSummary)
+	manageTaxonomyMaintenanceLogs.GET("/vendors/:id", middleware.RequirePermission([]string{"manage-taxonomy", "maintenance-logs"}, nil, db), h.GetVendor)
+	manageTaxonomy.PUT("/vendors/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Update)
+	manageTaxonomy.DELETE("/vendors/:id", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.Delete)
+	manageTaxonomyMaintenanceLogs.GET("/vendors/:id/assets", middleware.RequirePermission([]string{"manage-taxonomy", "maintenance-logs"}, nil, db), h.GetAssets)
+	manageTaxonomy.GET("/vendors/:id/spend", middleware.RequirePermission([]string{"manage-taxonomy"}, nil, db), h.GetSpend)
+	manageTaxonomyMaintenanceLogs.GET("/vendors/:id/maintenance", middleware.RequirePermission([]string{"manage-taxonomy", "maintenance-logs"}, nil, db), h.GetMaintenanceJobs)
+	manageTaxonomyDashboards.GET("/vendors/:id/performance", middleware.RequirePermission([]string{"manage-taxonomy", "dashboards"}, nil, db), h.GetPerformance)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"BE_Manage_device/api/handler"
+	"BE_Manage_device/api/middleware"
+	"BE_Manage_device/config"
+	repository "BE_Manage_device/internal/repository/user_session"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func registerWebhookRoutes(api *gin.RouterGroup, h *handler.WebhookHandler, session repository.UsersSessionRepository, db *gorm.DB) {
+	auth := middleware.AuthMiddleware(config.AccessSecret, session, db)
+	integrations := api.Group("", middleware.RequireScope("integrations"), auth)
+
+	integrations.GET("/webhooks", middleware.RequirePermission([]string{"integrations"}, nil, db), h.GetWebhooks)
+	integrations.POST("/webhooks", middleware.RequirePermission([]string{"integrations"}, nil, db), h.Create)
+	integrations.GET("/webhooks/:id", middleware.RequirePermission([]string{"integrations"}, nil, db), h.GetWebhook)
+	integrations.PUT("/webhooks/:id", middleware.RequirePermission([]string{"integrations"}, nil, db), h.Update)
+	integrations.DELETE("/webhooks/:id", middleware.RequirePermission([]string{"integrations"}, nil, db), h.Delete)
+	integrations.GET("/webhooks/:id/deliveries", middleware.RequirePermission([]string{"integrations"}, nil, db), h.GetDeliveries)
+	integrations.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", middleware.RequirePermission([]string{"integrations"}, nil, db), h.Redeliver)
+
+}
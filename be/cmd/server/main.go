@@ -7,7 +7,9 @@ import (
 	"BE_Manage_device/config"
 	"BE_Manage_device/internal/repository"
 	"BE_Manage_device/internal/service"
+	emailS "BE_Manage_device/internal/service/email"
 	cronjob "BE_Manage_device/pkg/cron_job"
+	"BE_Manage_device/pkg/storage"
 	"log"
 
 	"github.com/gin-contrib/pprof"
@@ -22,7 +24,18 @@ func main() {
 	db := config.ConnectToDB()
 	config.InitRedis()
 	repos := repository.NewRepository(db)
-	services := service.NewServices(repos, config.SmtpPasswd)
+	store, err := storage.NewStorage()
+	if err != nil {
+		log.Fatal("failed to init storage:", err)
+	}
+	storage.SetDefault(store)
+	mailTransport, err := emailS.NewTransport()
+	if err != nil {
+		log.Fatal("failed to init mail transport:", err)
+	}
+	services := service.NewServices(repos, mailTransport, store)
+	// Nhận message SSE publish từ mọi instance qua Redis
+	services.Notification.StartFanOut()
 	//User
 	userHandler := handler.NewUserHandler(services.User)
 	//Location
@@ -48,13 +61,29 @@ func main() {
 	// Notification
 	notificationsHandler := handler.NewNotificationHandler(services.Notification)
 	//CronjobTest
-	cronJobTestHandler := handler.NewCronJobTestHandler(db, services.Email, repos.Assets, repos.User, services.Notification, repos.AssetsLog)
+	cronJobTestHandler := handler.NewCronJobTestHandler(db, repos.Assets, repos.User, services.Notification, repos.AssetsLog, repos.AssetLoan)
 	//CompanyHandler
 	companyHandler := handler.NewCompanyHandler(services.Company)
 	//BillHandler
 	billHandler := handler.NewBillHandler(services.Bill)
 	//MonthlySummaryHandler
 	monthlySummaryHandler := handler.NewMonthlySummry(services.MonthlySummary)
+	//AssetLoanHandler
+	assetLoanHandler := handler.NewAssetLoanHandler(services.AssetLoan)
+	//DepreciationHandler
+	depreciationHandler := handler.NewDepreciationHandler(services.Depreciation)
+	//ApiTokenHandler
+	apiTokenHandler := handler.NewApiTokenHandler(services.ApiToken)
+	//WebhookHandler
+	webhookHandler := handler.NewWebhookHandler(services.Webhook)
+	//OutboxHandler
+	outboxHandler := handler.NewOutboxHandler(services.Outbox)
+	//VendorHandler
+	vendorHandler := handler.NewVendorHandler(services.Vendor)
+	//PurchaseOrderHandler
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(services.PurchaseOrder)
+	//FileHandler
+	fileHandler := handler.NewFileHandler(store)
 	docs.SwaggerInfo.Title = "API Tool device manage"
 	docs.SwaggerInfo.Description = "App Tool device manage"
 	docs.SwaggerInfo.Version = "1.0"
@@ -64,10 +93,10 @@ func main() {
 
 	r := gin.Default()
 	pprof.Register(r)
-	api.SetupRoutes(r, userHandler, locationHandler, categoriesHandler, departmentHandler, assetsHandler, roleHandler, assignmentHandler, assetLogHandler, requestTransferHandler, maintenanceHandler, SSeHandler, notificationsHandler, cronJobTestHandler, companyHandler, billHandler, monthlySummaryHandler, repos.UserSession, db)
+	api.SetupRoutes(r, userHandler, locationHandler, categoriesHandler, departmentHandler, assetsHandler, roleHandler, assignmentHandler, assetLogHandler, requestTransferHandler, maintenanceHandler, SSeHandler, notificationsHandler, cronJobTestHandler, companyHandler, billHandler, monthlySummaryHandler, fileHandler, assetLoanHandler, depreciationHandler, apiTokenHandler, webhookHandler, outboxHandler, vendorHandler, purchaseOrderHandler, repos.UserSession, db)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	cronjob.InitCronJobs(db, services.Email, repos.Assets, repos.User, services.Notification, repos.AssetsLog, repos.Bill, repos.MonthlySummary, repos.Company)
+	cronjob.InitCronJobs(db, repos.Assets, repos.User, services.Notification, repos.AssetsLog, repos.Bill, repos.MonthlySummary, repos.Company, repos.AssetLoan, services.Webhook, services.Outbox, services.Bill)
 
 	if err := r.Run(config.Port); err != nil {
 		log.Fatal("failed to run server:", err)
@@ -252,10 +252,22 @@ func ConnectToDB() *gorm.DB {
 	db.Exec(createEnumSQL)
 	sql := "CREATE SEQUENCE bill_number_seq START WITH 1 INCREMENT BY 1;"
 	db.Exec(sql)
-	err = db.AutoMigrate(&entity.Roles{}, &entity.Permission{}, &entity.RolePermission{}, &entity.Users{}, &entity.UsersSessions{}, &entity.UserRbac{}, &entity.Locations{}, &entity.Departments{}, &entity.Categories{}, &entity.Assets{}, &entity.AssetLog{}, &entity.Assignments{}, &entity.RequestTransfer{}, &entity.Notifications{}, &entity.MaintenanceSchedules{}, &entity.MaintenanceNotifications{}, &entity.Company{}, &entity.Bill{}, &entity.MonthlySummary{}, &entity.BillAsset{})
+	db.Exec("CREATE SEQUENCE IF NOT EXISTS purchase_order_number_seq START WITH 1 INCREMENT BY 1;")
+	// Slug role chỉ unique trong 1 công ty (role hệ thống có company_id null)
+	db.Exec("DROP INDEX IF EXISTS unique_slug")
+	err = db.AutoMigrate(&entity.Roles{}, &entity.Permission{}, &entity.RolePermission{}, &entity.Users{}, &entity.UsersSessions{}, &entity.Locations{}, &entity.Departments{}, &entity.Categories{}, &entity.Assets{}, &entity.AssetLog{}, &entity.Assignments{}, &entity.RequestTransfer{}, &entity.Notifications{}, &entity.MaintenanceSchedules{}, &entity.MaintenanceNotifications{}, &entity.Company{}, &entity.Bill{}, &entity.MonthlySummary{}, &entity.BillAsset{}, &entity.BillLineItems{}, &entity.BillPayments{}, &entity.AssetLoans{}, &entity.MaintenanceScheduleExceptions{}, &entity.MaintenanceJobs{}, &entity.AssetUsages{}, &entity.TransferApprovalSteps{}, &entity.RequestTransferApprovals{}, &entity.RequestTransferLogs{}, &entity.UserMfa{}, &entity.UserMfaRecoveryCodes{}, &entity.UsedRefreshTokens{}, &entity.AssetPermissionGrants{}, &entity.ApiTokens{}, &entity.Webhooks{}, &entity.WebhookDeliveries{}, &entity.WebhookDeliveryAttempts{}, &entity.OutboxMessages{}, &entity.NotificationPreferences{}, &entity.UserNotificationSettings{}, &entity.NotificationDigestItems{}, &entity.Vendors{}, &entity.VendorContacts{}, &entity.PurchaseOrders{}, &entity.PurchaseOrderItems{}, &entity.GoodsReceipts{}, &entity.GoodsReceiptLines{})
 	if err != nil {
 		log.Fatal("Error migrate to database. Error:", err)
 	}
+	if err = collapseUserRbacs(db); err != nil {
+		log.Fatal("Error collapse user_rbacs. Error:", err)
+	}
+	if err = backfillBillLineItems(db); err != nil {
+		log.Fatal("Error backfill bill line items. Error:", err)
+	}
+	if err = backfillBillPayments(db); err != nil {
+		log.Fatal("Error backfill bill payments. Error:", err)
+	}
 	for _, company := range company {
 		var existing entity.Company
 		db.Where("company_name = ?", existing.CompanyName).FirstOrCreate(&existing, company)
@@ -263,7 +275,7 @@ func ConnectToDB() *gorm.DB {
 
 	for _, role := range roles {
 		var existing entity.Roles
-		db.Where("slug = ?", role.Slug).FirstOrCreate(&existing, role)
+		db.Where("slug = ? and company_id is null", role.Slug).FirstOrCreate(&existing, role)
 	}
 
 	for _, permission := range permissions {
@@ -289,3 +301,71 @@ func ConnectToDB() *gorm.DB {
 	}
 	return db
 }
+
+// collapseUserRbacs: quyền xem tài sản giờ được suy ra từ role/phòng ban/owner nên bỏ bảng user_rbacs (1 dòng cho mỗi user x tài sản).
+// Chỉ giữ lại thành grant riêng những dòng mà luật mới không suy ra được, vd user đã đổi sang role không còn view-assets
+func collapseUserRbacs(db *gorm.DB) error {
+	if !db.Migrator().HasTable("user_rbacs") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+		INSERT INTO asset_permission_grants (company_id, asset_id, user_id, permission_slug, created_at)
+		SELECT DISTINCT a.company_id, ur.asset_id, ur.user_id, 'view-assets', now()
+		FROM user_rbacs ur
+		JOIN assets a ON a.id = ur.asset_id
+		JOIN users u ON u.id = ur.user_id AND u.company_id = a.company_id
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id AND p.slug = 'view-assets'
+		WHERE (a.owner IS NULL OR a.owner <> ur.user_id)
+		AND NOT EXISTS (
+			SELECT 1 FROM role_permissions crp
+			JOIN permissions cp ON cp.id = crp.permission_id AND cp.slug = 'view-assets'
+			WHERE crp.role_id = u.role_id
+			AND (crp.access_level = 'full' OR (u.department_id IS NOT NULL AND u.department_id = a.department_id))
+		)
+		ON CONFLICT DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("user_rbacs")
+	})
+}
+
+// backfillBillLineItems: bill tạo trước khi có line item chỉ có bill_assets, sinh cho mỗi tài sản 1 dòng theo giá tài sản
+// (không thuế, không chiết khấu) rồi tính lại tổng tiền. Chạy lại nhiều lần không sao vì chỉ xử lý bill chưa có dòng nào
+func backfillBillLineItems(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+		WITH pending AS (
+			SELECT b.id FROM bills b
+			WHERE NOT EXISTS (SELECT 1 FROM bill_line_items li WHERE li.bill_id = b.id)
+		), inserted AS (
+			INSERT INTO bill_line_items (bill_id, asset_id, position, description, quantity, unit_price, tax_rate, discount, line_subtotal, line_tax, line_total, created_at)
+			SELECT ba.bill_id, a.id, (ROW_NUMBER() OVER (PARTITION BY ba.bill_id ORDER BY a.id)) - 1, a.asset_name, 1, a.cost, 0, 0, a.cost, 0, a.cost, now()
+			FROM bill_assets ba
+			JOIN pending p ON p.id = ba.bill_id
+			JOIN assets a ON a.id = ba.asset_id
+			RETURNING bill_id, line_total
+		)
+		UPDATE bills b SET subtotal = t.total, tax_total = 0, discount_total = 0, grand_total = t.total
+		FROM (SELECT bill_id, SUM(line_total) AS total FROM inserted GROUP BY bill_id) t
+		WHERE b.id = t.bill_id`).Error
+		return err
+	})
+}
+
+// backfillBillPayments: bill cũ được đánh dấu Paid mà chưa có lần thanh toán nào thì ghi 1 lần thanh toán cho toàn bộ số tiền
+// vào ngày tạo bill, để PaidAmount khớp với trạng thái khi trạng thái được suy ra từ các lần thanh toán
+func backfillBillPayments(db *gorm.DB) error {
+	return db.Exec(`
+	WITH inserted AS (
+		INSERT INTO bill_payments (bill_id, amount, method, reference, payer_name, paid_at, recorded_by_id, created_at)
+		SELECT b.id, b.grand_total, 'other', 'Migrated', b.buyer_name, b.create_at, b.create_by_id, now()
+		FROM bills b
+		WHERE b.status_bill = 'Paid' AND b.paid_amount = 0 AND b.grand_total > 0
+		AND NOT EXISTS (SELECT 1 FROM bill_payments p WHERE p.bill_id = b.id)
+		RETURNING bill_id, amount
+	)
+	UPDATE bills b SET paid_amount = i.amount FROM inserted i WHERE b.id = i.bill_id`).Error
+}
@@ -3,9 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
-	storage_go "github.com/supabase-community/storage-go"
 )
 
 var (
@@ -19,8 +19,44 @@ var (
 	BASE_URL_FRONTEND            string
 	BASE_URL_BACKEND             string
 	DB_DNS                       string
-	StorageClient                *storage_go.Client
 	BASE_URL_BACKEND_FOR_SWAGGER string
+
+	// Storage
+	STORAGE_DRIVER         string
+	STORAGE_LOCAL_DIR      string
+	STORAGE_LOCAL_PUBLIC   bool
+	STORAGE_SIGNING_SECRET string
+	SUPABASE_URL           string
+	SUPABASE_BUCKET        string
+	S3_ENDPOINT            string
+	S3_ACCESS_KEY          string
+	S3_SECRET_KEY          string
+	S3_BUCKET              string
+	S3_REGION              string
+	S3_USE_SSL             bool
+	S3_PUBLIC_URL          string
+
+	// MFA
+	MFA_ISSUER           string
+	MFA_ENCRYPTION_KEY   string
+	MFA_CHALLENGE_SECRET string
+
+	// Mail: MAIL_TRANSPORT = smtp | file | console, file/console dùng khi chạy local/test để không gửi mail thật
+	MAIL_TRANSPORT        string
+	MAIL_SINK_DIR         string
+	MAIL_DEFAULT_LANGUAGE string
+	SMTP_HOST             string
+	SMTP_PORT             int
+	SMTP_USERNAME         string
+	SMTP_FROM             string
+	SMTP_TLS              string // starttls | ssl | none
+
+	// Số ngày giữ thông báo đã đọc/lưu trữ trước khi bị xoá
+	NOTIFICATION_RETENTION_DAYS int
+
+	// Hạn thanh toán mặc định của bill (tính từ ngày tạo) và khoảng cách giữa 2 lần nhắc bill quá hạn
+	BILL_DEFAULT_DUE_DAYS       int
+	BILL_REMINDER_INTERVAL_DAYS int
 )
 
 func LoadEnv() {
@@ -39,5 +75,80 @@ func LoadEnv() {
 	BASE_URL_FRONTEND = os.Getenv("BASE_URL_FRONTEND")
 	BASE_URL_BACKEND = os.Getenv("BASE_URL_BACKEND")
 	DB_DNS = os.Getenv("DATABASE_URL")
-	StorageClient = storage_go.NewClient("https://mvfitrngobsxryjosznw.supabase.co/storage/v1", SupabaseKey, nil)
+
+	// Mặc định giữ supabase như trước, local driver phải bật rõ ràng và mặc định không public
+	STORAGE_DRIVER = getEnvDefault("STORAGE_DRIVER", "supabase")
+	STORAGE_LOCAL_DIR = getEnvDefault("STORAGE_LOCAL_DIR", "./uploads")
+	STORAGE_LOCAL_PUBLIC = os.Getenv("STORAGE_LOCAL_PUBLIC") == "true"
+	STORAGE_SIGNING_SECRET = os.Getenv("STORAGE_SIGNING_SECRET")
+	SUPABASE_URL = os.Getenv("SUPABASE_URL")
+	if SUPABASE_URL == "" && SUPABASE_PROJECT_REF != "" {
+		SUPABASE_URL = "https://" + SUPABASE_PROJECT_REF + ".supabase.co"
+	}
+	SUPABASE_BUCKET = getEnvDefault("SUPABASE_BUCKET", "images")
+	S3_ENDPOINT = os.Getenv("S3_ENDPOINT")
+	S3_ACCESS_KEY = os.Getenv("S3_ACCESS_KEY")
+	S3_SECRET_KEY = os.Getenv("S3_SECRET_KEY")
+	S3_BUCKET = getEnvDefault("S3_BUCKET", "images")
+	S3_REGION = os.Getenv("S3_REGION")
+	S3_USE_SSL = os.Getenv("S3_USE_SSL") == "true"
+	S3_PUBLIC_URL = os.Getenv("S3_PUBLIC_URL")
+
+	MFA_ISSUER = getEnvDefault("MFA_ISSUER", "Manage Device")
+	// Khoá mã hoá secret TOTP và secret ký challenge phải riêng, không suy ra từ secret khác.
+	// Không cấu hình thì server vẫn chạy, chỉ các API MFA trả lỗi
+	MFA_ENCRYPTION_KEY = os.Getenv("MFA_ENCRYPTION_KEY")
+	MFA_CHALLENGE_SECRET = os.Getenv("MFA_CHALLENGE_SECRET")
+	if (MFA_ENCRYPTION_KEY != "" && MFA_ENCRYPTION_KEY == PasswordSecret) || (MFA_CHALLENGE_SECRET != "" && MFA_CHALLENGE_SECRET == AccessSecret) {
+		log.Fatal("MFA_ENCRYPTION_KEY and MFA_CHALLENGE_SECRET must differ from PasswordSecret and AccessSecret")
+	}
+
+	MAIL_TRANSPORT = getEnvDefault("MAIL_TRANSPORT", "smtp")
+	MAIL_SINK_DIR = getEnvDefault("MAIL_SINK_DIR", "./tmp/mail")
+	MAIL_DEFAULT_LANGUAGE = getEnvDefault("MAIL_DEFAULT_LANGUAGE", "en")
+	SMTP_HOST = getEnvDefault("SMTP_HOST", "smtp.gmail.com")
+	SMTP_PORT, err = strconv.Atoi(getEnvDefault("SMTP_PORT", "587"))
+	if err != nil {
+		log.Println("Invalid SMTP_PORT, using 587")
+		SMTP_PORT = 587
+	}
+	// Chỉ bắt buộc khi gửi thật qua SMTP, file/console dùng cho môi trường dev
+	if MAIL_TRANSPORT == "smtp" {
+		SMTP_USERNAME = getEnvRequired("SMTP_USERNAME")
+	} else {
+		SMTP_USERNAME = os.Getenv("SMTP_USERNAME")
+	}
+	SMTP_FROM = getEnvDefault("SMTP_FROM", SMTP_USERNAME)
+	SMTP_TLS = getEnvDefault("SMTP_TLS", "starttls")
+
+	NOTIFICATION_RETENTION_DAYS, err = strconv.Atoi(getEnvDefault("NOTIFICATION_RETENTION_DAYS", "90"))
+	if err != nil || NOTIFICATION_RETENTION_DAYS <= 0 {
+		log.Println("Invalid NOTIFICATION_RETENTION_DAYS, using 90")
+		NOTIFICATION_RETENTION_DAYS = 90
+	}
+	BILL_DEFAULT_DUE_DAYS, err = strconv.Atoi(getEnvDefault("BILL_DEFAULT_DUE_DAYS", "30"))
+	if err != nil || BILL_DEFAULT_DUE_DAYS < 0 {
+		log.Println("Invalid BILL_DEFAULT_DUE_DAYS, using 30")
+		BILL_DEFAULT_DUE_DAYS = 30
+	}
+	BILL_REMINDER_INTERVAL_DAYS, err = strconv.Atoi(getEnvDefault("BILL_REMINDER_INTERVAL_DAYS", "7"))
+	if err != nil || BILL_REMINDER_INTERVAL_DAYS <= 0 {
+		log.Println("Invalid BILL_REMINDER_INTERVAL_DAYS, using 7")
+		BILL_REMINDER_INTERVAL_DAYS = 7
+	}
+}
+
+func getEnvRequired(key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		log.Fatalf("%s is required", key)
+	}
+	return value
+}
+
+func getEnvDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
 }
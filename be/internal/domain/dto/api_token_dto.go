@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type CreateApiTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"` // permission slug, phải nằm trong quyền của role hiện tại
+	ExpiresInDays int      `json:"expiresInDays" binding:"required,min=1,max=365"`
+}
+
+type ApiTokenResponse struct {
+	Id         int64          `json:"id"`
+	Name       string         `json:"name"`
+	Prefix     string         `json:"prefix"`
+	Scopes     []string       `json:"scopes"`
+	ExpiresAt  time.Time      `json:"expiresAt"`
+	LastUsedAt *time.Time     `json:"lastUsedAt"`
+	LastUsedIp string         `json:"lastUsedIp"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	RevokedAt  *time.Time     `json:"revokedAt"`
+	Active     bool           `json:"active"`
+	User       *OwnerResponse `json:"user,omitempty"`
+}
+
+// CreateApiTokenResponse chỉ trả token gốc đúng 1 lần lúc tạo
+type CreateApiTokenResponse struct {
+	ApiTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+type CreateAssetGrantRequest struct {
+	UserId     int64  `json:"userId" binding:"required"`
+	Permission string `json:"permission"` // mặc định view-assets
+}
+
+type AssetGrantResponse struct {
+	Id             int64         `json:"id"`
+	AssetId        int64         `json:"assetId"`
+	PermissionSlug string        `json:"permissionSlug"`
+	User           OwnerResponse `json:"user"`
+	GrantedBy      *int64        `json:"grantedBy"`
+	CreatedAt      time.Time     `json:"createdAt"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type CheckOutAssetRequest struct {
+	AssetId    int64     `json:"assetId" binding:"required"`
+	BorrowerId int64     `json:"borrowerId" binding:"required"`
+	DueDate    time.Time `json:"dueDate" binding:"required"`
+	Condition  string    `json:"condition" binding:"required"`
+	Note       string    `json:"note"`
+}
+
+type CheckInAssetRequest struct {
+	Condition string `json:"condition" binding:"required"`
+}
+
+type AssetLoanResponse struct {
+	Id                int64                       `json:"id"`
+	Asset             UserAssignmentAssetResponse `json:"asset"`
+	Borrower          UsersAssignmentResponse     `json:"borrower"`
+	PreviousOwner     *UsersAssignmentResponse    `json:"previousOwner"`
+	CheckedOutBy      UsersAssignmentResponse     `json:"checkedOutBy"`
+	CheckedInBy       *UsersAssignmentResponse    `json:"checkedInBy"`
+	CheckOutDate      string                      `json:"checkOutDate"`
+	DueDate           string                      `json:"dueDate"`
+	CheckOutCondition string                      `json:"checkOutCondition"`
+	CheckInDate       *string                     `json:"checkInDate"`
+	CheckInCondition  *string                     `json:"checkInCondition"`
+	Note              string                      `json:"note"`
+	Status            string                      `json:"status"`
+	Overdue           bool                        `json:"overdue"`
+}
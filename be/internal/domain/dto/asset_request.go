@@ -14,6 +14,7 @@ type AssetResponse struct {
 	Category       CategoryResponse   `json:"category"`
 	QrURL          string             `json:"qrUrl"`
 	Department     DepartmentResponse `json:"department"`
+	VendorId       *int64             `json:"vendorId"`
 }
 
 type CategoryResponse struct {
@@ -24,6 +25,7 @@ type CategoryResponse struct {
 type DepartmentResponse struct {
 	ID             int64            `json:"id"`
 	DepartmentName string           `json:"departmentName"`
+	HeadId         *int64           `json:"headId,omitempty"`
 	Location       LocationResponse `json:"location"`
 }
 
@@ -53,3 +55,31 @@ type GetAssetsByCateOfDepartmentRequest struct {
 type RetiredAssetRequest struct {
 	ResidualValue float64 `json:"residualValue" binding:"required"`
 }
+
+type AssetImportRow struct {
+	Row           int
+	AssetName     string
+	SerialNumber  string
+	PurchaseDate  string
+	WarrantExpiry string
+	Cost          string
+	Category      string
+	Department    string
+	Owner         string
+}
+
+type AssetImportRowError struct {
+	Row          int      `json:"row"`
+	SerialNumber string   `json:"serialNumber"`
+	Errors       []string `json:"errors"`
+}
+
+type AssetImportReport struct {
+	DryRun          bool                  `json:"dryRun"`
+	Imported        bool                  `json:"imported"`
+	TotalRows       int                   `json:"totalRows"`
+	ValidRows       int                   `json:"validRows"`
+	InvalidRows     int                   `json:"invalidRows"`
+	Errors          []AssetImportRowError `json:"errors"`
+	CreatedAssetIds []int64               `json:"createdAssetIds,omitempty"`
+}
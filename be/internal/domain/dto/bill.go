@@ -10,16 +10,100 @@ type BillCreateRequest struct {
 	Status      bool   `json:"status"`
 }
 
+// BillLineItemRequest là 1 dòng client gửi lên, tiền của dòng do server tính lại
+type BillLineItemRequest struct {
+	AssetId     *int64  `json:"assetId"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	TaxRate     float64 `json:"taxRate"`  // phần trăm, 0 - 100
+	Discount    float64 `json:"discount"` // số tiền giảm trên cả dòng
+}
+
+type BillLineItemResponse struct {
+	Id           int64   `json:"id"`
+	AssetId      *int64  `json:"assetId"`
+	AssetName    string  `json:"assetName,omitempty"`
+	Description  string  `json:"description"`
+	Quantity     float64 `json:"quantity"`
+	UnitPrice    float64 `json:"unitPrice"`
+	TaxRate      float64 `json:"taxRate"`
+	Discount     float64 `json:"discount"`
+	LineSubtotal float64 `json:"lineSubtotal"`
+	LineTax      float64 `json:"lineTax"`
+	LineTotal    float64 `json:"lineTotal"`
+}
+
 type BillResponse struct {
-	BillNumber         string          `json:"billNumber"`
-	Description        string          `json:"description"`
-	CreateAt           time.Time       `json:"createAt"`
-	Asset              []AssetResponse `json:"assets"`
-	CreateBy           UserResponse    `json:"createBy"`
-	StatusBill         string          `json:"statusBill"`
-	FileAttachmentBill string          `json:"fileAttachmentBill"`
-	ImageUploadBill    string          `json:"imageUploadBill"`
-	Buyer              BuyerResponse   `json:"buyer"`
+	BillNumber         string                 `json:"billNumber"`
+	Description        string                 `json:"description"`
+	CreateAt           time.Time              `json:"createAt"`
+	Asset              []AssetResponse        `json:"assets"`
+	CreateBy           UserResponse           `json:"createBy"`
+	StatusBill         string                 `json:"statusBill"`
+	FileAttachmentBill string                 `json:"fileAttachmentBill"`
+	ImageUploadBill    string                 `json:"imageUploadBill"`
+	Buyer              BuyerResponse          `json:"buyer"`
+	VendorId           *int64                 `json:"vendorId"`
+	Currency           string                 `json:"currency"`
+	LineItems          []BillLineItemResponse `json:"lineItems"`
+	Subtotal           float64                `json:"subtotal"`
+	DiscountTotal      float64                `json:"discountTotal"`
+	TaxTotal           float64                `json:"taxTotal"`
+	GrandTotal         float64                `json:"grandTotal"`
+	PaidAmount         float64                `json:"paidAmount"`
+	Outstanding        float64                `json:"outstanding"`
+	DueDate            *time.Time             `json:"dueDate"`
+	Payments           []BillPaymentResponse  `json:"payments"`
+}
+
+// CreateBillPaymentRequest ghi 1 lần thanh toán, paidAt bỏ trống là thời điểm hiện tại
+type CreateBillPaymentRequest struct {
+	Amount    float64    `json:"amount" binding:"required,gt=0"`
+	Method    string     `json:"method" binding:"required,oneof=cash bank_transfer card other"`
+	Reference string     `json:"reference" binding:"max=255"`
+	PayerName string     `json:"payerName" binding:"max=255"`
+	PaidAt    *time.Time `json:"paidAt"`
+}
+
+type BillPaymentResponse struct {
+	Id         int64                    `json:"id"`
+	Amount     float64                  `json:"amount"`
+	Method     string                   `json:"method"`
+	Reference  string                   `json:"reference"`
+	PayerName  string                   `json:"payerName"`
+	PaidAt     time.Time                `json:"paidAt"`
+	RecordedBy *UsersAssignmentResponse `json:"recordedBy"`
+}
+
+// BillAgingReportResponse là tuổi nợ các bill chưa trả đủ, tách theo đồng tiền vì không quy đổi tỉ giá
+type BillAgingReportResponse struct {
+	AsOf       time.Time                 `json:"asOf"`
+	Currencies []BillAgingBucketResponse `json:"currencies"`
+	Bills      []BillAgingItemResponse   `json:"bills"`
+}
+
+// BillAgingBucketResponse cộng số tiền còn nợ theo số ngày quá hạn, Current là bill chưa tới hạn hoặc không có hạn
+type BillAgingBucketResponse struct {
+	Currency   string  `json:"currency"`
+	Current    float64 `json:"current"`
+	Days0To30  float64 `json:"days0To30"`
+	Days31To60 float64 `json:"days31To60"`
+	Days61To90 float64 `json:"days61To90"`
+	Days90Plus float64 `json:"days90Plus"`
+	Total      float64 `json:"total"`
+	BillCount  int64   `json:"billCount"`
+}
+
+type BillAgingItemResponse struct {
+	BillNumber  string     `json:"billNumber"`
+	BuyerName   string     `json:"buyerName"`
+	StatusBill  string     `json:"statusBill"`
+	DueDate     *time.Time `json:"dueDate"`
+	DaysOverdue int        `json:"daysOverdue"`
+	Bucket      string     `json:"bucket"`
+	Currency    string     `json:"currency"`
+	Outstanding float64    `json:"outstanding"`
 }
 
 type BuyerResponse struct {
@@ -4,3 +4,7 @@ type CreateCompanyRequest struct {
 	CompanyName string `json:"companyName"`
 	Email       string `json:"email"`
 }
+
+type UpdateCompanyMfaPolicyRequest struct {
+	RequireMfa *bool `json:"requireMfa" binding:"required"`
+}
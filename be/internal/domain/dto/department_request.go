@@ -4,3 +4,8 @@ type CreateDepartmentRequest struct {
 	DepartmentName string `json:"departmentName" binding:"required"`
 	LocationId     int64  `json:"locationId" binding:"required"`
 }
+
+// UpdateDepartmentHeadRequest: headId = null để bỏ trưởng phòng
+type UpdateDepartmentHeadRequest struct {
+	HeadId *int64 `json:"headId"`
+}
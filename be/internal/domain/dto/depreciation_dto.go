@@ -0,0 +1,49 @@
+package dto
+
+type UpdateCategoryDepreciationRequest struct {
+	DepreciationMethod *string  `json:"depreciationMethod"`
+	UsefulLife         *float64 `json:"usefulLife" binding:"omitempty,gt=0"`
+	ResidualRate       *float64 `json:"residualRate" binding:"omitempty,gte=0,lt=1"`
+	DecliningFactor    *float64 `json:"decliningFactor" binding:"omitempty,gt=0"`
+}
+
+// UpdateAssetDepreciationRequest ghi đè cấu hình của category, chỉ cập nhật các trường được gửi lên.
+// ResetToCategory xoá toàn bộ ghi đè hiện có trước khi áp dụng các trường được gửi lên
+type UpdateAssetDepreciationRequest struct {
+	DepreciationMethod *string  `json:"depreciationMethod"`
+	UsefulLife         *float64 `json:"usefulLife" binding:"omitempty,gt=0"`
+	ResidualValue      *float64 `json:"residualValue" binding:"omitempty,gte=0"`
+	TotalUnits         *float64 `json:"totalUnits" binding:"omitempty,gt=0"`
+	ResetToCategory    bool     `json:"resetToCategory"`
+}
+
+type RecordAssetUsageRequest struct {
+	Period int     `json:"period" binding:"required,min=1"`
+	Units  float64 `json:"units" binding:"gte=0"`
+}
+
+type DepreciationScheduleResponse struct {
+	AssetId                 int64                        `json:"assetId"`
+	AssetName               string                       `json:"assetName"`
+	Method                  string                       `json:"method"`
+	Cost                    float64                      `json:"cost"`
+	ResidualValue           float64                      `json:"residualValue"`
+	UsefulLife              float64                      `json:"usefulLife"`
+	DecliningFactor         *float64                     `json:"decliningFactor"`
+	TotalUnits              *float64                     `json:"totalUnits"`
+	StartDate               string                       `json:"startDate"`
+	CurrentBookValue        float64                      `json:"currentBookValue"`
+	AccumulatedDepreciation float64                      `json:"accumulatedDepreciation"`
+	Periods                 []DepreciationPeriodResponse `json:"periods"`
+}
+
+type DepreciationPeriodResponse struct {
+	Period                  int      `json:"period"`
+	StartDate               string   `json:"startDate"`
+	EndDate                 string   `json:"endDate"`
+	OpeningValue            float64  `json:"openingValue"`
+	Charge                  float64  `json:"charge"`
+	AccumulatedDepreciation float64  `json:"accumulatedDepreciation"`
+	ClosingValue            float64  `json:"closingValue"`
+	Units                   *float64 `json:"units,omitempty"`
+}
@@ -2,22 +2,64 @@ package dto
 
 import "time"
 
+// MaintenanceRecurrenceRequest mô tả rule lặp giống RRULE: FREQ, INTERVAL, BYDAY, UNTIL, COUNT
+type MaintenanceRecurrenceRequest struct {
+	Frequency  string     `json:"frequency" binding:"required,oneof=daily weekly monthly yearly"`
+	Interval   int        `json:"interval" binding:"omitempty,min=1"`
+	ByWeekdays []string   `json:"byWeekdays"` // MO, TU, WE, TH, FR, SA, SU - chỉ dùng cho weekly
+	Until      *time.Time `json:"until"`
+	Count      *int       `json:"count" binding:"omitempty,min=1"`
+}
+
 type CreateMaintenanceSchedulesRequest struct {
-	AssetId   int64     `json:"assetId" binding:"required"`
-	StartDate time.Time `json:"startDate" binding:"required"`
-	EndDate   time.Time `json:"endDate" binding:"required"`
+	AssetId    int64                         `json:"assetId" binding:"required"`
+	StartDate  time.Time                     `json:"startDate" binding:"required"`
+	EndDate    time.Time                     `json:"endDate" binding:"required"`
+	Recurrence *MaintenanceRecurrenceRequest `json:"recurrence"`
+	VendorId   *int64                        `json:"vendorId"` // đơn vị bảo trì / sửa chữa
 }
 
 type UpdateMaintenanceSchedulesRequest struct {
 	StartDate time.Time `json:"startDate" binding:"required"`
 	EndDate   time.Time `json:"endDate" binding:"required"`
+	// all: cả series, this: chỉ lần lặp occurrenceStart, following: lần lặp occurrenceStart và các lần sau
+	Scope           string                        `json:"scope" binding:"omitempty,oneof=all this following"`
+	OccurrenceStart *time.Time                    `json:"occurrenceStart"`
+	Recurrence      *MaintenanceRecurrenceRequest `json:"recurrence"`
+	VendorId        *int64                        `json:"vendorId"` // bỏ trống là giữ vendor hiện tại, không áp dụng cho scope this
+}
+
+// RecordMaintenanceJobRequest ghi thời điểm thực tế giao tài sản cho vendor và nhận lại của lần bảo trì occurrenceStart
+type RecordMaintenanceJobRequest struct {
+	OccurrenceStart time.Time  `json:"occurrenceStart" binding:"required"`
+	HandedOverAt    *time.Time `json:"handedOverAt"`
+	ReturnedAt      *time.Time `json:"returnedAt"`
 }
 
 type MaintenanceSchedulesResponse struct {
-	Id        int64                               `json:"id"`
-	StartDate string                              `json:"startDate"`
-	EndDate   string                              `json:"endDate"`
-	Asset     AssetResponseInMaintenanceSchedules `json:"asset"`
+	Id             int64                               `json:"id"`
+	StartDate      string                              `json:"startDate"`
+	EndDate        string                              `json:"endDate"`
+	Recurrence     *MaintenanceRecurrenceResponse      `json:"recurrence"`
+	ParentId       *int64                              `json:"parentId"`
+	VendorId       *int64                              `json:"vendorId"`
+	NextOccurrence *MaintenanceOccurrenceResponse      `json:"nextOccurrence"`
+	Asset          AssetResponseInMaintenanceSchedules `json:"asset"`
+}
+
+type MaintenanceRecurrenceResponse struct {
+	Frequency  string   `json:"frequency"`
+	Interval   int      `json:"interval"`
+	ByWeekdays []string `json:"byWeekdays"`
+	Until      *string  `json:"until"`
+	Count      *int     `json:"count"`
+}
+
+type MaintenanceOccurrenceResponse struct {
+	ScheduleId      int64     `json:"scheduleId"`
+	OccurrenceStart time.Time `json:"occurrenceStart"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
 }
 
 type AssetResponseInMaintenanceSchedules struct {
@@ -5,16 +5,44 @@ import (
 )
 
 type MonthlySummaryResponse struct {
-	Month               int64                          `json:"month"`
-	Year                int64                          `json:"year"`
-	TotalAmount         float64                        `json:"totalAmount"`
-	BillCount           int64                          `json:"billCount"`
-	AssetCount          int64                          `json:"assetCount"`
-	TotalCategoryAmount []*TotalCategoryAmountResponse `json:"totalCategoryAmount"`
-	GeneratedAt         time.Time                      `json:"generatedAt"`
+	Month                 int64                          `json:"month"`
+	Year                  int64                          `json:"year"`
+	Totals                []*CurrencyTotalResponse       `json:"totals"`
+	BillCount             int64                          `json:"billCount"`
+	AssetCount            int64                          `json:"assetCount"`
+	TotalCategoryAmount   []*TotalCategoryAmountResponse `json:"totalCategoryAmount"`
+	TotalDepartmentAmount []*SummaryBreakdownResponse    `json:"totalDepartmentAmount"`
+	TotalLocationAmount   []*SummaryBreakdownResponse    `json:"totalLocationAmount"`
+	GeneratedAt           time.Time                      `json:"generatedAt"`
+}
+
+type CurrencyTotalResponse struct {
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	BillCount int64   `json:"billCount"`
 }
 
 type TotalCategoryAmountResponse struct {
+	CategoryId   int64   `json:"categoryId"`
 	CategoryName string  `json:"categoryName"`
+	Currency     string  `json:"currency"`
 	Amount       float64 `json:"amount"`
+	AssetCount   int64   `json:"assetCount"`
+}
+
+type SummaryBreakdownResponse struct {
+	Id         int64   `json:"id"`
+	Name       string  `json:"name"`
+	Currency   string  `json:"currency"`
+	Amount     float64 `json:"amount"`
+	AssetCount int64   `json:"assetCount"`
+}
+
+// RegenerateMonthlySummaryRequest tính lại summary từ tháng from tới tháng to (bao gồm cả 2 đầu)
+type RegenerateMonthlySummaryRequest struct {
+	FromMonth   int  `json:"fromMonth" binding:"required,min=1,max=12"`
+	FromYear    int  `json:"fromYear" binding:"required,min=2000"`
+	ToMonth     int  `json:"toMonth" binding:"required,min=1,max=12"`
+	ToYear      int  `json:"toYear" binding:"required,min=2000"`
+	OnlyMissing bool `json:"onlyMissing"` // true: chỉ backfill các tháng chưa có summary
 }
@@ -0,0 +1,79 @@
+package dto
+
+import "time"
+
+// NotificationEvent là data của 1 frame SSE và 1 dòng trong hộp thư
+type NotificationEvent struct {
+	Id         int64      `json:"id"`
+	Type       string     `json:"type"`
+	Content    string     `json:"content"`
+	Status     string     `json:"status"`
+	AssetId    *int64     `json:"assetId"`
+	NotifyDate *time.Time `json:"notifyDate"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+}
+
+// NotificationPage: nextCursor = nil khi đã hết, truyền lại vào query cursor để lấy trang tiếp
+type NotificationPage struct {
+	Items      []NotificationEvent `json:"items"`
+	NextCursor *int64              `json:"nextCursor"`
+}
+
+type NotificationIdsRequest struct {
+	Ids []int64 `json:"ids" binding:"required,min=1,max=500"`
+}
+
+type NotificationCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// NotificationPreference là 1 ô của ma trận nhóm sự kiện x kênh
+type NotificationPreference struct {
+	Category string `json:"category" binding:"required"`
+	Channel  string `json:"channel" binding:"required"`
+	Delivery string `json:"delivery" binding:"required"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required,dive"`
+}
+
+// NotificationSettingsRequest: giờ yên lặng dạng HH:MM theo múi giờ của user, bỏ trống cả 2 để tắt
+type NotificationSettingsRequest struct {
+	Timezone        string  `json:"timezone" binding:"required"`
+	QuietHoursStart *string `json:"quietHoursStart"`
+	QuietHoursEnd   *string `json:"quietHoursEnd"`
+	DigestHour      *int    `json:"digestHour" binding:"required,min=0,max=23"`
+	WebhookUrl      *string `json:"webhookUrl"`
+}
+
+// NotificationSettingsResponse chỉ trả webhookSecret đúng 1 lần khi webhook cá nhân được đặt hoặc đổi url
+type NotificationSettingsResponse struct {
+	Timezone        string  `json:"timezone"`
+	QuietHoursStart *string `json:"quietHoursStart"`
+	QuietHoursEnd   *string `json:"quietHoursEnd"`
+	DigestHour      int     `json:"digestHour"`
+	WebhookUrl      *string `json:"webhookUrl"`
+	WebhookSecret   string  `json:"webhookSecret,omitempty"`
+}
+
+type NotificationPreferencesResponse struct {
+	Settings    NotificationSettingsResponse `json:"settings"`
+	Preferences []NotificationPreference     `json:"preferences"`
+}
+
+// UserWebhookPayload là body gửi tới webhook cá nhân, digest gửi kèm danh sách Items
+type UserWebhookPayload struct {
+	Event      string                  `json:"event"`
+	OccurredAt time.Time               `json:"occurredAt"`
+	Message    string                  `json:"message,omitempty"`
+	AssetId    *int64                  `json:"assetId,omitempty"`
+	Items      []UserWebhookDigestItem `json:"items,omitempty"`
+}
+
+type UserWebhookDigestItem struct {
+	Event     string    `json:"event"`
+	Message   string    `json:"message"`
+	AssetId   *int64    `json:"assetId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
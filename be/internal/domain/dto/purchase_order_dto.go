@@ -0,0 +1,61 @@
+package dto
+
+import "time"
+
+type PurchaseOrderItemRequest struct {
+	CategoryId        int64   `json:"categoryId" binding:"required"`
+	Description       string  `json:"description" binding:"required,max=255"`
+	Quantity          int     `json:"quantity" binding:"required,min=1,max=1000"`
+	EstimatedUnitCost float64 `json:"estimatedUnitCost" binding:"min=0"`
+	WarrantyMonths    int     `json:"warrantyMonths" binding:"min=0,max=240"`
+}
+
+type PurchaseOrderRequest struct {
+	DepartmentId int64                      `json:"departmentId" binding:"required"`
+	VendorId     *int64                     `json:"vendorId"`
+	Description  string                     `json:"description" binding:"max=1000"`
+	ExpectedDate *time.Time                 `json:"expectedDate"`
+	Items        []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// PurchaseOrderDecisionRequest dùng khi duyệt, từ chối hoặc huỷ đơn
+type PurchaseOrderDecisionRequest struct {
+	Note string `json:"note" binding:"max=1000"`
+}
+
+// GoodsReceiptLineRequest: unitCost bỏ trống thì lấy giá dự kiến, serialNumbers bỏ trống thì hệ thống tự sinh, có thì phải đủ 1 serial cho mỗi đơn vị
+type GoodsReceiptLineRequest struct {
+	PurchaseOrderItemId int64    `json:"purchaseOrderItemId" binding:"required"`
+	Quantity            int      `json:"quantity" binding:"required,min=1"`
+	UnitCost            *float64 `json:"unitCost" binding:"omitempty,min=0"`
+	SerialNumbers       []string `json:"serialNumbers"`
+}
+
+// GoodsReceiptRequest: redirectUrl là trang tài sản trên frontend mà QR của tài sản mới trỏ tới, giống khi tạo tài sản
+type GoodsReceiptRequest struct {
+	ReceivedAt  *time.Time                `json:"receivedAt"`
+	Note        string                    `json:"note" binding:"max=1000"`
+	RedirectUrl string                    `json:"redirectUrl" binding:"required"`
+	Lines       []GoodsReceiptLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// PurchaseBudgetResponse so sánh số tiền đã cam kết (phần chưa nhận của đơn đã duyệt, theo giá dự kiến) với số đã chi (giá thực tế lúc nhập kho)
+type PurchaseBudgetResponse struct {
+	From        *time.Time                         `json:"from"`
+	To          *time.Time                         `json:"to"`
+	Approved    float64                            `json:"approved"`
+	Committed   float64                            `json:"committed"`
+	Spent       float64                            `json:"spent"`
+	Departments []PurchaseBudgetDepartmentResponse `json:"departments"`
+}
+
+type PurchaseBudgetDepartmentResponse struct {
+	DepartmentId   int64   `json:"departmentId"`
+	DepartmentName string  `json:"departmentName"`
+	OpenOrders     int     `json:"openOrders"`
+	Approved       float64 `json:"approved"` // tổng dự kiến của các đơn được duyệt trong kỳ
+	Committed      float64 `json:"committed"`
+	Spent          float64 `json:"spent"`
+	PendingUnits   int     `json:"pendingUnits"`
+	ReceivedUnits  int     `json:"receivedUnits"`
+}
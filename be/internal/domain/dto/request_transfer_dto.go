@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateRequestTransferRequest struct {
 	CategoryId  int64  `json:"categoryId" binding:"required"`
 	Description string `json:"description" binding:"required"`
@@ -11,6 +13,28 @@ type RequestTransferResponse struct {
 	User        UserResponseInRequestTransfer     `json:"user"`
 	Category    CategoryResponseInRequestTransfer `json:"category"`
 	Description string                            `json:"description"`
+	Asset       *AssetResponseInRequestTransfer   `json:"asset"`
+	CurrentStep int                               `json:"currentStep"`
+	CreatedAt   time.Time                         `json:"createdAt"`
+	UpdatedAt   time.Time                         `json:"updatedAt"`
+	Approvals   []RequestTransferApprovalResponse `json:"approvals"`
+}
+
+type AssetResponseInRequestTransfer struct {
+	Id           int64   `json:"id"`
+	AssetName    string  `json:"assetName"`
+	Cost         float64 `json:"cost"`
+	DepartmentId int64   `json:"departmentId"`
+}
+
+type RequestTransferApprovalResponse struct {
+	StepOrder    int                            `json:"stepOrder"`
+	ApproverType string                         `json:"approverType"`
+	MinAssetCost *float64                       `json:"minAssetCost"`
+	Approver     *UserResponseInRequestTransfer `json:"approver"`
+	Decision     string                         `json:"decision"`
+	Comment      string                         `json:"comment"`
+	DecidedAt    *time.Time                     `json:"decidedAt"`
 }
 
 type UserResponseInRequestTransfer struct {
@@ -26,6 +50,27 @@ type CategoryResponseInRequestTransfer struct {
 	CategoryName string `json:"categoryName"`
 }
 
+// AssetId bắt buộc ở bước đầu tiên cần biết tài sản (asset manager phòng ban nguồn hoặc admin chọn)
 type ConfirmRequestTransferRequest struct {
-	AssetId int64 `json:"assetId" binding:"required"`
+	AssetId *int64 `json:"assetId"`
+	Comment string `json:"comment"`
+}
+
+type DecisionRequestTransferRequest struct {
+	Comment string `json:"comment"`
+}
+
+type TransferApprovalStepRequest struct {
+	ApproverType string   `json:"approverType" binding:"required,oneof=requester_department_head source_department_manager admin"`
+	MinAssetCost *float64 `json:"minAssetCost" binding:"omitempty,min=0"`
+}
+
+type UpdateTransferApprovalChainRequest struct {
+	Steps []TransferApprovalStepRequest `json:"steps" binding:"required,min=1,dive"`
+}
+
+type TransferApprovalStepResponse struct {
+	StepOrder    int      `json:"stepOrder"`
+	ApproverType string   `json:"approverType"`
+	MinAssetCost *float64 `json:"minAssetCost"`
 }
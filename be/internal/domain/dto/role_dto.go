@@ -2,6 +2,45 @@ package dto
 
 type RoleResponse struct {
 	Id          int64  `json:"id"`
+	Title       string `json:"title"`
 	Slug        string `json:"slug"`
 	Description string `json:"description"`
+	Activated   bool   `json:"activated"`
+	IsSystem    bool   `json:"isSystem"`
+}
+
+type RolePermissionResponse struct {
+	PermissionId int64  `json:"permissionId"`
+	Slug         string `json:"slug"`
+	Title        string `json:"title"`
+	AccessLevel  string `json:"accessLevel"`
+}
+
+type RoleDetailResponse struct {
+	RoleResponse
+	UserCount   int64                    `json:"userCount"`
+	Permissions []RolePermissionResponse `json:"permissions"`
+}
+
+type PermissionResponse struct {
+	Id          int64  `json:"id"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+type CreateRoleRequest struct {
+	Title       string `json:"title" binding:"required,max=250"`
+	Slug        string `json:"slug" binding:"max=250"`
+	Description string `json:"description"`
+}
+
+type UpdateRoleRequest struct {
+	Title       string `json:"title" binding:"required,max=250"`
+	Description string `json:"description"`
+	Activated   *bool  `json:"activated"`
+}
+
+type SetRolePermissionRequest struct {
+	AccessLevel string `json:"accessLevel" binding:"required"`
 }
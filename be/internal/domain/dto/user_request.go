@@ -1,16 +1,20 @@
 package dto
 
+import "time"
+
 type UserRegisterRequest struct {
 	Password    string `json:"password" binding:"required,min=6"`
 	Email       string `json:"email" binding:"required,email"`
 	FirstName   string `json:"firstName" binding:"required,min=2,max=30"`
 	LastName    string `json:"lastName" binding:"required,min=2,max=30"`
 	RedirectUrl string `json:"redirectUrl" binding:"required"`
+	Language    string `json:"language" binding:"omitempty,oneof=en vi"` // ngôn ngữ email, mặc định MAIL_DEFAULT_LANGUAGE
 }
 
 type UserLoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email       string `json:"email" binding:"required,email"`
+	Password    string `json:"password" binding:"required"`
+	DeviceLabel string `json:"deviceLabel" binding:"max=255"`
 }
 
 type UserLoginResponse struct {
@@ -57,6 +61,7 @@ type UserResponse struct {
 	IsActive   bool                    `json:"isActivate"`
 	Role       UserRoleResponse        `json:"role"`
 	Avatar     string                  `json:"avatar"`
+	Language   string                  `json:"language"`
 	Department *UserDepartmentResponse `json:"department,omitempty"`
 }
 
@@ -74,3 +79,72 @@ type UserUpdateDepartmentRequest struct {
 	UserId       int64 `json:"userId"`
 	DepartmentId int64 `json:"departmentId"`
 }
+
+type MfaChallengeResponse struct {
+	MfaRequired      bool      `json:"mfa_required"`
+	MfaSetupRequired bool      `json:"mfa_setup_required"`
+	MfaToken         string    `json:"mfa_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+type MfaEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthUrl string `json:"otpauthUrl"`
+	QrCode     string `json:"qrCode"` // PNG base64
+}
+
+type MfaStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	ConfirmedAt            *time.Time `json:"confirmedAt"`
+	RecoveryCodesRemaining int        `json:"recoveryCodesRemaining"`
+}
+
+type MfaRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MfaDisableRequest struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+type MfaVerifyRequest struct {
+	MfaToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+	DeviceLabel  string `json:"deviceLabel" binding:"max=255"`
+}
+
+type MfaChallengeEnrollRequest struct {
+	MfaToken string `json:"mfaToken" binding:"required"`
+}
+
+type MfaChallengeConfirmRequest struct {
+	MfaToken    string `json:"mfaToken" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+	DeviceLabel string `json:"deviceLabel" binding:"max=255"`
+}
+
+// SessionDevice thông tin thiết bị gắn vào session khi login
+type SessionDevice struct {
+	DeviceLabel string
+	UserAgent   string
+	IpAddress   string
+}
+
+type UserSessionResponse struct {
+	Id          int64      `json:"id"`
+	DeviceLabel string     `json:"deviceLabel"`
+	UserAgent   string     `json:"userAgent"`
+	IpAddress   string     `json:"ipAddress"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	LastSeenAt  *time.Time `json:"lastSeenAt"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+	Current     bool       `json:"current"`
+}
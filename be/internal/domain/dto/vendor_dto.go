@@ -0,0 +1,83 @@
+package dto
+
+import "time"
+
+type VendorContactRequest struct {
+	Name      string `json:"name" binding:"required,max=255"`
+	Position  string `json:"position" binding:"max=255"`
+	Email     string `json:"email" binding:"omitempty,email"`
+	Phone     string `json:"phone" binding:"max=50"`
+	IsPrimary bool   `json:"isPrimary"`
+}
+
+// VendorRequest dùng cho cả tạo và sửa vendor, sửa thì danh sách contacts thay thế toàn bộ danh sách cũ
+type VendorRequest struct {
+	Name     string                 `json:"name" binding:"required,max=255"`
+	TaxId    string                 `json:"taxId" binding:"max=50"`
+	Address  string                 `json:"address" binding:"max=500"`
+	Email    string                 `json:"email" binding:"omitempty,email"`
+	Phone    string                 `json:"phone" binding:"max=50"`
+	Website  string                 `json:"website" binding:"omitempty,url"`
+	Notes    string                 `json:"notes"`
+	Contacts []VendorContactRequest `json:"contacts" binding:"dive"`
+}
+
+// VendorSpendResponse là tổng chi cho vendor theo bill (tách theo đồng tiền) và nguyên giá tài sản mua từ vendor
+type VendorSpendResponse struct {
+	VendorId          int64                         `json:"vendorId"`
+	VendorName        string                        `json:"vendorName"`
+	From              *time.Time                    `json:"from"`
+	To                *time.Time                    `json:"to"`
+	Currencies        []VendorSpendCurrencyResponse `json:"currencies"`
+	Bills             []VendorSpendBillResponse     `json:"bills"`
+	AssetCount        int                           `json:"assetCount"`
+	AssetPurchaseCost float64                       `json:"assetPurchaseCost"`
+}
+
+type VendorSpendCurrencyResponse struct {
+	Currency    string  `json:"currency"`
+	BillCount   int     `json:"billCount"`
+	Total       float64 `json:"total"`
+	Paid        float64 `json:"paid"`
+	Outstanding float64 `json:"outstanding"`
+}
+
+type VendorSpendBillResponse struct {
+	BillNumber  string     `json:"billNumber"`
+	CreateAt    time.Time  `json:"createAt"`
+	StatusBill  string     `json:"statusBill"`
+	DueDate     *time.Time `json:"dueDate"`
+	Currency    string     `json:"currency"`
+	GrandTotal  float64    `json:"grandTotal"`
+	PaidAmount  float64    `json:"paidAmount"`
+	Outstanding float64    `json:"outstanding"`
+}
+
+// VendorMaintenanceJobResponse là 1 lần bảo trì do vendor thực hiện, status: completed, in_progress hoặc scheduled.
+// Start/End là lịch dự kiến, TurnaroundHours tính theo thời điểm giao và nhận lại thực tế
+type VendorMaintenanceJobResponse struct {
+	ScheduleId      int64                               `json:"scheduleId"`
+	OccurrenceStart time.Time                           `json:"occurrenceStart"`
+	Start           time.Time                           `json:"start"`
+	End             time.Time                           `json:"end"`
+	HandedOverAt    *time.Time                          `json:"handedOverAt"`
+	ReturnedAt      *time.Time                          `json:"returnedAt"`
+	Status          string                              `json:"status"`
+	TurnaroundHours *float64                            `json:"turnaroundHours"`
+	Asset           AssetResponseInMaintenanceSchedules `json:"asset"`
+}
+
+// VendorPerformanceResponse tổng hợp hiệu quả vendor trong khoảng [from, to): thời gian sửa chữa trung bình tính trên các lần bảo trì đã nhận lại tài sản
+type VendorPerformanceResponse struct {
+	VendorId               int64                         `json:"vendorId"`
+	VendorName             string                        `json:"vendorName"`
+	From                   time.Time                     `json:"from"`
+	To                     time.Time                     `json:"to"`
+	AssetCount             int64                         `json:"assetCount"`
+	CompletedJobs          int                           `json:"completedJobs"`
+	InProgressJobs         int                           `json:"inProgressJobs"`
+	AverageTurnaroundHours *float64                      `json:"averageTurnaroundHours"`
+	MinTurnaroundHours     *float64                      `json:"minTurnaroundHours"`
+	MaxTurnaroundHours     *float64                      `json:"maxTurnaroundHours"`
+	Spend                  []VendorSpendCurrencyResponse `json:"spend"`
+}
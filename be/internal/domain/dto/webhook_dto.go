@@ -0,0 +1,43 @@
+package dto
+
+import "time"
+
+type CreateWebhookRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Url    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events"` // rỗng = mọi sự kiện
+}
+
+type UpdateWebhookRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Url    string   `json:"url" binding:"required,url"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active" binding:"required"`
+}
+
+// CreateWebhookResponse chỉ trả secret đúng 1 lần lúc tạo
+type CreateWebhookResponse struct {
+	Id     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Url    string   `json:"url"`
+	Events []string `json:"events"`
+	Active bool     `json:"active"`
+	Secret string   `json:"secret"`
+}
+
+// WebhookEventPayload là body gửi tới webhook
+type WebhookEventPayload struct {
+	Id         string           `json:"id"`
+	Type       string           `json:"type"`
+	OccurredAt time.Time        `json:"occurredAt"`
+	Data       WebhookEventData `json:"data"`
+}
+
+type WebhookEventData struct {
+	AssetLogId    int64  `json:"assetLogId"`
+	AssetId       int64  `json:"assetId"`
+	Action        string `json:"action"`
+	ChangeSummary string `json:"changeSummary"`
+	ByUserId      *int64 `json:"byUserId"`
+	AssignUserId  *int64 `json:"assignUserId"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// ApiTokens là personal access token cho script/tool, chỉ lưu hash của token.
+// Scopes là tập permission slug token được dùng, khi dùng sẽ giao với quyền hiện tại của role owner
+type ApiTokens struct {
+	Id         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId     int64      `gorm:"not null;index" json:"userId"`
+	CompanyId  int64      `gorm:"not null;index" json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // vài ký tự đầu của token để user nhận ra
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;type:jsonb" json:"scopes"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	LastUsedIp string     `gorm:"type:varchar(64)" json:"lastUsedIp"`
+	CreatedAt  time.Time  `gorm:"not null" json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	RevokedBy  *int64     `json:"revokedBy"`
+	User       *Users     `gorm:"foreignKey:UserId;references:Id" json:"user,omitempty"`
+}
+
+func (t *ApiTokens) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
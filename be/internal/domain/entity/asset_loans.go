@@ -0,0 +1,29 @@
+package entity
+
+import "time"
+
+type AssetLoans struct {
+	Id                    int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	AssetId               int64      `gorm:"index;uniqueIndex:idx_asset_loans_active_asset,where:status = 'Checked Out'" json:"assetId"` // mỗi tài sản chỉ có 1 khoản mượn chưa trả
+	AssignmentId          int64      `json:"assignmentId"`
+	BorrowerId            int64      `gorm:"index" json:"borrowerId"`
+	PreviousOwnerId       *int64     `json:"previousOwnerId"`
+	PreviousStatus        string     `json:"previousStatus"` // trạng thái tài sản trước khi cho mượn
+	CheckedOutById        int64      `json:"checkedOutById"`
+	CheckOutDate          time.Time  `json:"checkOutDate"`
+	DueDate               time.Time  `gorm:"index" json:"dueDate"`
+	CheckOutCondition     string     `json:"checkOutCondition"`
+	CheckedInById         *int64     `json:"checkedInById"`
+	CheckInDate           *time.Time `json:"checkInDate"`
+	CheckInCondition      *string    `json:"checkInCondition"`
+	Note                  string     `json:"note"`
+	Status                string     `gorm:"index" json:"status"` // Checked Out, Returned
+	LastOverdueNotifiedAt *time.Time `json:"-"`
+	CompanyId             int64      `json:"-"`
+
+	Asset         Assets `gorm:"foreignKey:AssetId;references:Id"`
+	Borrower      Users  `gorm:"foreignKey:BorrowerId;references:Id"`
+	PreviousOwner *Users `gorm:"foreignKey:PreviousOwnerId;references:Id"`
+	CheckedOutBy  Users  `gorm:"foreignKey:CheckedOutById;references:Id"`
+	CheckedInBy   *Users `gorm:"foreignKey:CheckedInById;references:Id"`
+}
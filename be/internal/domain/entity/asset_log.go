@@ -11,6 +11,7 @@ type AssetLog struct {
 	AssetId       int64     `json:"assetId"`
 	ChangeSummary string    `json:"changeSummary"`
 	CompanyId     int64     `json:"-"`
+	Event         string    `gorm:"-" json:"-"` // sự kiện webhook do nơi ghi log khai báo, rỗng thì không phát webhook
 	ByUser        *Users    `gorm:"foreignKey:ByUserId;references:Id"`
 	AssignUser    *Users    `gorm:"foreignKey:AssignUserId;references:Id"`
 	Asset         Assets    `gorm:"foreignKey:AssetId;references:Id"`
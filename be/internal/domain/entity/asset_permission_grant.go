@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// AssetPermissionGrants cấp quyền riêng trên 1 tài sản cho 1 user, ngoài quyền suy ra từ role/phòng ban/owner
+type AssetPermissionGrants struct {
+	Id             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CompanyId      int64     `gorm:"not null;index" json:"-"`
+	AssetId        int64     `gorm:"not null;uniqueIndex:idx_asset_grant_unique" json:"assetId"`
+	UserId         int64     `gorm:"not null;uniqueIndex:idx_asset_grant_unique;index" json:"userId"`
+	PermissionSlug string    `gorm:"type:varchar(250);not null;uniqueIndex:idx_asset_grant_unique" json:"permissionSlug"`
+	GrantedBy      *int64    `json:"grantedBy"`
+	CreatedAt      time.Time `gorm:"not null;default:now()" json:"createdAt"`
+
+	User *Users `gorm:"foreignKey:UserId;references:Id" json:"user,omitempty"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// AssetUsages lưu sản lượng thực tế theo từng kỳ khấu hao (năm thứ Period tính từ ngày bắt đầu sử dụng)
+type AssetUsages struct {
+	Id           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	AssetId      int64     `gorm:"uniqueIndex:idx_asset_usage_period" json:"assetId"`
+	Period       int       `gorm:"uniqueIndex:idx_asset_usage_period" json:"period"`
+	Units        float64   `json:"units"`
+	RecordedById int64     `json:"recordedById"`
+	CompanyId    int64     `json:"-"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
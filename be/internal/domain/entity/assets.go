@@ -20,11 +20,15 @@ type Assets struct {
 	QrUrl                *string    `json:"qrUrl"`
 	RetiredOrDisposeTime *time.Time `json:"-"`
 	CompanyId            int64      `json:"-"`
+	VendorId             *int64     `gorm:"index" json:"vendorId"`           // mua từ nhà cung cấp nào
+	GoodsReceiptLineId   *int64     `gorm:"index" json:"goodsReceiptLineId"` // nhập kho từ đơn mua hàng
 
 	AnnualDepreciation *float64   `json:"annualDepreciation"` //Nguyên giá tài sản
 	ResidualValue      *float64   `json:"residualValue"`      //Giá trị thu hồi dự kiến
 	UsefulLife         *float64   `json:"usefulLife"`         //Thời gian sử dụng dự kiến
 	AcquisitionDate    *time.Time `json:"acquisitionDate"`    //Ngày bắt đầu sử dụng
+	DepreciationMethod *string    `json:"depreciationMethod"` //Ghi đè phương pháp khấu hao của category
+	TotalUnits         *float64   `json:"totalUnits"`         //Tổng sản lượng dự kiến (units-of-production)
 
 	Category   Categories  `gorm:"foreignKey:CategoryId;references:Id"`
 	Department Departments `gorm:"foreignKey:DepartmentId;references:Id"`
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// BillLineItems là 1 dòng trên bill, có thể gắn với 1 tài sản hoặc là dòng tự do (phí ship, gia hạn bảo hành...).
+// Các cột Line* do server tính, client chỉ gửi số lượng, đơn giá, thuế suất và chiết khấu
+type BillLineItems struct {
+	Id           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BillId       int64     `gorm:"index;not null" json:"billId"`
+	AssetId      *int64    `gorm:"index" json:"assetId"`
+	Position     int       `gorm:"not null;default:0" json:"position"`
+	Description  string    `gorm:"not null" json:"description"`
+	Quantity     float64   `gorm:"not null;default:1" json:"quantity"`
+	UnitPrice    float64   `gorm:"not null" json:"unitPrice"`
+	TaxRate      float64   `gorm:"not null;default:0" json:"taxRate"`  // phần trăm, vd 10 = VAT 10%
+	Discount     float64   `gorm:"not null;default:0" json:"discount"` // số tiền giảm trên cả dòng, trước thuế
+	LineSubtotal float64   `gorm:"not null" json:"lineSubtotal"`       // quantity * unitPrice - discount
+	LineTax      float64   `gorm:"not null" json:"lineTax"`
+	LineTotal    float64   `gorm:"not null" json:"lineTotal"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	Asset *Assets `gorm:"foreignKey:AssetId;references:Id"`
+}
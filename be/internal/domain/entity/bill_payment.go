@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+const (
+	BillPaymentMethodCash         = "cash"
+	BillPaymentMethodBankTransfer = "bank_transfer"
+	BillPaymentMethodCard         = "card"
+	BillPaymentMethodOther        = "other"
+)
+
+var BillPaymentMethods = []string{BillPaymentMethodCash, BillPaymentMethodBankTransfer, BillPaymentMethodCard, BillPaymentMethodOther}
+
+// BillPayments là 1 lần thanh toán (có thể 1 phần) cho bill, tổng các lần được cộng vào Bill.PaidAmount
+type BillPayments struct {
+	Id           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	BillId       int64     `gorm:"index;not null" json:"billId"`
+	Amount       float64   `gorm:"not null" json:"amount"`
+	Method       string    `gorm:"type:varchar(20);not null" json:"method"`
+	Reference    string    `json:"reference"` // mã giao dịch ngân hàng, số phiếu thu...
+	PayerName    string    `json:"payerName"`
+	PaidAt       time.Time `gorm:"not null" json:"paidAt"`
+	RecordedById int64     `json:"recordedById"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	RecordedBy Users `gorm:"foreignKey:RecordedById;references:Id"`
+}
@@ -2,21 +2,72 @@ package entity
 
 import "time"
 
+// Trạng thái bill được suy ra từ số tiền đã trả và hạn thanh toán (DeriveStatus), cột status_bill chỉ là bản lưu để lọc
+const (
+	BillStatusUnpaid        = "Unpaid"
+	BillStatusPartiallyPaid = "Partially Paid"
+	BillStatusPaid          = "Paid"
+	BillStatusOverdue       = "Overdue"
+)
+
+// billAmountEpsilon bỏ qua sai số float khi so tổng đã trả với tổng bill
+const billAmountEpsilon = 0.005
+
 type Bill struct {
-	Id                 int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	BillNumber         string    `gorm:"index" json:"billNumber"`
-	Description        string    `json:"description"`
-	CreateAt           time.Time `json:"createAt"`
-	CreateById         int64     `json:"createById"`
-	StatusBill         string    `json:"statusBill"`
-	FileAttachmentBill *string   `json:"fileAttachmentBill"`
-	ImageUploadBill    *string   `json:"imageUploadBill"`
-	CompanyId          int64     `json:"-"`
-	BuyerName          string    `json:"buyerName"`
-	BuyerPhone         string    `json:"buyerPhone"`
-	BuyerEmail         string    `json:"buyerEmail"`
-	BuyerAddress       string    `json:"buyerAddress"`
-
-	CreateBy   Users       `gorm:"foreignKey:CreateById;references:Id"`
-	BillAssets []BillAsset `gorm:"foreignKey:BillId;references:Id"`
+	Id                 int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	BillNumber         string     `gorm:"index" json:"billNumber"`
+	Description        string     `json:"description"`
+	CreateAt           time.Time  `json:"createAt"`
+	CreateById         int64      `json:"createById"`
+	StatusBill         string     `json:"statusBill"`
+	FileAttachmentBill *string    `json:"fileAttachmentBill"`
+	ImageUploadBill    *string    `json:"imageUploadBill"`
+	CompanyId          int64      `json:"-"`
+	BuyerName          string     `json:"buyerName"`
+	BuyerPhone         string     `json:"buyerPhone"`
+	BuyerEmail         string     `json:"buyerEmail"`
+	BuyerAddress       string     `json:"buyerAddress"`
+	VendorId           *int64     `gorm:"index" json:"vendorId"`                                  // đối tác của bill
+	Currency           string     `gorm:"type:varchar(3);not null;default:'VND'" json:"currency"` // mã ISO 4217
+	Subtotal           float64    `gorm:"not null;default:0" json:"subtotal"`
+	DiscountTotal      float64    `gorm:"not null;default:0" json:"discountTotal"`
+	TaxTotal           float64    `gorm:"not null;default:0" json:"taxTotal"`
+	GrandTotal         float64    `gorm:"not null;default:0" json:"grandTotal"`
+	PaidAmount         float64    `gorm:"not null;default:0" json:"paidAmount"`
+	DueDate            *time.Time `gorm:"index" json:"dueDate"` // hết ngày DueDate mà chưa trả đủ thì quá hạn
+	LastReminderAt     *time.Time `json:"lastReminderAt"`
+
+	CreateBy   Users           `gorm:"foreignKey:CreateById;references:Id"`
+	BillAssets []BillAsset     `gorm:"foreignKey:BillId;references:Id"`
+	LineItems  []BillLineItems `gorm:"foreignKey:BillId;references:Id"`
+	Payments   []BillPayments  `gorm:"foreignKey:BillId;references:Id"`
+}
+
+// Outstanding là số tiền còn phải trả
+func (b *Bill) Outstanding() float64 {
+	if outstanding := b.GrandTotal - b.PaidAmount; outstanding > billAmountEpsilon {
+		return outstanding
+	}
+	return 0
+}
+
+// DaysOverdue là số ngày đã quá hạn tính tới now, <= 0 khi chưa quá hạn hoặc không có hạn
+func (b *Bill) DaysOverdue(now time.Time) int {
+	if b.DueDate == nil {
+		return 0
+	}
+	return int(now.Sub(*b.DueDate).Hours() / 24)
+}
+
+// DeriveStatus suy ra trạng thái tại thời điểm now: trả đủ là Paid, còn nợ mà quá hạn là Overdue, trả 1 phần là Partially Paid
+func (b *Bill) DeriveStatus(now time.Time) string {
+	switch {
+	case b.Outstanding() == 0:
+		return BillStatusPaid
+	case b.DaysOverdue(now) > 0:
+		return BillStatusOverdue
+	case b.PaidAmount > 0:
+		return BillStatusPartiallyPaid
+	}
+	return BillStatusUnpaid
 }
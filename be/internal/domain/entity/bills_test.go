@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillOutstanding(t *testing.T) {
+	tests := []struct {
+		name       string
+		grandTotal float64
+		paid       float64
+		want       float64
+	}{
+		{"unpaid", 100, 0, 100},
+		{"partially paid", 100, 40, 60},
+		{"fully paid", 100, 100, 0},
+		// sai số float nhỏ hơn epsilon coi như đã trả đủ
+		{"float noise", 0.3, 0.1 + 0.2, 0},
+		{"below epsilon", 100, 99.996, 0},
+		{"overpaid", 100, 120, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bill{GrandTotal: tt.grandTotal, PaidAmount: tt.paid}
+			if got := b.Outstanding(); got != tt.want {
+				t.Errorf("Outstanding = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillDeriveStatus(t *testing.T) {
+	due := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		paid     float64
+		dueDate  *time.Time
+		now      time.Time
+		wantDays int
+		want     string
+	}{
+		{"unpaid before due", 0, &due, due.Add(-time.Hour), 0, BillStatusUnpaid},
+		// trong ngày DueDate vẫn chưa quá hạn
+		{"unpaid on due day", 0, &due, due.Add(23 * time.Hour), 0, BillStatusUnpaid},
+		{"unpaid day after due", 0, &due, due.Add(24 * time.Hour), 1, BillStatusOverdue},
+		{"partially paid before due", 40, &due, due.Add(-time.Hour), 0, BillStatusPartiallyPaid},
+		{"partially paid overdue", 40, &due, due.AddDate(0, 0, 45), 45, BillStatusOverdue},
+		{"paid overdue", 100, &due, due.AddDate(0, 0, 45), 45, BillStatusPaid},
+		{"no due date", 0, nil, due.AddDate(1, 0, 0), 0, BillStatusUnpaid},
+		{"no due date partially paid", 10, nil, due.AddDate(1, 0, 0), 0, BillStatusPartiallyPaid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bill{GrandTotal: 100, PaidAmount: tt.paid, DueDate: tt.dueDate}
+			if got := b.DaysOverdue(tt.now); got != tt.wantDays {
+				t.Errorf("DaysOverdue = %d, want %d", got, tt.wantDays)
+			}
+			if got := b.DeriveStatus(tt.now); got != tt.want {
+				t.Errorf("DeriveStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
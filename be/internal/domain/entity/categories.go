@@ -4,4 +4,10 @@ type Categories struct {
 	Id           int64  `gorm:"primaryKey;autoIncrement" json:"id"`
 	CategoryName string `gorm:"uniqueIndex:idx_category_company" json:"categoryName"`
 	CompanyId    int64  `gorm:"uniqueIndex:idx_category_company" json:"-"`
+
+	// Cấu hình khấu hao mặc định cho tài sản thuộc category, tài sản có thể ghi đè
+	DepreciationMethod *string  `json:"depreciationMethod"`
+	UsefulLife         *float64 `json:"usefulLife"`      // số năm sử dụng
+	ResidualRate       *float64 `json:"residualRate"`    // giá trị thu hồi theo tỉ lệ nguyên giá (0 - 1)
+	DecliningFactor    *float64 `json:"decliningFactor"` // hệ số cho declining-balance, mặc định 1.5
 }
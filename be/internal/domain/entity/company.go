@@ -4,4 +4,5 @@ type Company struct {
 	Id          int64  `gorm:"primaryKey;autoIncrement" json:"id"`
 	CompanyName string `gorm:"unique" json:"companyName"`
 	Email       string `gorm:"unique" json:"email"`
+	RequireMfa  bool   `gorm:"not null;default:false" json:"requireMfa"` // bắt buộc MFA cho admin và assetManager
 }
@@ -5,6 +5,7 @@ type Departments struct {
 	DepartmentName string `gorm:"uniqueIndex:uniq_dept_location_company" json:"departmentName"`
 	LocationId     int64  `gorm:"uniqueIndex:uniq_dept_location_company" json:"locationId"`
 	CompanyId      int64  `gorm:"uniqueIndex:uniq_dept_location_company" json:"-"`
+	HeadId         *int64 `json:"headId"` // trưởng phòng, duyệt bước requester_department_head của request transfer
 
 	Location Locations `gorm:"foreignKey:LocationId;references:Id"`
 }
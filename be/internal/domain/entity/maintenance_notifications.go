@@ -3,9 +3,10 @@ package entity
 import "time"
 
 type MaintenanceNotifications struct {
-	Id         int64 `gorm:"primaryKey;autoIncrement" json:"id"`
-	ScheduleId int64
-	NotifyDate time.Time
+	Id              int64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleId      int64
+	OccurrenceStart *time.Time // lần lặp đã thông báo, nil với bản ghi của lịch 1 lần trước đây
+	NotifyDate      time.Time
 
 	MaintenanceSchedule MaintenanceSchedules `gorm:"foreignKey:ScheduleId;references:Id"`
 }
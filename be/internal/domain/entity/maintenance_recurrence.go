@@ -0,0 +1,288 @@
+package entity
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+
+	// MaxOccurrenceIterations giới hạn số lần lặp được sinh tính từ đầu series để series lỗi không chạy vô hạn,
+	// vượt giới hạn thì trả về ErrTooManyOccurrences thay vì cắt bớt kết quả
+	MaxOccurrenceIterations = 10000
+	// OverlapHorizonYears: series không có điểm dừng chỉ được kiểm tra trùng lịch trong bấy nhiêu năm kể từ hiện tại,
+	// các lần lặp sau đó có thể trùng với lịch khác mà không bị chặn
+	OverlapHorizonYears = 2
+)
+
+var ErrTooManyOccurrences = errors.New("maintenance series has more than 10000 occurrences before the requested time")
+
+var weekdayCodes = map[string]int{"MO": 0, "TU": 1, "WE": 2, "TH": 3, "FR": 4, "SA": 5, "SU": 6}
+
+// MaintenanceOccurrence là 1 lần bảo trì cụ thể được sinh ra từ series
+type MaintenanceOccurrence struct {
+	ScheduleId      int64     `json:"scheduleId"`
+	OccurrenceStart time.Time `json:"occurrenceStart"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+}
+
+func (s *MaintenanceSchedules) IsRecurring() bool {
+	return s.Frequency != nil && *s.Frequency != ""
+}
+
+func ValidFrequency(frequency string) bool {
+	switch frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+		return true
+	}
+	return false
+}
+
+func ValidWeekday(code string) bool {
+	_, ok := weekdayCodes[strings.ToUpper(code)]
+	return ok
+}
+
+// Occurrences trả về các lần bảo trì (đã áp dụng exception) có khoảng thời gian giao với [from, to).
+// Các lần lặp được sinh dần theo thứ tự thời gian và dừng ngay khi vượt quá to.
+func (s *MaintenanceSchedules) Occurrences(from, to time.Time) ([]MaintenanceOccurrence, error) {
+	duration := s.EndDate.Sub(s.StartDate)
+	exceptions := s.exceptionMap()
+	limit := to
+	for _, ex := range s.Exceptions {
+		// Lần lặp bị dời vào khoảng [from, to) thì vẫn phải sinh tới thời điểm gốc của nó
+		if !ex.Cancelled && ex.StartDate != nil && ex.StartDate.Before(to) && ex.OccurrenceStart.After(limit) {
+			limit = ex.OccurrenceStart.Add(time.Nanosecond)
+		}
+	}
+	res := []MaintenanceOccurrence{}
+	err := s.eachOccurrenceStart(func(start time.Time) bool {
+		if !start.Before(limit) {
+			return false
+		}
+		occ, ok := s.applyException(start, duration, exceptions)
+		if !ok {
+			return true
+		}
+		if occ.End.After(from) && occ.Start.Before(to) {
+			res = append(res, occ)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Start.Before(res[j].Start) })
+	return res, nil
+}
+
+// OccurrenceAt tìm lần lặp (chưa bị huỷ) có thời điểm bắt đầu gốc là occurrenceStart, index là thứ tự của nó trong series
+func (s *MaintenanceSchedules) OccurrenceAt(occurrenceStart time.Time) (*MaintenanceOccurrence, int, bool, error) {
+	exceptions := s.exceptionMap()
+	duration := s.EndDate.Sub(s.StartDate)
+	var found *MaintenanceOccurrence
+	index := 0
+	err := s.eachOccurrenceStart(func(start time.Time) bool {
+		if start.Unix() > occurrenceStart.Unix() {
+			return false
+		}
+		if start.Unix() == occurrenceStart.Unix() {
+			if occ, ok := s.applyException(start, duration, exceptions); ok {
+				found = &occ
+			}
+			return false
+		}
+		index++
+		return true
+	})
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if found == nil {
+		return nil, 0, false, nil
+	}
+	return found, index, true, nil
+}
+
+// NextOccurrence trả về lần bảo trì đầu tiên chưa kết thúc tại thời điểm from
+func (s *MaintenanceSchedules) NextOccurrence(from time.Time) (*MaintenanceOccurrence, bool, error) {
+	exceptions := s.exceptionMap()
+	duration := s.EndDate.Sub(s.StartDate)
+	var next *MaintenanceOccurrence
+	err := s.eachOccurrenceStart(func(start time.Time) bool {
+		occ, ok := s.applyException(start, duration, exceptions)
+		if !ok {
+			return true
+		}
+		if occ.End.After(from) {
+			next = &occ
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return next, next != nil, nil
+}
+
+// LastOccurrenceEnd trả về thời điểm kết thúc của lần lặp cuối, ok = false nếu series không có điểm dừng
+func (s *MaintenanceSchedules) LastOccurrenceEnd() (time.Time, bool, error) {
+	if s.IsRecurring() && s.RepeatUntil == nil && s.RepeatCount == nil {
+		return time.Time{}, false, nil
+	}
+	var last time.Time
+	err := s.eachOccurrenceStart(func(start time.Time) bool {
+		last = start
+		return true
+	})
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	end := last.Add(s.EndDate.Sub(s.StartDate))
+	for _, ex := range s.Exceptions {
+		if ex.EndDate != nil && ex.EndDate.After(end) {
+			end = *ex.EndDate
+		}
+	}
+	return end, true, nil
+}
+
+// eachOccurrenceStart gọi yield lần lượt với thời điểm bắt đầu gốc của từng lần lặp tới khi yield trả về false hoặc
+// series kết thúc, vượt MaxOccurrenceIterations mà vẫn chưa dừng thì trả về ErrTooManyOccurrences
+func (s *MaintenanceSchedules) eachOccurrenceStart(yield func(time.Time) bool) error {
+	if !s.IsRecurring() {
+		yield(s.StartDate)
+		return nil
+	}
+	interval := s.RepeatInterval
+	if interval < 1 {
+		interval = 1
+	}
+	count := 0
+	emit := func(t time.Time) bool {
+		if s.RepeatUntil != nil && t.After(*s.RepeatUntil) {
+			return false
+		}
+		if s.RepeatCount != nil && count >= *s.RepeatCount {
+			return false
+		}
+		count++
+		return yield(t)
+	}
+	start := s.StartDate
+	switch *s.Frequency {
+	case FrequencyWeekly:
+		weekdays := s.weekdays()
+		if len(weekdays) == 0 {
+			for i := 0; i < MaxOccurrenceIterations; i++ {
+				if !emit(start.AddDate(0, 0, 7*i*interval)) {
+					return nil
+				}
+			}
+			return ErrTooManyOccurrences
+		}
+		// Tuần bắt đầu từ thứ 2 giống WKST=MO mặc định của RRULE
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := start.AddDate(0, 0, -offset)
+		for w := 0; w < MaxOccurrenceIterations; w++ {
+			base := weekStart.AddDate(0, 0, 7*w*interval)
+			for _, d := range weekdays {
+				t := base.AddDate(0, 0, d)
+				if t.Before(start) {
+					continue
+				}
+				if !emit(t) {
+					return nil
+				}
+			}
+		}
+	case FrequencyMonthly, FrequencyYearly:
+		months := interval
+		if *s.Frequency == FrequencyYearly {
+			months = 12 * interval
+		}
+		for i := 0; i < MaxOccurrenceIterations; i++ {
+			if !emit(addMonthsClamped(start, i*months)) {
+				return nil
+			}
+		}
+	default:
+		for i := 0; i < MaxOccurrenceIterations; i++ {
+			if !emit(start.AddDate(0, 0, i*interval)) {
+				return nil
+			}
+		}
+	}
+	return ErrTooManyOccurrences
+}
+
+func (s *MaintenanceSchedules) exceptionMap() map[int64]MaintenanceScheduleExceptions {
+	exceptions := map[int64]MaintenanceScheduleExceptions{}
+	for _, ex := range s.Exceptions {
+		exceptions[ex.OccurrenceStart.Unix()] = ex
+	}
+	return exceptions
+}
+
+// applyException trả về lần lặp sau khi áp dụng exception, ok = false nếu lần lặp đã bị huỷ
+func (s *MaintenanceSchedules) applyException(start time.Time, duration time.Duration, exceptions map[int64]MaintenanceScheduleExceptions) (MaintenanceOccurrence, bool) {
+	occ := MaintenanceOccurrence{ScheduleId: s.Id, OccurrenceStart: start, Start: start, End: start.Add(duration)}
+	ex, found := exceptions[start.Unix()]
+	if !found {
+		return occ, true
+	}
+	if ex.Cancelled {
+		return occ, false
+	}
+	if ex.StartDate != nil {
+		occ.Start = *ex.StartDate
+	}
+	if ex.EndDate != nil {
+		occ.End = *ex.EndDate
+	}
+	return occ, true
+}
+
+func (s *MaintenanceSchedules) weekdays() []int {
+	days := []int{}
+	seen := map[int]bool{}
+	for _, code := range strings.Split(s.ByWeekdays, ",") {
+		if d, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(code))]; ok && !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+// addMonthsClamped cộng tháng nhưng giữ ngày cuối tháng (31/01 + 1 tháng = 28/02 thay vì 03/03)
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if d > lastDay {
+		d = lastDay
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// OccurrencesOverlap kiểm tra 2 danh sách lần lặp có khoảng thời gian giao nhau hay không
+func OccurrencesOverlap(a, b []MaintenanceOccurrence) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if !(x.End.Before(y.Start) || x.Start.After(y.End)) {
+				return true
+			}
+		}
+	}
+	return false
+}
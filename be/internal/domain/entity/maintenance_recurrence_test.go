@@ -0,0 +1,178 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 9, 0, 0, 0, time.UTC)
+}
+
+func recurring(frequency string, interval int, start time.Time) *MaintenanceSchedules {
+	return &MaintenanceSchedules{
+		Id:             1,
+		StartDate:      start,
+		EndDate:        start.Add(2 * time.Hour),
+		Frequency:      &frequency,
+		RepeatInterval: interval,
+	}
+}
+
+func starts(occurrences []MaintenanceOccurrence) []time.Time {
+	res := make([]time.Time, 0, len(occurrences))
+	for _, occ := range occurrences {
+		res = append(res, occ.Start)
+	}
+	return res
+}
+
+func TestOccurrences(t *testing.T) {
+	count := func(n int) *int { return &n }
+	until := func(t time.Time) *time.Time { return &t }
+	moved := day(2025, 1, 4)
+	movedEnd := moved.Add(time.Hour)
+	tests := []struct {
+		name     string
+		schedule func() *MaintenanceSchedules
+		from, to time.Time
+		want     []time.Time
+	}{
+		{"one-off inside range", func() *MaintenanceSchedules {
+			return &MaintenanceSchedules{StartDate: day(2025, 1, 1), EndDate: day(2025, 1, 2)}
+		}, day(2024, 12, 1), day(2025, 2, 1), []time.Time{day(2025, 1, 1)}},
+		{"one-off overlapping range start", func() *MaintenanceSchedules {
+			return &MaintenanceSchedules{StartDate: day(2025, 1, 1), EndDate: day(2025, 1, 5)}
+		}, day(2025, 1, 3), day(2025, 2, 1), []time.Time{day(2025, 1, 1)}},
+		{"daily every 2 days", func() *MaintenanceSchedules {
+			return recurring(FrequencyDaily, 2, day(2025, 1, 1))
+		}, day(2025, 1, 1), day(2025, 1, 8), []time.Time{day(2025, 1, 1), day(2025, 1, 3), day(2025, 1, 5), day(2025, 1, 7)}},
+		{"weekly by weekdays", func() *MaintenanceSchedules {
+			s := recurring(FrequencyWeekly, 1, day(2025, 1, 1)) // thứ 4
+			s.ByWeekdays = "MO,WE"
+			return s
+		}, day(2025, 1, 1), day(2025, 1, 14), []time.Time{day(2025, 1, 1), day(2025, 1, 6), day(2025, 1, 8), day(2025, 1, 13)}},
+		{"monthly clamps to end of month", func() *MaintenanceSchedules {
+			return recurring(FrequencyMonthly, 1, day(2025, 1, 31))
+		}, day(2025, 1, 1), day(2025, 4, 1), []time.Time{day(2025, 1, 31), day(2025, 2, 28), day(2025, 3, 31)}},
+		{"yearly on leap day", func() *MaintenanceSchedules {
+			return recurring(FrequencyYearly, 1, day(2024, 2, 29))
+		}, day(2024, 1, 1), day(2026, 1, 1), []time.Time{day(2024, 2, 29), day(2025, 2, 28)}},
+		{"repeat count", func() *MaintenanceSchedules {
+			s := recurring(FrequencyDaily, 1, day(2025, 1, 1))
+			s.RepeatCount = count(2)
+			return s
+		}, day(2025, 1, 1), day(2025, 2, 1), []time.Time{day(2025, 1, 1), day(2025, 1, 2)}},
+		{"repeat until", func() *MaintenanceSchedules {
+			s := recurring(FrequencyWeekly, 1, day(2025, 1, 1))
+			s.RepeatUntil = until(day(2025, 1, 15))
+			return s
+		}, day(2025, 1, 1), day(2025, 3, 1), []time.Time{day(2025, 1, 1), day(2025, 1, 8), day(2025, 1, 15)}},
+		{"cancelled and moved occurrences", func() *MaintenanceSchedules {
+			s := recurring(FrequencyDaily, 1, day(2025, 1, 1))
+			s.Exceptions = []MaintenanceScheduleExceptions{
+				{OccurrenceStart: day(2025, 1, 2), Cancelled: true},
+				{OccurrenceStart: day(2025, 1, 3), StartDate: &moved, EndDate: &movedEnd},
+			}
+			return s
+		}, day(2025, 1, 1), day(2025, 1, 4), []time.Time{day(2025, 1, 1)}},
+		{"occurrence moved into range", func() *MaintenanceSchedules {
+			s := recurring(FrequencyWeekly, 1, day(2025, 1, 1))
+			early := day(2025, 1, 2)
+			earlyEnd := early.Add(time.Hour)
+			s.Exceptions = []MaintenanceScheduleExceptions{{OccurrenceStart: day(2025, 1, 8), StartDate: &early, EndDate: &earlyEnd}}
+			return s
+		}, day(2025, 1, 2), day(2025, 1, 3), []time.Time{day(2025, 1, 2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.schedule().Occurrences(tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotStarts := starts(got)
+			if len(gotStarts) != len(tt.want) {
+				t.Fatalf("got %v, want %v", gotStarts, tt.want)
+			}
+			for i := range tt.want {
+				if !gotStarts[i].Equal(tt.want[i]) {
+					t.Errorf("got %v, want %v", gotStarts, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestOccurrencesIterationLimit(t *testing.T) {
+	s := recurring(FrequencyDaily, 1, day(2000, 1, 1))
+	from := day(2000, 1, 1).AddDate(0, 0, MaxOccurrenceIterations+10)
+	if _, err := s.Occurrences(from, from.AddDate(0, 0, 1)); !errors.Is(err, ErrTooManyOccurrences) {
+		t.Fatalf("err = %v, want ErrTooManyOccurrences", err)
+	}
+	// Series có điểm dừng sau giới hạn cũng báo lỗi thay vì cắt bớt
+	s.RepeatUntil = &from
+	if _, _, err := s.LastOccurrenceEnd(); !errors.Is(err, ErrTooManyOccurrences) {
+		t.Fatalf("err = %v, want ErrTooManyOccurrences", err)
+	}
+	// Trong giới hạn thì vẫn sinh bình thường
+	if _, err := s.Occurrences(day(2000, 1, 1), day(2000, 2, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOccurrenceAtAndNext(t *testing.T) {
+	s := recurring(FrequencyWeekly, 1, day(2025, 1, 1))
+	s.Exceptions = []MaintenanceScheduleExceptions{{OccurrenceStart: day(2025, 1, 8), Cancelled: true}}
+	tests := []struct {
+		name      string
+		start     time.Time
+		wantOk    bool
+		wantIndex int
+	}{
+		{"first", day(2025, 1, 1), true, 0},
+		{"cancelled", day(2025, 1, 8), false, 0},
+		{"after cancelled keeps series index", day(2025, 1, 15), true, 2},
+		{"not on rule", day(2025, 1, 16), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occ, index, ok, err := s.OccurrenceAt(tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOk || index != tt.wantIndex {
+				t.Fatalf("got ok=%v index=%d, want ok=%v index=%d", ok, index, tt.wantOk, tt.wantIndex)
+			}
+			if ok && !occ.Start.Equal(tt.start) {
+				t.Errorf("start = %v", occ.Start)
+			}
+		})
+	}
+	next, ok, err := s.NextOccurrence(day(2025, 1, 2))
+	if err != nil || !ok || !next.Start.Equal(day(2025, 1, 15)) {
+		t.Errorf("NextOccurrence = %v, %v, %v, want 2025-01-15", next, ok, err)
+	}
+}
+
+func TestOccurrencesOverlap(t *testing.T) {
+	occ := func(start, end time.Time) MaintenanceOccurrence { return MaintenanceOccurrence{Start: start, End: end} }
+	tests := []struct {
+		name string
+		a, b []MaintenanceOccurrence
+		want bool
+	}{
+		{"disjoint", []MaintenanceOccurrence{occ(day(2025, 1, 1), day(2025, 1, 2))}, []MaintenanceOccurrence{occ(day(2025, 1, 3), day(2025, 1, 4))}, false},
+		{"overlapping", []MaintenanceOccurrence{occ(day(2025, 1, 1), day(2025, 1, 3))}, []MaintenanceOccurrence{occ(day(2025, 1, 2), day(2025, 1, 4))}, true},
+		{"touching", []MaintenanceOccurrence{occ(day(2025, 1, 1), day(2025, 1, 2))}, []MaintenanceOccurrence{occ(day(2025, 1, 2), day(2025, 1, 3))}, true},
+		{"empty", nil, []MaintenanceOccurrence{occ(day(2025, 1, 2), day(2025, 1, 3))}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OccurrencesOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,8 +7,48 @@ type MaintenanceSchedules struct {
 	AssetId   int64
 	StartDate time.Time
 	EndDate   time.Time
+	VendorId  *int64 `gorm:"index"` // đơn vị bảo trì / sửa chữa
 
-	Asset Assets `gorm:"foreignKey:AssetId;references:Id"`
+	// Lặp lại kiểu RRULE, Frequency = nil là lịch 1 lần
+	Frequency      *string    // daily, weekly, monthly, yearly
+	RepeatInterval int        `gorm:"not null;default:1"`
+	ByWeekdays     string     // MO,TU,WE,... chỉ dùng cho weekly
+	RepeatUntil    *time.Time // lần lặp cuối phải bắt đầu trước thời điểm này
+	RepeatCount    *int       // số lần lặp tối đa
+	ParentId       *int64     // series gốc khi tách bằng "this and following"
+
+	Asset      Assets                          `gorm:"foreignKey:AssetId;references:Id"`
+	Exceptions []MaintenanceScheduleExceptions `gorm:"foreignKey:ScheduleId;references:Id"`
+	Jobs       []MaintenanceJobs               `gorm:"foreignKey:ScheduleId;references:Id"`
+}
+
+// MaintenanceScheduleExceptions ghi đè hoặc huỷ 1 lần lặp ("this occurrence only")
+type MaintenanceScheduleExceptions struct {
+	Id              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleId      int64     `gorm:"uniqueIndex:idx_schedule_occurrence"`
+	OccurrenceStart time.Time `gorm:"uniqueIndex:idx_schedule_occurrence"` // thời điểm bắt đầu gốc theo rule
+	Cancelled       bool      `gorm:"not null;default:false"`
+	StartDate       *time.Time
+	EndDate         *time.Time
+}
+
+// MaintenanceJobs ghi thời điểm thực tế giao tài sản cho đơn vị bảo trì và nhận lại của 1 lần bảo trì
+type MaintenanceJobs struct {
+	Id              int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleId      int64      `gorm:"uniqueIndex:idx_maintenance_job_occurrence" json:"scheduleId"`
+	OccurrenceStart time.Time  `gorm:"uniqueIndex:idx_maintenance_job_occurrence" json:"occurrenceStart"` // thời điểm bắt đầu gốc theo rule
+	HandedOverAt    *time.Time `json:"handedOverAt"`
+	ReturnedAt      *time.Time `json:"returnedAt"`
+	RecordedById    int64      `json:"recordedById"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// TurnaroundHours là thời gian từ lúc giao tới lúc nhận lại, false khi chưa có đủ 2 mốc
+func (j *MaintenanceJobs) TurnaroundHours() (float64, bool) {
+	if j.HandedOverAt == nil || j.ReturnedAt == nil {
+		return 0, false
+	}
+	return j.ReturnedAt.Sub(*j.HandedOverAt).Hours(), true
 }
 
 type TimeRange struct {
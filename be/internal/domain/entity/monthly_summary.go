@@ -3,13 +3,31 @@ package entity
 import "time"
 
 type MonthlySummary struct {
-	Id                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	Month               int64     `json:"month"`
-	Year                int64     `json:"year"`
-	TotalAmount         float64   `json:"totalAmount"`
-	BillCount           int64     `json:"billCount"`
-	AssetCount          int64     `json:"assetCount"`
-	TotalCategoryAmount string    `json:"totalCategoryAmount"`
-	GeneratedAt         time.Time `json:"generatedAt"`
-	CompanyId           int64     `json:"-"`
+	Id                  int64              `gorm:"primaryKey;autoIncrement" json:"id"`
+	Month               int64              `gorm:"uniqueIndex:idx_monthly_summary_company_period" json:"month"`
+	Year                int64              `gorm:"uniqueIndex:idx_monthly_summary_company_period" json:"year"`
+	Totals              []CurrencyTotal    `gorm:"serializer:json;type:jsonb" json:"totals"` // tổng tiền theo từng loại tiền tệ, không cộng lẫn các loại tiền
+	BillCount           int64              `json:"billCount"`
+	AssetCount          int64              `json:"assetCount"`
+	CategoryBreakdown   []SummaryBreakdown `gorm:"serializer:json;type:jsonb" json:"categoryBreakdown"`
+	DepartmentBreakdown []SummaryBreakdown `gorm:"serializer:json;type:jsonb" json:"departmentBreakdown"`
+	LocationBreakdown   []SummaryBreakdown `gorm:"serializer:json;type:jsonb" json:"locationBreakdown"`
+	GeneratedAt         time.Time          `json:"generatedAt"`
+	CompanyId           int64              `gorm:"uniqueIndex:idx_monthly_summary_company_period" json:"-"`
+}
+
+// SummaryBreakdown là tổng tiền theo 1 nhóm (category, department hoặc location) và 1 loại tiền tệ, lưu dạng JSON trong MonthlySummary
+type SummaryBreakdown struct {
+	Id         int64   `json:"id"`
+	Name       string  `json:"name"`
+	Currency   string  `json:"currency"`
+	Amount     float64 `json:"amount"`
+	AssetCount int64   `json:"assetCount"` // số tài sản khác nhau, 1 tài sản có nhiều dòng chỉ tính 1 lần
+}
+
+// CurrencyTotal là tổng tiền và số bill của 1 loại tiền tệ trong tháng
+type CurrencyTotal struct {
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	BillCount int64   `json:"billCount"`
 }
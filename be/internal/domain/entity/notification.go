@@ -2,6 +2,32 @@ package entity
 
 import "time"
 
+// Loại thông báo, lưu ở Notifications.Type và dùng làm event của SSE
+const (
+	NotificationEventAssetUpdated         = "asset.updated"
+	NotificationEventAssetRetired         = "asset.retired"
+	NotificationEventAssetDisposed        = "asset.disposed"
+	NotificationEventAssetAssigned        = "asset.assigned"
+	NotificationEventMaintenanceScheduled = "maintenance.scheduled"
+	NotificationEventMaintenanceStarted   = "maintenance.started"
+	NotificationEventMaintenanceFinished  = "maintenance.finished"
+	NotificationEventWarrantyExpired      = "warranty.expired"
+	NotificationEventLoanCheckedOut       = "loan.checked_out"
+	NotificationEventLoanCheckedIn        = "loan.checked_in"
+	NotificationEventLoanOverdue          = "loan.overdue"
+	NotificationEventTransferRequested    = "transfer.requested"
+	NotificationEventTransferUpdated      = "transfer.updated"
+	NotificationEventBillOverdue          = "bill.overdue"
+	NotificationEventDigest               = "notification.digest" // bản tổng hợp hằng ngày các thông báo user chọn nhận dạng digest
+	NotificationEventResync               = "notification.resync" // SSE báo client tải lại hộp thư khi số thông báo bị lỡ vượt giới hạn replay
+)
+
+// Trạng thái đọc của thông báo, lưu ở Notifications.Status
+const (
+	NotificationStatusUnread = "pending"
+	NotificationStatusSeen   = "seen"
+)
+
 type Notifications struct {
 	Id         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Content    *string    `json:"content"`
@@ -10,6 +36,9 @@ type Notifications struct {
 	Type       *string    `json:"type"`
 	AssetId    *int64     `json:"assetId"`
 	NotifyDate *time.Time `json:"notifyDate"`
-	User       Users      `gorm:"foreignKey:UserId;references:Id"`
-	Asset      Assets     `gorm:"foreignKey:AssetId;references:Id"`
+	ArchivedAt *time.Time `json:"archivedAt"` // đã lưu trữ thì không còn hiện trong hộp thư mặc định
+	// Message outbox đã tạo thông báo này, outbox gửi lại cũng không tạo trùng
+	OutboxMessageId *int64 `gorm:"uniqueIndex" json:"-"`
+	User            Users  `gorm:"foreignKey:UserId;references:Id"`
+	Asset           Assets `gorm:"foreignKey:AssetId;references:Id"`
 }
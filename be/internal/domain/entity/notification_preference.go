@@ -0,0 +1,150 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// Nhóm sự kiện mà user chọn nhận hay không
+const (
+	NotificationCategoryMaintenance = "maintenance"
+	NotificationCategoryWarranty    = "warranty"
+	NotificationCategoryAssignment  = "assignment"
+	NotificationCategoryTransfer    = "transfer"
+	NotificationCategoryAssetChange = "asset_change"
+	NotificationCategoryBilling     = "billing"
+)
+
+var NotificationCategories = []string{
+	NotificationCategoryMaintenance,
+	NotificationCategoryWarranty,
+	NotificationCategoryAssignment,
+	NotificationCategoryTransfer,
+	NotificationCategoryAssetChange,
+	NotificationCategoryBilling,
+}
+
+const (
+	NotificationChannelInApp   = "in_app"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+)
+
+var NotificationChannels = []string{NotificationChannelInApp, NotificationChannelEmail, NotificationChannelWebhook}
+
+const (
+	NotificationDeliveryImmediate = "immediate"
+	NotificationDeliveryDigest    = "digest"
+	NotificationDeliveryOff       = "off"
+)
+
+var NotificationDeliveries = []string{NotificationDeliveryImmediate, NotificationDeliveryDigest, NotificationDeliveryOff}
+
+const DefaultNotificationTimezone = "Asia/Ho_Chi_Minh"
+
+// NotificationCategoryOf suy ra nhóm của 1 loại thông báo (NotificationEvent...)
+func NotificationCategoryOf(event string) string {
+	switch {
+	case strings.HasPrefix(event, "maintenance."):
+		return NotificationCategoryMaintenance
+	case strings.HasPrefix(event, "warranty."):
+		return NotificationCategoryWarranty
+	case strings.HasPrefix(event, "loan."), event == NotificationEventAssetAssigned:
+		return NotificationCategoryAssignment
+	case strings.HasPrefix(event, "transfer."):
+		return NotificationCategoryTransfer
+	case strings.HasPrefix(event, "bill."):
+		return NotificationCategoryBilling
+	}
+	return NotificationCategoryAssetChange
+}
+
+// DefaultNotificationDelivery giữ hành vi cũ khi user chưa cấu hình: in-app mọi sự kiện, email cho các nhắc nhở của cron, webhook tắt
+func DefaultNotificationDelivery(category, channel string) string {
+	switch channel {
+	case NotificationChannelInApp:
+		return NotificationDeliveryImmediate
+	case NotificationChannelEmail:
+		if category == NotificationCategoryMaintenance || category == NotificationCategoryWarranty || category == NotificationCategoryAssignment || category == NotificationCategoryBilling {
+			return NotificationDeliveryImmediate
+		}
+	}
+	return NotificationDeliveryOff
+}
+
+// NotificationPreferences là 1 ô của ma trận nhóm sự kiện x kênh, ô không có bản ghi dùng DefaultNotificationDelivery
+type NotificationPreferences struct {
+	Id        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId    int64     `gorm:"not null;uniqueIndex:idx_notification_preference" json:"-"`
+	Category  string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_notification_preference" json:"category"`
+	Channel   string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_notification_preference" json:"channel"`
+	Delivery  string    `gorm:"type:varchar(16);not null" json:"delivery"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserNotificationSettings: múi giờ, giờ yên lặng (HH:MM theo giờ địa phương), giờ gửi digest và webhook cá nhân
+type UserNotificationSettings struct {
+	UserId          int64     `gorm:"primaryKey" json:"-"`
+	Timezone        string    `gorm:"type:varchar(64);not null" json:"timezone"`
+	QuietHoursStart *string   `gorm:"type:varchar(5)" json:"quietHoursStart"`
+	QuietHoursEnd   *string   `gorm:"type:varchar(5)" json:"quietHoursEnd"`
+	DigestHour      int       `gorm:"not null;default:8" json:"digestHour"`
+	WebhookUrl      *string   `gorm:"type:text" json:"webhookUrl"`
+	WebhookSecret   string    `gorm:"type:varchar(100)" json:"-"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// Location trả về múi giờ của user, sai tên thì dùng múi giờ mặc định
+func (s *UserNotificationSettings) Location() *time.Location {
+	if loc, err := time.LoadLocation(s.Timezone); err == nil {
+		return loc
+	}
+	loc, _ := time.LoadLocation(DefaultNotificationTimezone)
+	return loc
+}
+
+// QuietUntil trả về thời điểm kết thúc giờ yên lặng nếu now đang nằm trong khoảng đó, khoảng có thể qua nửa đêm (22:00-07:00)
+func (s *UserNotificationSettings) QuietUntil(now time.Time) (time.Time, bool) {
+	if s.QuietHoursStart == nil || s.QuietHoursEnd == nil {
+		return time.Time{}, false
+	}
+	start, err1 := time.Parse("15:04", *s.QuietHoursStart)
+	end, err2 := time.Parse("15:04", *s.QuietHoursEnd)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+	local := now.In(s.Location())
+	minutes := local.Hour()*60 + local.Minute()
+	startMin := start.Hour()*60 + start.Minute()
+	endMin := end.Hour()*60 + end.Minute()
+	if startMin == endMin {
+		return time.Time{}, false
+	}
+	var inQuiet bool
+	if startMin < endMin {
+		inQuiet = minutes >= startMin && minutes < endMin
+	} else {
+		inQuiet = minutes >= startMin || minutes < endMin
+	}
+	if !inQuiet {
+		return time.Time{}, false
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, local.Location())
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// NotificationDigestItems là thông báo chờ gom vào digest hằng ngày của 1 kênh
+type NotificationDigestItems struct {
+	Id         int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId     int64      `gorm:"not null;index:idx_digest_pending" json:"userId"`
+	CompanyId  *int64     `json:"companyId"`
+	Channel    string     `gorm:"type:varchar(16);not null" json:"channel"`
+	Event      string     `gorm:"type:varchar(64);not null" json:"event"`
+	Message    string     `gorm:"type:text;not null" json:"message"`
+	AssetId    *int64     `json:"assetId"`
+	CreatedAt  time.Time  `gorm:"not null" json:"createdAt"`
+	DigestedAt *time.Time `gorm:"index:idx_digest_pending" json:"digestedAt"`
+}
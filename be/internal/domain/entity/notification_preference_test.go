@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func quietSettings(timezone string, start, end *string) *UserNotificationSettings {
+	return &UserNotificationSettings{UserId: 1, Timezone: timezone, QuietHoursStart: start, QuietHoursEnd: end}
+}
+
+func hhmm(s string) *string {
+	return &s
+}
+
+func TestQuietUntil(t *testing.T) {
+	hcm, err := time.LoadLocation(DefaultNotificationTimezone)
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	tests := []struct {
+		name      string
+		settings  *UserNotificationSettings
+		now       time.Time
+		wantQuiet bool
+		wantUntil time.Time
+	}{
+		{"not configured", quietSettings("UTC", nil, nil), time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"only start", quietSettings("UTC", hhmm("22:00"), nil), time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"invalid format", quietSettings("UTC", hhmm("10pm"), hhmm("07:00")), time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"start equals end", quietSettings("UTC", hhmm("07:00"), hhmm("07:00")), time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"same day inside", quietSettings("UTC", hhmm("12:00"), hhmm("13:30")), time.Date(2026, 3, 1, 12, 45, 0, 0, time.UTC), true, time.Date(2026, 3, 1, 13, 30, 0, 0, time.UTC)},
+		{"same day at start", quietSettings("UTC", hhmm("12:00"), hhmm("13:30")), time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC), true, time.Date(2026, 3, 1, 13, 30, 0, 0, time.UTC)},
+		{"same day at end", quietSettings("UTC", hhmm("12:00"), hhmm("13:30")), time.Date(2026, 3, 1, 13, 30, 0, 0, time.UTC), false, time.Time{}},
+		{"same day outside", quietSettings("UTC", hhmm("12:00"), hhmm("13:30")), time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), false, time.Time{}},
+		// khoảng qua nửa đêm: trước 0h thì kết thúc vào sáng hôm sau
+		{"overnight before midnight", quietSettings("UTC", hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 1, 23, 15, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)},
+		{"overnight after midnight", quietSettings("UTC", hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)},
+		{"overnight outside", quietSettings("UTC", hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"overnight month end", quietSettings("UTC", hhmm("22:00"), hhmm("07:00")), time.Date(2026, 2, 28, 22, 30, 0, 0, time.UTC), true, time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC)},
+		// 16:00 UTC là 23:00 ở Hồ Chí Minh
+		{"user timezone", quietSettings(DefaultNotificationTimezone, hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 1, 16, 0, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 7, 0, 0, 0, hcm)},
+		{"user timezone outside", quietSettings(DefaultNotificationTimezone, hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 1, 5, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"invalid timezone uses default", quietSettings("Mars/Base", hhmm("22:00"), hhmm("07:00")), time.Date(2026, 3, 1, 16, 0, 0, 0, time.UTC), true, time.Date(2026, 3, 2, 7, 0, 0, 0, hcm)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := tt.settings.QuietUntil(tt.now)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if !until.Equal(tt.wantUntil) {
+				t.Errorf("until = %v, want %v", until, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestNotificationCategoryOf(t *testing.T) {
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{"maintenance.due", NotificationCategoryMaintenance},
+		{"warranty.expiring", NotificationCategoryWarranty},
+		{"loan.overdue", NotificationCategoryAssignment},
+		{NotificationEventAssetAssigned, NotificationCategoryAssignment},
+		{"transfer.approved", NotificationCategoryTransfer},
+		{"bill.paid", NotificationCategoryBilling},
+		{"asset.updated", NotificationCategoryAssetChange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			if got := NotificationCategoryOf(tt.event); got != tt.want {
+				t.Errorf("NotificationCategoryOf(%q) = %q, want %q", tt.event, got, tt.want)
+			}
+		})
+	}
+}
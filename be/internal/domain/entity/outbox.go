@@ -0,0 +1,33 @@
+package entity
+
+import "time"
+
+const (
+	OutboxChannelNotification = "notification" // lưu Notifications và đẩy SSE
+	OutboxChannelEmail        = "email"
+	OutboxChannelWebhook      = "webhook"      // fan-out sự kiện ra các WebhookDeliveries
+	OutboxChannelUserWebhook  = "user_webhook" // webhook cá nhân trong cài đặt thông báo của user
+)
+
+var OutboxChannels = []string{OutboxChannelNotification, OutboxChannelEmail, OutboxChannelWebhook, OutboxChannelUserWebhook}
+
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead" // hết số lần retry, chờ admin replay
+)
+
+// OutboxMessages được ghi cùng transaction với thay đổi nghiệp vụ, dispatcher đọc và gửi sau khi commit
+type OutboxMessages struct {
+	Id            int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CompanyId     *int64     `gorm:"index" json:"companyId"`
+	Channel       string     `gorm:"type:varchar(32);not null" json:"channel"`
+	Payload       string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status        string     `gorm:"type:varchar(16);not null;index:idx_outbox_due" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt *time.Time `gorm:"index:idx_outbox_due" json:"nextAttemptAt"`
+	LastError     string     `gorm:"type:text" json:"lastError"`
+	ReplayCount   int        `gorm:"not null;default:0" json:"replayCount"`
+	CreatedAt     time.Time  `gorm:"not null" json:"createdAt"`
+	ProcessedAt   *time.Time `json:"processedAt"`
+}
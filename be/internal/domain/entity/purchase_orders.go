@@ -0,0 +1,80 @@
+package entity
+
+import "time"
+
+// Trạng thái đơn mua hàng: Pending chờ duyệt, đơn đã duyệt chuyển sang Partially Received / Received theo số lượng đã nhập kho
+const (
+	PurchaseOrderStatusPending           = "Pending"
+	PurchaseOrderStatusApproved          = "Approved"
+	PurchaseOrderStatusRejected          = "Rejected"
+	PurchaseOrderStatusPartiallyReceived = "Partially Received"
+	PurchaseOrderStatusReceived          = "Received"
+	PurchaseOrderStatusCancelled         = "Cancelled"
+)
+
+// PurchaseOrders là đơn mua hàng của 1 phòng ban, phòng ban này nhận tài sản khi nhập kho và chịu ngân sách của đơn
+type PurchaseOrders struct {
+	Id             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrderNumber    string     `gorm:"uniqueIndex" json:"orderNumber"`
+	CompanyId      int64      `gorm:"index" json:"-"`
+	DepartmentId   int64      `gorm:"index" json:"departmentId"`
+	VendorId       *int64     `gorm:"index" json:"vendorId"`
+	Status         string     `gorm:"index;not null" json:"status"`
+	Description    string     `json:"description"`
+	ExpectedDate   *time.Time `json:"expectedDate"` // ngày dự kiến giao hàng
+	EstimatedTotal float64    `gorm:"not null;default:0" json:"estimatedTotal"`
+	RequestedById  int64      `json:"requestedById"`
+	DecidedById    *int64     `json:"decidedById"` // người duyệt hoặc từ chối
+	DecidedAt      *time.Time `json:"decidedAt"`
+	DecisionNote   string     `json:"decisionNote"`
+	CancelledAt    *time.Time `json:"cancelledAt"` // phần chưa nhận hết cam kết từ thời điểm này
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	Department  Departments          `gorm:"foreignKey:DepartmentId;references:Id" json:"department"`
+	Vendor      *Vendors             `gorm:"foreignKey:VendorId;references:Id" json:"vendor,omitempty"`
+	RequestedBy Users                `gorm:"foreignKey:RequestedById;references:Id" json:"-"`
+	Items       []PurchaseOrderItems `gorm:"foreignKey:PurchaseOrderId;references:Id" json:"items"`
+	Receipts    []GoodsReceipts      `gorm:"foreignKey:PurchaseOrderId;references:Id" json:"receipts"`
+}
+
+// PurchaseOrderItems là 1 dòng hàng cần mua theo category, mỗi đơn vị nhận về thành 1 tài sản
+type PurchaseOrderItems struct {
+	Id                int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchaseOrderId   int64   `gorm:"index;not null" json:"purchaseOrderId"`
+	CategoryId        int64   `json:"categoryId"`
+	Description       string  `gorm:"not null" json:"description"` // tên tài sản khi nhập kho
+	Quantity          int     `gorm:"not null" json:"quantity"`
+	ReceivedQuantity  int     `gorm:"not null;default:0" json:"receivedQuantity"`
+	EstimatedUnitCost float64 `gorm:"not null" json:"estimatedUnitCost"`
+	WarrantyMonths    int     `gorm:"not null;default:0" json:"warrantyMonths"` // hạn bảo hành tính từ ngày nhận
+
+	Category Categories `gorm:"foreignKey:CategoryId;references:Id" json:"category"`
+}
+
+// Remaining là số lượng còn chờ nhận
+func (i *PurchaseOrderItems) Remaining() int {
+	return max(i.Quantity-i.ReceivedQuantity, 0)
+}
+
+// GoodsReceipts là 1 lần nhập kho của đơn mua hàng, có thể nhận 1 phần số lượng
+type GoodsReceipts struct {
+	Id              int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchaseOrderId int64     `gorm:"index;not null" json:"purchaseOrderId"`
+	ReceivedById    int64     `json:"receivedById"`
+	ReceivedAt      time.Time `json:"receivedAt"`
+	Note            string    `json:"note"`
+
+	Lines []GoodsReceiptLines `gorm:"foreignKey:GoodsReceiptId;references:Id" json:"lines"`
+}
+
+// GoodsReceiptLines là số lượng nhận của 1 dòng hàng trong lần nhập kho, UnitCost là giá thực tế (tính vào đã chi)
+type GoodsReceiptLines struct {
+	Id                  int64   `gorm:"primaryKey;autoIncrement" json:"id"`
+	GoodsReceiptId      int64   `gorm:"index;not null" json:"goodsReceiptId"`
+	PurchaseOrderItemId int64   `gorm:"index;not null" json:"purchaseOrderItemId"`
+	Quantity            int     `gorm:"not null" json:"quantity"`
+	UnitCost            float64 `gorm:"not null" json:"unitCost"`
+
+	Assets []Assets `gorm:"foreignKey:GoodsReceiptLineId;references:Id" json:"assets"`
+}
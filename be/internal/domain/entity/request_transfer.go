@@ -1,13 +1,33 @@
 package entity
 
+import "time"
+
 type RequestTransfer struct {
-	Id          int64  `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserId      int64  `json:"userId"`
-	CategoryId  int64  `json:"categoryId"`
-	Status      string `json:"status"`
-	Description string `json:"description"`
-	CompanyId   int64  `json:"-"`
-
-	User     Users      `gorm:"foreignKey:UserId;references:Id"`
-	Category Categories `gorm:"foreignKey:CategoryId;references:Id"`
+	Id          int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId      int64     `json:"userId"`
+	CategoryId  int64     `json:"categoryId"`
+	AssetId     *int64    `json:"assetId"` // tài sản được chọn trong quá trình duyệt
+	Status      string    `json:"status"`  // Pending, Confirm, Deny, Cancelled
+	Description string    `json:"description"`
+	CurrentStep int       `gorm:"not null;default:0" json:"currentStep"`
+	CreatedAt   time.Time `gorm:"default:now()" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"default:now()" json:"updatedAt"`
+	CompanyId   int64     `json:"-"`
+
+	User      Users                      `gorm:"foreignKey:UserId;references:Id"`
+	Category  Categories                 `gorm:"foreignKey:CategoryId;references:Id"`
+	Asset     *Assets                    `gorm:"foreignKey:AssetId;references:Id"`
+	Approvals []RequestTransferApprovals `gorm:"foreignKey:RequestTransferId;references:Id"`
+	Logs      []RequestTransferLogs      `gorm:"foreignKey:RequestTransferId;references:Id"`
+}
+
+// RequestTransferLogs là lịch sử thay đổi trạng thái của request, ghi cả khi request chưa chọn tài sản
+type RequestTransferLogs struct {
+	Id                int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	RequestTransferId int64     `gorm:"index;not null" json:"requestTransferId"`
+	Action            string    `json:"action"`
+	ByUserId          int64     `json:"byUserId"`
+	ChangeSummary     string    `json:"changeSummary"`
+	Timestamp         time.Time `json:"timeStamp"`
+	CompanyId         int64     `json:"-"`
 }
@@ -5,7 +5,8 @@ import "time"
 type Roles struct {
 	Id          int64      `gorm:"primaryKey;autoIncrement" json:"id"`
 	Title       string     `gorm:"type:VARCHAR(250)" json:"title"`
-	Slug        string     `gorm:"type:VARCHAR(250);index:unique_slug,unique" json:"slug"`
+	Slug        string     `gorm:"type:VARCHAR(250);index:idx_roles_company_slug,unique" json:"slug"`
+	CompanyId   *int64     `gorm:"index:idx_roles_company_slug,unique" json:"companyId"` // nil: role hệ thống dùng chung
 	Description string     `json:"description"`
 	Activated   bool       `gorm:"default:true" json:"activated"`
 	Created_at  time.Time  `gorm:"NOT NULL" json:"createdAt"`
@@ -14,5 +15,9 @@ type Roles struct {
 
 	Users           []Users          `gorm:"foreignKey:RoleId;references:Id"`
 	RolePermissions []RolePermission `gorm:"foreignKey:RoleId;references:Id"`
-	UserRbacs       []UserRbac       `gorm:"foreignKey:RoleId;references:Id"`
+}
+
+// IsSystem role seed sẵn (admin, assetManager, employee), không sửa/xoá qua API
+func (r *Roles) IsSystem() bool {
+	return r.CompanyId == nil
 }
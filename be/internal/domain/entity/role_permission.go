@@ -11,3 +11,24 @@ type RolePermission struct {
 
 	Permission Permission `gorm:"foreignKey:PermissionId;references:Id"`
 }
+
+const (
+	AccessLevelFull        = "full"
+	AccessLevelScoped      = "scoped"
+	AccessLevelLimited     = "limited"
+	AccessLevelConditional = "conditional"
+	AccessLevelPartial     = "partial"
+	AccessLevelView        = "view"
+	AccessLevelScan        = "scan"
+	AccessLevelAction      = "action"
+	AccessLevelCanRequest  = "can-request"
+)
+
+func ValidAccessLevel(level string) bool {
+	switch level {
+	case AccessLevelFull, AccessLevelScoped, AccessLevelLimited, AccessLevelConditional, AccessLevelPartial,
+		AccessLevelView, AccessLevelScan, AccessLevelAction, AccessLevelCanRequest:
+		return true
+	}
+	return false
+}
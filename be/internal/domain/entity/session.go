@@ -2,12 +2,26 @@ package entity
 
 import "time"
 
+// Mỗi UsersSessions là 1 thiết bị đăng nhập (token family), refresh token được rotate tại chỗ
 type UsersSessions struct {
-	Id           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserId       int64     `gorm:"not null;index:idx_userid_revoked"`
-	RefreshToken string    `gorm:"type:text"`
-	AccessToken  string    `gorm:"type:text"`
-	CreatedAt    time.Time `gorm:"not null" json:"createdAt"`
-	ExpiresAt    time.Time `gorm:"not null" json:"expiresAt"`
-	IsRevoked    bool      `gorm:"default:false;index:idx_userid_revoked" json:"isRevoked"`
+	Id           int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId       int64      `gorm:"not null;index:idx_userid_revoked"`
+	RefreshToken string     `gorm:"type:text;index"`
+	AccessToken  string     `gorm:"type:text;index"`
+	DeviceLabel  string     `gorm:"type:varchar(255)" json:"deviceLabel"`
+	UserAgent    string     `gorm:"type:text" json:"userAgent"`
+	IpAddress    string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	LastSeenAt   *time.Time `json:"lastSeenAt"`
+	CreatedAt    time.Time  `gorm:"not null" json:"createdAt"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expiresAt"`
+	IsRevoked    bool       `gorm:"default:false;index:idx_userid_revoked" json:"isRevoked"`
+	RevokedAt    *time.Time `json:"revokedAt"`
+}
+
+// UsedRefreshTokens lưu hash các refresh token đã bị rotate, gặp lại 1 token trong đây nghĩa là token bị dùng lại
+type UsedRefreshTokens struct {
+	Id        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	SessionId int64     `gorm:"not null;index" json:"sessionId"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UsedAt    time.Time `gorm:"not null" json:"usedAt"`
 }
@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+const (
+	ApproverRequesterDepartmentHead = "requester_department_head"
+	ApproverSourceDepartmentManager = "source_department_manager"
+	ApproverAdmin                   = "admin"
+)
+
+const (
+	ApprovalDecisionPending  = "Pending"
+	ApprovalDecisionApproved = "Approved"
+	ApprovalDecisionDenied   = "Denied"
+	ApprovalDecisionSkipped  = "Skipped"
+)
+
+// TransferApprovalSteps là cấu hình chuỗi duyệt request transfer của 1 công ty
+type TransferApprovalSteps struct {
+	Id           int64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	CompanyId    int64    `gorm:"uniqueIndex:idx_transfer_step_company_order" json:"-"`
+	StepOrder    int      `gorm:"uniqueIndex:idx_transfer_step_company_order" json:"stepOrder"`
+	ApproverType string   `json:"approverType"`
+	MinAssetCost *float64 `json:"minAssetCost"` // chỉ áp dụng khi giá tài sản >= MinAssetCost
+}
+
+// RequestTransferApprovals là 1 bước duyệt của request, được copy từ cấu hình công ty lúc tạo request
+type RequestTransferApprovals struct {
+	Id                int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RequestTransferId int64      `gorm:"index" json:"requestTransferId"`
+	StepOrder         int        `json:"stepOrder"`
+	ApproverType      string     `json:"approverType"`
+	MinAssetCost      *float64   `json:"minAssetCost"`
+	ApproverId        *int64     `json:"approverId"`
+	Decision          string     `json:"decision"`
+	Comment           string     `json:"comment"`
+	DecidedAt         *time.Time `json:"decidedAt"`
+
+	Approver *Users `gorm:"foreignKey:ApproverId;references:Id"`
+}
+
+func ValidApproverType(approverType string) bool {
+	switch approverType {
+	case ApproverRequesterDepartmentHead, ApproverSourceDepartmentManager, ApproverAdmin:
+		return true
+	}
+	return false
+}
+
+// AppliesTo: bước có ngưỡng giá chỉ áp dụng khi đã biết tài sản và giá >= ngưỡng
+func (step RequestTransferApprovals) AppliesTo(asset *Assets) bool {
+	if step.MinAssetCost == nil || asset == nil {
+		return true
+	}
+	return asset.Cost >= *step.MinAssetCost
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// UserMfa lưu secret TOTP (đã mã hoá) của user, Enabled = false khi mới enroll chưa xác nhận
+type UserMfa struct {
+	Id             int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId         int64      `gorm:"uniqueIndex" json:"userId"`
+	Secret         string     `gorm:"type:text;not null" json:"-"`
+	Enabled        bool       `gorm:"not null;default:false" json:"enabled"`
+	ConfirmedAt    *time.Time `json:"confirmedAt"`
+	LastUsedStep   int64      `json:"-"` // chặn dùng lại cùng 1 mã TOTP
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+type UserMfaRecoveryCodes struct {
+	Id       int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserId   int64      `gorm:"index" json:"userId"`
+	CodeHash string     `gorm:"type:varchar(256);not null" json:"-"`
+	UsedAt   *time.Time `json:"usedAt"`
+}
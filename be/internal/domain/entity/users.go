@@ -14,6 +14,7 @@ type Users struct {
 	CompanyId      int64       `json:"-"`
 	CanExport      bool        `gorm:"not null;default:false" json:"canExport"`
 	Avatar         string      `json:"Avatar"`
+	Language       string      `gorm:"type:varchar(5);not null;default:'en'" json:"language"` // ngôn ngữ email: en | vi
 	Role           Roles       `gorm:"foreignKey:RoleId;references:Id"`
 	Department     Departments `gorm:"DepartmentId:RoleId;references:Id"`
 }
@@ -0,0 +1,32 @@
+package entity
+
+import "time"
+
+// Vendors là nhà cung cấp / đơn vị sửa chữa của công ty, được gắn vào tài sản (nơi mua), bill (đối tác) và lịch bảo trì (đơn vị bảo trì)
+type Vendors struct {
+	Id        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CompanyId int64     `gorm:"index;uniqueIndex:idx_vendors_company_tax_id,where:tax_id <> ''" json:"-"`
+	Name      string    `gorm:"not null" json:"name"`
+	TaxId     string    `gorm:"index;uniqueIndex:idx_vendors_company_tax_id,where:tax_id <> ''" json:"taxId"` // mã số thuế, không trùng trong 1 công ty
+	Address   string    `json:"address"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	Website   string    `json:"website"`
+	Notes     string    `json:"notes"`
+	CreatedBy int64     `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Contacts []VendorContacts `gorm:"foreignKey:VendorId;references:Id" json:"contacts"`
+}
+
+// VendorContacts là người liên hệ của vendor, tối đa 1 người là liên hệ chính
+type VendorContacts struct {
+	Id        int64  `gorm:"primaryKey;autoIncrement" json:"id"`
+	VendorId  int64  `gorm:"index" json:"vendorId"`
+	Name      string `gorm:"not null" json:"name"`
+	Position  string `json:"position"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	IsPrimary bool   `gorm:"not null;default:false" json:"isPrimary"`
+}
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"time"
+)
+
+const (
+	WebhookEventAssetCreated            = "asset.created"
+	WebhookEventAssetUpdated            = "asset.updated"
+	WebhookEventAssetRetired            = "asset.retired"
+	WebhookEventAssetDisposed           = "asset.disposed"
+	WebhookEventAssetAssigned           = "asset.assigned"
+	WebhookEventAssetTransferred        = "asset.transferred"
+	WebhookEventAssetMaintenanceStarted = "asset.maintenance_started"
+)
+
+var WebhookEvents = []string{
+	WebhookEventAssetCreated,
+	WebhookEventAssetUpdated,
+	WebhookEventAssetRetired,
+	WebhookEventAssetDisposed,
+	WebhookEventAssetAssigned,
+	WebhookEventAssetTransferred,
+	WebhookEventAssetMaintenanceStarted,
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // hết số lần retry
+)
+
+// Webhooks là 1 subscription của công ty, Events rỗng nghĩa là nhận mọi sự kiện
+type Webhooks struct {
+	Id        int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	CompanyId int64     `gorm:"not null;index" json:"-"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Url       string    `gorm:"type:text;not null" json:"url"`
+	Secret    string    `gorm:"type:varchar(100);not null" json:"-"`
+	Events    []string  `gorm:"serializer:json;type:jsonb" json:"events"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedBy int64     `json:"createdBy"`
+	CreatedAt time.Time `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (w *Webhooks) Subscribes(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveries là 1 lần gửi sự kiện tới 1 webhook, được retry tới khi thành công hoặc hết lượt
+type WebhookDeliveries struct {
+	Id            int64                      `gorm:"primaryKey;autoIncrement" json:"id"`
+	WebhookId     int64                      `gorm:"not null;index" json:"webhookId"`
+	CompanyId     int64                      `gorm:"not null" json:"-"`
+	EventId       string                     `gorm:"type:varchar(36);not null;index" json:"eventId"`
+	EventType     string                     `gorm:"type:varchar(64);not null" json:"eventType"`
+	Payload       string                     `gorm:"type:jsonb;not null" json:"payload"`
+	Status        string                     `gorm:"type:varchar(16);not null;index:idx_webhook_delivery_due" json:"status"`
+	Attempts      int                        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt *time.Time                 `gorm:"index:idx_webhook_delivery_due" json:"nextAttemptAt"`
+	ResponseCode  *int                       `json:"responseCode"`
+	LatencyMs     *int64                     `json:"latencyMs"`
+	LastError     string                     `gorm:"type:text" json:"lastError"`
+	RedeliveryOf  *int64                     `json:"redeliveryOf"`
+	CreatedAt     time.Time                  `gorm:"not null" json:"createdAt"`
+	DeliveredAt   *time.Time                 `json:"deliveredAt"`
+	Webhook       *Webhooks                  `gorm:"foreignKey:WebhookId;references:Id" json:"-"`
+	AttemptLogs   []*WebhookDeliveryAttempts `gorm:"foreignKey:DeliveryId;references:Id" json:"attemptLogs,omitempty"`
+}
+
+// WebhookDeliveryAttempts là log từng lần gọi: mã phản hồi và độ trễ
+type WebhookDeliveryAttempts struct {
+	Id           int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryId   int64     `gorm:"not null;index" json:"deliveryId"`
+	Attempt      int       `gorm:"not null" json:"attempt"`
+	ResponseCode *int      `json:"responseCode"`
+	LatencyMs    int64     `json:"latencyMs"`
+	Error        string    `gorm:"type:text" json:"error"`
+	AttemptedAt  time.Time `gorm:"not null" json:"attemptedAt"`
+}
@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"BE_Manage_device/internal/domain/policy"
 	"BE_Manage_device/pkg/utils"
 	"fmt"
 	"strings"
@@ -16,7 +17,6 @@ type AssetFilter struct {
 	SerialNumber *string `form:"serialNumber" json:"serialNumber"`
 	Email        *string `form:"email" json:"email"`
 	DepartmentId *string `form:"departmentId" json:"departmentId"`
-	CompanyId    int64
 }
 
 type AssetFilterDashboard struct {
@@ -26,14 +26,9 @@ type AssetFilterDashboard struct {
 	Export       *string `form:"export" json:"export"` // "csv" hoặc "pdf" hoặc ""
 }
 
-func (f *AssetFilter) ApplyFilter(db *gorm.DB, userId int64) *gorm.DB {
-	db.Where("assets.company_id  = ?", f.CompanyId)
-	db = db.Joins("JOIN user_rbacs on user_rbacs.asset_id = assets.id").
-		Joins("JOIN roles on roles.id = user_rbacs.role_id").
-		Joins("JOIN role_permissions on role_permissions.role_id = roles.id").
-		Joins("JOIN permissions on permissions.id = role_permissions.permission_id").
-		Joins("JOIN users on users.id = assets.owner").
-		Where("user_rbacs.user_id = ? and permissions.slug = ?", userId, "view-assets")
+func (f *AssetFilter) ApplyFilter(db *gorm.DB, auth *policy.AuthContext) *gorm.DB {
+	db = db.Scopes(auth.AssetScope(policy.PermissionViewAssets)).
+		Joins("JOIN users on users.id = assets.owner")
 	if f.Status != nil {
 		db = db.Where("status = ?", *f.Status)
 	}
@@ -75,12 +70,19 @@ func (f *AssetFilter) ApplyFilter(db *gorm.DB, userId int64) *gorm.DB {
 	return db.Preload("Category").Preload("Department").Preload("OnwerUser").Preload("Department.Location")
 }
 
-func (f *AssetFilterDashboard) ApplyFilterDashBoard(db *gorm.DB, userId int64) *gorm.DB {
+// ApplyFilterDashBoard lọc tài sản của công ty, level dashboards giới hạn phòng ban (scoped) thì bỏ qua departmentId của client
+func (f *AssetFilterDashboard) ApplyFilterDashBoard(db *gorm.DB, auth *policy.AuthContext) *gorm.DB {
+	db = db.Where("assets.company_id = ?", auth.CompanyId)
 	if f.CategoryId != nil {
 		parsedID, _ := utils.ParseInt64Ptr(f.CategoryId)
-		db = db.Where("categories.id = ?", parsedID)
+		db = db.Where("assets.category_id = ?", parsedID)
 	}
-	if f.DepartmentId != nil {
+	if departmentId, restricted := auth.DepartmentScope(policy.PermissionDashboards); restricted {
+		if departmentId == nil {
+			return db.Where("1 = 0")
+		}
+		db = db.Where("assets.department_id = ?", *departmentId)
+	} else if f.DepartmentId != nil {
 		parsedID, _ := utils.ParseInt64Ptr(f.DepartmentId)
 		db = db.Where("assets.department_id = ?", parsedID)
 	}
@@ -0,0 +1,37 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AssetLoanFilter struct {
+	Status        *string `form:"status" json:"status"`
+	Overdue       *bool   `form:"overdue" json:"overdue"`
+	AssetId       *int64  `form:"assetId" json:"assetId"`
+	BorrowerEmail *string `form:"borrowerEmail" json:"borrowerEmail"`
+	CompanyId     int64
+}
+
+func (f *AssetLoanFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	db = db.Where("asset_loans.company_id = ?", f.CompanyId).
+		Joins("join users as borrowers on borrowers.id = asset_loans.borrower_id")
+	if f.Status != nil {
+		db = db.Where("asset_loans.status = ?", *f.Status)
+	}
+	if f.Overdue != nil && *f.Overdue {
+		db = db.Where("asset_loans.status = ? AND asset_loans.due_date < ?", "Checked Out", time.Now())
+	}
+	if f.AssetId != nil {
+		db = db.Where("asset_loans.asset_id = ?", *f.AssetId)
+	}
+	if f.BorrowerEmail != nil {
+		str := fmt.Sprintf("%v", strings.ToLower(*f.BorrowerEmail))
+		str += "%"
+		db = db.Where("LOWER(borrowers.email) LIKE ?", str)
+	}
+	return db.Order("asset_loans.id DESC")
+}
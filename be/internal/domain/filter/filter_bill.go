@@ -31,5 +31,5 @@ func (f *BillFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
 		str += "%"
 		db = db.Where("LOWER(bills.bill_number) LIKE LOWER(?)", str)
 	}
-	return db.Select("DISTINCT ON (bills.id) bills.*").Preload("CreateBy").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").Order("bills.id, bill_number ASC")
+	return db.Select("DISTINCT ON (bills.id) bills.*").Preload("CreateBy").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").Preload("LineItems", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, id ASC") }).Preload("LineItems.Asset").Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("paid_at ASC, id ASC") }).Preload("Payments.RecordedBy").Order("bills.id, bill_number ASC")
 }
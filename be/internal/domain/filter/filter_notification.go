@@ -0,0 +1,38 @@
+package filter
+
+import (
+	"gorm.io/gorm"
+)
+
+// NotificationFilter lọc hộp thư của 1 user, phân trang theo cursor là id của thông báo cuối trang trước
+type NotificationFilter struct {
+	Type     *string `form:"type" json:"type"`
+	AssetId  *int64  `form:"assetId" json:"assetId"`
+	Status   *string `form:"status" json:"status"`
+	Archived *bool   `form:"archived" json:"archived"`
+	Cursor   *int64  `form:"cursor" json:"cursor"`
+	Limit    int     `form:"limit" json:"limit" binding:"omitempty,min=1,max=100"`
+	UserId   int64
+}
+
+func (f *NotificationFilter) ApplyFilter(db *gorm.DB) *gorm.DB {
+	db = db.Where("notifications.user_id = ?", f.UserId)
+	if f.Archived != nil && *f.Archived {
+		db = db.Where("notifications.archived_at IS NOT NULL")
+	} else {
+		db = db.Where("notifications.archived_at IS NULL")
+	}
+	if f.Type != nil {
+		db = db.Where("notifications.type = ?", *f.Type)
+	}
+	if f.AssetId != nil {
+		db = db.Where("notifications.asset_id = ?", *f.AssetId)
+	}
+	if f.Status != nil {
+		db = db.Where("notifications.status = ?", *f.Status)
+	}
+	if f.Cursor != nil {
+		db = db.Where("notifications.id < ?", *f.Cursor)
+	}
+	return db.Order("notifications.id DESC")
+}
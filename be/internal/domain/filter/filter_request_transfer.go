@@ -18,5 +18,6 @@ func (f *RequestTransferFilter) ApplyFilter(db *gorm.DB, userId int64) *gorm.DB
 	if f.Status != nil && *f.Status != "" {
 		db = db.Where("status = ?", *f.Status)
 	}
-	return db.Preload("User").Preload("User.Department").Preload("Category").Order("request_transfers.id ASC")
+	return db.Preload("User").Preload("User.Department").Preload("Category").Preload("Asset").
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB { return db.Order("step_order ASC") }).Preload("Approvals.Approver").Order("request_transfers.id ASC")
 }
@@ -69,17 +69,35 @@ func (_m *UserRepository) Create(user *entity.Users) error {
 	return r0
 }
 
-// DeleteUser provides a mock function with given fields: email
-func (_m *UserRepository) DeleteUser(email string) error {
-	ret := _m.Called(email)
+// DeleteUser provides a mock function with given fields: email, companyId, tx
+func (_m *UserRepository) DeleteUser(email string, companyId int64, tx *gorm.DB) error {
+	ret := _m.Called(email, companyId, tx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(email)
+	if rf, ok := ret.Get(0).(func(string, int64, *gorm.DB) error); ok {
+		r0 = rf(email, companyId, tx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateRole provides a mock function with given fields: userId, roleId, tx
+func (_m *UserRepository) UpdateRole(userId int64, roleId int64, tx *gorm.DB) error {
+	ret := _m.Called(userId, roleId, tx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int64, int64, *gorm.DB) error); ok {
+		r0 = rf(userId, roleId, tx)
 	} else {
 		r0 = ret.Error(0)
 	}
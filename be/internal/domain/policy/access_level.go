@@ -0,0 +1,108 @@
+package policy
+
+import "BE_Manage_device/internal/domain/entity"
+
+// Permission slug được seed sẵn, dùng khi service kiểm tra quyền trên từng tài nguyên
+const (
+	PermissionManageAssets    = "manage-assets"
+	PermissionViewAssets      = "view-assets"
+	PermissionAssignAssets    = "assign-assets"
+	PermissionTransferAssets  = "transfer-assets"
+	PermissionMaintenanceLogs = "maintenance-logs"
+	PermissionDepreciation    = "depreciation"
+	PermissionQrBarcodes      = "qr-barcodes"
+	PermissionDashboards      = "dashboards"
+	PermissionExportReports   = "export-reports"
+	PermissionAuditLogs       = "audit-logs"
+	PermissionNotifications   = "notifications"
+	PermissionManageTaxonomy  = "manage-taxonomy"
+)
+
+// Operation là loại thao tác, xếp theo mức độ tăng dần
+type Operation int
+
+const (
+	OpRead   Operation = iota // xem
+	OpAct                     // thao tác trên bản ghi của mình: quét QR, đánh dấu đã đọc, gửi yêu cầu
+	OpWrite                   // tạo, sửa
+	OpDelete                  // xoá
+)
+
+// levelRule là ý nghĩa của 1 access level
+type levelRule struct {
+	maxOp          Operation               // thao tác cao nhất được phép
+	departmentOnly bool                    // chỉ với dữ liệu thuộc phòng ban của user
+	condition      func(*AuthContext) bool // điều kiện thêm về user, nil = không có
+}
+
+func (r levelRule) satisfied(a *AuthContext) bool {
+	return r.condition == nil || r.condition(a)
+}
+
+// levelRules là ý nghĩa mặc định của từng access level:
+//   - full: mọi thao tác trong công ty
+//   - scoped: mọi thao tác nhưng chỉ trong phòng ban của user
+//   - limited: tạo/sửa trong phòng ban của user, không được xoá
+//   - conditional: như limited, permission có điều kiện riêng thì khai báo ở permissionRules
+//   - partial: chỉ xem dữ liệu thuộc phòng ban của user
+//   - view: chỉ xem
+//   - scan, action, can-request: xem và thao tác trên bản ghi của mình (quét QR, xử lý thông báo, gửi yêu cầu)
+var levelRules = map[string]levelRule{
+	entity.AccessLevelFull:        {maxOp: OpDelete},
+	entity.AccessLevelScoped:      {maxOp: OpDelete, departmentOnly: true},
+	entity.AccessLevelLimited:     {maxOp: OpWrite, departmentOnly: true},
+	entity.AccessLevelConditional: {maxOp: OpWrite, departmentOnly: true},
+	entity.AccessLevelPartial:     {maxOp: OpRead, departmentOnly: true},
+	entity.AccessLevelView:        {maxOp: OpRead},
+	entity.AccessLevelScan:        {maxOp: OpAct},
+	entity.AccessLevelAction:      {maxOp: OpAct},
+	entity.AccessLevelCanRequest:  {maxOp: OpAct},
+}
+
+// permissionRules ghi đè ý nghĩa của level cho permission cụ thể
+var permissionRules = map[string]map[string]levelRule{
+	// Xuất báo cáo conditional: chỉ user được bật CanExport, phạm vi dữ liệu đã do dashboards quyết định
+	PermissionExportReports: {
+		entity.AccessLevelConditional: {maxOp: OpRead, condition: func(a *AuthContext) bool { return a.CanExport }},
+	},
+}
+
+func (a *AuthContext) rule(permSlug string) (levelRule, bool) {
+	level, ok := a.levels[permSlug]
+	if !ok {
+		return levelRule{}, false
+	}
+	if r, ok := permissionRules[permSlug][level]; ok {
+		return r, true
+	}
+	r, ok := levelRules[level]
+	return r, ok
+}
+
+// Allows kiểm tra role được làm op với permission, chưa xét phạm vi phòng ban
+func (a *AuthContext) Allows(permSlug string, op Operation) bool {
+	r, ok := a.rule(permSlug)
+	return ok && op <= r.maxOp && r.satisfied(a)
+}
+
+// AllowsInDepartment như Allows và thêm phạm vi: level giới hạn phòng ban chỉ áp dụng cho phòng ban của user
+func (a *AuthContext) AllowsInDepartment(permSlug string, op Operation, departmentId int64) bool {
+	if !a.Allows(permSlug, op) {
+		return false
+	}
+	r, _ := a.rule(permSlug)
+	return !r.departmentOnly || a.InDepartment(departmentId)
+}
+
+// DepartmentScope cho biết danh sách theo permission có bị giới hạn trong phòng ban của user không.
+// restricted = true và departmentId = nil nghĩa là user chưa có phòng ban nên không thấy gì
+func (a *AuthContext) DepartmentScope(permSlug string) (departmentId *int64, restricted bool) {
+	if r, ok := a.rule(permSlug); ok && !r.departmentOnly {
+		return nil, false
+	}
+	return a.DepartmentId, true
+}
+
+func (a *AuthContext) InDepartment(departmentId int64) bool {
+	return a.DepartmentId != nil && *a.DepartmentId == departmentId
+}
@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AssetScope là GORM scope lọc bảng assets theo cùng luật với CanAccessAsset:
+//   - level không giới hạn phòng ban (full, view...): mọi tài sản của công ty
+//   - level giới hạn phòng ban: tài sản thuộc phòng ban của user
+//   - owner luôn được xem tài sản của mình
+//   - grant riêng theo tài sản
+func (a *AuthContext) AssetScope(permSlug string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("assets.company_id = ?", a.CompanyId)
+		if !a.InScope(permSlug) {
+			return db.Where("1 = 0")
+		}
+		allowed := a.Allows(permSlug, OpRead)
+		departmentId, restricted := a.DepartmentScope(permSlug)
+		if allowed && !restricted {
+			return db
+		}
+		conds := []string{"EXISTS (SELECT 1 FROM asset_permission_grants g WHERE g.asset_id = assets.id AND g.user_id = ? AND g.permission_slug = ?)"}
+		args := []interface{}{a.UserId, permSlug}
+		if permSlug == PermissionViewAssets {
+			conds = append(conds, "assets.owner = ?")
+			args = append(args, a.UserId)
+		}
+		if allowed && departmentId != nil {
+			conds = append(conds, "assets.department_id = ?")
+			args = append(args, *departmentId)
+		}
+		return db.Where("("+strings.Join(conds, " OR ")+")", args...)
+	}
+}
+
+// CanAccessAsset kiểm tra quyền xem 1 tài sản cụ thể, dùng cho các endpoint theo id
+func (a *AuthContext) CanAccessAsset(permSlug string, asset *entity.Assets) bool {
+	if asset == nil || asset.CompanyId != a.CompanyId || !a.InScope(permSlug) {
+		return false
+	}
+	if a.grants[asset.Id][permSlug] {
+		return true
+	}
+	if permSlug == PermissionViewAssets && asset.Owner != nil && *asset.Owner == a.UserId {
+		return true
+	}
+	return a.AllowsInDepartment(permSlug, OpRead, asset.DepartmentId)
+}
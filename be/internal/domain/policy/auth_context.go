@@ -0,0 +1,137 @@
+package policy
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+// AuthContext là quyền của 1 user đã được nạp sẵn cho cả request: thông tin user, access level theo từng permission
+// và các grant riêng theo tài sản. Service nhận AuthContext thay vì tự đọc lại user/role
+type AuthContext struct {
+	UserId         int64
+	Email          string
+	CompanyId      int64
+	DepartmentId   *int64
+	RoleId         int64
+	RoleSlug       string
+	IsActive       bool
+	IsAssetManager bool
+	CanExport      bool
+	ApiTokenId     int64 // > 0 khi request xác thực bằng API token
+
+	levels map[string]string
+	grants map[int64]map[string]bool
+	scopes []string // nil = không giới hạn (đăng nhập bằng JWT)
+}
+
+// LoadAuthContext đọc role, permission và grant của user từ DB (không cache nên đổi quyền có hiệu lực ngay)
+func LoadAuthContext(db *gorm.DB, userId int64) (*AuthContext, error) {
+	var user entity.Users
+	if err := db.Preload("Role.RolePermissions.Permission").First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+	a := &AuthContext{
+		UserId:         user.Id,
+		Email:          user.Email,
+		CompanyId:      user.CompanyId,
+		DepartmentId:   user.DepartmentId,
+		RoleId:         user.RoleId,
+		RoleSlug:       user.Role.Slug,
+		IsActive:       user.IsActive,
+		IsAssetManager: user.IsAssetManager,
+		CanExport:      user.CanExport,
+		levels:         map[string]string{},
+		grants:         map[int64]map[string]bool{},
+	}
+	if user.Role.Activated {
+		for _, rp := range user.Role.RolePermissions {
+			if rp.Permission.Activated {
+				a.levels[rp.Permission.Slug] = rp.AccessLevel
+			}
+		}
+	}
+	var grants []entity.AssetPermissionGrants
+	if err := db.Model(&entity.AssetPermissionGrants{}).Where("user_id = ? and company_id = ?", user.Id, user.CompanyId).Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	for _, g := range grants {
+		if a.grants[g.AssetId] == nil {
+			a.grants[g.AssetId] = map[string]bool{}
+		}
+		a.grants[g.AssetId][g.PermissionSlug] = true
+	}
+	return a, nil
+}
+
+// NewAuthContext dựng AuthContext từ access level theo permission, không đọc DB (dùng cho test và job nội bộ)
+func NewAuthContext(userId int64, companyId int64, departmentId *int64, levels map[string]string) *AuthContext {
+	a := &AuthContext{UserId: userId, CompanyId: companyId, DepartmentId: departmentId, IsActive: true, levels: map[string]string{}, grants: map[int64]map[string]bool{}}
+	for permSlug, level := range levels {
+		a.levels[permSlug] = level
+	}
+	return a
+}
+
+// RestrictToScopes giới hạn quyền theo scopes của API token: chỉ giữ permission vừa có trong role vừa có trong scopes,
+// nên đổi role của owner cũng thu hẹp quyền của token ngay
+func (a *AuthContext) RestrictToScopes(apiTokenId int64, scopes []string) {
+	a.ApiTokenId = apiTokenId
+	a.scopes = scopes
+	for permSlug := range a.levels {
+		if !slices.Contains(scopes, permSlug) {
+			delete(a.levels, permSlug)
+		}
+	}
+	for _, perms := range a.grants {
+		for permSlug := range perms {
+			if !slices.Contains(scopes, permSlug) {
+				delete(perms, permSlug)
+			}
+		}
+	}
+}
+
+// InScope cho biết permission có nằm trong scopes của API token không, luôn true khi đăng nhập bằng JWT
+func (a *AuthContext) InScope(permSlug string) bool {
+	return a.scopes == nil || slices.Contains(a.scopes, permSlug)
+}
+
+// Level trả về access level của role với permission, ok = false nếu role không có
+func (a *AuthContext) Level(permSlug string) (string, bool) {
+	level, ok := a.levels[permSlug]
+	return level, ok
+}
+
+func (a *AuthContext) HasLevel(permSlug string, levels ...string) bool {
+	level, ok := a.levels[permSlug]
+	if !ok {
+		return false
+	}
+	return slices.Contains(levels, level)
+}
+
+// Granted dùng cho middleware: role có 1 trong các permission với level nằm trong danh sách route cho phép
+// (nil = chỉ full) và điều kiện của level đó được thoả
+func (a *AuthContext) Granted(permSlugs []string, levels []string) bool {
+	for _, permSlug := range permSlugs {
+		level, ok := a.levels[permSlug]
+		if !ok {
+			continue
+		}
+		if levels == nil {
+			if level == entity.AccessLevelFull {
+				return true
+			}
+			continue
+		}
+		if !slices.Contains(levels, level) {
+			continue
+		}
+		if r, ok := a.rule(permSlug); ok && r.satisfied(a) {
+			return true
+		}
+	}
+	return false
+}
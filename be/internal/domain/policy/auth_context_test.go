@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"testing"
+)
+
+func TestRestrictToScopes(t *testing.T) {
+	levels := map[string]string{
+		PermissionViewAssets:    entity.AccessLevelFull,
+		PermissionManageAssets:  entity.AccessLevelFull,
+		PermissionNotifications: entity.AccessLevelAction,
+	}
+	tests := []struct {
+		name      string
+		scopes    []string
+		permSlug  string
+		wantScope bool
+		wantLevel bool
+	}{
+		{"in scope and role", []string{PermissionViewAssets}, PermissionViewAssets, true, true},
+		{"in role outside scope", []string{PermissionViewAssets}, PermissionManageAssets, false, false},
+		// scope không có trong role thì vẫn không có quyền
+		{"in scope outside role", []string{PermissionAuditLogs}, PermissionAuditLogs, true, false},
+		{"empty scopes", []string{}, PermissionViewAssets, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthContext(1, 1, nil, levels)
+			a.RestrictToScopes(7, tt.scopes)
+			if a.ApiTokenId != 7 {
+				t.Errorf("ApiTokenId = %d, want 7", a.ApiTokenId)
+			}
+			if got := a.InScope(tt.permSlug); got != tt.wantScope {
+				t.Errorf("InScope = %v, want %v", got, tt.wantScope)
+			}
+			if _, got := a.Level(tt.permSlug); got != tt.wantLevel {
+				t.Errorf("Level ok = %v, want %v", got, tt.wantLevel)
+			}
+			if got := a.Allows(tt.permSlug, OpRead); got != tt.wantLevel {
+				t.Errorf("Allows = %v, want %v", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestRestrictToScopesGrants(t *testing.T) {
+	a := NewAuthContext(1, 1, nil, nil)
+	a.grants[5] = map[string]bool{PermissionViewAssets: true, PermissionManageAssets: true}
+	a.RestrictToScopes(7, []string{PermissionViewAssets})
+	asset := &entity.Assets{Id: 5, CompanyId: 1, DepartmentId: 10}
+	if !a.CanAccessAsset(PermissionViewAssets, asset) {
+		t.Error("grant within scope must be kept")
+	}
+	if a.CanAccessAsset(PermissionManageAssets, asset) {
+		t.Error("grant outside scope must be dropped")
+	}
+}
+
+func TestInScopeWithoutApiToken(t *testing.T) {
+	a := NewAuthContext(1, 1, nil, nil)
+	if !a.InScope(PermissionManageAssets) {
+		t.Error("JWT login must not be limited by scopes")
+	}
+}
+
+func TestHasLevel(t *testing.T) {
+	a := NewAuthContext(1, 1, nil, map[string]string{PermissionViewAssets: entity.AccessLevelPartial})
+	tests := []struct {
+		name     string
+		permSlug string
+		levels   []string
+		want     bool
+	}{
+		{"matching level", PermissionViewAssets, []string{entity.AccessLevelFull, entity.AccessLevelPartial}, true},
+		{"other level", PermissionViewAssets, []string{entity.AccessLevelFull}, false},
+		{"no permission", PermissionManageAssets, []string{entity.AccessLevelPartial}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.HasLevel(tt.permSlug, tt.levels...); got != tt.want {
+				t.Errorf("HasLevel = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
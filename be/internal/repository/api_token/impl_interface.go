@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgreSQLApiTokensRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLApiTokensRepository(db *gorm.DB) ApiTokensRepository {
+	return &PostgreSQLApiTokensRepository{db: db}
+}
+
+func (r *PostgreSQLApiTokensRepository) Create(token *entity.ApiTokens, tx *gorm.DB) error {
+	result := tx.Create(token)
+	return result.Error
+}
+
+func (r *PostgreSQLApiTokensRepository) FindByHash(tokenHash string) (*entity.ApiTokens, error) {
+	var token = &entity.ApiTokens{}
+	result := r.db.Model(&entity.ApiTokens{}).Where("token_hash = ?", tokenHash).First(token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return token, nil
+}
+
+func (r *PostgreSQLApiTokensRepository) GetById(id int64) (*entity.ApiTokens, error) {
+	var token = &entity.ApiTokens{}
+	result := r.db.Model(&entity.ApiTokens{}).Where("id = ?", id).Preload("User").First(token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return token, nil
+}
+
+func (r *PostgreSQLApiTokensRepository) GetByUserId(userId int64) ([]*entity.ApiTokens, error) {
+	var tokens = []*entity.ApiTokens{}
+	result := r.db.Model(&entity.ApiTokens{}).Where("user_id = ?", userId).Order("id DESC").Find(&tokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tokens, nil
+}
+
+func (r *PostgreSQLApiTokensRepository) GetByCompanyId(companyId int64) ([]*entity.ApiTokens, error) {
+	var tokens = []*entity.ApiTokens{}
+	result := r.db.Model(&entity.ApiTokens{}).Where("company_id = ?", companyId).Preload("User").Order("id DESC").Find(&tokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tokens, nil
+}
+
+func (r *PostgreSQLApiTokensRepository) Revoke(id int64, revokedBy int64, tx *gorm.DB) error {
+	result := tx.Model(&entity.ApiTokens{}).Where("id = ? and revoked_at is null", id).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"revoked_by": revokedBy,
+	})
+	return result.Error
+}
+
+func (r *PostgreSQLApiTokensRepository) UpdateLastUsed(id int64, lastUsedAt time.Time, ipAddress string) error {
+	result := r.db.Model(&entity.ApiTokens{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": lastUsedAt,
+		"last_used_ip": ipAddress,
+	})
+	return result.Error
+}
+
+func (r *PostgreSQLApiTokensRepository) GetDB() *gorm.DB {
+	return r.db
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiTokensRepository interface {
+	Create(token *entity.ApiTokens, tx *gorm.DB) error
+	FindByHash(tokenHash string) (*entity.ApiTokens, error)
+	GetById(id int64) (*entity.ApiTokens, error)
+	GetByUserId(userId int64) ([]*entity.ApiTokens, error)
+	GetByCompanyId(companyId int64) ([]*entity.ApiTokens, error)
+	Revoke(id int64, revokedBy int64, tx *gorm.DB) error
+	UpdateLastUsed(id int64, lastUsedAt time.Time, ipAddress string) error
+	GetDB() *gorm.DB
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgreSQLAssetLoansRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLAssetLoansRepository(db *gorm.DB) AssetLoansRepository {
+	return &PostgreSQLAssetLoansRepository{db: db}
+}
+
+func (r *PostgreSQLAssetLoansRepository) Create(loan *entity.AssetLoans, tx *gorm.DB) (*entity.AssetLoans, error) {
+	result := tx.Create(loan)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return loan, nil
+}
+
+func (r *PostgreSQLAssetLoansRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *PostgreSQLAssetLoansRepository) GetLoanById(id int64) (*entity.AssetLoans, error) {
+	loan := entity.AssetLoans{}
+	result := r.db.Model(entity.AssetLoans{}).Where("id = ?", id).Preload("Asset").Preload("Borrower").Preload("PreviousOwner").Preload("CheckedOutBy").Preload("CheckedInBy").First(&loan)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &loan, nil
+}
+
+func (r *PostgreSQLAssetLoansRepository) HasActiveLoan(assetId int64, tx *gorm.DB) (bool, error) {
+	var count int64
+	result := tx.Model(entity.AssetLoans{}).Where("asset_id = ? AND status = ?", assetId, "Checked Out").Count(&count)
+	return count > 0, result.Error
+}
+
+// CheckIn chỉ cập nhật khoản mượn còn Checked Out, trả về false nếu khoản mượn đã được trả trước đó
+func (r *PostgreSQLAssetLoansRepository) CheckIn(id int64, byUserId int64, condition string, checkInDate time.Time, tx *gorm.DB) (bool, error) {
+	updates := map[string]interface{}{
+		"status":             "Returned",
+		"checked_in_by_id":   byUserId,
+		"check_in_condition": condition,
+		"check_in_date":      checkInDate,
+	}
+	result := tx.Model(&entity.AssetLoans{}).Where("id = ? AND status = ?", id, "Checked Out").Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *PostgreSQLAssetLoansRepository) GetLoansWithFilter(dbFilter *gorm.DB) ([]*entity.AssetLoans, error) {
+	loans := []*entity.AssetLoans{}
+	result := dbFilter.Preload("Asset").Preload("Borrower").Preload("PreviousOwner").Preload("CheckedOutBy").Preload("CheckedInBy").Find(&loans)
+	return loans, result.Error
+}
+
+func (r *PostgreSQLAssetLoansRepository) GetOverdueLoans(now time.Time) ([]*entity.AssetLoans, error) {
+	loans := []*entity.AssetLoans{}
+	result := r.db.Model(entity.AssetLoans{}).Where("status = ? AND due_date < ?", "Checked Out", now).Preload("Asset").Preload("Borrower").Preload("PreviousOwner").Find(&loans)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return loans, nil
+}
+
+func (r *PostgreSQLAssetLoansRepository) UpdateLastOverdueNotifiedAt(id int64, notifiedAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&entity.AssetLoans{}).Where("id = ?", id).Update("last_overdue_notified_at", notifiedAt).Error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AssetLoansRepository interface {
+	Create(loan *entity.AssetLoans, tx *gorm.DB) (*entity.AssetLoans, error)
+	GetDB() *gorm.DB
+	GetLoanById(id int64) (*entity.AssetLoans, error)
+	HasActiveLoan(assetId int64, tx *gorm.DB) (bool, error)
+	CheckIn(id int64, byUserId int64, condition string, checkInDate time.Time, tx *gorm.DB) (bool, error)
+	GetLoansWithFilter(dbFilter *gorm.DB) ([]*entity.AssetLoans, error)
+	GetOverdueLoans(now time.Time) ([]*entity.AssetLoans, error)
+	UpdateLastOverdueNotifiedAt(id int64, notifiedAt time.Time, tx *gorm.DB) error
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+// AssetLogListener được gọi trong cùng transaction ngay sau khi ghi AssetLog, lỗi sẽ rollback cả transaction
+type AssetLogListener func(assetsLog *entity.AssetLog, tx *gorm.DB) error
+
+// publishingAssetsLogRepository bọc AssetsLogRepository để phát sự kiện (webhook...) từ mọi nơi ghi AssetLog
+type publishingAssetsLogRepository struct {
+	AssetsLogRepository
+	listener AssetLogListener
+}
+
+func NewPublishingAssetsLogRepository(inner AssetsLogRepository, listener AssetLogListener) AssetsLogRepository {
+	return &publishingAssetsLogRepository{AssetsLogRepository: inner, listener: listener}
+}
+
+func (r *publishingAssetsLogRepository) Create(assetsLog *entity.AssetLog, tx *gorm.DB) (*entity.AssetLog, error) {
+	created, err := r.AssetsLogRepository.Create(assetsLog, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.listener(created, tx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgreSQLAssetPermissionGrantsRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLAssetPermissionGrantsRepository(db *gorm.DB) AssetPermissionGrantsRepository {
+	return &PostgreSQLAssetPermissionGrantsRepository{db: db}
+}
+
+// Create bỏ qua nếu grant đã tồn tại
+func (r *PostgreSQLAssetPermissionGrantsRepository) Create(grant *entity.AssetPermissionGrants, tx *gorm.DB) error {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "asset_id"}, {Name: "user_id"}, {Name: "permission_slug"}},
+		DoNothing: true,
+	}).Create(grant)
+	return result.Error
+}
+
+func (r *PostgreSQLAssetPermissionGrantsRepository) Delete(id int64, tx *gorm.DB) error {
+	result := tx.Where("id = ?", id).Delete(&entity.AssetPermissionGrants{})
+	return result.Error
+}
+
+func (r *PostgreSQLAssetPermissionGrantsRepository) GetById(id int64) (*entity.AssetPermissionGrants, error) {
+	grant := &entity.AssetPermissionGrants{}
+	result := r.db.Model(&entity.AssetPermissionGrants{}).Where("id = ?", id).First(grant)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return grant, nil
+}
+
+func (r *PostgreSQLAssetPermissionGrantsRepository) GetByAssetId(assetId int64) ([]*entity.AssetPermissionGrants, error) {
+	grants := []*entity.AssetPermissionGrants{}
+	result := r.db.Model(&entity.AssetPermissionGrants{}).Where("asset_id = ?", assetId).Preload("User").Order("id").Find(&grants)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return grants, nil
+}
+
+func (r *PostgreSQLAssetPermissionGrantsRepository) GetDB() *gorm.DB {
+	return r.db
+}
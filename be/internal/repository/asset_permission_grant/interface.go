@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type AssetPermissionGrantsRepository interface {
+	Create(grant *entity.AssetPermissionGrants, tx *gorm.DB) error
+	Delete(id int64, tx *gorm.DB) error
+	GetById(id int64) (*entity.AssetPermissionGrants, error)
+	GetByAssetId(assetId int64) ([]*entity.AssetPermissionGrants, error)
+	GetDB() *gorm.DB
+}
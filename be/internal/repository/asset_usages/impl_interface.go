@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgreSQLAssetUsagesRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLAssetUsagesRepository(db *gorm.DB) AssetUsagesRepository {
+	return &PostgreSQLAssetUsagesRepository{db: db}
+}
+
+func (r *PostgreSQLAssetUsagesRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *PostgreSQLAssetUsagesRepository) Upsert(usage *entity.AssetUsages, tx *gorm.DB) (*entity.AssetUsages, error) {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "asset_id"}, {Name: "period"}},
+		DoUpdates: clause.AssignmentColumns([]string{"units", "recorded_by_id", "updated_at"}),
+	}).Create(usage)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return usage, nil
+}
+
+func (r *PostgreSQLAssetUsagesRepository) GetUsagesByAssetId(assetId int64) ([]*entity.AssetUsages, error) {
+	usages := []*entity.AssetUsages{}
+	result := r.db.Model(entity.AssetUsages{}).Where("asset_id = ?", assetId).Order("period ASC").Find(&usages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return usages, nil
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
+
+type AssetUsagesRepository interface {
+	Upsert(usage *entity.AssetUsages, tx *gorm.DB) (*entity.AssetUsages, error)
+	GetDB() *gorm.DB
+	GetUsagesByAssetId(assetId int64) ([]*entity.AssetUsages, error)
+}
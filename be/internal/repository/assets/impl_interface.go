@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLAssetsRepository struct {
@@ -100,6 +101,18 @@ func (r *PostgreSQLAssetsRepository) UpdateAsset(assets *entity.Assets, tx *gorm
 	if assets.DepartmentId != 0 {
 		updates["DepartmentId"] = assets.DepartmentId
 	}
+	if assets.ResidualValue != nil {
+		updates["residual_value"] = assets.ResidualValue
+	}
+	if assets.AnnualDepreciation != nil {
+		updates["annual_depreciation"] = assets.AnnualDepreciation
+	}
+	if assets.RetiredOrDisposeTime != nil {
+		updates["retired_or_dispose_time"] = assets.RetiredOrDisposeTime
+	}
+	if assets.VendorId != nil {
+		updates["vendor_id"] = assets.VendorId
+	}
 	err := tx.Model(&assetUpdate).Where("id = ?", assets.Id).Updates(updates).Error
 	if err != nil {
 		return nil, err
@@ -126,12 +139,11 @@ func (r *PostgreSQLAssetsRepository) UpdateQrURL(assetId int64, qrUrl string) er
 func (r *PostgreSQLAssetsRepository) GetUserHavePermissionNotifications(id int64) ([]*entity.Users, error) {
 	users := []*entity.Users{}
 	result := r.db.Model(&entity.Assets{}).
-		Joins("JOIN user_rbacs ON user_rbacs.asset_id = assets.id").
-		Joins("JOIN users ON users.id = user_rbacs.user_id").
-		Joins("JOIN roles ON roles.id = user_rbacs.role_id").
+		Joins("JOIN users ON users.company_id = assets.company_id").
+		Joins("JOIN roles ON roles.id = users.role_id").
 		Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
 		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
-		Where("assets.id = ? AND permissions.slug = ?", id, "notifications").
+		Where("assets.id = ? AND permissions.slug = ? AND roles.activated = ?", id, "notifications", true).
 		Select("users.*").
 		Find(&users)
 
@@ -142,15 +154,16 @@ func (r *PostgreSQLAssetsRepository) GetUserHavePermissionNotifications(id int64
 }
 
 func (r *PostgreSQLAssetsRepository) CheckAssetFinishMaintenance(id int64) (bool, error) {
-	var schedule entity.MaintenanceSchedules
+	var schedules []entity.MaintenanceSchedules
 	err := r.db.
-		Debug().
 		Model(&entity.MaintenanceSchedules{}).
 		Joins("JOIN assets ON assets.id = maintenance_schedules.asset_id").
 		Where("assets.id = ?", id).
-		Order("maintenance_schedules.start_date DESC").
-		First(&schedule).Error
-
+		Preload("Exceptions").
+		Find(&schedules).Error
+	if err == nil && len(schedules) == 0 {
+		err = gorm.ErrRecordNotFound
+	}
 	if err != nil {
 		logrus.Printf("⚠️ Error checking maintenance for asset %d: %v", id, err)
 		return false, err
@@ -161,8 +174,25 @@ func (r *PostgreSQLAssetsRepository) CheckAssetFinishMaintenance(id int64) (bool
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
+	// Lấy lần bảo trì đang diễn ra (lần gần nhất đã bắt đầu) trong tất cả các lịch của tài sản
+	var current *entity.MaintenanceOccurrence
+	for _, s := range schedules {
+		occurrences, err := s.Occurrences(now.AddDate(-1, 0, 0), now)
+		if err != nil {
+			return false, err
+		}
+		for _, occ := range occurrences {
+			if !occ.Start.After(now) && (current == nil || occ.Start.After(current.Start)) {
+				o := occ
+				current = &o
+			}
+		}
+	}
+	if current == nil {
+		return true, nil
+	}
 	// Chuyển EndDate về đúng timezone
-	endDateInLoc := schedule.EndDate.In(loc)
+	endDateInLoc := current.End.In(loc)
 
 	return endDateInLoc.Before(endOfDay), nil
 }
@@ -268,3 +298,35 @@ func (r *PostgreSQLAssetsRepository) GetAllAssetOfDep(depId int64) ([]*entity.As
 	}
 	return assets, nil
 }
+
+func (r *PostgreSQLAssetsRepository) GetAssetsBySerialNumbers(companyId int64, serialNumbers []string) ([]*entity.Assets, error) {
+	assets := []*entity.Assets{}
+	if len(serialNumbers) == 0 {
+		return assets, nil
+	}
+	result := r.db.Model(entity.Assets{}).Where("company_id = ? AND serial_number IN ?", companyId, serialNumbers).Find(&assets)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return assets, nil
+}
+
+// UpdateDepreciationSettings ghi đè cấu hình khấu hao của tài sản, giá trị nil thì dùng lại cấu hình của category
+func (r *PostgreSQLAssetsRepository) UpdateDepreciationSettings(asset *entity.Assets, tx *gorm.DB) (*entity.Assets, error) {
+	result := tx.Model(entity.Assets{}).Where("id = ?", asset.Id).
+		Select("depreciation_method", "useful_life", "residual_value", "total_units").
+		Updates(asset)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var assetUpdate entity.Assets
+	if err := tx.Preload("Category").First(&assetUpdate, asset.Id).Error; err != nil {
+		return nil, err
+	}
+	return &assetUpdate, nil
+}
+
+// LockAsset khoá dòng tài sản tới hết tx, để cho mượn và điều chuyển cùng tài sản không chạy song song
+func (r *PostgreSQLAssetsRepository) LockAsset(id int64, tx *gorm.DB) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).Take(&entity.Assets{}).Error
+}
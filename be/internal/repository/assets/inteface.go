@@ -27,7 +27,10 @@ type AssetsRepository interface {
 	GetAssetsByCateOfDepartment(categoryId int64, departmentId int64) ([]*entity.Assets, error)
 	UpdateCost(id int64, cost float64) error
 	UpdateAcquisitionDate(id int64, AcquisitionDate time.Time, tx *gorm.DB) error
+	UpdateDepreciationSettings(asset *entity.Assets, tx *gorm.DB) (*entity.Assets, error)
 	DeleteOwnerAssetOfOwnerId(ownerId int64) error
 	GetAllAssetNotHaveMaintenance(companyId int64) ([]*entity.Assets, error)
 	GetAllAssetOfDep(depId int64) ([]*entity.Assets, error)
+	GetAssetsBySerialNumbers(companyId int64, serialNumbers []string) ([]*entity.Assets, error)
+	LockAsset(id int64, tx *gorm.DB) error
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLBillsRepository struct {
@@ -16,20 +17,29 @@ func NewPostgreSQLBillsRepository(db *gorm.DB) BillsRepository {
 	return &PostgreSQLBillsRepository{db: db}
 }
 
-func (r *PostgreSQLBillsRepository) Create(bill *entity.Bill) (*entity.Bill, error) {
+// orderedLineItems, orderedPayments giữ thứ tự các dòng và các lần thanh toán khi preload
+func orderedLineItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
+
+func orderedPayments(db *gorm.DB) *gorm.DB {
+	return db.Order("paid_at ASC, id ASC")
+}
+
+func (r *PostgreSQLBillsRepository) Create(bill *entity.Bill, tx *gorm.DB) (*entity.Bill, error) {
 	var billNumber int64
-	err := r.db.Raw("SELECT nextval('bill_number_seq')").Scan(&billNumber).Error
+	err := tx.Raw("SELECT nextval('bill_number_seq')").Scan(&billNumber).Error
 	if err != nil {
 		return nil, err
 	}
 	bill.BillNumber = fmt.Sprintf("BILL-%08d", billNumber)
-	result := r.db.Create(bill)
+	result := tx.Create(bill)
 	return bill, result.Error
 }
 
 func (r *PostgreSQLBillsRepository) GetByBillNumber(billNumber string) (*entity.Bill, error) {
 	var bill entity.Bill
-	result := r.db.Model(entity.Bill{}).Where("bill_number =?", billNumber).Preload("CreateBy").Preload("BillAssets").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").First(&bill)
+	result := r.db.Model(entity.Bill{}).Where("bill_number =?", billNumber).Preload("CreateBy").Preload("BillAssets").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").Preload("LineItems", orderedLineItems).Preload("LineItems.Asset").Preload("Payments", orderedPayments).Preload("Payments.RecordedBy").First(&bill)
 	return &bill, result.Error
 }
 
@@ -48,22 +58,67 @@ func (r *PostgreSQLBillsRepository) GetAllBillOfMonth(time time.Time, companyId
 	m := time.Month()
 	first, last := monthInterval(y, m)
 	var bills []*entity.Bill
-	result := r.db.Model(entity.Bill{}).Where("company_id = ?", companyId).Where("create_at >= ? and create_at <= ?", first, last).Preload("BillAssets.Asset").Preload("BillAssets.Asset.Category").Find(&bills)
+	result := r.db.Model(entity.Bill{}).Where("company_id = ?", companyId).Where("create_at >= ? and create_at <= ?", first, last).Preload("BillAssets.Asset").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("LineItems", orderedLineItems).Preload("LineItems.Asset").Preload("LineItems.Asset.Category").Preload("LineItems.Asset.Department").Preload("LineItems.Asset.Department.Location").Find(&bills)
 	return bills, result.Error
 }
 
 func (r *PostgreSQLBillsRepository) GetAllBillUnpaid(companyId int64) ([]*entity.Bill, error) {
 	var bills []*entity.Bill
-	result := r.db.Model(entity.Bill{}).Where("company_id = ?", companyId).Where("status_bill = ?", "Unpaid").Preload("CreateBy").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").Find(&bills)
+	result := r.db.Model(entity.Bill{}).Where("company_id = ?", companyId).Where("status_bill <> ?", entity.BillStatusPaid).Preload("CreateBy").Preload("BillAssets.Asset").Preload("CreateBy.Role").Preload("BillAssets.Asset.Category").Preload("BillAssets.Asset.Department").Preload("BillAssets.Asset.Department.Location").Preload("BillAssets.Asset.OnwerUser").Preload("LineItems", orderedLineItems).Preload("LineItems.Asset").Preload("Payments", orderedPayments).Preload("Payments.RecordedBy").Find(&bills)
+	return bills, result.Error
+}
+
+// LockByBillNumber lấy bill của công ty và khoá dòng tới hết transaction để các lần ghi thanh toán đồng thời không cộng sai PaidAmount
+func (r *PostgreSQLBillsRepository) LockByBillNumber(billNumber string, companyId int64, tx *gorm.DB) (*entity.Bill, error) {
+	var bill entity.Bill
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("bill_number = ? and company_id = ?", billNumber, companyId).First(&bill)
+	return &bill, result.Error
+}
+
+func (r *PostgreSQLBillsRepository) CreatePayment(payment *entity.BillPayments, tx *gorm.DB) error {
+	return tx.Create(payment).Error
+}
+
+func (r *PostgreSQLBillsRepository) UpdatePaymentState(billId int64, paidAmount float64, status string, tx *gorm.DB) error {
+	result := tx.Model(entity.Bill{}).Where("id = ?", billId).Updates(map[string]interface{}{"paid_amount": paidAmount, "status_bill": status})
+	return result.Error
+}
+
+// GetOverdueBills lấy bill của mọi công ty chưa trả đủ và đã quá hạn (hết ngày due_date) tính tới now
+func (r *PostgreSQLBillsRepository) GetOverdueBills(now time.Time) ([]*entity.Bill, error) {
+	var bills []*entity.Bill
+	result := r.db.Where("due_date IS NOT NULL AND due_date <= ?", now.AddDate(0, 0, -1)).Where("status_bill <> ?", entity.BillStatusPaid).Preload("CreateBy").Order("due_date ASC").Find(&bills)
 	return bills, result.Error
 }
 
-func (r *PostgreSQLBillsRepository) UpdatePaid(billNumberStr string) error {
-	result := r.db.Model(entity.Bill{}).Where("bill_number = ?", billNumberStr).Update("status_bill", "Paid")
+func (r *PostgreSQLBillsRepository) UpdateOverdueReminder(billId int64, remindedAt time.Time, tx *gorm.DB) error {
+	result := tx.Model(entity.Bill{}).Where("id = ?", billId).Updates(map[string]interface{}{"status_bill": entity.BillStatusOverdue, "last_reminder_at": remindedAt})
+	return result.Error
+}
+
+func (r *PostgreSQLBillsRepository) MarkOverdue(billIds []int64) error {
+	if len(billIds) == 0 {
+		return nil
+	}
+	result := r.db.Model(entity.Bill{}).Where("id IN ? AND status_bill <> ?", billIds, entity.BillStatusPaid).Update("status_bill", entity.BillStatusOverdue)
 	return result.Error
 }
 
-func (r *PostgreSQLBillsRepository) AddAssetsToBill(billAsset *entity.BillAsset) error {
-	result := r.db.Create(billAsset)
+// GetOutstandingBills lấy các bill còn nợ của công ty để lập báo cáo tuổi nợ
+func (r *PostgreSQLBillsRepository) GetOutstandingBills(companyId int64) ([]*entity.Bill, error) {
+	var bills []*entity.Bill
+	result := r.db.Where("company_id = ? AND status_bill <> ?", companyId, entity.BillStatusPaid).Order("due_date ASC NULLS LAST, id ASC").Find(&bills)
+	return bills, result.Error
+}
+
+func (r *PostgreSQLBillsRepository) AddAssetsToBill(billAsset *entity.BillAsset, tx *gorm.DB) error {
+	result := tx.Create(billAsset)
 	return result.Error
 }
+
+func (r *PostgreSQLBillsRepository) CreateLineItems(items []entity.BillLineItems, tx *gorm.DB) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return tx.Create(&items).Error
+}
@@ -8,11 +8,18 @@ import (
 )
 
 type BillsRepository interface {
-	Create(*entity.Bill) (*entity.Bill, error)
+	Create(bill *entity.Bill, tx *gorm.DB) (*entity.Bill, error)
 	GetByBillNumber(string) (*entity.Bill, error)
 	GetDB() *gorm.DB
 	GetAllBillOfMonth(time time.Time, companyId int64) ([]*entity.Bill, error)
 	GetAllBillUnpaid(companyId int64) ([]*entity.Bill, error)
-	UpdatePaid(billNumberStr string) error
-	AddAssetsToBill(*entity.BillAsset) error
+	LockByBillNumber(billNumber string, companyId int64, tx *gorm.DB) (*entity.Bill, error)
+	CreatePayment(payment *entity.BillPayments, tx *gorm.DB) error
+	UpdatePaymentState(billId int64, paidAmount float64, status string, tx *gorm.DB) error
+	GetOverdueBills(now time.Time) ([]*entity.Bill, error)
+	UpdateOverdueReminder(billId int64, remindedAt time.Time, tx *gorm.DB) error
+	MarkOverdue(billIds []int64) error
+	GetOutstandingBills(companyId int64) ([]*entity.Bill, error)
+	AddAssetsToBill(billAsset *entity.BillAsset, tx *gorm.DB) error
+	CreateLineItems(items []entity.BillLineItems, tx *gorm.DB) error
 }
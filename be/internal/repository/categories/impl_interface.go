@@ -29,3 +29,22 @@ func (r *PostgreSQLCategoriesRepository) Delete(id int64) error {
 	result := r.db.Model(entity.Categories{}).Where("id = ?", id).Delete(entity.Categories{})
 	return result.Error
 }
+
+func (r *PostgreSQLCategoriesRepository) GetById(id int64) (*entity.Categories, error) {
+	category := entity.Categories{}
+	result := r.db.Model(entity.Categories{}).Where("id = ?", id).First(&category)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &category, nil
+}
+
+func (r *PostgreSQLCategoriesRepository) UpdateDepreciation(category *entity.Categories) (*entity.Categories, error) {
+	result := r.db.Model(entity.Categories{}).Where("id = ?", category.Id).
+		Select("depreciation_method", "useful_life", "residual_rate", "declining_factor").
+		Updates(category)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return r.GetById(category.Id)
+}
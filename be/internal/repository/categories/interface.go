@@ -6,4 +6,6 @@ type CategoriesRepository interface {
 	Create(*entity.Categories) (*entity.Categories, error)
 	GetAll(companyId int64) ([]*entity.Categories, error)
 	Delete(id int64) error
+	GetById(id int64) (*entity.Categories, error)
+	UpdateDepreciation(category *entity.Categories) (*entity.Categories, error)
 }
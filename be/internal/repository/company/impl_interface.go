@@ -36,3 +36,11 @@ func (r *PostgreSQLCompanyRepository) GetAllCompany() ([]*entity.Company, error)
 	result := r.db.Model(entity.Company{}).Find(&company)
 	return company, result.Error
 }
+
+func (r *PostgreSQLCompanyRepository) UpdateRequireMfa(id int64, requireMfa bool) (*entity.Company, error) {
+	result := r.db.Model(entity.Company{}).Where("id = ?", id).Update("require_mfa", requireMfa)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return r.GetCompanyById(id)
+}
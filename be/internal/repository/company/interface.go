@@ -7,4 +7,5 @@ type CompanyRepository interface {
 	GetCompanyById(id int64) (*entity.Company, error)
 	GetCompanyBySuffixEmail(email string) (*entity.Company, error)
 	GetAllCompany() ([]*entity.Company, error)
+	UpdateRequireMfa(id int64, requireMfa bool) (*entity.Company, error)
 }
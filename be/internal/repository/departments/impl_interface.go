@@ -38,3 +38,8 @@ func (r *PostgreSQLDepartmentsRepository) GetDepartmentById(id int64) (*entity.D
 	}
 	return department, nil
 }
+
+func (r *PostgreSQLDepartmentsRepository) UpdateHead(id int64, headId *int64) error {
+	result := r.db.Model(entity.Departments{}).Where("id = ?", id).Update("head_id", headId)
+	return result.Error
+}
@@ -1,7 +1,11 @@
 package repository
 
 import (
+	apiToken "BE_Manage_device/internal/repository/api_token"
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
+	assetGrant "BE_Manage_device/internal/repository/asset_permission_grant"
+	assetUsage "BE_Manage_device/internal/repository/asset_usages"
 	asset "BE_Manage_device/internal/repository/assets"
 	assignment "BE_Manage_device/internal/repository/assignments"
 	bill "BE_Manage_device/internal/repository/bill"
@@ -13,11 +17,16 @@ import (
 	maintenanceSchedules "BE_Manage_device/internal/repository/maintenance_schedules"
 	monthlySummary "BE_Manage_device/internal/repository/monthly_summary"
 	notification "BE_Manage_device/internal/repository/noftifications"
+	notificationPreference "BE_Manage_device/internal/repository/notification_preference"
+	outbox "BE_Manage_device/internal/repository/outbox"
+	purchaseOrder "BE_Manage_device/internal/repository/purchase_order"
 	request_transfer "BE_Manage_device/internal/repository/request_transfer"
 	role "BE_Manage_device/internal/repository/role"
 	user "BE_Manage_device/internal/repository/user"
-	userRBAC "BE_Manage_device/internal/repository/user_rbac"
+	userMfa "BE_Manage_device/internal/repository/user_mfa"
 	userSession "BE_Manage_device/internal/repository/user_session"
+	vendor "BE_Manage_device/internal/repository/vendor"
+	webhook "BE_Manage_device/internal/repository/webhook"
 
 	"gorm.io/gorm"
 )
@@ -31,7 +40,6 @@ type Repository struct {
 	Assets                  asset.AssetsRepository
 	AssetsLog               asset_log.AssetsLogRepository
 	Role                    role.RoleRepository
-	UserRBAC                userRBAC.UserRBACRepository
 	Assignment              assignment.AssignmentRepository
 	RequestTransfer         request_transfer.RequestTransferRepository
 	MaintenanceSchedules    maintenanceSchedules.MaintenanceSchedulesRepository
@@ -40,6 +48,16 @@ type Repository struct {
 	Company                 company.CompanyRepository
 	Bill                    bill.BillsRepository
 	MonthlySummary          monthlySummary.MonthlySummaryRepository
+	AssetLoan               assetLoan.AssetLoansRepository
+	AssetUsage              assetUsage.AssetUsagesRepository
+	UserMfa                 userMfa.UserMfaRepository
+	AssetGrant              assetGrant.AssetPermissionGrantsRepository
+	ApiToken                apiToken.ApiTokensRepository
+	Webhook                 webhook.WebhooksRepository
+	Outbox                  outbox.OutboxRepository
+	NotificationPreference  notificationPreference.NotificationPreferencesRepository
+	Vendor                  vendor.VendorsRepository
+	PurchaseOrder           purchaseOrder.PurchaseOrdersRepository
 }
 
 func NewRepository(db *gorm.DB) *Repository {
@@ -52,7 +70,6 @@ func NewRepository(db *gorm.DB) *Repository {
 		Assets:                  asset.NewPostgreSQLAssetsRepository(db),
 		AssetsLog:               asset_log.NewPostgreSQLAssetsLogRepository(db),
 		Role:                    role.NewPostgreSQLRoleRepository(db),
-		UserRBAC:                userRBAC.NewPostgreSQLUserRBACRepository(db),
 		Assignment:              assignment.NewPostgreSQLAssignmentRepository(db),
 		RequestTransfer:         request_transfer.NewPostgreSQLRequestTransferRepository(db),
 		MaintenanceSchedules:    maintenanceSchedules.NewPostgreSQLMaintenanceSchedulesRepository(db),
@@ -61,5 +78,15 @@ func NewRepository(db *gorm.DB) *Repository {
 		Company:                 company.NewPostgreSQLCompanyRepository(db),
 		Bill:                    bill.NewPostgreSQLBillsRepository(db),
 		MonthlySummary:          monthlySummary.NewPostgreSQLMonthlySummary(db),
+		AssetLoan:               assetLoan.NewPostgreSQLAssetLoansRepository(db),
+		AssetUsage:              assetUsage.NewPostgreSQLAssetUsagesRepository(db),
+		UserMfa:                 userMfa.NewPostgreSQLUserMfaRepository(db),
+		AssetGrant:              assetGrant.NewPostgreSQLAssetPermissionGrantsRepository(db),
+		ApiToken:                apiToken.NewPostgreSQLApiTokensRepository(db),
+		Webhook:                 webhook.NewPostgreSQLWebhooksRepository(db),
+		Outbox:                  outbox.NewPostgreSQLOutboxRepository(db),
+		NotificationPreference:  notificationPreference.NewPostgreSQLNotificationPreferencesRepository(db),
+		Vendor:                  vendor.NewPostgreSQLVendorsRepository(db),
+		PurchaseOrder:           purchaseOrder.NewPostgreSQLPurchaseOrdersRepository(db),
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLMaintenanceSchedulesRepository struct {
@@ -15,8 +16,12 @@ func NewPostgreSQLMaintenanceSchedulesRepository(db *gorm.DB) MaintenanceSchedul
 	return &PostgreSQLMaintenanceSchedulesRepository{db: db}
 }
 
-func (r *PostgreSQLMaintenanceSchedulesRepository) Create(maintenance *entity.MaintenanceSchedules) (*entity.MaintenanceSchedules, error) {
-	result := r.db.Create(maintenance)
+func (r *PostgreSQLMaintenanceSchedulesRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *PostgreSQLMaintenanceSchedulesRepository) Create(maintenance *entity.MaintenanceSchedules, tx *gorm.DB) (*entity.MaintenanceSchedules, error) {
+	result := tx.Omit("Asset", "Exceptions").Create(maintenance)
 	return maintenance, result.Error
 }
 
@@ -24,25 +29,31 @@ func (r *PostgreSQLMaintenanceSchedulesRepository) GetAllMaintenanceSchedulesByA
 	maintenances := []*entity.MaintenanceSchedules{}
 	startOfDay := time.Now().Truncate(24 * time.Hour)
 	endOfDay := startOfDay.Add(24 * time.Hour)
-	result := r.db.Model(entity.MaintenanceSchedules{}).Joins("join assets on assets.id = maintenance_schedules.asset_id").Where("maintenance_schedules.asset_id = ?", assetId).Where("maintenance_schedules.end_date >= ?", endOfDay).Where("assets.status != ? and assets.status != ?","Disposed","Retired").Preload("Asset").Find(&maintenances)
+	result := r.db.Model(entity.MaintenanceSchedules{}).Joins("join assets on assets.id = maintenance_schedules.asset_id").Where("maintenance_schedules.asset_id = ?", assetId).Where("maintenance_schedules.end_date >= ? OR maintenance_schedules.frequency IS NOT NULL", endOfDay).Where("assets.status != ? and assets.status != ?", "Disposed", "Retired").Preload("Asset").Preload("Exceptions").Find(&maintenances)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return maintenances, result.Error
 }
 
-func (r *PostgreSQLMaintenanceSchedulesRepository) Update(id int64, startDate time.Time, endDate time.Time) (*entity.MaintenanceSchedules, error) {
-	maintenance := entity.MaintenanceSchedules{}
-	result := r.db.Model(entity.MaintenanceSchedules{}).Where("id = ?", id).Update("start_date", startDate).Update("end_date", endDate)
+func (r *PostgreSQLMaintenanceSchedulesRepository) Update(maintenance *entity.MaintenanceSchedules, tx *gorm.DB) (*entity.MaintenanceSchedules, error) {
+	result := tx.Model(entity.MaintenanceSchedules{}).Where("id = ?", maintenance.Id).
+		Select("start_date", "end_date", "vendor_id", "frequency", "repeat_interval", "by_weekdays", "repeat_until", "repeat_count").
+		Updates(maintenance)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	r.db.Model(entity.MaintenanceSchedules{}).Where("id = ?", id).Preload("Asset").First(&maintenance)
-	return &maintenance, nil
+	return maintenance, nil
 }
 
-func (r *PostgreSQLMaintenanceSchedulesRepository) Delete(id int64) error {
-	if err := r.db.Delete(&entity.MaintenanceSchedules{}, id).Error; err != nil {
+func (r *PostgreSQLMaintenanceSchedulesRepository) Delete(id int64, tx *gorm.DB) error {
+	if err := tx.Where("schedule_id = ?", id).Delete(&entity.MaintenanceScheduleExceptions{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("schedule_id = ?", id).Delete(&entity.MaintenanceJobs{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Delete(&entity.MaintenanceSchedules{}, id).Error; err != nil {
 		return err
 	}
 	return nil
@@ -50,7 +61,7 @@ func (r *PostgreSQLMaintenanceSchedulesRepository) Delete(id int64) error {
 
 func (r *PostgreSQLMaintenanceSchedulesRepository) GetMaintenanceSchedulesById(id int64) (*entity.MaintenanceSchedules, error) {
 	maintenance := entity.MaintenanceSchedules{}
-	result := r.db.Model(entity.MaintenanceSchedules{}).Where("id = ?", id).Preload("Asset").Preload("Asset.OnwerUser").First(&maintenance)
+	result := r.db.Model(entity.MaintenanceSchedules{}).Where("id = ?", id).Preload("Asset").Preload("Asset.OnwerUser").Preload("Exceptions").First(&maintenance)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -61,24 +72,46 @@ func (r *PostgreSQLMaintenanceSchedulesRepository) GetAllMaintenanceSchedules()
 	startOfDay := time.Now().Truncate(24 * time.Hour)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 	maintenances := []*entity.MaintenanceSchedules{}
-	result := r.db.Model(entity.MaintenanceSchedules{}).Joins("join assets on assets.id = maintenance_schedules.asset_id").Where("end_date >= ?", endOfDay).Where("assets.status != ? and assets.status != ?","Disposed","Retired").Preload("Asset").Find(&maintenances)
+	result := r.db.Model(entity.MaintenanceSchedules{}).Joins("join assets on assets.id = maintenance_schedules.asset_id").Where("maintenance_schedules.end_date >= ? OR maintenance_schedules.frequency IS NOT NULL", endOfDay).Where("assets.status != ? and assets.status != ?", "Disposed", "Retired").Preload("Asset").Preload("Exceptions").Find(&maintenances)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return maintenances, result.Error
 }
 
-func (r *PostgreSQLMaintenanceSchedulesRepository) GetDateMaintenanceSchedulesInFuture(assetId int64) ([]*entity.TimeRange, error) {
-	startOfDay := time.Now().Truncate(24 * time.Hour)
-	endOfDay := startOfDay.Add(24 * time.Hour)
+// GetActiveMaintenanceSchedulesByAssetId lấy các lịch còn có thể sinh lần bảo trì trong tương lai (lịch 1 lần chưa kết thúc và mọi lịch lặp)
+func (r *PostgreSQLMaintenanceSchedulesRepository) GetActiveMaintenanceSchedulesByAssetId(assetId int64) ([]*entity.MaintenanceSchedules, error) {
 	maintenances := []*entity.MaintenanceSchedules{}
-	result := r.db.Model(entity.MaintenanceSchedules{}).Where("asset_id = ?", assetId).Where("start_date >= ?", endOfDay).Find(&maintenances)
+	result := r.db.Model(entity.MaintenanceSchedules{}).Where("asset_id = ?", assetId).Where("end_date >= ? OR frequency IS NOT NULL", time.Now()).Preload("Exceptions").Find(&maintenances)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	var TimeRange = []*entity.TimeRange{}
-	for _, i := range maintenances {
-		TimeRange = append(TimeRange, &entity.TimeRange{Start: i.StartDate, End: i.EndDate})
+	return maintenances, nil
+}
+
+func (r *PostgreSQLMaintenanceSchedulesRepository) SaveException(exception *entity.MaintenanceScheduleExceptions, tx *gorm.DB) (*entity.MaintenanceScheduleExceptions, error) {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "schedule_id"}, {Name: "occurrence_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"cancelled", "start_date", "end_date"}),
+	}).Create(exception)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return exception, nil
+}
+
+// SaveJob ghi đè thời điểm giao/nhận thực tế của 1 lần bảo trì
+func (r *PostgreSQLMaintenanceSchedulesRepository) SaveJob(job *entity.MaintenanceJobs, tx *gorm.DB) (*entity.MaintenanceJobs, error) {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "schedule_id"}, {Name: "occurrence_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{"handed_over_at", "returned_at", "recorded_by_id", "updated_at"}),
+	}).Create(job)
+	if result.Error != nil {
+		return nil, result.Error
 	}
-	return TimeRange, nil
+	return job, nil
+}
+
+func (r *PostgreSQLMaintenanceSchedulesRepository) DeleteExceptionsFrom(scheduleId int64, from time.Time, tx *gorm.DB) error {
+	return tx.Where("schedule_id = ? AND occurrence_start >= ?", scheduleId, from).Delete(&entity.MaintenanceScheduleExceptions{}).Error
 }
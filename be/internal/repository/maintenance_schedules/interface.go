@@ -3,14 +3,20 @@ package repository
 import (
 	"BE_Manage_device/internal/domain/entity"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type MaintenanceSchedulesRepository interface {
-	Create(*entity.MaintenanceSchedules) (*entity.MaintenanceSchedules, error)
+	Create(maintenance *entity.MaintenanceSchedules, tx *gorm.DB) (*entity.MaintenanceSchedules, error)
+	GetDB() *gorm.DB
 	GetAllMaintenanceSchedulesByAssetId(assetId int64) ([]*entity.MaintenanceSchedules, error)
-	Update(id int64, startDate time.Time, endDate time.Time) (*entity.MaintenanceSchedules, error)
-	Delete(id int64) error
+	Update(maintenance *entity.MaintenanceSchedules, tx *gorm.DB) (*entity.MaintenanceSchedules, error)
+	Delete(id int64, tx *gorm.DB) error
 	GetMaintenanceSchedulesById(id int64) (*entity.MaintenanceSchedules, error)
 	GetAllMaintenanceSchedules() ([]*entity.MaintenanceSchedules, error)
-	GetDateMaintenanceSchedulesInFuture(assetId int64) ([]*entity.TimeRange, error)
+	GetActiveMaintenanceSchedulesByAssetId(assetId int64) ([]*entity.MaintenanceSchedules, error)
+	SaveException(exception *entity.MaintenanceScheduleExceptions, tx *gorm.DB) (*entity.MaintenanceScheduleExceptions, error)
+	DeleteExceptionsFrom(scheduleId int64, from time.Time, tx *gorm.DB) error
+	SaveJob(job *entity.MaintenanceJobs, tx *gorm.DB) (*entity.MaintenanceJobs, error)
 }
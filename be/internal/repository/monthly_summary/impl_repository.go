@@ -4,6 +4,7 @@ import (
 	"BE_Manage_device/internal/domain/entity"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLMonthlySummary struct {
@@ -19,6 +20,24 @@ func (r *PostgreSQLMonthlySummary) Create(monthlySummary *entity.MonthlySummary)
 	return monthlySummary, result.Error
 }
 
+// Upsert ghi đè summary đã có của cùng công ty và tháng, dùng khi chạy lại generator
+func (r *PostgreSQLMonthlySummary) Upsert(monthlySummary *entity.MonthlySummary) (*entity.MonthlySummary, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "month"}, {Name: "year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"totals", "bill_count", "asset_count", "category_breakdown", "department_breakdown", "location_breakdown", "generated_at"}),
+	}).Create(monthlySummary)
+	return monthlySummary, result.Error
+}
+
+func (r *PostgreSQLMonthlySummary) GetByPeriod(companyId int64, month, year int64) (*entity.MonthlySummary, error) {
+	var monthlySummary entity.MonthlySummary
+	result := r.db.Model(entity.MonthlySummary{}).Where("company_id = ? and month = ? and year = ?", companyId, month, year).First(&monthlySummary)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &monthlySummary, nil
+}
+
 func (r *PostgreSQLMonthlySummary) GetDB() *gorm.DB {
 	return r.db
 }
@@ -8,5 +8,7 @@ import (
 
 type MonthlySummaryRepository interface {
 	Create(*entity.MonthlySummary) (*entity.MonthlySummary, error)
+	Upsert(*entity.MonthlySummary) (*entity.MonthlySummary, error)
+	GetByPeriod(companyId int64, month, year int64) (*entity.MonthlySummary, error)
 	GetDB() *gorm.DB
 }
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"BE_Manage_device/internal/domain/entity"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLNotificationRepository struct {
@@ -22,16 +24,80 @@ func (r *PostgreSQLNotificationRepository) Create(notification *entity.Notificat
 	return notification, nil
 }
 
-func (r *PostgreSQLNotificationRepository) GetNotificationsByUserId(userId int64) ([]*entity.Notifications, error) {
+// CreateFromOutbox bỏ qua nếu message outbox đã tạo thông báo, trả về false khi đã tồn tại
+func (r *PostgreSQLNotificationRepository) CreateFromOutbox(notification *entity.Notifications) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "outbox_message_id"}},
+		DoNothing: true,
+	}).Create(notification)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *PostgreSQLNotificationRepository) GetNotificationsWithFilter(db *gorm.DB, limit int) ([]*entity.Notifications, error) {
 	notifications := []*entity.Notifications{}
-	result := r.db.Model(entity.Notifications{}).Where("user_id = ?", userId).Find(&notifications)
+	result := db.Limit(limit).Find(&notifications)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return notifications, nil
 }
 
-func (r *PostgreSQLNotificationRepository) UpdateStatus(id int64) error {
-	result := r.db.Model(entity.Notifications{}).Where("id = ?", id).Update("status", "seen")
-	return result.Error
+func (r *PostgreSQLNotificationRepository) GetNotificationsAfterId(userId int64, afterId int64, limit int) ([]*entity.Notifications, error) {
+	notifications := []*entity.Notifications{}
+	result := r.db.Model(entity.Notifications{}).Where("user_id = ? AND id > ?", userId, afterId).Order("id").Limit(limit).Find(&notifications)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return notifications, nil
+}
+
+// GetLatestId trả về id thông báo mới nhất của user, chưa có thông báo thì trả về 0
+func (r *PostgreSQLNotificationRepository) GetLatestId(userId int64) (int64, error) {
+	var id int64
+	result := r.db.Model(entity.Notifications{}).Where("user_id = ?", userId).Select("COALESCE(MAX(id), 0)").Scan(&id)
+	return id, result.Error
+}
+
+func (r *PostgreSQLNotificationRepository) CountUnread(userId int64) (int64, error) {
+	var count int64
+	result := r.db.Model(entity.Notifications{}).
+		Where("user_id = ? AND status = ? AND archived_at IS NULL", userId, entity.NotificationStatusUnread).Count(&count)
+	return count, result.Error
+}
+
+// MarkSeen chỉ cập nhật các thông báo thuộc userId, trả về số bản ghi thực sự được cập nhật
+func (r *PostgreSQLNotificationRepository) MarkSeen(userId int64, ids []int64) (int64, error) {
+	result := r.db.Model(entity.Notifications{}).Where("user_id = ? AND id IN ?", userId, ids).Update("status", entity.NotificationStatusSeen)
+	return result.RowsAffected, result.Error
+}
+
+func (r *PostgreSQLNotificationRepository) MarkAllSeen(userId int64) (int64, error) {
+	result := r.db.Model(entity.Notifications{}).
+		Where("user_id = ? AND status = ? AND archived_at IS NULL", userId, entity.NotificationStatusUnread).Update("status", entity.NotificationStatusSeen)
+	return result.RowsAffected, result.Error
+}
+
+func (r *PostgreSQLNotificationRepository) Archive(userId int64, ids []int64, archivedAt time.Time) (int64, error) {
+	result := r.db.Model(entity.Notifications{}).Where("user_id = ? AND id IN ? AND archived_at IS NULL", userId, ids).
+		Updates(map[string]interface{}{"archived_at": archivedAt, "status": entity.NotificationStatusSeen})
+	return result.RowsAffected, result.Error
+}
+
+func (r *PostgreSQLNotificationRepository) Delete(userId int64, ids []int64) (int64, error) {
+	result := r.db.Where("user_id = ? AND id IN ?", userId, ids).Delete(&entity.Notifications{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteOlderThan xoá các thông báo đã đọc hoặc đã lưu trữ cũ hơn before, thông báo chưa đọc được giữ lại
+func (r *PostgreSQLNotificationRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result := r.db.Where("notify_date < ? AND (status <> ? OR archived_at IS NOT NULL OR user_id IS NULL)", before, entity.NotificationStatusUnread).
+		Delete(&entity.Notifications{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *PostgreSQLNotificationRepository) GetDB() *gorm.DB {
+	return r.db
 }
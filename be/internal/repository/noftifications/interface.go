@@ -1,9 +1,23 @@
 package repository
 
-import "BE_Manage_device/internal/domain/entity"
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type NotificationRepository interface {
 	Create(*entity.Notifications) (*entity.Notifications, error)
-	GetNotificationsByUserId(userId int64) ([]*entity.Notifications, error)
-	UpdateStatus(id int64) error
+	CreateFromOutbox(notification *entity.Notifications) (bool, error)
+	GetNotificationsWithFilter(db *gorm.DB, limit int) ([]*entity.Notifications, error)
+	GetNotificationsAfterId(userId int64, afterId int64, limit int) ([]*entity.Notifications, error)
+	GetLatestId(userId int64) (int64, error)
+	CountUnread(userId int64) (int64, error)
+	MarkSeen(userId int64, ids []int64) (int64, error)
+	MarkAllSeen(userId int64) (int64, error)
+	Archive(userId int64, ids []int64, archivedAt time.Time) (int64, error)
+	Delete(userId int64, ids []int64) (int64, error)
+	DeleteOlderThan(before time.Time) (int64, error)
+	GetDB() *gorm.DB
 }
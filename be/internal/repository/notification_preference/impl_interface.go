@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgreSQLNotificationPreferencesRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLNotificationPreferencesRepository(db *gorm.DB) NotificationPreferencesRepository {
+	return &PostgreSQLNotificationPreferencesRepository{db: db}
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) GetPreferencesByUserIds(userIds []int64, tx *gorm.DB) ([]*entity.NotificationPreferences, error) {
+	var preferences = []*entity.NotificationPreferences{}
+	result := tx.Model(&entity.NotificationPreferences{}).Where("user_id IN ?", userIds).Find(&preferences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return preferences, nil
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) UpsertPreferences(preferences []*entity.NotificationPreferences, tx *gorm.DB) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"delivery", "updated_at"}),
+	}).Create(&preferences).Error
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) GetSettingsByUserIds(userIds []int64, tx *gorm.DB) ([]*entity.UserNotificationSettings, error) {
+	var settings = []*entity.UserNotificationSettings{}
+	result := tx.Model(&entity.UserNotificationSettings{}).Where("user_id IN ?", userIds).Find(&settings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return settings, nil
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) SaveSettings(settings *entity.UserNotificationSettings, tx *gorm.DB) error {
+	return tx.Save(settings).Error
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) CreateDigestItem(item *entity.NotificationDigestItems, tx *gorm.DB) error {
+	return tx.Create(item).Error
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) GetUserIdsWithPendingDigest() ([]int64, error) {
+	var userIds []int64
+	result := r.db.Model(&entity.NotificationDigestItems{}).Where("digested_at IS NULL").Distinct().Pluck("user_id", &userIds)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userIds, nil
+}
+
+// GetPendingDigestItems khoá các item đang chờ để 2 instance không gửi trùng digest
+func (r *PostgreSQLNotificationPreferencesRepository) GetPendingDigestItems(userId int64, tx *gorm.DB) ([]*entity.NotificationDigestItems, error) {
+	var items = []*entity.NotificationDigestItems{}
+	result := tx.Model(&entity.NotificationDigestItems{}).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("user_id = ? AND digested_at IS NULL", userId).Order("id").Find(&items)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return items, nil
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) MarkDigested(ids []int64, digestedAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&entity.NotificationDigestItems{}).Where("id IN ?", ids).Update("digested_at", digestedAt).Error
+}
+
+func (r *PostgreSQLNotificationPreferencesRepository) GetDB() *gorm.DB {
+	return r.db
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationPreferencesRepository interface {
+	GetPreferencesByUserIds(userIds []int64, tx *gorm.DB) ([]*entity.NotificationPreferences, error)
+	UpsertPreferences(preferences []*entity.NotificationPreferences, tx *gorm.DB) error
+	GetSettingsByUserIds(userIds []int64, tx *gorm.DB) ([]*entity.UserNotificationSettings, error)
+	SaveSettings(settings *entity.UserNotificationSettings, tx *gorm.DB) error
+	CreateDigestItem(item *entity.NotificationDigestItems, tx *gorm.DB) error
+	GetUserIdsWithPendingDigest() ([]int64, error)
+	GetPendingDigestItems(userId int64, tx *gorm.DB) ([]*entity.NotificationDigestItems, error)
+	MarkDigested(ids []int64, digestedAt time.Time, tx *gorm.DB) error
+	GetDB() *gorm.DB
+}
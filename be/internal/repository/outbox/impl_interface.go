@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgreSQLOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &PostgreSQLOutboxRepository{db: db}
+}
+
+func (r *PostgreSQLOutboxRepository) Create(message *entity.OutboxMessages, tx *gorm.DB) error {
+	return tx.Create(message).Error
+}
+
+// ClaimDue lấy các message tới hạn và đẩy next_attempt_at ra sau lease để instance khác không xử lý trùng
+func (r *PostgreSQLOutboxRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessages, error) {
+	var messages = []*entity.OutboxMessages{}
+	result := r.db.Raw(`UPDATE outbox_messages SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_messages
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), entity.OutboxStatusPending, now, limit).Scan(&messages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return messages, nil
+}
+
+func (r *PostgreSQLOutboxRepository) Update(message *entity.OutboxMessages, tx *gorm.DB) error {
+	return tx.Model(&entity.OutboxMessages{}).Where("id = ?", message.Id).Updates(map[string]interface{}{
+		"status":          message.Status,
+		"attempts":        message.Attempts,
+		"next_attempt_at": message.NextAttemptAt,
+		"last_error":      message.LastError,
+		"replay_count":    message.ReplayCount,
+		"processed_at":    message.ProcessedAt,
+	}).Error
+}
+
+func (r *PostgreSQLOutboxRepository) GetById(id int64) (*entity.OutboxMessages, error) {
+	var message = &entity.OutboxMessages{}
+	result := r.db.Model(&entity.OutboxMessages{}).Where("id = ?", id).First(message)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return message, nil
+}
+
+func (r *PostgreSQLOutboxRepository) GetByCompanyId(companyId int64, status string, channel string, limit int) ([]*entity.OutboxMessages, error) {
+	var messages = []*entity.OutboxMessages{}
+	query := r.db.Model(&entity.OutboxMessages{}).Where("company_id = ?", companyId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	result := query.Order("id DESC").Limit(limit).Find(&messages)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return messages, nil
+}
+
+func (r *PostgreSQLOutboxRepository) GetDB() *gorm.DB {
+	return r.db
+}
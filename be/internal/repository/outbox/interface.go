@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Create(message *entity.OutboxMessages, tx *gorm.DB) error
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessages, error)
+	Update(message *entity.OutboxMessages, tx *gorm.DB) error
+	GetById(id int64) (*entity.OutboxMessages, error)
+	GetByCompanyId(companyId int64, status string, channel string, limit int) ([]*entity.OutboxMessages, error)
+	GetDB() *gorm.DB
+}
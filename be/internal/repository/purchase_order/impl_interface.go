@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgreSQLPurchaseOrdersRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLPurchaseOrdersRepository(db *gorm.DB) PurchaseOrdersRepository {
+	return &PostgreSQLPurchaseOrdersRepository{db: db}
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func orderedItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
+
+func orderedReceipts(db *gorm.DB) *gorm.DB {
+	return db.Order("received_at, id")
+}
+
+// Create sinh số đơn từ sequence rồi ghi đơn cùng các dòng hàng
+func (r *PostgreSQLPurchaseOrdersRepository) Create(order *entity.PurchaseOrders, tx *gorm.DB) error {
+	var orderNumber int64
+	if err := tx.Raw("SELECT nextval('purchase_order_number_seq')").Scan(&orderNumber).Error; err != nil {
+		return err
+	}
+	order.OrderNumber = fmt.Sprintf("PO-%08d", orderNumber)
+	return tx.Create(order).Error
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) GetById(id int64) (*entity.PurchaseOrders, error) {
+	order := &entity.PurchaseOrders{}
+	result := r.db.Model(&entity.PurchaseOrders{}).Where("id = ?", id).Preload("Department").Preload("Vendor").Preload("Items", orderedItems).Preload("Items.Category").Preload("Receipts", orderedReceipts).Preload("Receipts.Lines").Preload("Receipts.Lines.Assets").First(order)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return order, nil
+}
+
+// LockById khoá đơn trong tx để 2 lần nhập kho cùng lúc không nhận vượt số lượng
+func (r *PostgreSQLPurchaseOrdersRepository) LockById(id int64, companyId int64, tx *gorm.DB) (*entity.PurchaseOrders, error) {
+	order := &entity.PurchaseOrders{}
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ? and company_id = ?", id, companyId).First(order)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if err := tx.Where("purchase_order_id = ?", id).Order("id").Find(&order.Items).Error; err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) GetByCompanyId(companyId int64, departmentId *int64, status string) ([]*entity.PurchaseOrders, error) {
+	orders := []*entity.PurchaseOrders{}
+	query := r.db.Model(&entity.PurchaseOrders{}).Where("company_id = ?", companyId)
+	if departmentId != nil {
+		query = query.Where("department_id = ?", *departmentId)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	result := query.Preload("Department").Preload("Vendor").Preload("Items", orderedItems).Preload("Items.Category").Order("created_at desc, id desc").Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// GetCommittedOrders lấy các đơn đã được duyệt (kể cả đã nhận xong hoặc huỷ sau khi nhận 1 phần) kèm các lần nhập kho
+func (r *PostgreSQLPurchaseOrdersRepository) GetCommittedOrders(companyId int64, departmentId *int64) ([]*entity.PurchaseOrders, error) {
+	orders := []*entity.PurchaseOrders{}
+	query := r.db.Model(&entity.PurchaseOrders{}).Where("company_id = ? and decided_at is not null and status <> ?", companyId, entity.PurchaseOrderStatusRejected)
+	if departmentId != nil {
+		query = query.Where("department_id = ?", *departmentId)
+	}
+	result := query.Preload("Department").Preload("Items", orderedItems).Preload("Receipts", orderedReceipts).Preload("Receipts.Lines").Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) UpdateDecision(order *entity.PurchaseOrders, tx *gorm.DB) error {
+	return tx.Model(&entity.PurchaseOrders{}).Where("id = ?", order.Id).Updates(map[string]interface{}{
+		"status":        order.Status,
+		"decided_by_id": order.DecidedById,
+		"decided_at":    order.DecidedAt,
+		"decision_note": order.DecisionNote,
+	}).Error
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) UpdateStatus(id int64, status string, tx *gorm.DB) error {
+	return tx.Model(&entity.PurchaseOrders{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) Cancel(id int64, cancelledAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&entity.PurchaseOrders{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       entity.PurchaseOrderStatusCancelled,
+		"cancelled_at": cancelledAt,
+	}).Error
+}
+
+func (r *PostgreSQLPurchaseOrdersRepository) UpdateReceivedQuantity(itemId int64, receivedQuantity int, tx *gorm.DB) error {
+	return tx.Model(&entity.PurchaseOrderItems{}).Where("id = ?", itemId).Update("received_quantity", receivedQuantity).Error
+}
+
+// CreateReceipt ghi lần nhập kho cùng các dòng, Id của từng dòng được gán lại để gắn vào tài sản
+func (r *PostgreSQLPurchaseOrdersRepository) CreateReceipt(receipt *entity.GoodsReceipts, tx *gorm.DB) error {
+	return tx.Omit("Lines.Assets").Create(receipt).Error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PurchaseOrdersRepository interface {
+	Create(order *entity.PurchaseOrders, tx *gorm.DB) error
+	GetById(id int64) (*entity.PurchaseOrders, error)
+	LockById(id int64, companyId int64, tx *gorm.DB) (*entity.PurchaseOrders, error)
+	GetByCompanyId(companyId int64, departmentId *int64, status string) ([]*entity.PurchaseOrders, error)
+	GetCommittedOrders(companyId int64, departmentId *int64) ([]*entity.PurchaseOrders, error)
+	UpdateDecision(order *entity.PurchaseOrders, tx *gorm.DB) error
+	UpdateStatus(id int64, status string, tx *gorm.DB) error
+	Cancel(id int64, cancelledAt time.Time, tx *gorm.DB) error
+	UpdateReceivedQuantity(itemId int64, receivedQuantity int, tx *gorm.DB) error
+	CreateReceipt(receipt *entity.GoodsReceipts, tx *gorm.DB) error
+	GetDB() *gorm.DB
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"BE_Manage_device/internal/domain/entity"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLRequestTransferRepository struct {
@@ -14,51 +16,98 @@ func NewPostgreSQLRequestTransferRepository(db *gorm.DB) RequestTransferReposito
 	return &PostgreSQLRequestTransferRepository{db: db}
 }
 
-func (r *PostgreSQLRequestTransferRepository) Create(requestTransfer *entity.RequestTransfer) (*entity.RequestTransfer, error) {
-	result := r.db.Model(entity.RequestTransfer{}).Preload("User").Preload("User.Department").Preload("Category").Create(requestTransfer)
+func preloadRequestTransfer(db *gorm.DB) *gorm.DB {
+	return db.Preload("User").Preload("User.Department").Preload("Category").Preload("Asset").
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB { return db.Order("step_order ASC") }).Preload("Approvals.Approver").
+		Preload("Logs", func(db *gorm.DB) *gorm.DB { return db.Order("timestamp ASC, id ASC") })
+}
+
+func (r *PostgreSQLRequestTransferRepository) Create(requestTransfer *entity.RequestTransfer, tx *gorm.DB) (*entity.RequestTransfer, error) {
+	result := tx.Create(requestTransfer)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	request := entity.RequestTransfer{}
-	result = r.db.Model(entity.RequestTransfer{}).Where("id = ?", requestTransfer.Id).Preload("User").Preload("User.Department").Preload("Category").First(&request)
+	result = preloadRequestTransfer(tx.Model(entity.RequestTransfer{})).Where("id = ?", requestTransfer.Id).First(&request)
 	return &request, result.Error
 }
 
-func (r *PostgreSQLRequestTransferRepository) UpdateStatusConfirm(id int64, tx *gorm.DB) (*entity.RequestTransfer, error) {
+func (r *PostgreSQLRequestTransferRepository) UpdateStatus(id int64, status string, tx *gorm.DB) error {
+	result := tx.Model(entity.RequestTransfer{}).Where("id = ?", id).Updates(map[string]interface{}{"status": status, "updated_at": gorm.Expr("now()")})
+	return result.Error
+}
+
+func (r *PostgreSQLRequestTransferRepository) UpdateAsset(id int64, assetId int64, tx *gorm.DB) error {
+	result := tx.Model(entity.RequestTransfer{}).Where("id = ?", id).Update("asset_id", assetId)
+	return result.Error
+}
+
+func (r *PostgreSQLRequestTransferRepository) UpdateCurrentStep(id int64, step int, tx *gorm.DB) error {
+	result := tx.Model(entity.RequestTransfer{}).Where("id = ?", id).Updates(map[string]interface{}{"current_step": step, "updated_at": gorm.Expr("now()")})
+	return result.Error
+}
+
+func (r *PostgreSQLRequestTransferRepository) UpdateApproval(approval *entity.RequestTransferApprovals, tx *gorm.DB) error {
+	result := tx.Model(entity.RequestTransferApprovals{}).Where("id = ?", approval.Id).Updates(map[string]interface{}{
+		"approver_id": approval.ApproverId,
+		"decision":    approval.Decision,
+		"comment":     approval.Comment,
+		"decided_at":  approval.DecidedAt,
+	})
+	return result.Error
+}
+
+// SkipPendingApprovals đánh dấu các bước chưa duyệt là Skipped khi request bị từ chối hoặc huỷ
+func (r *PostgreSQLRequestTransferRepository) SkipPendingApprovals(requestTransferId int64, tx *gorm.DB) error {
+	result := tx.Model(entity.RequestTransferApprovals{}).Where("request_transfer_id = ? and decision = ?", requestTransferId, entity.ApprovalDecisionPending).Updates(map[string]interface{}{
+		"decision":   entity.ApprovalDecisionSkipped,
+		"decided_at": time.Now(),
+	})
+	return result.Error
+}
+
+func (r *PostgreSQLRequestTransferRepository) CreateLog(log *entity.RequestTransferLogs, tx *gorm.DB) error {
+	return tx.Create(log).Error
+}
+
+func (r *PostgreSQLRequestTransferRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+func (r *PostgreSQLRequestTransferRepository) GetRequestTransferById(id int64) (*entity.RequestTransfer, error) {
 	request := entity.RequestTransfer{}
-	result := tx.Model(entity.RequestTransfer{}).Where("id = ?", id).Update("status", "Confirm")
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	result = tx.Model(entity.RequestTransfer{}).Where("id = ?", id).Preload("User").Preload("User.Department").Preload("Category").First(&request)
+	result := preloadRequestTransfer(r.db.Model(entity.RequestTransfer{})).Where("id = ?", id).First(&request)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &request, nil
 }
 
-func (r PostgreSQLRequestTransferRepository) UpdateStatusDeny(id int64, tx *gorm.DB) (*entity.RequestTransfer, error) {
-	request := entity.RequestTransfer{}
-	result := r.db.Model(entity.RequestTransfer{}).Where("id = ?", id).Update("status", "Deny")
-	if result.Error != nil {
-		return nil, result.Error
+// GetRequestTransferForUpdate khoá dòng request tới hết tx rồi đọc lại, để các lần duyệt, từ chối, huỷ cùng request chạy lần lượt
+func (r *PostgreSQLRequestTransferRepository) GetRequestTransferForUpdate(id int64, tx *gorm.DB) (*entity.RequestTransfer, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).Take(&entity.RequestTransfer{}).Error; err != nil {
+		return nil, err
 	}
-	result = r.db.Model(entity.RequestTransfer{}).Where("id = ?", id).Preload("User").Preload("User.Department").Preload("Category").First(&request)
+	request := entity.RequestTransfer{}
+	result := preloadRequestTransfer(tx.Model(entity.RequestTransfer{})).Where("id = ?", id).First(&request)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &request, nil
 }
 
-func (r *PostgreSQLRequestTransferRepository) GetDB() *gorm.DB {
-	return r.db
+func (r *PostgreSQLRequestTransferRepository) GetApprovalStepsByCompanyId(companyId int64) ([]*entity.TransferApprovalSteps, error) {
+	var steps []*entity.TransferApprovalSteps
+	result := r.db.Model(entity.TransferApprovalSteps{}).Where("company_id = ?", companyId).Order("step_order ASC").Find(&steps)
+	return steps, result.Error
 }
 
-func (r *PostgreSQLRequestTransferRepository) GetRequestTransferById(id int64) (*entity.RequestTransfer, error) {
-	request := entity.RequestTransfer{}
-	result := r.db.Model(entity.RequestTransfer{}).Where("id = ?", id).Preload("User").Preload("User.Department").Preload("Category").First(&request)
-	if result.Error != nil {
-		return nil, result.Error
+func (r *PostgreSQLRequestTransferRepository) ReplaceApprovalSteps(companyId int64, steps []*entity.TransferApprovalSteps, tx *gorm.DB) error {
+	if err := tx.Where("company_id = ?", companyId).Delete(&entity.TransferApprovalSteps{}).Error; err != nil {
+		return err
 	}
-	return &request, nil
+	if len(steps) == 0 {
+		return nil
+	}
+	return tx.Create(&steps).Error
 }
@@ -7,9 +7,16 @@ import (
 )
 
 type RequestTransferRepository interface {
-	Create(*entity.RequestTransfer) (*entity.RequestTransfer, error)
-	UpdateStatusConfirm(id int64, tx *gorm.DB) (*entity.RequestTransfer, error)
-	UpdateStatusDeny(id int64, tx *gorm.DB) (*entity.RequestTransfer, error)
+	Create(requestTransfer *entity.RequestTransfer, tx *gorm.DB) (*entity.RequestTransfer, error)
+	UpdateStatus(id int64, status string, tx *gorm.DB) error
+	UpdateAsset(id int64, assetId int64, tx *gorm.DB) error
+	UpdateCurrentStep(id int64, step int, tx *gorm.DB) error
+	UpdateApproval(approval *entity.RequestTransferApprovals, tx *gorm.DB) error
+	SkipPendingApprovals(requestTransferId int64, tx *gorm.DB) error
+	CreateLog(log *entity.RequestTransferLogs, tx *gorm.DB) error
 	GetDB() *gorm.DB
 	GetRequestTransferById(id int64) (*entity.RequestTransfer, error)
+	GetRequestTransferForUpdate(id int64, tx *gorm.DB) (*entity.RequestTransfer, error)
+	GetApprovalStepsByCompanyId(companyId int64) ([]*entity.TransferApprovalSteps, error)
+	ReplaceApprovalSteps(companyId int64, steps []*entity.TransferApprovalSteps, tx *gorm.DB) error
 }
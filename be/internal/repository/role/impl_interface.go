@@ -2,8 +2,10 @@ package repository
 
 import (
 	"BE_Manage_device/internal/domain/entity"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostgreSQLRoleRepository struct {
@@ -40,7 +42,7 @@ func (r *PostgreSQLRoleRepository) GetSlugByRoleId(id int64) string {
 
 func (r *PostgreSQLRoleRepository) GetRoleBySlug(slug string) *entity.Roles {
 	roles := entity.Roles{}
-	r.db.Model(entity.Roles{}).Where("slug = ?", slug).Find(&roles)
+	r.db.Model(entity.Roles{}).Where("slug = ? and company_id is null", slug).Find(&roles)
 	return &roles
 }
 
@@ -49,3 +51,119 @@ func (r *PostgreSQLRoleRepository) GetAllRole() []*entity.Roles {
 	r.db.Model(entity.Roles{}).Find(&roles)
 	return roles
 }
+
+// GetRoleBySlugOfCompany tìm role hệ thống hoặc role riêng của công ty theo slug
+func (r *PostgreSQLRoleRepository) GetRoleBySlugOfCompany(slug string, companyId int64) (*entity.Roles, error) {
+	role := &entity.Roles{}
+	result := r.db.Model(entity.Roles{}).Where("slug = ? and (company_id is null or company_id = ?)", slug, companyId).Preload("RolePermissions.Permission").First(role)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return role, nil
+}
+
+func (r *PostgreSQLRoleRepository) GetRolesOfCompany(companyId int64) ([]*entity.Roles, error) {
+	roles := []*entity.Roles{}
+	result := r.db.Model(entity.Roles{}).Where("company_id is null or company_id = ?", companyId).Preload("RolePermissions.Permission").Order("company_id NULLS FIRST, id").Find(&roles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return roles, nil
+}
+
+func (r *PostgreSQLRoleRepository) GetRoleById(id int64) (*entity.Roles, error) {
+	role := &entity.Roles{}
+	result := r.db.Model(entity.Roles{}).Where("id = ?", id).Preload("RolePermissions.Permission").First(role)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return role, nil
+}
+
+func (r *PostgreSQLRoleRepository) Create(role *entity.Roles, tx *gorm.DB) error {
+	result := tx.Create(role)
+	return result.Error
+}
+
+func (r *PostgreSQLRoleRepository) Update(role *entity.Roles, tx *gorm.DB) error {
+	result := tx.Model(&entity.Roles{}).Where("id = ?", role.Id).Updates(map[string]interface{}{
+		"title":       role.Title,
+		"description": role.Description,
+		"activated":   role.Activated,
+		"updated_at":  role.Updated_at,
+	})
+	return result.Error
+}
+
+func (r *PostgreSQLRoleRepository) Delete(id int64, tx *gorm.DB) error {
+	if err := tx.Where("role_id = ?", id).Delete(&entity.RolePermission{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id = ?", id).Delete(&entity.Roles{}).Error
+}
+
+func (r *PostgreSQLRoleRepository) CountUsersOfRole(roleId int64, tx *gorm.DB) (int64, error) {
+	var count int64
+	result := tx.Model(entity.Users{}).Where("role_id = ?", roleId).Count(&count)
+	return count, result.Error
+}
+
+// LockRole khoá dòng role tới hết tx, để gán role và xoá role không chạy song song
+func (r *PostgreSQLRoleRepository) LockRole(id int64, tx *gorm.DB) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", id).Take(&entity.Roles{}).Error
+}
+
+func (r *PostgreSQLRoleRepository) UpsertRolePermission(rolePermission *entity.RolePermission, tx *gorm.DB) error {
+	now := time.Now()
+	rolePermission.Updated_at = &now
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role_id"}, {Name: "permission_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"access_level", "updated_at"}),
+	}).Create(rolePermission)
+	return result.Error
+}
+
+func (r *PostgreSQLRoleRepository) DeleteRolePermission(roleId, permissionId int64, tx *gorm.DB) error {
+	result := tx.Where("role_id = ? and permission_id = ?", roleId, permissionId).Delete(&entity.RolePermission{})
+	return result.Error
+}
+
+// CountUserManagementHolders đếm user đang hoạt động có quyền user-management full trong công ty, chạy trong tx để thấy thay đổi chưa commit.
+// Khoá dòng company trước khi đếm để 2 tx cùng bỏ quyền của 2 người cuối cùng không cùng thấy người kia còn quyền
+func (r *PostgreSQLRoleRepository) CountUserManagementHolders(companyId int64, excludeUserId int64, tx *gorm.DB) (int64, error) {
+	var count int64
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", companyId).Take(&entity.Company{}).Error; err != nil {
+		return 0, err
+	}
+	result := tx.Model(entity.Users{}).
+		Joins("join roles on roles.id = users.role_id").
+		Joins("join role_permissions on role_permissions.role_id = roles.id").
+		Joins("join permissions on permissions.id = role_permissions.permission_id").
+		Where("users.company_id = ? and users.is_active = ? and users.id != ?", companyId, true, excludeUserId).
+		Where("roles.activated = ? and permissions.slug = ? and role_permissions.access_level = ?", true, "user-management", entity.AccessLevelFull).
+		Distinct("users.id").
+		Count(&count)
+	return count, result.Error
+}
+
+func (r *PostgreSQLRoleRepository) GetAllPermissions() ([]*entity.Permission, error) {
+	permissions := []*entity.Permission{}
+	result := r.db.Model(entity.Permission{}).Order("id").Find(&permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return permissions, nil
+}
+
+func (r *PostgreSQLRoleRepository) GetPermissionById(id int64) (*entity.Permission, error) {
+	permission := &entity.Permission{}
+	result := r.db.Model(entity.Permission{}).Where("id = ?", id).First(permission)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return permission, nil
+}
+
+func (r *PostgreSQLRoleRepository) GetDB() *gorm.DB {
+	return r.db
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "BE_Manage_device/internal/domain/entity"
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
 
 type RoleRepository interface {
 	GetAllUserByRoleId(roleId int64) []*entity.Users
@@ -8,4 +12,18 @@ type RoleRepository interface {
 	GetSlugByRoleId(id int64) string
 	GetRoleBySlug(roleSlug string) *entity.Roles
 	GetAllRole() []*entity.Roles
+	GetRoleBySlugOfCompany(slug string, companyId int64) (*entity.Roles, error)
+	GetRolesOfCompany(companyId int64) ([]*entity.Roles, error)
+	GetRoleById(id int64) (*entity.Roles, error)
+	Create(role *entity.Roles, tx *gorm.DB) error
+	Update(role *entity.Roles, tx *gorm.DB) error
+	Delete(id int64, tx *gorm.DB) error
+	CountUsersOfRole(roleId int64, tx *gorm.DB) (int64, error)
+	LockRole(id int64, tx *gorm.DB) error
+	UpsertRolePermission(rolePermission *entity.RolePermission, tx *gorm.DB) error
+	DeleteRolePermission(roleId, permissionId int64, tx *gorm.DB) error
+	CountUserManagementHolders(companyId int64, excludeUserId int64, tx *gorm.DB) (int64, error)
+	GetAllPermissions() ([]*entity.Permission, error)
+	GetPermissionById(id int64) (*entity.Permission, error)
+	GetDB() *gorm.DB
 }
@@ -15,7 +15,7 @@ func NewPostgreSQLUserRepository(db *gorm.DB) UserRepository {
 	return &PostgreSQLUserRepository{db: db}
 }
 
-func (r *PostgreSQLUserRepository) Create(users *entity.Users) error {
+func (r *PostgreSQLUserRepository) Create(users *entity.Users, tx *gorm.DB) error {
 	if users.FirstName == "" {
 		return errors.New("name can't not blank")
 	}
@@ -25,7 +25,7 @@ func (r *PostgreSQLUserRepository) Create(users *entity.Users) error {
 	if users.Password == "" {
 		return errors.New("password can't not blank")
 	}
-	if err := r.db.Create(users).Error; err != nil {
+	if err := tx.Create(users).Error; err != nil {
 		return err
 	}
 	return nil
@@ -68,8 +68,13 @@ func (r *PostgreSQLUserRepository) FindByUserId(userId int64) (*entity.Users, er
 	return users, nil
 }
 
-func (r *PostgreSQLUserRepository) DeleteUser(email string) error {
-	result := r.db.Where("email = ?", email).Delete(&entity.Users{})
+func (r *PostgreSQLUserRepository) DeleteUser(email string, companyId int64, tx *gorm.DB) error {
+	result := tx.Where("email = ? and company_id = ?", email, companyId).Delete(&entity.Users{})
+	return result.Error
+}
+
+func (r *PostgreSQLUserRepository) UpdateRole(userId int64, roleId int64, tx *gorm.DB) error {
+	result := tx.Model(entity.Users{}).Where("id = ?", userId).Update("role_id", roleId)
 	return result.Error
 }
 
@@ -101,6 +106,9 @@ func (r *PostgreSQLUserRepository) UpdateUser(user *entity.Users) (*entity.Users
 	if user.Avatar != "" {
 		updates["avatar"] = user.Avatar
 	}
+	if user.Language != "" {
+		updates["language"] = user.Language
+	}
 	err := r.db.Model(&userUpdate).Where("id = ?", user.Id).Updates(updates).Error
 	if err != nil {
 		return nil, err
@@ -220,6 +228,24 @@ func (r *PostgreSQLUserRepository) GetUserRoleAdmin() ([]*entity.Users, error) {
 	return users, nil
 }
 
+func (r *PostgreSQLUserRepository) GetUserRoleAdminOfCompany(companyId int64) ([]*entity.Users, error) {
+	var users []*entity.Users
+	result := r.db.Model(entity.Users{}).Where("company_id = ? and role_id = (select id from roles where slug = ?)", companyId, "admin").Preload("Role").Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
+func (r *PostgreSQLUserRepository) GetAllAssetManagerOfCompany(companyId int64) ([]*entity.Users, error) {
+	var users []*entity.Users
+	result := r.db.Model(entity.Users{}).Where("company_id = ? and is_asset_manager = true", companyId).Preload("Role").Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
 func (r *PostgreSQLUserRepository) FindManager(userId int64) (*entity.Users, error) {
 	var user entity.Users
 	result := r.db.Model(entity.Users{}).Where("id = ?", userId).First(&user)
@@ -7,14 +7,15 @@ import (
 )
 
 type UserRepository interface {
-	Create(user *entity.Users) error
+	Create(user *entity.Users, tx *gorm.DB) error
 	FindByToken(token string) (*entity.Users, error)
 	Update(user *entity.Users) error
 	UpdatePassword(user *entity.Users) error
 	FindByEmail(email string) (*entity.Users, error)
 	FindByEmailForLogin(email string) (*entity.Users, error)
 	FindByUserId(userId int64) (*entity.Users, error)
-	DeleteUser(email string) error
+	DeleteUser(email string, companyId int64, tx *gorm.DB) error
+	UpdateRole(userId int64, roleId int64, tx *gorm.DB) error
 	GetDB() *gorm.DB
 	GetAllUser(companyId int64) []*entity.Users
 	UpdateUser(user *entity.Users) (*entity.Users, error)
@@ -30,5 +31,7 @@ type UserRepository interface {
 	UpdateCanExport(id int64, canExport bool) error
 	GetUserNotHaveDep() ([]*entity.Users, error)
 	GetUserRoleAdmin() ([]*entity.Users, error)
+	GetUserRoleAdminOfCompany(companyId int64) ([]*entity.Users, error)
+	GetAllAssetManagerOfCompany(companyId int64) ([]*entity.Users, error)
 	FindManager(userId int64) (*entity.Users, error)
 }
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PostgreSQLUserMfaRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLUserMfaRepository(db *gorm.DB) UserMfaRepository {
+	return &PostgreSQLUserMfaRepository{db: db}
+}
+
+func (r *PostgreSQLUserMfaRepository) GetByUserId(userId int64) (*entity.UserMfa, error) {
+	var mfa entity.UserMfa
+	result := r.db.Model(entity.UserMfa{}).Where("user_id = ?", userId).First(&mfa)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &mfa, nil
+}
+
+// Save ghi đè secret khi user enroll lại (chưa xác nhận)
+func (r *PostgreSQLUserMfaRepository) Save(mfa *entity.UserMfa, tx *gorm.DB) error {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "confirmed_at", "last_used_step", "failed_attempts", "locked_until", "created_at"}),
+	}).Create(mfa)
+	return result.Error
+}
+
+func (r *PostgreSQLUserMfaRepository) Enable(userId int64, confirmedAt time.Time, lastUsedStep int64, tx *gorm.DB) error {
+	result := tx.Model(entity.UserMfa{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"enabled":         true,
+		"confirmed_at":    confirmedAt,
+		"last_used_step":  lastUsedStep,
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	})
+	return result.Error
+}
+
+// UpdateLastUsedStep chỉ ghi khi step mới hơn step đã dùng và tài khoản chưa bị khoá,
+// false nghĩa là mã đã bị dùng hoặc request sai song song vừa khoá tài khoản
+func (r *PostgreSQLUserMfaRepository) UpdateLastUsedStep(userId int64, step int64, now time.Time) (bool, error) {
+	result := r.db.Model(entity.UserMfa{}).Where("user_id = ? and last_used_step < ? and (locked_until is null or locked_until <= ?)", userId, step, now).Updates(map[string]interface{}{
+		"last_used_step":  step,
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *PostgreSQLUserMfaRepository) ResetFailures(userId int64) error {
+	result := r.db.Model(entity.UserMfa{}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"failed_attempts": 0,
+		"locked_until":    nil,
+	})
+	return result.Error
+}
+
+// RecordFailure tăng số lần sai ngay trong SQL để các request sai song song không ghi đè nhau,
+// lần sai thứ maxAttempts khoá tới lockedUntil và đếm lại từ 0. Trả về locked_until của dòng sau khi cập nhật
+func (r *PostgreSQLUserMfaRepository) RecordFailure(userId int64, maxAttempts int, lockedUntil time.Time) (*time.Time, error) {
+	mfa := entity.UserMfa{}
+	result := r.db.Model(&mfa).Clauses(clause.Returning{Columns: []clause.Column{{Name: "locked_until"}}}).Where("user_id = ?", userId).Updates(map[string]interface{}{
+		"failed_attempts": gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN 0 ELSE failed_attempts + 1 END", maxAttempts),
+		"locked_until":    gorm.Expr("CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_until END", maxAttempts, lockedUntil),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mfa.LockedUntil, nil
+}
+
+func (r *PostgreSQLUserMfaRepository) Delete(userId int64, tx *gorm.DB) error {
+	if err := tx.Where("user_id = ?", userId).Delete(&entity.UserMfaRecoveryCodes{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userId).Delete(&entity.UserMfa{}).Error
+}
+
+func (r *PostgreSQLUserMfaRepository) ReplaceRecoveryCodes(userId int64, codeHashes []string, tx *gorm.DB) error {
+	if err := tx.Where("user_id = ?", userId).Delete(&entity.UserMfaRecoveryCodes{}).Error; err != nil {
+		return err
+	}
+	codes := make([]entity.UserMfaRecoveryCodes, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, entity.UserMfaRecoveryCodes{UserId: userId, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
+
+func (r *PostgreSQLUserMfaRepository) GetUnusedRecoveryCodes(userId int64) ([]*entity.UserMfaRecoveryCodes, error) {
+	var codes []*entity.UserMfaRecoveryCodes
+	result := r.db.Model(entity.UserMfaRecoveryCodes{}).Where("user_id = ? and used_at is null", userId).Find(&codes)
+	return codes, result.Error
+}
+
+// MarkRecoveryCodeUsed trả về false nếu mã đã bị dùng bởi request khác
+func (r *PostgreSQLUserMfaRepository) MarkRecoveryCodeUsed(id int64) (bool, error) {
+	result := r.db.Model(entity.UserMfaRecoveryCodes{}).Where("id = ? and used_at is null", id).Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *PostgreSQLUserMfaRepository) GetDB() *gorm.DB {
+	return r.db
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserMfaRepository interface {
+	GetByUserId(userId int64) (*entity.UserMfa, error)
+	Save(mfa *entity.UserMfa, tx *gorm.DB) error
+	Enable(userId int64, confirmedAt time.Time, lastUsedStep int64, tx *gorm.DB) error
+	UpdateLastUsedStep(userId int64, step int64, now time.Time) (bool, error)
+	ResetFailures(userId int64) error
+	RecordFailure(userId int64, maxAttempts int, lockedUntil time.Time) (*time.Time, error)
+	Delete(userId int64, tx *gorm.DB) error
+	ReplaceRecoveryCodes(userId int64, codeHashes []string, tx *gorm.DB) error
+	GetUnusedRecoveryCodes(userId int64) ([]*entity.UserMfaRecoveryCodes, error)
+	MarkRecoveryCodeUsed(id int64) (bool, error)
+	GetDB() *gorm.DB
+}
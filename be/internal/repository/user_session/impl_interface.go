@@ -2,7 +2,7 @@ package repository
 
 import (
 	"BE_Manage_device/internal/domain/entity"
-	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -29,28 +29,89 @@ func (r *PostgreSQLUserSessionRepository) FindByRefreshToken(refreshToken string
 	return userSession, nil
 }
 
-func (r *PostgreSQLUserSessionRepository) UpdateIsRevoked(user *entity.UsersSessions) error {
-	result := r.db.Model(&entity.UsersSessions{}).Where("id = ?", user.Id).Update("is_revoked", true)
-	return result.Error
+func (r *PostgreSQLUserSessionRepository) FindByAccessToken(accessToken string) (*entity.UsersSessions, error) {
+	var userSession = &entity.UsersSessions{}
+	result := r.db.Model(&entity.UsersSessions{}).Where("access_token = ?", accessToken).First(userSession)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userSession, nil
 }
 
-func (r *PostgreSQLUserSessionRepository) CheckUserInSession(userId int64) bool {
-	var userSessions = &entity.UsersSessions{}
-	result := r.db.Model(&entity.UsersSessions{}).Where("user_id = ? and is_revoked = ?", userId, false).First(userSessions)
-	return !errors.Is(result.Error, gorm.ErrRecordNotFound)
+func (r *PostgreSQLUserSessionRepository) FindById(id int64) (*entity.UsersSessions, error) {
+	var userSession = &entity.UsersSessions{}
+	result := r.db.Model(&entity.UsersSessions{}).Where("id = ?", id).First(userSession)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userSession, nil
 }
 
-func (r *PostgreSQLUserSessionRepository) FindByUserIdInSession(userId int64) (*entity.UsersSessions, error) {
-	var userSessions = &entity.UsersSessions{}
-	result := r.db.Model(&entity.UsersSessions{}).Where("user_id = ? and is_revoked = ?", userId, false).First(userSessions)
+func (r *PostgreSQLUserSessionRepository) GetActiveByUserId(userId int64) ([]*entity.UsersSessions, error) {
+	var userSessions = []*entity.UsersSessions{}
+	result := r.db.Model(&entity.UsersSessions{}).Where("user_id = ? and is_revoked = ? and expires_at > ?", userId, false, time.Now()).Order("last_seen_at DESC NULLS LAST, id DESC").Find(&userSessions)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return userSessions, nil
 }
 
-func (r *PostgreSQLUserSessionRepository) CheckTokenWasInVoked(accessToken string) bool {
-	var userSessions = &entity.UsersSessions{}
-	r.db.Model(&entity.UsersSessions{}).Where("access_token = ?", accessToken).First(userSessions)
-	return userSessions.IsRevoked
+// Rotate chỉ cập nhật khi refresh token hiện tại vẫn là oldRefreshToken, trả về false nếu đã bị request khác rotate trước
+func (r *PostgreSQLUserSessionRepository) Rotate(usersSessions *entity.UsersSessions, oldRefreshToken string, usedTokenHash string, tx *gorm.DB) (bool, error) {
+	result := tx.Model(&entity.UsersSessions{}).Where("id = ? and refresh_token = ? and is_revoked = ?", usersSessions.Id, oldRefreshToken, false).Updates(map[string]interface{}{
+		"refresh_token": usersSessions.RefreshToken,
+		"access_token":  usersSessions.AccessToken,
+		"expires_at":    usersSessions.ExpiresAt,
+		"last_seen_at":  usersSessions.LastSeenAt,
+		"ip_address":    usersSessions.IpAddress,
+		"user_agent":    usersSessions.UserAgent,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	usedToken := &entity.UsedRefreshTokens{
+		SessionId: usersSessions.Id,
+		TokenHash: usedTokenHash,
+		UsedAt:    time.Now(),
+	}
+	if err := tx.Create(usedToken).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PostgreSQLUserSessionRepository) FindSessionIdByUsedToken(tokenHash string) (int64, error) {
+	var usedToken = &entity.UsedRefreshTokens{}
+	result := r.db.Model(&entity.UsedRefreshTokens{}).Where("token_hash = ?", tokenHash).First(usedToken)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return usedToken.SessionId, nil
+}
+
+// Revoke huỷ cả session (token family), các token cũ không cần giữ lại nữa
+func (r *PostgreSQLUserSessionRepository) Revoke(id int64, tx *gorm.DB) error {
+	result := tx.Model(&entity.UsersSessions{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"is_revoked": true,
+		"revoked_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	return tx.Where("session_id = ?", id).Delete(&entity.UsedRefreshTokens{}).Error
+}
+
+func (r *PostgreSQLUserSessionRepository) UpdateLastSeen(id int64, lastSeenAt time.Time, ipAddress string) error {
+	result := r.db.Model(&entity.UsersSessions{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_seen_at": lastSeenAt,
+		"ip_address":   ipAddress,
+	})
+	return result.Error
+}
+
+func (r *PostgreSQLUserSessionRepository) GetDB() *gorm.DB {
+	return r.db
 }
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"BE_Manage_device/internal/domain/entity"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -9,8 +10,12 @@ import (
 type UsersSessionRepository interface {
 	Create(usersSessions *entity.UsersSessions, tx *gorm.DB) error
 	FindByRefreshToken(refreshToken string) (*entity.UsersSessions, error)
-	UpdateIsRevoked(user *entity.UsersSessions) error
-	CheckUserInSession(userId int64) bool
-	FindByUserIdInSession(UserId int64) (*entity.UsersSessions, error)
-	CheckTokenWasInVoked(accessToken string) bool
+	FindByAccessToken(accessToken string) (*entity.UsersSessions, error)
+	FindById(id int64) (*entity.UsersSessions, error)
+	GetActiveByUserId(userId int64) ([]*entity.UsersSessions, error)
+	Rotate(usersSessions *entity.UsersSessions, oldRefreshToken string, usedTokenHash string, tx *gorm.DB) (bool, error)
+	FindSessionIdByUsedToken(tokenHash string) (int64, error)
+	Revoke(id int64, tx *gorm.DB) error
+	UpdateLastSeen(id int64, lastSeenAt time.Time, ipAddress string) error
+	GetDB() *gorm.DB
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PostgreSQLWebhooksRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgreSQLWebhooksRepository(db *gorm.DB) WebhooksRepository {
+	return &PostgreSQLWebhooksRepository{db: db}
+}
+
+func (r *PostgreSQLWebhooksRepository) Create(webhook *entity.Webhooks, tx *gorm.DB) error {
+	return tx.Create(webhook).Error
+}
+
+func (r *PostgreSQLWebhooksRepository) Update(webhook *entity.Webhooks, tx *gorm.DB) error {
+	return tx.Save(webhook).Error
+}
+
+func (r *PostgreSQLWebhooksRepository) Delete(id int64, tx *gorm.DB) error {
+	var deliveryIds []int64
+	if err := tx.Model(&entity.WebhookDeliveries{}).Where("webhook_id = ?", id).Pluck("id", &deliveryIds).Error; err != nil {
+		return err
+	}
+	if len(deliveryIds) > 0 {
+		if err := tx.Where("delivery_id IN ?", deliveryIds).Delete(&entity.WebhookDeliveryAttempts{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("webhook_id = ?", id).Delete(&entity.WebhookDeliveries{}).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&entity.Webhooks{}, id).Error
+}
+
+func (r *PostgreSQLWebhooksRepository) GetById(id int64) (*entity.Webhooks, error) {
+	var webhook = &entity.Webhooks{}
+	result := r.db.Model(&entity.Webhooks{}).Where("id = ?", id).First(webhook)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return webhook, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) GetByCompanyId(companyId int64) ([]*entity.Webhooks, error) {
+	var webhooks = []*entity.Webhooks{}
+	result := r.db.Model(&entity.Webhooks{}).Where("company_id = ?", companyId).Order("id").Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return webhooks, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) GetActiveByCompanyId(companyId int64, tx *gorm.DB) ([]*entity.Webhooks, error) {
+	var webhooks = []*entity.Webhooks{}
+	result := tx.Model(&entity.Webhooks{}).Where("company_id = ? and active = ?", companyId, true).Find(&webhooks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return webhooks, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) CreateDelivery(delivery *entity.WebhookDeliveries, tx *gorm.DB) error {
+	return tx.Create(delivery).Error
+}
+
+// ClaimDueDeliveries lấy các delivery tới hạn và đẩy next_attempt_at ra sau lease để instance khác không gửi trùng
+func (r *PostgreSQLWebhooksRepository) ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDeliveries, error) {
+	var deliveries = []*entity.WebhookDeliveries{}
+	result := r.db.Raw(`UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), entity.WebhookDeliveryPending, now, limit).Scan(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) UpdateDelivery(delivery *entity.WebhookDeliveries, tx *gorm.DB) error {
+	return tx.Model(&entity.WebhookDeliveries{}).Where("id = ?", delivery.Id).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"response_code":   delivery.ResponseCode,
+		"latency_ms":      delivery.LatencyMs,
+		"last_error":      delivery.LastError,
+		"delivered_at":    delivery.DeliveredAt,
+	}).Error
+}
+
+func (r *PostgreSQLWebhooksRepository) CreateAttempt(attempt *entity.WebhookDeliveryAttempts, tx *gorm.DB) error {
+	return tx.Create(attempt).Error
+}
+
+func (r *PostgreSQLWebhooksRepository) GetDeliveryById(id int64) (*entity.WebhookDeliveries, error) {
+	var delivery = &entity.WebhookDeliveries{}
+	result := r.db.Model(&entity.WebhookDeliveries{}).Where("id = ?", id).
+		Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		First(delivery)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return delivery, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) GetDeliveriesOfWebhook(webhookId int64, limit int) ([]*entity.WebhookDeliveries, error) {
+	var deliveries = []*entity.WebhookDeliveries{}
+	result := r.db.Model(&entity.WebhookDeliveries{}).Where("webhook_id = ?", webhookId).
+		Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB { return db.Order("attempt") }).
+		Order("id DESC").Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
+
+func (r *PostgreSQLWebhooksRepository) GetDB() *gorm.DB {
+	return r.db
+}
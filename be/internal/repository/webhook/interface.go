@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhooksRepository interface {
+	Create(webhook *entity.Webhooks, tx *gorm.DB) error
+	Update(webhook *entity.Webhooks, tx *gorm.DB) error
+	Delete(id int64, tx *gorm.DB) error
+	GetById(id int64) (*entity.Webhooks, error)
+	GetByCompanyId(companyId int64) ([]*entity.Webhooks, error)
+	GetActiveByCompanyId(companyId int64, tx *gorm.DB) ([]*entity.Webhooks, error)
+	CreateDelivery(delivery *entity.WebhookDeliveries, tx *gorm.DB) error
+	ClaimDueDeliveries(now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDeliveries, error)
+	UpdateDelivery(delivery *entity.WebhookDeliveries, tx *gorm.DB) error
+	CreateAttempt(attempt *entity.WebhookDeliveryAttempts, tx *gorm.DB) error
+	GetDeliveryById(id int64) (*entity.WebhookDeliveries, error)
+	GetDeliveriesOfWebhook(webhookId int64, limit int) ([]*entity.WebhookDeliveries, error)
+	GetDB() *gorm.DB
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	apiToken "BE_Manage_device/internal/repository/api_token"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const apiTokenPrefixLength = 12
+
+var (
+	ErrApiTokenNotFound      = errors.New("api token not found")
+	ErrApiTokenNotAllowed    = errors.New("api tokens cannot manage api tokens")
+	ErrApiTokenScopeRequired = errors.New("at least one scope is required")
+)
+
+type ApiTokenService struct {
+	repo apiToken.ApiTokensRepository
+}
+
+func NewApiTokenService(repo apiToken.ApiTokensRepository) *ApiTokenService {
+	return &ApiTokenService{repo: repo}
+}
+
+// Create sinh token mới cho user, scopes phải nằm trong quyền hiện tại của role. Token gốc chỉ trả về 1 lần
+func (service *ApiTokenService) Create(auth *policy.AuthContext, name string, scopes []string, expiresInDays int) (*entity.ApiTokens, string, error) {
+	var err error
+	if auth.ApiTokenId != 0 {
+		return nil, "", ErrApiTokenNotAllowed
+	}
+	uniqueScopes := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || slices.Contains(uniqueScopes, scope) {
+			continue
+		}
+		if _, ok := auth.Level(scope); !ok {
+			return nil, "", fmt.Errorf("permission %q is not granted to your role", scope)
+		}
+		uniqueScopes = append(uniqueScopes, scope)
+	}
+	if len(uniqueScopes) == 0 {
+		return nil, "", ErrApiTokenScopeRequired
+	}
+	rawToken, err := utils.GenerateApiToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	token := &entity.ApiTokens{
+		UserId:    auth.UserId,
+		CompanyId: auth.CompanyId,
+		Name:      strings.TrimSpace(name),
+		Prefix:    rawToken[:apiTokenPrefixLength],
+		TokenHash: utils.HashToken(rawToken),
+		Scopes:    uniqueScopes,
+		ExpiresAt: now.AddDate(0, 0, expiresInDays),
+		CreatedAt: now,
+	}
+	if err = service.repo.Create(token, service.repo.GetDB()); err != nil {
+		return nil, "", err
+	}
+	return token, rawToken, nil
+}
+
+func (service *ApiTokenService) GetMyTokens(userId int64) ([]*entity.ApiTokens, error) {
+	return service.repo.GetByUserId(userId)
+}
+
+func (service *ApiTokenService) RevokeMyToken(auth *policy.AuthContext, tokenId int64) error {
+	if auth.ApiTokenId != 0 {
+		return ErrApiTokenNotAllowed
+	}
+	token, err := service.repo.GetById(tokenId)
+	if err != nil || token.UserId != auth.UserId {
+		return ErrApiTokenNotFound
+	}
+	return service.repo.Revoke(token.Id, auth.UserId, service.repo.GetDB())
+}
+
+// GetCompanyTokens cho admin xem token của mọi user trong công ty
+func (service *ApiTokenService) GetCompanyTokens(auth *policy.AuthContext) ([]*entity.ApiTokens, error) {
+	return service.repo.GetByCompanyId(auth.CompanyId)
+}
+
+func (service *ApiTokenService) RevokeCompanyToken(auth *policy.AuthContext, tokenId int64) error {
+	token, err := service.repo.GetById(tokenId)
+	if err != nil || token.CompanyId != auth.CompanyId {
+		return ErrApiTokenNotFound
+	}
+	return service.repo.Revoke(token.Id, auth.UserId, service.repo.GetDB())
+}
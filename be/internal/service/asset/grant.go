@@ -0,0 +1,119 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// getAssetOfCompany trả về tài sản nếu cùng công ty với user
+func (service *AssetsService) getAssetOfCompany(userId, assetId int64) (*entity.Users, *entity.Assets, error) {
+	users, err := service.userRepository.FindByUserId(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	assets, err := service.repo.GetAssetById(assetId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if assets.CompanyId != users.CompanyId {
+		return nil, nil, ErrAssetNotVisible
+	}
+	return users, assets, nil
+}
+
+func (service *AssetsService) GetAssetGrants(userId, assetId int64) ([]*entity.AssetPermissionGrants, error) {
+	if _, _, err := service.getAssetOfCompany(userId, assetId); err != nil {
+		return nil, err
+	}
+	return service.grantRepository.GetByAssetId(assetId)
+}
+
+// CreateAssetGrant cấp quyền riêng trên tài sản cho user không có quyền đó qua role/phòng ban
+func (service *AssetsService) CreateAssetGrant(userId, assetId, grantUserId int64, permissionSlug string) ([]*entity.AssetPermissionGrants, error) {
+	var err error
+	users, assets, err := service.getAssetOfCompany(userId, assetId)
+	if err != nil {
+		return nil, err
+	}
+	if permissionSlug == "" {
+		permissionSlug = policy.PermissionViewAssets
+	}
+	permissions, err := service.roleRepository.GetAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, p := range permissions {
+		if p.Slug == permissionSlug {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("permission %s not found", permissionSlug)
+	}
+	grantUser, err := service.userRepository.FindByUserId(grantUserId)
+	if err != nil {
+		return nil, err
+	}
+	if grantUser.CompanyId != users.CompanyId {
+		return nil, errors.New("user not found")
+	}
+	tx := service.grantRepository.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	grant := &entity.AssetPermissionGrants{
+		CompanyId:      assets.CompanyId,
+		AssetId:        assets.Id,
+		UserId:         grantUserId,
+		PermissionSlug: permissionSlug,
+		GrantedBy:      &userId,
+		CreatedAt:      time.Now(),
+	}
+	if err = service.grantRepository.Create(grant, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.grantRepository.GetByAssetId(assetId)
+}
+
+func (service *AssetsService) DeleteAssetGrant(userId, assetId, grantId int64) error {
+	var err error
+	if _, _, err = service.getAssetOfCompany(userId, assetId); err != nil {
+		return err
+	}
+	grant, err := service.grantRepository.GetById(grantId)
+	if err != nil {
+		return err
+	}
+	if grant.AssetId != assetId {
+		return errors.New("grant not found")
+	}
+	tx := service.grantRepository.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.grantRepository.Delete(grantId, tx); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	asset_log "BE_Manage_device/internal/repository/asset_log"
+	asset "BE_Manage_device/internal/repository/assets"
+	assignment "BE_Manage_device/internal/repository/assignments"
+	categories "BE_Manage_device/internal/repository/categories"
+	department "BE_Manage_device/internal/repository/departments"
+	user "BE_Manage_device/internal/repository/user"
+	"BE_Manage_device/pkg/storage"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// txPool cho phép Begin/Commit/Rollback mà không cần database, các repository fake không chạy SQL
+type txPool struct{}
+
+func (*txPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+func (p *txPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+func (*txPool) Commit() error   { return nil }
+func (*txPool) Rollback() error { return nil }
+
+type fakeAssetRepo struct {
+	asset.AssetsRepository
+	db      *gorm.DB
+	serials []string
+	created []entity.Assets
+}
+
+func (r *fakeAssetRepo) GetDB() *gorm.DB { return r.db }
+
+func (r *fakeAssetRepo) GetAssetsBySerialNumbers(companyId int64, serialNumbers []string) ([]*entity.Assets, error) {
+	var result []*entity.Assets
+	for _, s := range serialNumbers {
+		for _, existing := range r.serials {
+			if s == existing {
+				result = append(result, &entity.Assets{SerialNumber: s, CompanyId: companyId})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeAssetRepo) Create(a *entity.Assets, tx *gorm.DB) (*entity.Assets, error) {
+	a.Id = int64(len(r.created) + 1)
+	r.created = append(r.created, *a)
+	return a, nil
+}
+
+type fakeAssetLogRepo struct{ asset_log.AssetsLogRepository }
+
+func (fakeAssetLogRepo) Create(l *entity.AssetLog, tx *gorm.DB) (*entity.AssetLog, error) {
+	return l, nil
+}
+
+type fakeAssignmentRepo struct {
+	assignment.AssignmentRepository
+}
+
+func (fakeAssignmentRepo) Create(a *entity.Assignments, tx *gorm.DB) (*entity.Assignments, error) {
+	return a, nil
+}
+
+type fakeCategoriesRepo struct {
+	categories.CategoriesRepository
+}
+
+func (fakeCategoriesRepo) GetAll(companyId int64) ([]*entity.Categories, error) {
+	return []*entity.Categories{{Id: 1, CategoryName: "Laptop"}}, nil
+}
+
+type fakeDepartmentsRepo struct {
+	department.DepartmentsRepository
+}
+
+func (fakeDepartmentsRepo) GetAll(companyId int64) ([]*entity.Departments, error) {
+	return []*entity.Departments{{Id: 10, DepartmentName: "IT"}}, nil
+}
+
+type fakeUserRepo struct{ user.UserRepository }
+
+func (fakeUserRepo) GetAllUser(companyId int64) []*entity.Users {
+	return nil
+}
+
+func (fakeUserRepo) GetUserAssetManageOfDepartment(departmentId int64) (*entity.Users, error) {
+	return &entity.Users{Id: 5, DepartmentId: &departmentId}, nil
+}
+
+// failingStorage làm GenQrAsync dừng sớm, QR không thuộc phạm vi test import
+type failingStorage struct{ storage.Storage }
+
+func (failingStorage) UploadReader(objectPath string, reader io.Reader, contentType string) (string, error) {
+	return "", errors.New("storage disabled in test")
+}
+
+func importFile(t *testing.T, content string) *multipart.FileHeader {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "assets.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	return req.MultipartForm.File["file"][0]
+}
+
+func TestImportAssetsMixedRows(t *testing.T) {
+	const file = "assetName,serialNumber,purchaseDate,warrantExpiry,cost,category,department\n" +
+		"Laptop 01,SN-1,2026-01-10,2028-01-10,1200,Laptop,IT\n" +
+		"Laptop 02,SN-OLD,2026-01-10,2028-01-10,1200,Laptop,IT\n" +
+		"Laptop 03,SN-3,2026-01-10,2028-01-10,abc,Laptop,IT\n" +
+		"Laptop 04,SN-4,2026-01-10,2028-01-10,900,Printer,IT\n" +
+		"Laptop 05,SN-5,2026-01-10,2028-01-10,800,Laptop,IT\n"
+	tests := []struct {
+		name         string
+		dryRun       bool
+		wantImported bool
+		wantCreated  []string
+	}{
+		{"dry run", true, false, nil},
+		// dòng lỗi không chặn các dòng hợp lệ
+		{"import valid rows", false, true, []string{"SN-1", "SN-5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: &txPool{}}), &gorm.Config{DisableAutomaticPing: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo := &fakeAssetRepo{db: db, serials: []string{"SN-OLD"}}
+			service := &AssetsService{
+				repo:                 repo,
+				assertLogRepository:  fakeAssetLogRepo{},
+				assignRepository:     fakeAssignmentRepo{},
+				categoriesRepository: fakeCategoriesRepo{},
+				departmentRepository: fakeDepartmentsRepo{},
+				userRepository:       fakeUserRepo{},
+				storage:              failingStorage{},
+			}
+			auth := policy.NewAuthContext(1, 1, nil, map[string]string{policy.PermissionManageAssets: entity.AccessLevelFull})
+			report, err := service.ImportAssets(auth, importFile(t, file), tt.dryRun, "http://localhost")
+			if err != nil {
+				t.Fatalf("ImportAssets: %v", err)
+			}
+			if report.TotalRows != 5 || report.ValidRows != 2 || report.InvalidRows != 3 || len(report.Errors) != 3 {
+				t.Errorf("report = %+v", report)
+			}
+			wantRows := []int{3, 4, 5}
+			for i, e := range report.Errors {
+				if e.Row != wantRows[i] {
+					t.Errorf("error %d on row %d, want %d", i, e.Row, wantRows[i])
+				}
+			}
+			if report.Imported != tt.wantImported {
+				t.Errorf("imported = %v, want %v", report.Imported, tt.wantImported)
+			}
+			if len(repo.created) != len(tt.wantCreated) || len(report.CreatedAssetIds) != len(tt.wantCreated) {
+				t.Fatalf("created %d assets, ids %v, want %v", len(repo.created), report.CreatedAssetIds, tt.wantCreated)
+			}
+			for i, a := range repo.created {
+				if a.SerialNumber != tt.wantCreated[i] || a.Owner == nil || *a.Owner != 5 {
+					t.Errorf("created %d = %s owner %v, want %s owner 5", i, a.SerialNumber, a.Owner, tt.wantCreated[i])
+				}
+			}
+		})
+	}
+}
@@ -6,16 +6,21 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
+	"BE_Manage_device/internal/domain/policy"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
+	assetGrant "BE_Manage_device/internal/repository/asset_permission_grant"
+	asset_usages "BE_Manage_device/internal/repository/asset_usages"
 	asset "BE_Manage_device/internal/repository/assets"
 	assignment "BE_Manage_device/internal/repository/assignments"
+	categories "BE_Manage_device/internal/repository/categories"
 	company "BE_Manage_device/internal/repository/company"
 	department "BE_Manage_device/internal/repository/departments"
 	role "BE_Manage_device/internal/repository/role"
 	user "BE_Manage_device/internal/repository/user"
-	userRBAC "BE_Manage_device/internal/repository/user_rbac"
 	notificationS "BE_Manage_device/internal/service/notification"
+	vendorS "BE_Manage_device/internal/service/vendor"
 	"BE_Manage_device/pkg"
+	"BE_Manage_device/pkg/storage"
 	"BE_Manage_device/pkg/utils"
 	"encoding/json"
 
@@ -30,25 +35,32 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+var ErrAssetNotVisible = errors.New("asset not found or you don't have permission to view it")
+
 type AssetsService struct {
 	repo                 asset.AssetsRepository
 	assertLogRepository  asset_log.AssetsLogRepository
 	roleRepository       role.RoleRepository
-	userRBACRepository   userRBAC.UserRBACRepository
+	grantRepository      assetGrant.AssetPermissionGrantsRepository
 	userRepository       user.UserRepository
 	assignRepository     assignment.AssignmentRepository
 	departmentRepository department.DepartmentsRepository
 	NotificationService  *notificationS.NotificationService
 	companyRepo          company.CompanyRepository
+	categoriesRepository categories.CategoriesRepository
+	storage              storage.Storage
+	vendorService        *vendorS.VendorService
+	usageRepository      asset_usages.AssetUsagesRepository
 }
 
-func NewAssetsService(repo asset.AssetsRepository, assertLogRepository asset_log.AssetsLogRepository, roleRepository role.RoleRepository, userRBACRepository userRBAC.UserRBACRepository, userRepository user.UserRepository, assignRepository assignment.AssignmentRepository, departmentRepository department.DepartmentsRepository, NotificationService *notificationS.NotificationService, companyRepo company.CompanyRepository) *AssetsService {
-	return &AssetsService{repo: repo, assertLogRepository: assertLogRepository, roleRepository: roleRepository, userRBACRepository: userRBACRepository, userRepository: userRepository, assignRepository: assignRepository, departmentRepository: departmentRepository, NotificationService: NotificationService, companyRepo: companyRepo}
+func NewAssetsService(repo asset.AssetsRepository, assertLogRepository asset_log.AssetsLogRepository, roleRepository role.RoleRepository, grantRepository assetGrant.AssetPermissionGrantsRepository, userRepository user.UserRepository, assignRepository assignment.AssignmentRepository, departmentRepository department.DepartmentsRepository, NotificationService *notificationS.NotificationService, companyRepo company.CompanyRepository, categoriesRepository categories.CategoriesRepository, storage storage.Storage, vendorService *vendorS.VendorService, usageRepository asset_usages.AssetUsagesRepository) *AssetsService {
+	return &AssetsService{repo: repo, assertLogRepository: assertLogRepository, roleRepository: roleRepository, grantRepository: grantRepository, userRepository: userRepository, assignRepository: assignRepository, departmentRepository: departmentRepository, NotificationService: NotificationService, companyRepo: companyRepo, categoriesRepository: categoriesRepository, storage: storage, vendorService: vendorService, usageRepository: usageRepository}
 }
 
-func (service *AssetsService) Create(userId int64, assetName string, purchaseDate time.Time, warrantExpiry time.Time, serialNumber string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, categoryId int64, departmentId int64, url string, cost float64) (*entity.Assets, error) {
+func (service *AssetsService) Create(userId int64, assetName string, purchaseDate time.Time, warrantExpiry time.Time, serialNumber string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, categoryId int64, departmentId int64, url string, cost float64, vendorId *int64) (*entity.Assets, error) {
 	imgFile, err := image.Open()
 	if err != nil {
 		return nil, fmt.Errorf("cannot open image: %w", err)
@@ -63,7 +75,6 @@ func (service *AssetsService) Create(userId int64, assetName string, purchaseDat
 	imagePath := "images/" + uniqueName
 	uniqueName = fmt.Sprintf("%d_%s", time.Now().UnixNano(), fileAttachment.Filename)
 	filePath := "files/" + uniqueName
-	uploader := utils.NewSupabaseUploader()
 	var (
 		wg      sync.WaitGroup
 		errChan = make(chan error, 2)
@@ -75,7 +86,7 @@ func (service *AssetsService) Create(userId int64, assetName string, purchaseDat
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		i, err := uploader.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
+		i, err := service.storage.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
 		if err != nil {
 			errChan <- err
 			cancel()
@@ -85,7 +96,7 @@ func (service *AssetsService) Create(userId int64, assetName string, purchaseDat
 	}()
 	go func() {
 		defer wg.Done()
-		f, err := uploader.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
+		f, err := service.storage.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
 		if err != nil {
 			errChan <- err
 			cancel()
@@ -135,6 +146,9 @@ func (service *AssetsService) Create(userId int64, assetName string, purchaseDat
 	if err, ok := <-errChan; ok {
 		return nil, err
 	}
+	if err = service.vendorService.CheckVendor(company.Id, vendorId); err != nil {
+		return nil, err
+	}
 	tx := service.repo.GetDB().Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -157,58 +171,73 @@ func (service *AssetsService) Create(userId int64, assetName string, purchaseDat
 		DepartmentId:   departmentId,
 		Owner:          &userAssetManager.Id,
 		CompanyId:      company.Id,
+		VendorId:       vendorId,
+	}
+	assetCreate, err := service.CreateInTx(tx, userId, &asset, "Create asset")
+	if err != nil {
+		return nil, err
 	}
-	assetCreate, err := service.repo.Create(&asset, tx)
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	service.GenQrAsync(assetCreate.Id, url)
+	return assetCreate, nil
+}
+
+// CreateInTx tạo asset trong tx kèm log "Create" và bản giao cho người giữ asset.Owner trong phòng ban của asset.
+// Dùng chung cho tạo tay, import và nhập kho từ đơn mua hàng
+func (service *AssetsService) CreateInTx(tx *gorm.DB, userId int64, asset *entity.Assets, changeSummary string) (*entity.Assets, error) {
+	assetCreate, err := service.repo.Create(asset, tx)
 	if err != nil {
 		return nil, err
 	}
-	changeSummary := "Create asset"
 	assetLog := entity.AssetLog{
 		Action:        "Create",
+		Event:         entity.WebhookEventAssetCreated,
 		Timestamp:     time.Now(),
 		ByUserId:      &userId,
-		AssignUserId:  &userAssetManager.Id,
+		AssignUserId:  asset.Owner,
 		ChangeSummary: changeSummary,
 		AssetId:       assetCreate.Id,
-		CompanyId:     company.Id,
+		CompanyId:     asset.CompanyId,
 	}
 	_, err = service.assertLogRepository.Create(&assetLog, tx)
 	if err != nil {
 		return nil, err
 	}
+	departmentId := asset.DepartmentId
 	assign := entity.Assignments{
 		AssetId:      assetCreate.Id,
-		UserId:       &userAssetManager.Id,
+		UserId:       asset.Owner,
 		AssignBy:     userId,
 		DepartmentId: &departmentId,
-		CompanyId:    company.Id,
+		CompanyId:    asset.CompanyId,
 	}
 	_, err = service.assignRepository.Create(&assign, tx)
 	if err != nil {
 		return nil, err
 	}
-	if err := tx.Commit().Error; err != nil {
-		return nil, err
-	}
-	go service.SetRole(assetCreate.Id)
-	go utils.GenQrAndUpdate(service.repo, assetCreate.Id, url)
 	return assetCreate, nil
 }
 
-func (service *AssetsService) GetAssetById(userId int64, assertId int64) (*entity.Assets, error) {
+// GenQrAsync sinh QR cho asset sau khi transaction tạo asset đã commit
+func (service *AssetsService) GenQrAsync(assetId int64, url string) {
+	go utils.GenQrAndUpdate(service.repo, service.storage, assetId, url)
+}
+
+func (service *AssetsService) GetAssetById(auth *policy.AuthContext, assertId int64) (*entity.Assets, error) {
 	assert, err := service.repo.GetAssetById(assertId)
 	if err != nil {
 		return nil, err
 	}
+	if !auth.CanAccessAsset(policy.PermissionViewAssets, assert) {
+		return nil, ErrAssetNotVisible
+	}
 	return assert, err
 }
 
-func (service *AssetsService) GetAllAsset(userId int64, cacheKey string) ([]*entity.Assets, error) {
-	user, err := service.userRepository.FindByUserId(userId)
-	if err != nil {
-		return nil, err
-	}
-	cacheKeyAssetCompanyId := fmt.Sprintf("%v:%v", cacheKey, user.CompanyId)
+func (service *AssetsService) GetAllAsset(auth *policy.AuthContext, cacheKey string) ([]*entity.Assets, error) {
+	cacheKeyAssetCompanyId := fmt.Sprintf("%v:%v", cacheKey, auth.CompanyId)
 	val, err := config.Rdb.Get(config.Ctx, cacheKeyAssetCompanyId).Result()
 	var assets []*entity.Assets
 	if err == nil {
@@ -223,7 +252,7 @@ func (service *AssetsService) GetAllAsset(userId int64, cacheKey string) ([]*ent
 			pkg.PanicExeption(constant.UnknownError, "Happened error when get all asset in redis")
 		}
 	} else {
-		assets, err = service.repo.GetAllAsset(user.CompanyId)
+		assets, err = service.repo.GetAllAsset(auth.CompanyId)
 		if err != nil {
 			log.Error("Happened error when get all asset. Error", err)
 			pkg.PanicExeption(constant.UnknownError, "Happened error when get all asset")
@@ -232,31 +261,18 @@ func (service *AssetsService) GetAllAsset(userId int64, cacheKey string) ([]*ent
 	// ✅ Cache lại dữ liệu
 	bytes, _ := json.Marshal(assets)
 	config.Rdb.Set(config.Ctx, cacheKeyAssetCompanyId, bytes, 10*time.Minute)
-	return assets, err
-}
-
-func (service *AssetsService) SetRole(assetId int64) error {
-	db := service.repo.GetDB()
-	assets, err := service.repo.GetAssetById(assetId)
-	if err != nil {
-		return err
-	}
-	users := service.userRepository.GetAllUser(assets.CompanyId)
-	for _, user := range users {
-		userRbac := entity.UserRbac{
-			AssetId: assetId,
-			UserId:  user.Id,
-			RoleId:  user.RoleId,
-		}
-		err := service.userRBACRepository.Create(&userRbac, db)
-		if err != nil {
-			return err
+	// Cache dùng chung cho cả công ty nên lọc theo quyền của từng user sau khi đọc
+	visible := make([]*entity.Assets, 0, len(assets))
+	for _, a := range assets {
+		a.CompanyId = auth.CompanyId // CompanyId không được lưu vào cache (json:"-")
+		if auth.CanAccessAsset(policy.PermissionViewAssets, a) {
+			visible = append(visible, a)
 		}
 	}
-	return nil
+	return visible, nil
 }
 
-func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName string, purchaseDate time.Time, warrantExpiry time.Time, serialNumber string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, categoryId int64, cost float64) (*entity.Assets, error) {
+func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName string, purchaseDate time.Time, warrantExpiry time.Time, serialNumber string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, categoryId int64, cost float64, vendorId *int64) (*entity.Assets, error) {
 	var err error
 	var imgFile multipart.File
 	imgFile, err = image.Open()
@@ -277,16 +293,20 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	if err != nil {
 		return nil, fmt.Errorf("cannot find asset: %w", err)
 	}
+	if err = service.vendorService.CheckVendor(oldAsset.CompanyId, vendorId); err != nil {
+		return nil, err
+	}
 	var filedUpdate []string
-	uploader := utils.NewSupabaseUploader()
 	if oldAsset.ImageUpload != nil && *oldAsset.ImageUpload != "" {
-		oldImagePath, _ := utils.ExtractFilePath(*oldAsset.ImageUpload)
-		_ = uploader.Delete(oldImagePath)
+		if oldImagePath, ok := service.storage.ObjectPath(*oldAsset.ImageUpload); ok {
+			_ = service.storage.Delete(oldImagePath)
+		}
 		filedUpdate = append(filedUpdate, "image")
 	}
 	if oldAsset.FileAttachment != nil && *oldAsset.FileAttachment != "" {
-		oldFilePath, _ := utils.ExtractFilePath(*oldAsset.FileAttachment)
-		_ = uploader.Delete(oldFilePath)
+		if oldFilePath, ok := service.storage.ObjectPath(*oldAsset.FileAttachment); ok {
+			_ = service.storage.Delete(oldFilePath)
+		}
 		filedUpdate = append(filedUpdate, "file")
 	}
 	var (
@@ -300,7 +320,7 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
-		i, err := uploader.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
+		i, err := service.storage.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
 		if err != nil {
 			errChan <- err
 			cancel()
@@ -310,7 +330,7 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	}()
 	go func() {
 		defer wg.Done()
-		f, err := uploader.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
+		f, err := service.storage.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
 		if err != nil {
 			errChan <- err
 			cancel()
@@ -341,6 +361,9 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	if oldAsset.CategoryId != categoryId {
 		filedUpdate = append(filedUpdate, "category")
 	}
+	if vendorId != nil && (oldAsset.VendorId == nil || *oldAsset.VendorId != *vendorId) {
+		filedUpdate = append(filedUpdate, "vendor")
+	}
 	tx := service.repo.GetDB().Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -360,6 +383,7 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 		ImageUpload:    &imageUrl,
 		FileAttachment: &fileUrl,
 		CategoryId:     categoryId,
+		VendorId:       vendorId,
 	}
 	assetUpdated, err := service.repo.UpdateAsset(&asset, tx)
 	if err != nil {
@@ -378,6 +402,7 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	}
 	assetLog := entity.AssetLog{
 		Action:        "Update",
+		Event:         entity.WebhookEventAssetUpdated,
 		Timestamp:     time.Now(),
 		ByUserId:      &userId,
 		ChangeSummary: changeSummary,
@@ -388,9 +413,6 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	if err != nil {
 		return nil, err
 	}
-	if err = tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("commit failed: %w", err)
-	}
 	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(assetUpdated.DepartmentId)
 	usersToNotifications := []*entity.Users{}
 	if assetUpdated.OnwerUser != nil {
@@ -401,14 +423,12 @@ func (service *AssetsService) UpdateAsset(userId int64, assetId int64, assetName
 	}
 	message := fmt.Sprintf("The asset '%v' (ID: %v) has just been updated by %v", assetName, assetId, userUpdate.Email)
 	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(userNotificationUnique, message, asset)
-	}()
+	if err = service.NotificationService.NotifyUsers(tx, userNotificationUnique, entity.NotificationEventAssetUpdated, message, *assetUpdated); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
 	return assetUpdated, nil
 }
 
@@ -438,6 +458,7 @@ func (service *AssetsService) DeleteAsset(userId int64, id int64) error {
 	changeSummary := "Delete asset chair"
 	assetLog := entity.AssetLog{
 		Action:        "Delete",
+		Event:         entity.WebhookEventAssetDisposed,
 		Timestamp:     time.Now(),
 		ByUserId:      &userId,
 		ChangeSummary: changeSummary,
@@ -448,23 +469,18 @@ func (service *AssetsService) DeleteAsset(userId int64, id int64) error {
 	if err != nil {
 		return err
 	}
-	if err = tx.Commit().Error; err != nil {
-		return fmt.Errorf("commit failed: %w", err)
-	}
 	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(asset.DepartmentId)
 	usersToNotifications := []*entity.Users{}
 	usersToNotifications = append(usersToNotifications, asset.OnwerUser)
 	usersToNotifications = append(usersToNotifications, userManagerAsset)
 	message := fmt.Sprintf("The asset '%v' (ID: %v) has just been delete by %v", asset.AssetName, asset.Id, userUpdate.Email)
 	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(userNotificationUnique, message, *asset)
-	}()
+	if err = service.NotificationService.NotifyUsers(tx, userNotificationUnique, entity.NotificationEventAssetDisposed, message, *asset); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
 	return nil
 }
 
@@ -495,26 +511,33 @@ func (service *AssetsService) UpdateAssetRetired(userId int64, id int64, Residua
 		return nil, err
 	}
 	now := time.Now()
-	asset.AcquisitionDate = &now
-	asset.ResidualValue = &ResidualValue
-	yearsUsed := now.Year() - asset.AcquisitionDate.Year()
-	if now.YearDay() < asset.AcquisitionDate.YearDay() {
-		yearsUsed--
+	// Khấu hao tại thời điểm thanh lý tính theo phương pháp của tài sản/danh mục
+	usages, err := service.usageRepository.GetUsagesByAssetId(id)
+	if err != nil {
+		return nil, err
+	}
+	assetCheck.ResidualValue = &ResidualValue
+	in, err := utils.ResolveDepreciationInput(assetCheck, usages)
+	if err != nil {
+		return nil, err
 	}
-	if yearsUsed <= 0 {
-		fmt.Println("Asset not in use for a full year.")
-		return nil, errors.New("a")
+	if !now.After(in.StartDate) {
+		err = errors.New("asset has not been in use yet")
+		return nil, err
 	}
-	// Tính khấu hao hàng năm
-	annualDepreciation := (asset.Cost - *asset.ResidualValue) / float64(yearsUsed)
+	bookValue := utils.CurrentAssetValue(in, now)
+	annualDepreciation := utils.DepreciationChargeAt(in, now)
+	asset.ResidualValue = &ResidualValue
+	asset.RetiredOrDisposeTime = &now
 	asset.AnnualDepreciation = &annualDepreciation
 	asset, err = service.repo.UpdateAsset(asset, tx)
 	if err != nil {
 		return nil, err
 	}
-	changeSummary := "Retired asset"
+	changeSummary := fmt.Sprintf("Retired asset, book value %.2f", bookValue)
 	assetLog := entity.AssetLog{
 		Action:        "Update",
+		Event:         entity.WebhookEventAssetRetired,
 		Timestamp:     time.Now(),
 		ByUserId:      &userId,
 		ChangeSummary: changeSummary,
@@ -525,28 +548,23 @@ func (service *AssetsService) UpdateAssetRetired(userId int64, id int64, Residua
 	if err != nil {
 		return nil, err
 	}
-	if err = tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("commit failed: %w", err)
-	}
 	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(asset.DepartmentId)
 	usersToNotifications := []*entity.Users{}
 	usersToNotifications = append(usersToNotifications, asset.OnwerUser)
 	usersToNotifications = append(usersToNotifications, userManagerAsset)
 	message := fmt.Sprintf("The asset '%v' (ID: %v) has just been updated by %v", asset.AssetName, asset.Id, userUpdate.Email)
 	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(userNotificationUnique, message, *asset)
-	}()
+	if err = service.NotificationService.NotifyUsers(tx, userNotificationUnique, entity.NotificationEventAssetRetired, message, *asset); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
 	return asset, nil
 
 }
 
-func (service *AssetsService) Filter(userId int64, assetName *string, status *string, categoryId *string, cost *string, serialNumber *string, email *string, departmentId *string) ([]dto.AssetResponse, error) {
+func (service *AssetsService) Filter(auth *policy.AuthContext, assetName *string, status *string, categoryId *string, cost *string, serialNumber *string, email *string, departmentId *string) ([]dto.AssetResponse, error) {
 	var filter = filter.AssetFilter{
 		AssetName:    assetName,
 		CategoryId:   categoryId,
@@ -556,23 +574,8 @@ func (service *AssetsService) Filter(userId int64, assetName *string, status *st
 		DepartmentId: departmentId,
 		Status:       status,
 	}
-	user, err := service.userRepository.FindByUserId(userId)
-	if err != nil {
-		return nil, err
-	}
-	filter.CompanyId = user.CompanyId
-	if user.Role.Slug == "departmentHead" {
-		var deptStr *string
-		if user.DepartmentId != nil {
-			s := strconv.FormatInt(*user.DepartmentId, 10)
-			deptStr = &s
-		} else {
-			deptStr = nil
-		}
-		filter.DepartmentId = deptStr
-	}
 	db := service.repo.GetDB()
-	dbFilter := filter.ApplyFilter(db.Model(&entity.Assets{}), userId)
+	dbFilter := filter.ApplyFilter(db.Model(&entity.Assets{}), auth)
 	var total int64
 	dbFilter.Count(&total)
 	var assets []entity.Assets
@@ -590,9 +593,10 @@ func (service *AssetsService) Filter(userId int64, assetName *string, status *st
 			WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 			Status:         asset.Status,
 			SerialNumber:   asset.SerialNumber,
-			FileAttachment: *asset.FileAttachment,
-			ImageUpload:    *asset.ImageUpload,
-			QrURL:          *asset.QrUrl,
+			FileAttachment: storage.FileURL(*asset.FileAttachment),
+			ImageUpload:    storage.FileURL(*asset.ImageUpload),
+			QrURL:          storage.FileURL(*asset.QrUrl),
+			VendorId:       asset.VendorId,
 			Category: dto.CategoryResponse{
 				ID:           asset.Category.Id,
 				CategoryName: asset.Category.CategoryName,
@@ -619,30 +623,14 @@ func (service *AssetsService) Filter(userId int64, assetName *string, status *st
 	return assetsResponse, nil
 }
 
-func (service *AssetsService) ApplyFilterDashBoard(userId int64, status *string, categoryId *string, departmentId *string, export *string) (*dto.DashboardSummary, []*entity.Assets, error) {
-	user, err := service.userRepository.FindByUserId(userId)
-	if err != nil {
-		return nil, nil, err
-	}
-	roleHeadDep := service.roleRepository.GetRoleBySlug("department-head")
-	roleView := service.roleRepository.GetRoleBySlug("employee")
-	if user.RoleId == roleHeadDep.Id || user.RoleId == roleView.Id {
-		if user.DepartmentId != nil {
-			// Convert *int64 to string
-			depIdStr := strconv.FormatInt(*user.DepartmentId, 10)
-			departmentId = &depIdStr // assign pointer to string
-		} else {
-			departmentId = nil
-		}
-	}
-
+func (service *AssetsService) ApplyFilterDashBoard(auth *policy.AuthContext, categoryId *string, departmentId *string, status *string) (*dto.DashboardSummary, []*entity.Assets, error) {
 	var filter = filter.AssetFilterDashboard{
 		CategoryId:   categoryId,
 		DepartmentId: departmentId,
 		Status:       status,
 	}
 	db := service.repo.GetDB()
-	dbFilter := filter.ApplyFilterDashBoard(db.Model(&entity.Assets{}), userId)
+	dbFilter := filter.ApplyFilterDashBoard(db.Model(&entity.Assets{}), auth)
 	var assets []*entity.Assets
 	result := dbFilter.Find(&assets)
 	if result.Error != nil {
@@ -680,26 +668,14 @@ func (service *AssetsService) GetAssetsByCateOfDepartment(userId, categoryId int
 	return assets, nil
 }
 
-func (service *AssetsService) CheckPermissionForManager(userId int64, depId int64) error {
-	user, err := service.userRepository.FindByUserId(userId)
-	role := service.roleRepository.GetRoleBySlug("admin")
-	if user.RoleId == role.Id {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-	if user.DepartmentId != nil && *user.DepartmentId == depId {
+// CheckPermissionForManager kiểm tra user được quản lý tài sản của phòng ban: full là mọi phòng ban, limited chỉ phòng ban của mình
+func (service *AssetsService) CheckPermissionForManager(auth *policy.AuthContext, depId int64) error {
+	if auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, depId) {
 		return nil
 	}
 	return errors.New("you are not allowed to manage departmental assets")
 }
 
-func (service *AssetsService) GetUserById(id int64) (entity.Users, error) {
-	user, err := service.userRepository.FindByUserId(id)
-	return *user, err
-}
-
 func (service *AssetsService) GetAllAssetNotHaveMaintenance(userId int64) ([]*entity.Assets, error) {
 	user, err := service.userRepository.FindByUserId(userId)
 	if err != nil {
@@ -711,3 +687,195 @@ func (service *AssetsService) GetAllAssetNotHaveMaintenance(userId int64) ([]*en
 	}
 	return assets, nil
 }
+
+type importedAsset struct {
+	row     int
+	asset   entity.Assets
+	ownerId int64
+}
+
+func (service *AssetsService) ImportAssets(auth *policy.AuthContext, file *multipart.FileHeader, dryRun bool, url string) (*dto.AssetImportReport, error) {
+	rows, err := utils.ParseAssetImportFile(file)
+	if err != nil {
+		return nil, err
+	}
+	userId := auth.UserId
+	categoriesOfCompany, err := service.categoriesRepository.GetAll(auth.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	departments, err := service.departmentRepository.GetAll(auth.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	usersOfCompany := service.userRepository.GetAllUser(auth.CompanyId)
+
+	categoryByName := map[string]int64{}
+	for _, c := range categoriesOfCompany {
+		categoryByName[strings.ToLower(c.CategoryName)] = c.Id
+	}
+	// Tên phòng ban chỉ duy nhất theo location nên có thể bị trùng
+	departmentByName := map[string][]int64{}
+	for _, d := range departments {
+		key := strings.ToLower(d.DepartmentName)
+		departmentByName[key] = append(departmentByName[key], d.Id)
+	}
+	ownerByKey := map[string][]*entity.Users{}
+	for _, u := range usersOfCompany {
+		ownerByKey[strings.ToLower(u.Email)] = append(ownerByKey[strings.ToLower(u.Email)], u)
+		fullName := strings.ToLower(strings.TrimSpace(u.FirstName + " " + u.LastName))
+		ownerByKey[fullName] = append(ownerByKey[fullName], u)
+	}
+
+	serialNumbers := []string{}
+	serialRows := map[string]int{}
+	for _, row := range rows {
+		if row.SerialNumber != "" {
+			serialNumbers = append(serialNumbers, row.SerialNumber)
+		}
+	}
+	existing, err := service.repo.GetAssetsBySerialNumbers(auth.CompanyId, serialNumbers)
+	if err != nil {
+		return nil, err
+	}
+	existingSerials := map[string]bool{}
+	for _, a := range existing {
+		existingSerials[a.SerialNumber] = true
+	}
+
+	assetManagers := map[int64]*entity.Users{}
+	managePermission := map[int64]error{}
+	report := &dto.AssetImportReport{DryRun: dryRun, TotalRows: len(rows), Errors: []dto.AssetImportRowError{}}
+	imports := []importedAsset{}
+	for _, row := range rows {
+		errs := []string{}
+		if row.AssetName == "" {
+			errs = append(errs, "assetName is required")
+		}
+		if row.SerialNumber == "" {
+			errs = append(errs, "serialNumber is required")
+		} else if existingSerials[row.SerialNumber] {
+			errs = append(errs, fmt.Sprintf("serialNumber %s already exists", row.SerialNumber))
+		} else if first, ok := serialRows[row.SerialNumber]; ok {
+			errs = append(errs, fmt.Sprintf("serialNumber %s is duplicated with row %d", row.SerialNumber, first))
+		} else {
+			serialRows[row.SerialNumber] = row.Row
+		}
+		purchaseDate, err := utils.ParseImportDate(row.PurchaseDate)
+		if err != nil {
+			errs = append(errs, "purchaseDate: "+err.Error())
+		}
+		warrantExpiry, err := utils.ParseImportDate(row.WarrantExpiry)
+		if err != nil {
+			errs = append(errs, "warrantExpiry: "+err.Error())
+		}
+		cost, err := strconv.ParseFloat(row.Cost, 64)
+		if err != nil || cost < 0 {
+			errs = append(errs, fmt.Sprintf("invalid cost %q", row.Cost))
+		}
+		categoryId, ok := categoryByName[strings.ToLower(row.Category)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("category %q not found", row.Category))
+		}
+		var departmentId int64
+		switch ids := departmentByName[strings.ToLower(row.Department)]; len(ids) {
+		case 0:
+			errs = append(errs, fmt.Sprintf("department %q not found", row.Department))
+		case 1:
+			departmentId = ids[0]
+		default:
+			errs = append(errs, fmt.Sprintf("department %q is ambiguous", row.Department))
+		}
+		var ownerId int64
+		if departmentId != 0 {
+			if _, ok := managePermission[departmentId]; !ok {
+				managePermission[departmentId] = service.CheckPermissionForManager(auth, departmentId)
+			}
+			if err := managePermission[departmentId]; err != nil {
+				errs = append(errs, err.Error())
+			}
+			if _, ok := assetManagers[departmentId]; !ok {
+				uam, err := service.userRepository.GetUserAssetManageOfDepartment(departmentId)
+				if err != nil {
+					uam = nil
+				}
+				assetManagers[departmentId] = uam
+			}
+			if row.Owner == "" {
+				if assetManagers[departmentId] == nil {
+					errs = append(errs, fmt.Sprintf("department %q has no asset manager", row.Department))
+				} else {
+					ownerId = assetManagers[departmentId].Id
+				}
+			} else {
+				switch owners := ownerByKey[strings.ToLower(row.Owner)]; len(owners) {
+				case 0:
+					errs = append(errs, fmt.Sprintf("owner %q not found", row.Owner))
+				case 1:
+					if owners[0].DepartmentId == nil || *owners[0].DepartmentId != departmentId {
+						errs = append(errs, fmt.Sprintf("owner %q does not belong to department %q", row.Owner, row.Department))
+					} else {
+						ownerId = owners[0].Id
+					}
+				default:
+					errs = append(errs, fmt.Sprintf("owner %q is ambiguous, use email instead", row.Owner))
+				}
+			}
+		}
+		if len(errs) > 0 {
+			report.Errors = append(report.Errors, dto.AssetImportRowError{Row: row.Row, SerialNumber: row.SerialNumber, Errors: errs})
+			continue
+		}
+		imports = append(imports, importedAsset{
+			row:     row.Row,
+			ownerId: ownerId,
+			asset: entity.Assets{
+				AssetName:     row.AssetName,
+				PurchaseDate:  purchaseDate,
+				Cost:          cost,
+				WarrantExpiry: warrantExpiry,
+				Status:        "New",
+				SerialNumber:  row.SerialNumber,
+				CategoryId:    categoryId,
+				DepartmentId:  departmentId,
+				CompanyId:     auth.CompanyId,
+			},
+		})
+	}
+	report.ValidRows = len(imports)
+	report.InvalidRows = len(report.Errors)
+	// Dòng lỗi chỉ được báo lại, các dòng hợp lệ vẫn được import
+	if dryRun || len(imports) == 0 {
+		return report, nil
+	}
+
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	createdIds := []int64{}
+	for _, item := range imports {
+		asset := item.asset
+		asset.Owner = utils.PtrInt64(item.ownerId)
+		var assetCreate *entity.Assets
+		assetCreate, err = service.CreateInTx(tx, userId, &asset, "Create asset (import)")
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", item.row, err)
+		}
+		createdIds = append(createdIds, assetCreate.Id)
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	for _, id := range createdIds {
+		service.GenQrAsync(id, url)
+	}
+	report.Imported = true
+	report.CreatedAssetIds = createdIds
+	return report, nil
+}
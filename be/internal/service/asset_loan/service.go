@@ -0,0 +1,301 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/filter"
+	"BE_Manage_device/internal/domain/policy"
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
+	asset_log "BE_Manage_device/internal/repository/asset_log"
+	asset "BE_Manage_device/internal/repository/assets"
+	assignment "BE_Manage_device/internal/repository/assignments"
+	user "BE_Manage_device/internal/repository/user"
+	notificationS "BE_Manage_device/internal/service/notification"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+const (
+	LoanStatusCheckedOut = "Checked Out"
+	LoanStatusReturned   = "Returned"
+)
+
+var (
+	ErrAssetCheckedOut = errors.New("asset is already checked out")
+	ErrLoanReturned    = errors.New("loan was already returned")
+)
+
+type AssetLoanService struct {
+	repo                assetLoan.AssetLoansRepository
+	assetRepo           asset.AssetsRepository
+	assignRepo          assignment.AssignmentRepository
+	assetLogRepo        asset_log.AssetsLogRepository
+	userRepo            user.UserRepository
+	NotificationService *notificationS.NotificationService
+}
+
+func NewAssetLoanService(repo assetLoan.AssetLoansRepository, assetRepo asset.AssetsRepository, assignRepo assignment.AssignmentRepository, assetLogRepo asset_log.AssetsLogRepository, userRepo user.UserRepository, NotificationService *notificationS.NotificationService) *AssetLoanService {
+	return &AssetLoanService{repo: repo, assetRepo: assetRepo, assignRepo: assignRepo, assetLogRepo: assetLogRepo, userRepo: userRepo, NotificationService: NotificationService}
+}
+
+func (service *AssetLoanService) CheckOut(auth *policy.AuthContext, assetId, borrowerId int64, dueDate time.Time, condition, note string) (*entity.AssetLoans, error) {
+	var err error
+	userId := auth.UserId
+	asset, err := service.assetRepo.GetAssetById(assetId)
+	if err != nil {
+		return nil, err
+	}
+	if err = service.checkManageAsset(auth, asset); err != nil {
+		return nil, err
+	}
+	if asset.Status != "New" && asset.Status != "In Use" {
+		return nil, fmt.Errorf("asset with status '%v' can't be checked out", asset.Status)
+	}
+	if !dueDate.After(time.Now()) {
+		return nil, errors.New("due date must be in the future")
+	}
+	borrower, err := service.userRepo.FindByUserId(borrowerId)
+	if err != nil {
+		return nil, err
+	}
+	if borrower.CompanyId != asset.CompanyId {
+		return nil, errors.New("borrower does not belong to this company")
+	}
+	if asset.Owner != nil && *asset.Owner == borrowerId {
+		return nil, errors.New("borrower is already the owner of this asset")
+	}
+	assign, err := service.assignRepo.GetAssignmentByAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	// Khoá tài sản rồi mới kiểm tra khoản mượn, 2 lần cho mượn hoặc cho mượn và điều chuyển song song không cùng qua được
+	if err = service.assetRepo.LockAsset(assetId, tx); err != nil {
+		return nil, err
+	}
+	active, err := service.repo.HasActiveLoan(assetId, tx)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		err = ErrAssetCheckedOut
+		return nil, err
+	}
+	loan := entity.AssetLoans{
+		AssetId:           assetId,
+		AssignmentId:      assign.Id,
+		BorrowerId:        borrowerId,
+		PreviousOwnerId:   asset.Owner,
+		PreviousStatus:    asset.Status,
+		CheckedOutById:    userId,
+		CheckOutDate:      time.Now(),
+		DueDate:           dueDate,
+		CheckOutCondition: condition,
+		Note:              note,
+		Status:            LoanStatusCheckedOut,
+		CompanyId:         asset.CompanyId,
+	}
+	if _, err = service.repo.Create(&loan, tx); err != nil {
+		return nil, activeLoanError(err)
+	}
+	if _, err = service.assignRepo.Update(assign.Id, userId, assetId, &borrowerId, nil, tx); err != nil {
+		return nil, err
+	}
+	if err = service.assetRepo.UpdateOwner(assetId, borrowerId, tx); err != nil {
+		return nil, err
+	}
+	if _, err = service.assetRepo.UpdateAssetLifeCycleStage(assetId, "In Use", tx); err != nil {
+		return nil, err
+	}
+	assetLog := entity.AssetLog{
+		Action:        "Check Out",
+		Event:         entity.WebhookEventAssetAssigned,
+		Timestamp:     time.Now(),
+		ByUserId:      &userId,
+		AssignUserId:  &borrowerId,
+		AssetId:       assetId,
+		ChangeSummary: fmt.Sprintf("Checked out to %v until %v, condition: %v", borrower.Email, dueDate.Format("2006-01-02"), condition),
+		CompanyId:     asset.CompanyId,
+	}
+	if _, err = service.assetLogRepo.Create(&assetLog, tx); err != nil {
+		return nil, err
+	}
+	userManagerAsset, _ := service.userRepo.GetUserAssetManageOfDepartment(asset.DepartmentId)
+	usersToNotifications := []*entity.Users{borrower, asset.OnwerUser, userManagerAsset}
+	message := fmt.Sprintf("The asset '%v' (ID: %v) has been checked out to %v until %v", asset.AssetName, asset.Id, borrower.Email, dueDate.Format("2006-01-02"))
+	if err = service.notify(tx, userId, usersToNotifications, entity.NotificationEventLoanCheckedOut, message, *asset); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetLoanById(loan.Id)
+}
+
+func (service *AssetLoanService) CheckIn(auth *policy.AuthContext, loanId int64, condition string) (*entity.AssetLoans, error) {
+	var err error
+	userId := auth.UserId
+	loan, err := service.repo.GetLoanById(loanId)
+	if err != nil {
+		return nil, err
+	}
+	if loan.Status != LoanStatusCheckedOut {
+		return nil, ErrLoanReturned
+	}
+	asset, err := service.assetRepo.GetAssetById(loan.AssetId)
+	if err != nil {
+		return nil, err
+	}
+	userManagerAsset, _ := service.userRepo.GetUserAssetManageOfDepartment(asset.DepartmentId)
+	if err = service.checkReturnLoan(auth, asset, userManagerAsset); err != nil {
+		return nil, err
+	}
+	// Trả lại cho chủ cũ, nếu không có thì trả về asset manager của phòng ban
+	var restoreOwner *entity.Users
+	if loan.PreviousOwner != nil {
+		restoreOwner = loan.PreviousOwner
+	} else {
+		restoreOwner, err = service.userRepo.GetUserAssetManageOfDepartment(asset.DepartmentId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	checkedIn, err := service.repo.CheckIn(loanId, userId, condition, time.Now(), tx)
+	if err != nil {
+		return nil, err
+	}
+	if !checkedIn {
+		err = ErrLoanReturned
+		return nil, err
+	}
+	if _, err = service.assignRepo.Update(loan.AssignmentId, userId, loan.AssetId, &restoreOwner.Id, nil, tx); err != nil {
+		return nil, err
+	}
+	if err = service.assetRepo.UpdateOwner(loan.AssetId, restoreOwner.Id, tx); err != nil {
+		return nil, err
+	}
+	// Trả về trạng thái trước khi mượn, trạng thái đã đổi trong lúc mượn hoặc khoản mượn cũ chưa lưu thì giữ nguyên
+	if loan.PreviousStatus != "" && asset.Status == "In Use" && loan.PreviousStatus != asset.Status {
+		if _, err = service.assetRepo.UpdateAssetLifeCycleStage(loan.AssetId, loan.PreviousStatus, tx); err != nil {
+			return nil, err
+		}
+	}
+	changeSummary := fmt.Sprintf("Checked in from %v, condition: %v, returned to %v", loan.Borrower.Email, condition, restoreOwner.Email)
+	if time.Now().After(loan.DueDate) {
+		changeSummary += fmt.Sprintf(" (overdue since %v)", loan.DueDate.Format("2006-01-02"))
+	}
+	assetLog := entity.AssetLog{
+		Action:        "Check In",
+		Event:         entity.WebhookEventAssetAssigned,
+		Timestamp:     time.Now(),
+		ByUserId:      &userId,
+		AssignUserId:  &restoreOwner.Id,
+		AssetId:       loan.AssetId,
+		ChangeSummary: changeSummary,
+		CompanyId:     loan.CompanyId,
+	}
+	if _, err = service.assetLogRepo.Create(&assetLog, tx); err != nil {
+		return nil, err
+	}
+	usersToNotifications := []*entity.Users{&loan.Borrower, restoreOwner, userManagerAsset}
+	message := fmt.Sprintf("The asset '%v' (ID: %v) has been checked in by %v", asset.AssetName, asset.Id, auth.Email)
+	if err = service.notify(tx, userId, usersToNotifications, entity.NotificationEventLoanCheckedIn, message, *asset); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetLoanById(loanId)
+}
+
+func (service *AssetLoanService) Filter(auth *policy.AuthContext, loanFilter filter.AssetLoanFilter) ([]*entity.AssetLoans, error) {
+	loanFilter.CompanyId = auth.CompanyId
+	db := loanFilter.ApplyFilter(service.repo.GetDB().Model(&entity.AssetLoans{}))
+	// Người quản lý tài sản xem khoản mượn theo phạm vi manage-assets, còn lại chỉ xem khoản mượn của mình
+	if auth.Allows(policy.PermissionManageAssets, policy.OpRead) {
+		if departmentId, restricted := auth.DepartmentScope(policy.PermissionManageAssets); restricted {
+			if departmentId == nil {
+				return []*entity.AssetLoans{}, nil
+			}
+			db = db.Joins("join assets on assets.id = asset_loans.asset_id").Where("assets.department_id = ?", *departmentId)
+		}
+	} else {
+		db = db.Where("asset_loans.borrower_id = ? OR asset_loans.previous_owner_id = ?", auth.UserId, auth.UserId)
+	}
+	return service.repo.GetLoansWithFilter(db)
+}
+
+func (service *AssetLoanService) GetLoanById(auth *policy.AuthContext, loanId int64) (*entity.AssetLoans, error) {
+	loan, err := service.repo.GetLoanById(loanId)
+	if err != nil {
+		return nil, err
+	}
+	if loan.CompanyId != auth.CompanyId {
+		return nil, errors.New("loan not found")
+	}
+	return loan, nil
+}
+
+// checkManageAsset: user được quản lý tài sản theo phạm vi manage-assets (full: cả công ty, limited: phòng ban mình), còn lại chỉ cho mượn tài sản mình đang giữ
+func (service *AssetLoanService) checkManageAsset(auth *policy.AuthContext, asset *entity.Assets) error {
+	permissionErrorMessage := errors.New("you are not allowed to lend this asset")
+	if auth.CompanyId != asset.CompanyId {
+		return permissionErrorMessage
+	}
+	if auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, asset.DepartmentId) {
+		return nil
+	}
+	if asset.Owner != nil && *asset.Owner == auth.UserId {
+		return nil
+	}
+	return permissionErrorMessage
+}
+
+// checkReturnLoan: chỉ người quản lý tài sản (theo phạm vi manage-assets) hoặc asset manager của phòng ban được nhận lại tài sản, người mượn không tự trả được
+func (service *AssetLoanService) checkReturnLoan(auth *policy.AuthContext, asset *entity.Assets, userManagerAsset *entity.Users) error {
+	if auth.CompanyId != asset.CompanyId {
+		return errors.New("you are not allowed to check in this asset")
+	}
+	if auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, asset.DepartmentId) {
+		return nil
+	}
+	if userManagerAsset != nil && userManagerAsset.Id == auth.UserId {
+		return nil
+	}
+	return errors.New("you are not allowed to check in this asset")
+}
+
+// activeLoanError đổi lỗi unique index khoản mượn đang mở (2 request cho mượn cùng lúc) thành ErrAssetCheckedOut
+func activeLoanError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_asset_loans_active_asset" {
+		return ErrAssetCheckedOut
+	}
+	return err
+}
+
+func (service *AssetLoanService) notify(tx *gorm.DB, userId int64, usersToNotifications []*entity.Users, event, message string, asset entity.Assets) error {
+	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
+	return service.NotificationService.NotifyUsers(tx, userNotificationUnique, event, message, asset)
+}
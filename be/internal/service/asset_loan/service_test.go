@@ -0,0 +1,35 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	"testing"
+)
+
+func TestCheckReturnLoan(t *testing.T) {
+	dept := int64(10)
+	borrower := int64(3)
+	asset := &entity.Assets{Id: 1, CompanyId: 1, DepartmentId: 10, Owner: &borrower}
+	manager := &entity.Users{Id: 5}
+	tests := []struct {
+		name    string
+		auth    *policy.AuthContext
+		wantErr bool
+	}{
+		{"full manage assets", policy.NewAuthContext(9, 1, nil, map[string]string{policy.PermissionManageAssets: entity.AccessLevelFull}), false},
+		{"limited own department", policy.NewAuthContext(9, 1, &dept, map[string]string{policy.PermissionManageAssets: entity.AccessLevelLimited}), false},
+		{"department asset manager", policy.NewAuthContext(5, 1, nil, nil), false},
+		// người mượn đang là owner nhưng không được tự trả
+		{"borrower", policy.NewAuthContext(borrower, 1, &dept, nil), true},
+		{"other company", policy.NewAuthContext(9, 2, nil, map[string]string{policy.PermissionManageAssets: entity.AccessLevelFull}), true},
+	}
+	service := &AssetLoanService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.checkReturnLoan(tt.auth, asset, manager)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
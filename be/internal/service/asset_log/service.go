@@ -4,10 +4,12 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
+	"BE_Manage_device/internal/domain/policy"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
 	asset "BE_Manage_device/internal/repository/assets"
 	role "BE_Manage_device/internal/repository/role"
 	user "BE_Manage_device/internal/repository/user"
+	"BE_Manage_device/pkg/storage"
 
 	"errors"
 )
@@ -30,22 +32,17 @@ func (service *AssetLogService) GetLogByAssetId(assetId int64) ([]*entity.AssetL
 	}
 	return assetlogs, nil
 }
-func (service *AssetLogService) Filter(userId int64, assetId int64, action, startTime, endTime *string) ([]dto.AssetLogsResponse, error) {
-	userCheck, err := service.userRepo.FindByUserId(userId)
-	if err != nil {
-		return nil, err
-	}
+func (service *AssetLogService) Filter(auth *policy.AuthContext, assetId int64, action, startTime, endTime *string) ([]dto.AssetLogsResponse, error) {
 	var filter = filter.AssetLogFilter{
 		Action:    action,
 		StartTime: startTime,
 		EndTime:   endTime,
+		CompanyId: auth.CompanyId,
 	}
-	if userCheck.Role.Slug == "assetManager" {
-		if userCheck.DepartmentId != nil {
-			filter.DepId = userCheck.DepartmentId
-		}
+	// partial chỉ xem log của tài sản thuộc phòng ban mình
+	if departmentId, restricted := auth.DepartmentScope(policy.PermissionAuditLogs); restricted && departmentId != nil {
+		filter.DepId = departmentId
 	}
-	filter.CompanyId = userCheck.CompanyId
 
 	db := service.repo.GetDB()
 	dbFilter := filter.ApplyFilter(db.Model(&entity.AssetLog{}), assetId)
@@ -91,13 +88,13 @@ func (service *AssetLogService) Filter(userId int64, assetId int64, action, star
 		assetLogResponse.Asset.SerialNumber = assetLog.Asset.SerialNumber
 
 		if assetLog.Asset.ImageUpload != nil {
-			assetLogResponse.Asset.ImageUpload = *assetLog.Asset.ImageUpload
+			assetLogResponse.Asset.ImageUpload = storage.FileURL(*assetLog.Asset.ImageUpload)
 		}
 		if assetLog.Asset.FileAttachment != nil {
-			assetLogResponse.Asset.FileAttachment = *assetLog.Asset.FileAttachment
+			assetLogResponse.Asset.FileAttachment = storage.FileURL(*assetLog.Asset.FileAttachment)
 		}
 		if assetLog.Asset.QrUrl != nil {
-			assetLogResponse.Asset.QrUrl = *assetLog.Asset.QrUrl
+			assetLogResponse.Asset.QrUrl = storage.FileURL(*assetLog.Asset.QrUrl)
 		}
 
 		assetLogResponses = append(assetLogResponses, assetLogResponse)
@@ -105,19 +102,11 @@ func (service *AssetLogService) Filter(userId int64, assetId int64, action, star
 	return assetLogResponses, nil
 }
 
-func (service *AssetLogService) CheckPermissionForManager(userId int64, depId int64) error {
-	user, err := service.userRepo.FindByUserId(userId)
-	role := service.roleRepo.GetRoleBySlug("admin")
-	if user.RoleId == role.Id {
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-	if user.DepartmentId != nil && *user.DepartmentId == depId {
+func (service *AssetLogService) CheckPermissionForManager(auth *policy.AuthContext, depId int64) error {
+	if auth.AllowsInDepartment(policy.PermissionAuditLogs, policy.OpRead, depId) {
 		return nil
 	}
-	return errors.New("you are not allowed to manage departmental assets")
+	return errors.New("you are not allowed to view logs of this department")
 }
 
 func (service *AssetLogService) GetAssetById(userId int64, assertId int64) (*entity.Assets, error) {
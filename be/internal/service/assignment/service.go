@@ -4,6 +4,7 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
+	"BE_Manage_device/internal/domain/policy"
 	"BE_Manage_device/pkg/utils"
 
 	asset_log "BE_Manage_device/internal/repository/asset_log"
@@ -11,6 +12,7 @@ import (
 	assignment "BE_Manage_device/internal/repository/assignments"
 	department "BE_Manage_device/internal/repository/departments"
 	user "BE_Manage_device/internal/repository/user"
+	emailS "BE_Manage_device/internal/service/email"
 	notificationS "BE_Manage_device/internal/service/notification"
 
 	"fmt"
@@ -159,6 +161,7 @@ func (service *AssignmentService) Update(userId, assignmentId int64, userIdAssig
 		assetLog := entity.AssetLog{
 			Timestamp: time.Now(),
 			Action:    "Transfer",
+			Event:     entity.WebhookEventAssetTransferred,
 			AssetId:   asset.Id,
 			ByUserId:  &byUser.Id,
 			CompanyId: byUser.CompanyId,
@@ -186,6 +189,7 @@ func (service *AssignmentService) Update(userId, assignmentId int64, userIdAssig
 		assetLog := entity.AssetLog{
 			Timestamp: time.Now(),
 			Action:    "Transfer",
+			Event:     entity.WebhookEventAssetAssigned,
 			AssetId:   asset.Id,
 			ByUserId:  &byUser.Id,
 			CompanyId: assignUser.CompanyId,
@@ -217,9 +221,6 @@ func (service *AssignmentService) Update(userId, assignmentId int64, userIdAssig
 	if err != nil {
 		return nil, err
 	}
-	if err := tx.Commit().Error; err != nil {
-		return nil, err
-	}
 	var userManagerAsset *entity.Users
 	if departmentId != nil {
 
@@ -230,40 +231,40 @@ func (service *AssignmentService) Update(userId, assignmentId int64, userIdAssig
 	usersToNotifications := []*entity.Users{asset.OnwerUser, userManagerAsset}
 	message := fmt.Sprintf("The asset '%v' (ID: %v) has just been updated by %v", asset.AssetName, asset.Id, byUser.Email)
 	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(userNotificationUnique, message, *asset)
-	}()
+	emailData := map[string]interface{}{
+		"AssetName":  asset.AssetName,
+		"AssignedTo": assignUser.FirstName + " " + assignUser.LastName + " (" + assignUser.Email + ")",
+		"AssignedBy": byUser.Email,
+	}
+	if err = service.NotificationService.NotifyUsersWithEmail(tx, userNotificationUnique, entity.NotificationEventAssetAssigned, message, emailS.TemplateAssignment, emailData, *asset); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
 	return assignmentUpdated, nil
 }
 
-func (service *AssignmentService) Filter(userId int64, emailAssigned *string, emailAssign *string, assetName *string) ([]dto.AssignmentResponse, error) {
+func (service *AssignmentService) Filter(auth *policy.AuthContext, emailAssigned *string, emailAssign *string, assetName *string) ([]dto.AssignmentResponse, error) {
 	var filter = filter.AssignmentFilter{
 		EmailAssigned: emailAssigned,
 		EmailAssign:   emailAssign,
 		AssetName:     assetName,
 	}
-	users, err := service.userRepo.FindByUserId(userId)
-	if err != nil {
-		return nil, err
-	}
-	filter.CompanyId = users.CompanyId
+	filter.CompanyId = auth.CompanyId
 	db := service.Repo.GetDB()
-	dbFilter := filter.ApplyFilter(db.Model(&entity.Assignments{}), userId)
+	dbFilter := filter.ApplyFilter(db.Model(&entity.Assignments{}), auth.UserId)
 	var assignments []entity.Assignments
-	switch users.Role.Slug {
-	case "admin":
+	var err error
+	// Phạm vi xem theo manage-assets: full xem cả công ty, giới hạn phòng ban thì xem phòng ban mình, còn lại chỉ xem của mình
+	departmentId, restricted := auth.DepartmentScope(policy.PermissionManageAssets)
+	switch {
+	case auth.Allows(policy.PermissionManageAssets, policy.OpRead) && !restricted:
 		assignments, err = service.Repo.GetAssignmentWithFilterForAdmin(dbFilter)
-	case "assetManager":
-		assignments, err = service.Repo.GetAssignmentWithFilterForManager(*users.DepartmentId, dbFilter)
-	case "employee":
-		assignments, err = service.Repo.GetAssignmentWithFilterForEmployee(userId, dbFilter)
+	case auth.Allows(policy.PermissionManageAssets, policy.OpRead) && departmentId != nil:
+		assignments, err = service.Repo.GetAssignmentWithFilterForManager(*departmentId, dbFilter)
 	default:
-		return nil, fmt.Errorf("Unauthorized role to perform assignment filtering.")
+		assignments, err = service.Repo.GetAssignmentWithFilterForEmployee(auth.UserId, dbFilter)
 	}
 	if err != nil {
 		return nil, err
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/entity"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phpdave11/gofpdf"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+var ErrBillNotFound = errors.New("bill not found")
+
+// Font DejaVu Sans Condensed (lấy từ gofpdf) có đủ dấu tiếng Việt, font core của PDF (Arial...) chỉ có cp1252
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	invoiceFontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	invoiceFontBold []byte
+)
+
+const invoiceFont = "DejaVu"
+
+// Cột của bảng hàng hoá, tổng độ rộng bằng vùng in của A4 trừ lề 15mm mỗi bên
+var invoiceColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"#", 8, "C"},
+	{"Mô tả / Description", 48, "L"},
+	{"Serial", 30, "L"},
+	{"SL / Qty", 14, "R"},
+	{"Đơn giá / Unit", 24, "R"},
+	{"Thuế / Tax", 16, "R"},
+	{"Giảm / Disc.", 18, "R"},
+	{"Thành tiền", 22, "R"},
+}
+
+// RenderInvoicePDF dựng hoá đơn PDF cho bill thuộc công ty của user, QR luôn trỏ về trang bill trên frontend (BASE_URL_FRONTEND/bills/billNumber)
+func (service *BillsService) RenderInvoicePDF(userId int64, billNumber string) ([]byte, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	bill, err := service.repo.GetByBillNumber(billNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && bill.CompanyId != user.CompanyId) {
+		return nil, ErrBillNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	company, err := service.companyRepo.GetCompanyById(bill.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	// Không nhận URL từ client để QR trên hoá đơn không thể trỏ ra trang ngoài
+	billUrl := strings.TrimRight(config.BASE_URL_FRONTEND, "/") + "/bills/" + bill.BillNumber
+	qr, err := qrcode.Encode(billUrl, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("QR encoding failed: %w", err)
+	}
+	return buildInvoicePDF(bill, company, qr)
+}
+
+func buildInvoicePDF(bill *entity.Bill, company *entity.Company, qr []byte) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddUTF8FontFromBytes(invoiceFont, "", invoiceFontRegular)
+	pdf.AddUTF8FontFromBytes(invoiceFont, "B", invoiceFontBold)
+	pdf.SetTitle("Invoice "+bill.BillNumber, true)
+	status := bill.DeriveStatus(time.Now())
+	pdf.SetHeaderFunc(func() { drawWatermark(pdf, status) })
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(invoiceFont, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s - Trang / Page %d", bill.BillNumber, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+
+	// Header: công ty bên trái, QR bên phải
+	const qrSize = 30.0
+	pdf.RegisterImageOptionsReader("bill-qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+	pdf.ImageOptions("bill-qr", pageWidth-right-qrSize, top, qrSize, qrSize, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	textWidth := pageWidth - left - right - qrSize - 5
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetFont(invoiceFont, "B", 15)
+	pdf.MultiCell(textWidth, 7, company.CompanyName, "", "L", false)
+	pdf.SetFont(invoiceFont, "", 9)
+	pdf.CellFormat(textWidth, 5, company.Email, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	pdf.SetFont(invoiceFont, "B", 18)
+	pdf.CellFormat(textWidth, 9, "HÓA ĐƠN / INVOICE", "", 1, "L", false, 0, "")
+	pdf.SetFont(invoiceFont, "", 9)
+	infoRow(pdf, "Số / No.", bill.BillNumber)
+	infoRow(pdf, "Ngày / Date", bill.CreateAt.Format("02/01/2006"))
+	if bill.DueDate != nil {
+		infoRow(pdf, "Hạn thanh toán / Due", bill.DueDate.In(billLocation).Format("02/01/2006"))
+	}
+	infoRow(pdf, "Trạng thái / Status", status)
+	infoRow(pdf, "Tiền tệ / Currency", bill.Currency)
+	if pdf.GetY() < top+qrSize+3 {
+		pdf.SetY(top + qrSize + 3)
+	}
+	pdf.Ln(3)
+
+	// Người mua
+	pdf.SetFont(invoiceFont, "B", 10)
+	pdf.CellFormat(0, 6, "Người mua / Buyer", "B", 1, "L", false, 0, "")
+	pdf.Ln(1)
+	pdf.SetFont(invoiceFont, "", 9)
+	infoRow(pdf, "Tên / Name", bill.BuyerName)
+	infoRow(pdf, "Điện thoại / Phone", bill.BuyerPhone)
+	infoRow(pdf, "Email", bill.BuyerEmail)
+	infoRow(pdf, "Địa chỉ / Address", bill.BuyerAddress)
+	if bill.Description != "" {
+		infoRow(pdf, "Ghi chú / Note", bill.Description)
+	}
+	pdf.Ln(4)
+
+	// Bảng hàng hoá, sang trang thì vẽ lại tiêu đề cột
+	tableHeader(pdf)
+	pdf.SetFont(invoiceFont, "", 8.5)
+	_, pageHeight := pdf.GetPageSize()
+	for i, item := range bill.LineItems {
+		if pdf.GetY()+6 > pageHeight-25 {
+			pdf.AddPage()
+			tableHeader(pdf)
+			pdf.SetFont(invoiceFont, "", 8.5)
+		}
+		serial := ""
+		if item.Asset != nil {
+			serial = item.Asset.SerialNumber
+		}
+		values := []string{
+			strconv.Itoa(i + 1),
+			item.Description,
+			serial,
+			formatQuantity(item.Quantity),
+			formatMoney(item.UnitPrice, bill.Currency),
+			formatQuantity(item.TaxRate) + "%",
+			formatMoney(item.Discount, bill.Currency),
+			formatMoney(item.LineTotal, bill.Currency),
+		}
+		fill := i%2 == 1
+		pdf.SetFillColor(245, 245, 245)
+		for j, col := range invoiceColumns {
+			pdf.CellFormat(col.width, 6, fitText(pdf, values[j], col.width-2), "", 0, col.align, fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Line(left, pdf.GetY(), pageWidth-right, pdf.GetY())
+	pdf.Ln(3)
+
+	// Tổng tiền
+	if pdf.GetY()+42 > pageHeight-25 {
+		pdf.AddPage()
+	}
+	totalRow(pdf, "Tạm tính / Subtotal", formatMoney(bill.Subtotal, bill.Currency), false)
+	totalRow(pdf, "Chiết khấu / Discount", formatMoney(bill.DiscountTotal, bill.Currency), false)
+	totalRow(pdf, "Thuế / Tax", formatMoney(bill.TaxTotal, bill.Currency), false)
+	totalRow(pdf, "Tổng cộng / Grand total", formatMoney(bill.GrandTotal, bill.Currency)+" "+bill.Currency, true)
+	if bill.PaidAmount > 0 {
+		totalRow(pdf, "Đã trả / Paid", formatMoney(bill.PaidAmount, bill.Currency), false)
+		totalRow(pdf, "Còn nợ / Outstanding", formatMoney(bill.Outstanding(), bill.Currency)+" "+bill.Currency, true)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawWatermark in chữ PAID/UNPAID mờ, xoay chéo giữa trang, vẽ trong header để nằm dưới nội dung
+func drawWatermark(pdf *gofpdf.Fpdf, status string) {
+	text := "UNPAID"
+	pdf.SetTextColor(200, 30, 30)
+	if status == entity.BillStatusPaid {
+		text = "PAID"
+		pdf.SetTextColor(30, 150, 60)
+	}
+	pageWidth, pageHeight := pdf.GetPageSize()
+	x, y := pdf.GetXY()
+	pdf.SetFont(invoiceFont, "B", 90)
+	pdf.SetAlpha(0.12, "Normal")
+	pdf.TransformBegin()
+	pdf.TransformRotate(45, pageWidth/2, pageHeight/2)
+	pdf.Text((pageWidth-pdf.GetStringWidth(text))/2, pageHeight/2+12, text)
+	pdf.TransformEnd()
+	pdf.SetAlpha(1, "Normal")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetXY(x, y)
+}
+
+func infoRow(pdf *gofpdf.Fpdf, label, value string) {
+	pdf.SetFont(invoiceFont, "B", 9)
+	pdf.CellFormat(40, 5, label+":", "", 0, "L", false, 0, "")
+	pdf.SetFont(invoiceFont, "", 9)
+	pdf.MultiCell(0, 5, value, "", "L", false)
+}
+
+func tableHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont(invoiceFont, "B", 8)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range invoiceColumns {
+		pdf.CellFormat(col.width, 7, col.title, "TB", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func totalRow(pdf *gofpdf.Fpdf, label, value string, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont(invoiceFont, style, 9.5)
+	pdf.CellFormat(120, 6, label, "", 0, "R", false, 0, "")
+	pdf.CellFormat(60, 6, value, "", 1, "R", false, 0, "")
+}
+
+// fitText cắt bớt chữ (theo rune để không vỡ dấu tiếng Việt) cho vừa độ rộng ô
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func formatQuantity(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// formatMoney in số tiền có dấu phân cách hàng nghìn, số chữ số lẻ theo đồng tiền
+func formatMoney(amount float64, currency string) string {
+	decimals := 2
+	if zeroDecimalCurrencies[currency] {
+		decimals = 0
+	}
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := strconv.FormatFloat(amount, 'f', decimals, 64)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	if fracPart != "" {
+		b.WriteString("." + fracPart)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+const DefaultCurrency = "VND"
+
+var (
+	ErrInvalidCurrency = errors.New("currency must be a 3-letter ISO 4217 code")
+	ErrInvalidLineItem = errors.New("invalid bill line item")
+	ErrEmptyBill       = errors.New("bill must have at least one line item or asset")
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Các đồng tiền không có phần lẻ, làm tròn tới đơn vị. Còn lại làm tròn 2 chữ số
+var zeroDecimalCurrencies = map[string]bool{"VND": true, "JPY": true, "KRW": true, "CLP": true, "ISK": true}
+
+// NormalizeCurrency đưa mã tiền tệ về chữ in hoa, trống thì dùng DefaultCurrency
+func NormalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DefaultCurrency, nil
+	}
+	if !currencyPattern.MatchString(currency) {
+		return "", ErrInvalidCurrency
+	}
+	return currency, nil
+}
+
+func roundMoney(amount float64, currency string) float64 {
+	if zeroDecimalCurrencies[currency] {
+		return math.Round(amount)
+	}
+	return math.Round(amount*100) / 100
+}
+
+// billTotals là tổng tiền của bill, cộng từ các dòng đã làm tròn để tổng luôn khớp với từng dòng
+type billTotals struct {
+	Subtotal      float64
+	DiscountTotal float64
+	TaxTotal      float64
+	GrandTotal    float64
+}
+
+// buildLineItems kiểm tra và tính tiền từng dòng: subtotal = quantity * unitPrice - discount, tax = subtotal * taxRate / 100
+func buildLineItems(requests []dto.BillLineItemRequest, currency string) ([]entity.BillLineItems, billTotals, error) {
+	var totals billTotals
+	items := make([]entity.BillLineItems, 0, len(requests))
+	for i, req := range requests {
+		description := strings.TrimSpace(req.Description)
+		switch {
+		case description == "":
+			return nil, totals, fmt.Errorf("%w: line %d: description is required", ErrInvalidLineItem, i+1)
+		case req.Quantity <= 0:
+			return nil, totals, fmt.Errorf("%w: line %d: quantity must be greater than 0", ErrInvalidLineItem, i+1)
+		case req.UnitPrice < 0:
+			return nil, totals, fmt.Errorf("%w: line %d: unit price must not be negative", ErrInvalidLineItem, i+1)
+		case req.TaxRate < 0 || req.TaxRate > 100:
+			return nil, totals, fmt.Errorf("%w: line %d: tax rate must be between 0 and 100", ErrInvalidLineItem, i+1)
+		case req.Discount < 0:
+			return nil, totals, fmt.Errorf("%w: line %d: discount must not be negative", ErrInvalidLineItem, i+1)
+		}
+		gross := roundMoney(req.Quantity*req.UnitPrice, currency)
+		discount := roundMoney(req.Discount, currency)
+		if discount > gross {
+			return nil, totals, fmt.Errorf("%w: line %d: discount exceeds line amount", ErrInvalidLineItem, i+1)
+		}
+		subtotal := gross - discount
+		tax := roundMoney(subtotal*req.TaxRate/100, currency)
+		items = append(items, entity.BillLineItems{
+			AssetId:      req.AssetId,
+			Position:     i,
+			Description:  description,
+			Quantity:     req.Quantity,
+			UnitPrice:    req.UnitPrice,
+			TaxRate:      req.TaxRate,
+			Discount:     discount,
+			LineSubtotal: subtotal,
+			LineTax:      tax,
+			LineTotal:    subtotal + tax,
+		})
+		totals.Subtotal += subtotal
+		totals.DiscountTotal += discount
+		totals.TaxTotal += tax
+	}
+	totals.Subtotal = roundMoney(totals.Subtotal, currency)
+	totals.DiscountTotal = roundMoney(totals.DiscountTotal, currency)
+	totals.TaxTotal = roundMoney(totals.TaxTotal, currency)
+	totals.GrandTotal = roundMoney(totals.Subtotal+totals.TaxTotal, currency)
+	return items, totals, nil
+}
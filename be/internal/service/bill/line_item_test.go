@@ -0,0 +1,181 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", DefaultCurrency, false},
+		{"  ", DefaultCurrency, false},
+		{"usd", "USD", false},
+		{" jpy ", "JPY", false},
+		{"US", "", true},
+		{"USDT", "", true},
+		{"U$D", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := NormalizeCurrency(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCurrency) {
+					t.Fatalf("err = %v, want ErrInvalidCurrency", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizeCurrency(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     float64
+	}{
+		{1000.5, "VND", 1001},
+		{1000.49, "VND", 1000},
+		{99.5, "JPY", 100},
+		{12.345, "USD", 12.35},
+		{12.344, "USD", 12.34},
+		{0.125, "EUR", 0.13},
+		{-1.5, "VND", -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			if got := roundMoney(tt.amount, tt.currency); !almostEqual(got, tt.want) {
+				t.Errorf("roundMoney(%v, %s) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLineItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		requests  []dto.BillLineItemRequest
+		currency  string
+		wantLines [][3]float64 // subtotal, tax, total của từng dòng
+		want      billTotals
+	}{
+		{
+			name:      "empty",
+			currency:  "USD",
+			wantLines: [][3]float64{},
+		},
+		{
+			name:      "zero decimal currency",
+			requests:  []dto.BillLineItemRequest{{Description: "Laptop", Quantity: 3, UnitPrice: 333.5, TaxRate: 10}},
+			currency:  "VND",
+			wantLines: [][3]float64{{1001, 100, 1101}},
+			want:      billTotals{Subtotal: 1001, TaxTotal: 100, GrandTotal: 1101},
+		},
+		{
+			name:      "discount and tax",
+			requests:  []dto.BillLineItemRequest{{Description: "Mouse", Quantity: 3, UnitPrice: 19.99, TaxRate: 8.25, Discount: 2.5}},
+			currency:  "USD",
+			wantLines: [][3]float64{{57.47, 4.74, 62.21}},
+			want:      billTotals{Subtotal: 57.47, DiscountTotal: 2.5, TaxTotal: 4.74, GrandTotal: 62.21},
+		},
+		{
+			name:      "fractional quantity",
+			requests:  []dto.BillLineItemRequest{{Description: "Cable", Quantity: 2.5, UnitPrice: 3.3}},
+			currency:  "EUR",
+			wantLines: [][3]float64{{8.25, 0, 8.25}},
+			want:      billTotals{Subtotal: 8.25, GrandTotal: 8.25},
+		},
+		// tổng cộng từ các dòng đã làm tròn nên 2 x 0.13 = 0.26 chứ không phải round(0.25)
+		{
+			name: "totals from rounded lines",
+			requests: []dto.BillLineItemRequest{
+				{Description: "A", Quantity: 1, UnitPrice: 0.125},
+				{Description: "B", Quantity: 1, UnitPrice: 0.125},
+			},
+			currency:  "USD",
+			wantLines: [][3]float64{{0.13, 0, 0.13}, {0.13, 0, 0.13}},
+			want:      billTotals{Subtotal: 0.26, GrandTotal: 0.26},
+		},
+		{
+			name:      "full discount",
+			requests:  []dto.BillLineItemRequest{{Description: "Gift", Quantity: 1, UnitPrice: 50, TaxRate: 10, Discount: 50}},
+			currency:  "USD",
+			wantLines: [][3]float64{{0, 0, 0}},
+			want:      billTotals{DiscountTotal: 50},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, totals, err := buildLineItems(tt.requests, tt.currency)
+			if err != nil {
+				t.Fatalf("buildLineItems: %v", err)
+			}
+			if len(items) != len(tt.wantLines) {
+				t.Fatalf("got %d items, want %d", len(items), len(tt.wantLines))
+			}
+			for i, item := range items {
+				want := tt.wantLines[i]
+				if item.Position != i || !almostEqual(item.LineSubtotal, want[0]) || !almostEqual(item.LineTax, want[1]) || !almostEqual(item.LineTotal, want[2]) {
+					t.Errorf("line %d = position %d, %v/%v/%v, want %v", i, item.Position, item.LineSubtotal, item.LineTax, item.LineTotal, want)
+				}
+			}
+			if !almostEqual(totals.Subtotal, tt.want.Subtotal) || !almostEqual(totals.DiscountTotal, tt.want.DiscountTotal) ||
+				!almostEqual(totals.TaxTotal, tt.want.TaxTotal) || !almostEqual(totals.GrandTotal, tt.want.GrandTotal) {
+				t.Errorf("totals = %+v, want %+v", totals, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildLineItemsTrimsDescription(t *testing.T) {
+	items, _, err := buildLineItems([]dto.BillLineItemRequest{{Description: "  Laptop  ", Quantity: 1, UnitPrice: 10}}, "USD")
+	if err != nil {
+		t.Fatalf("buildLineItems: %v", err)
+	}
+	if items[0].Description != "Laptop" {
+		t.Errorf("description = %q", items[0].Description)
+	}
+}
+
+func TestBuildLineItemsInvalid(t *testing.T) {
+	valid := dto.BillLineItemRequest{Description: "Laptop", Quantity: 1, UnitPrice: 100}
+	tests := []struct {
+		name    string
+		line    dto.BillLineItemRequest
+		wantMsg string
+	}{
+		{"blank description", dto.BillLineItemRequest{Description: "  ", Quantity: 1, UnitPrice: 1}, "description is required"},
+		{"zero quantity", dto.BillLineItemRequest{Description: "X", Quantity: 0, UnitPrice: 1}, "quantity must be greater than 0"},
+		{"negative price", dto.BillLineItemRequest{Description: "X", Quantity: 1, UnitPrice: -1}, "unit price must not be negative"},
+		{"tax above 100", dto.BillLineItemRequest{Description: "X", Quantity: 1, UnitPrice: 1, TaxRate: 101}, "tax rate must be between 0 and 100"},
+		{"negative tax", dto.BillLineItemRequest{Description: "X", Quantity: 1, UnitPrice: 1, TaxRate: -1}, "tax rate must be between 0 and 100"},
+		{"negative discount", dto.BillLineItemRequest{Description: "X", Quantity: 1, UnitPrice: 1, Discount: -1}, "discount must not be negative"},
+		{"discount above amount", dto.BillLineItemRequest{Description: "X", Quantity: 2, UnitPrice: 10, Discount: 20.01}, "discount exceeds line amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// dòng lỗi đặt ở vị trí 2 để kiểm tra số dòng trong thông báo
+			_, _, err := buildLineItems([]dto.BillLineItemRequest{valid, tt.line}, "USD")
+			if !errors.Is(err, ErrInvalidLineItem) {
+				t.Fatalf("err = %v, want ErrInvalidLineItem", err)
+			}
+			if !strings.Contains(err.Error(), "line 2: "+tt.wantMsg) {
+				t.Errorf("err = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
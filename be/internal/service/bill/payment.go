@@ -0,0 +1,274 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	emailS "BE_Manage_device/internal/service/email"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPayment            = errors.New("invalid payment")
+	ErrPaymentExceedsOutstanding = errors.New("payment amount exceeds the outstanding balance")
+	ErrBillAlreadyPaid           = errors.New("bill is already fully paid")
+	ErrInvalidDueDate            = errors.New("due date must be in format YYYY-MM-DD")
+)
+
+// Hạn thanh toán tính theo ngày ở giờ Việt Nam, giống múi giờ chạy cron
+var billLocation = time.FixedZone("Asia/Ho_Chi_Minh", 7*3600)
+
+const (
+	AgingBucketCurrent    = "current"
+	AgingBucket0To30      = "0-30"
+	AgingBucket31To60     = "31-60"
+	AgingBucket61To90     = "61-90"
+	AgingBucketOver90Days = "90+"
+)
+
+// resolveDueDate đọc hạn thanh toán YYYY-MM-DD, bỏ trống thì lấy ngày tạo + BILL_DEFAULT_DUE_DAYS
+func resolveDueDate(dueDate string, createdAt time.Time) (*time.Time, error) {
+	if strings.TrimSpace(dueDate) == "" {
+		local := createdAt.In(billLocation)
+		due := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, billLocation).AddDate(0, 0, config.BILL_DEFAULT_DUE_DAYS)
+		return &due, nil
+	}
+	due, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(dueDate), billLocation)
+	if err != nil {
+		return nil, ErrInvalidDueDate
+	}
+	return &due, nil
+}
+
+// recordPayment ghi 1 lần thanh toán cho bill đã khoá trong tx, cộng PaidAmount và lưu lại trạng thái suy ra
+func (service *BillsService) recordPayment(tx *gorm.DB, bill *entity.Bill, payment *entity.BillPayments) error {
+	now := time.Now()
+	payment.Amount = roundMoney(payment.Amount, bill.Currency)
+	if payment.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be greater than 0", ErrInvalidPayment)
+	}
+	if !slices.Contains(entity.BillPaymentMethods, payment.Method) {
+		return fmt.Errorf("%w: unknown method %q", ErrInvalidPayment, payment.Method)
+	}
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = now
+	} else if payment.PaidAt.After(now) {
+		return fmt.Errorf("%w: paid date must not be in the future", ErrInvalidPayment)
+	}
+	outstanding := bill.Outstanding()
+	if outstanding == 0 {
+		return ErrBillAlreadyPaid
+	}
+	if payment.Amount > roundMoney(outstanding, bill.Currency) {
+		return ErrPaymentExceedsOutstanding
+	}
+	payment.BillId = bill.Id
+	if err := service.repo.CreatePayment(payment, tx); err != nil {
+		return err
+	}
+	bill.PaidAmount = roundMoney(bill.PaidAmount+payment.Amount, bill.Currency)
+	bill.StatusBill = bill.DeriveStatus(now)
+	return service.repo.UpdatePaymentState(bill.Id, bill.PaidAmount, bill.StatusBill, tx)
+}
+
+// RecordPayment ghi 1 lần thanh toán (có thể 1 phần) cho bill thuộc công ty của user
+func (service *BillsService) RecordPayment(userId int64, billNumber string, request dto.CreateBillPaymentRequest) (*entity.Bill, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	bill, err := service.repo.LockByBillNumber(billNumber, user.CompanyId, tx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrBillNotFound
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	payment := entity.BillPayments{
+		Amount:       request.Amount,
+		Method:       request.Method,
+		Reference:    strings.TrimSpace(request.Reference),
+		PayerName:    strings.TrimSpace(request.PayerName),
+		RecordedById: userId,
+	}
+	if request.PaidAt != nil {
+		payment.PaidAt = *request.PaidAt
+	}
+	if err = service.recordPayment(tx, bill, &payment); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetByBillNumber(billNumber)
+}
+
+// GetPayments trả về các lần thanh toán của bill thuộc công ty của user
+func (service *BillsService) GetPayments(userId int64, billNumber string) ([]entity.BillPayments, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	bill, err := service.repo.GetByBillNumber(billNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && bill.CompanyId != user.CompanyId) {
+		return nil, ErrBillNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bill.Payments, nil
+}
+
+// SendOverdueReminders chạy mỗi ngày: chuyển các bill quá hạn sang Overdue, nhắc người mua qua email và người tạo bill
+// qua thông báo. Mỗi bill được nhắc lại sau BILL_REMINDER_INTERVAL_DAYS ngày cho tới khi trả đủ
+func (service *BillsService) SendOverdueReminders() {
+	now := time.Now().In(billLocation)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, billLocation)
+	bills, err := service.repo.GetOverdueBills(now)
+	if err != nil {
+		log.Error("Happened error when get overdue bills. Error", err)
+		return
+	}
+	var staleIds []int64
+	for _, b := range bills {
+		if b.LastReminderAt != nil {
+			last := b.LastReminderAt.In(billLocation)
+			lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, billLocation)
+			if startOfDay.Before(lastDay.AddDate(0, 0, config.BILL_REMINDER_INTERVAL_DAYS)) {
+				if b.StatusBill != entity.BillStatusOverdue {
+					staleIds = append(staleIds, b.Id)
+				}
+				continue
+			}
+		}
+		if err := service.sendOverdueReminder(b, now); err != nil {
+			log.Errorf("Happened error when send overdue reminder for bill %v. Error %v", b.BillNumber, err)
+		}
+	}
+	if err := service.repo.MarkOverdue(staleIds); err != nil {
+		log.Error("Happened error when mark bills overdue. Error", err)
+	}
+}
+
+func (service *BillsService) sendOverdueReminder(bill *entity.Bill, now time.Time) error {
+	data := map[string]interface{}{
+		"BillNumber":  bill.BillNumber,
+		"BuyerName":   bill.BuyerName,
+		"DueDate":     bill.DueDate.In(billLocation),
+		"DaysOverdue": bill.DaysOverdue(now),
+		"Outstanding": formatMoney(bill.Outstanding(), bill.Currency),
+		"Currency":    bill.Currency,
+	}
+	message := fmt.Sprintf("Bill %v of %v is overdue since %v, outstanding %v %v", bill.BillNumber, bill.BuyerName, bill.DueDate.In(billLocation).Format("2006-01-02"), data["Outstanding"], bill.Currency)
+	return service.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := service.repo.UpdateOverdueReminder(bill.Id, now, tx); err != nil {
+			return err
+		}
+		if bill.BuyerEmail != "" {
+			buyerData := map[string]interface{}{"Name": bill.BuyerName}
+			for k, v := range data {
+				buyerData[k] = v
+			}
+			if err := service.emailService.EnqueueTemplate(tx, &bill.CompanyId, bill.BuyerEmail, "", emailS.TemplateBillOverdue, buyerData, now); err != nil {
+				return err
+			}
+		}
+		return service.notificationService.NotifyWithEmail(tx, &bill.CompanyId, []*entity.Users{&bill.CreateBy}, entity.NotificationEventBillOverdue, message, emailS.TemplateBillOverdue, data)
+	})
+}
+
+// agingBucket xếp bill vào nhóm tuổi nợ theo số ngày đã quá hạn
+func agingBucket(daysOverdue int) string {
+	switch {
+	case daysOverdue <= 0:
+		return AgingBucketCurrent
+	case daysOverdue <= 30:
+		return AgingBucket0To30
+	case daysOverdue <= 60:
+		return AgingBucket31To60
+	case daysOverdue <= 90:
+		return AgingBucket61To90
+	}
+	return AgingBucketOver90Days
+}
+
+// GetAgingReport lập báo cáo tuổi nợ (0-30/31-60/61-90/90+ ngày quá hạn) các bill chưa trả đủ của công ty
+func (service *BillsService) GetAgingReport(userId int64) (*dto.BillAgingReportResponse, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	bills, err := service.repo.GetOutstandingBills(user.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	report := &dto.BillAgingReportResponse{AsOf: now, Currencies: []dto.BillAgingBucketResponse{}, Bills: []dto.BillAgingItemResponse{}}
+	byCurrency := map[string]*dto.BillAgingBucketResponse{}
+	for _, b := range bills {
+		outstanding := b.Outstanding()
+		if outstanding == 0 {
+			continue
+		}
+		days := b.DaysOverdue(now)
+		bucket := agingBucket(days)
+		total, ok := byCurrency[b.Currency]
+		if !ok {
+			total = &dto.BillAgingBucketResponse{Currency: b.Currency}
+			byCurrency[b.Currency] = total
+		}
+		switch bucket {
+		case AgingBucketCurrent:
+			total.Current += outstanding
+		case AgingBucket0To30:
+			total.Days0To30 += outstanding
+		case AgingBucket31To60:
+			total.Days31To60 += outstanding
+		case AgingBucket61To90:
+			total.Days61To90 += outstanding
+		default:
+			total.Days90Plus += outstanding
+		}
+		total.Total += outstanding
+		total.BillCount++
+		report.Bills = append(report.Bills, dto.BillAgingItemResponse{
+			BillNumber:  b.BillNumber,
+			BuyerName:   b.BuyerName,
+			StatusBill:  b.DeriveStatus(now),
+			DueDate:     b.DueDate,
+			DaysOverdue: max(days, 0),
+			Bucket:      bucket,
+			Currency:    b.Currency,
+			Outstanding: outstanding,
+		})
+	}
+	for currency, total := range byCurrency {
+		total.Current = roundMoney(total.Current, currency)
+		total.Days0To30 = roundMoney(total.Days0To30, currency)
+		total.Days31To60 = roundMoney(total.Days31To60, currency)
+		total.Days61To90 = roundMoney(total.Days61To90, currency)
+		total.Days90Plus = roundMoney(total.Days90Plus, currency)
+		total.Total = roundMoney(total.Total, currency)
+		report.Currencies = append(report.Currencies, *total)
+	}
+	sort.Slice(report.Currencies, func(i, j int) bool { return report.Currencies[i].Currency < report.Currencies[j].Currency })
+	return report, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAgingBucket(t *testing.T) {
+	tests := []struct {
+		daysOverdue int
+		want        string
+	}{
+		{-5, AgingBucketCurrent},
+		{0, AgingBucketCurrent},
+		{1, AgingBucket0To30},
+		{30, AgingBucket0To30},
+		{31, AgingBucket31To60},
+		{60, AgingBucket31To60},
+		{61, AgingBucket61To90},
+		{90, AgingBucket61To90},
+		{91, AgingBucketOver90Days},
+		{400, AgingBucketOver90Days},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := agingBucket(tt.daysOverdue); got != tt.want {
+				t.Errorf("agingBucket(%d) = %q, want %q", tt.daysOverdue, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDueDate(t *testing.T) {
+	defaultDueDays := config.BILL_DEFAULT_DUE_DAYS
+	defer func() { config.BILL_DEFAULT_DUE_DAYS = defaultDueDays }()
+	config.BILL_DEFAULT_DUE_DAYS = 30
+
+	tests := []struct {
+		name      string
+		dueDate   string
+		createdAt time.Time
+		want      time.Time
+		wantErr   bool
+	}{
+		{"explicit", "2026-04-15", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), time.Date(2026, 4, 15, 0, 0, 0, 0, billLocation), false},
+		{"explicit with spaces", " 2026-04-15 ", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), time.Date(2026, 4, 15, 0, 0, 0, 0, billLocation), false},
+		{"default", "", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, billLocation), false},
+		// 20:00 UTC đã là ngày hôm sau ở giờ Việt Nam
+		{"default uses local day", "", time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 0, 0, 0, 0, billLocation), false},
+		{"invalid format", "15/04/2026", time.Now(), time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDueDate(tt.dueDate, tt.createdAt)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDueDate) {
+					t.Fatalf("err = %v, want ErrInvalidDueDate", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDueDate: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("resolveDueDate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,7 +6,12 @@ import (
 	"BE_Manage_device/internal/domain/filter"
 	assets "BE_Manage_device/internal/repository/assets"
 	bill "BE_Manage_device/internal/repository/bill"
+	company "BE_Manage_device/internal/repository/company"
 	user "BE_Manage_device/internal/repository/user"
+	emailS "BE_Manage_device/internal/service/email"
+	notificationS "BE_Manage_device/internal/service/notification"
+	vendorS "BE_Manage_device/internal/service/vendor"
+	"BE_Manage_device/pkg/storage"
 	"BE_Manage_device/pkg/utils"
 	"fmt"
 	"mime/multipart"
@@ -15,17 +20,51 @@ import (
 )
 
 type BillsService struct {
-	repo      bill.BillsRepository
-	assetRepo assets.AssetsRepository
-	userRepo  user.UserRepository
+	repo        bill.BillsRepository
+	assetRepo   assets.AssetsRepository
+	userRepo    user.UserRepository
+	companyRepo company.CompanyRepository
+	storage     storage.Storage
+
+	notificationService *notificationS.NotificationService
+	emailService        *emailS.EmailService
+	vendorService       *vendorS.VendorService
 }
 
-func NewBillService(repo bill.BillsRepository, assetRepo assets.AssetsRepository, userRepo user.UserRepository) *BillsService {
-	return &BillsService{repo: repo, assetRepo: assetRepo, userRepo: userRepo}
+func NewBillService(repo bill.BillsRepository, assetRepo assets.AssetsRepository, userRepo user.UserRepository, companyRepo company.CompanyRepository, storage storage.Storage, notificationService *notificationS.NotificationService, emailService *emailS.EmailService, vendorService *vendorS.VendorService) *BillsService {
+	return &BillsService{repo: repo, assetRepo: assetRepo, userRepo: userRepo, companyRepo: companyRepo, storage: storage, notificationService: notificationService, emailService: emailService, vendorService: vendorService}
 }
 
-func (service *BillsService) Create(userId int64, assetIds []int64, description string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, status string, buyerName, buyerPhone, buyerEmail, buyerAddress string) (*entity.Bill, error) {
-	uploader := utils.NewSupabaseUploader()
+// Create tạo bill cùng các dòng hàng. assetIds cũ vẫn được nhận: tài sản nào chưa có dòng riêng thì sinh 1 dòng theo giá tài sản.
+// status Paid nghĩa là đã thu đủ lúc tạo, khi đó ghi luôn 1 lần thanh toán bằng paymentMethod cho toàn bộ số tiền
+func (service *BillsService) Create(userId int64, assetIds []int64, lineItems []dto.BillLineItemRequest, currency string, dueDate string, paymentMethod string, description string, image *multipart.FileHeader, fileAttachment *multipart.FileHeader, status string, buyerName, buyerPhone, buyerEmail, buyerAddress string, vendorId *int64) (*entity.Bill, error) {
+	currency, err := NormalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := time.Now()
+	due, err := resolveDueDate(dueDate, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if paymentMethod == "" {
+		paymentMethod = entity.BillPaymentMethodOther
+	}
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	if err = service.vendorService.CheckVendor(user.CompanyId, vendorId); err != nil {
+		return nil, err
+	}
+	lineItems, linkedAssetIds, err := service.resolveLineItems(user.CompanyId, assetIds, lineItems)
+	if err != nil {
+		return nil, err
+	}
+	items, totals, err := buildLineItems(lineItems, currency)
+	if err != nil {
+		return nil, err
+	}
 	var imageUrl string
 	var fileUrl string
 	if image != nil {
@@ -36,7 +75,7 @@ func (service *BillsService) Create(userId int64, assetIds []int64, description
 		defer imgFile.Close()
 		uniqueName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), image.Filename)
 		imagePath := "bill_image/" + uniqueName
-		i, err := uploader.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
+		i, err := service.storage.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
 		if err != nil {
 			return nil, err
 		}
@@ -50,43 +89,70 @@ func (service *BillsService) Create(userId int64, assetIds []int64, description
 		defer fileFile.Close()
 		uniqueName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), fileAttachment.Filename)
 		filePath := "bill_files/" + uniqueName
-		f, err := uploader.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
+		f, err := service.storage.Upload(filePath, fileFile, fileAttachment.Header.Get("Content-Type"))
 		if err != nil {
 			return nil, err
 		}
 		fileUrl = f
 	}
-	user, err := service.userRepo.FindByUserId(userId)
-	if err != nil {
-		return nil, err
-	}
 	bill := entity.Bill{
 		Description:        description,
-		CreateAt:           time.Now(),
+		CreateAt:           createdAt,
 		CreateById:         userId,
 		CompanyId:          user.CompanyId,
 		FileAttachmentBill: &fileUrl,
 		ImageUploadBill:    &imageUrl,
-		StatusBill:         status,
+		StatusBill:         entity.BillStatusUnpaid,
+		DueDate:            due,
 		BuyerName:          buyerName,
 		BuyerPhone:         buyerPhone,
 		BuyerEmail:         buyerEmail,
 		BuyerAddress:       buyerAddress,
+		VendorId:           vendorId,
+		Currency:           currency,
+		Subtotal:           totals.Subtotal,
+		DiscountTotal:      totals.DiscountTotal,
+		TaxTotal:           totals.TaxTotal,
+		GrandTotal:         totals.GrandTotal,
 	}
-	billCreate, err := service.repo.Create(&bill)
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	billCreate, err := service.repo.Create(&bill, tx)
 	if err != nil {
 		return nil, err
 	}
-	for _, assetId := range assetIds {
+	for _, assetId := range linkedAssetIds {
 		billAsset := entity.BillAsset{
 			BillId:  bill.Id,
 			AssetId: assetId,
 		}
-		err := service.repo.AddAssetsToBill(&billAsset)
+		err = service.repo.AddAssetsToBill(&billAsset, tx)
 		if err != nil {
 			return nil, err
 		}
 	}
+	for i := range items {
+		items[i].BillId = bill.Id
+	}
+	if err = service.repo.CreateLineItems(items, tx); err != nil {
+		return nil, err
+	}
+	if status == entity.BillStatusPaid && bill.GrandTotal > 0 {
+		payment := entity.BillPayments{Amount: bill.GrandTotal, Method: paymentMethod, PayerName: buyerName, PaidAt: createdAt, RecordedById: userId}
+		if err = service.recordPayment(tx, &bill, &payment); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
 	BillGet, err := service.repo.GetByBillNumber(billCreate.BillNumber)
 	if err != nil {
 		return nil, err
@@ -94,6 +160,51 @@ func (service *BillsService) Create(userId int64, assetIds []int64, description
 	return BillGet, nil
 }
 
+// resolveLineItems kiểm tra các tài sản thuộc công ty và thêm dòng cho tài sản chỉ có trong assetIds.
+// Trả về danh sách dòng và các tài sản cần gắn vào bill (không trùng)
+func (service *BillsService) resolveLineItems(companyId int64, assetIds []int64, lineItems []dto.BillLineItemRequest) ([]dto.BillLineItemRequest, []int64, error) {
+	var linked []int64
+	seen := map[int64]bool{}
+	link := func(assetId int64) (*entity.Assets, error) {
+		asset, err := service.assetRepo.GetAssetById(assetId)
+		if err != nil || asset.CompanyId != companyId {
+			return nil, fmt.Errorf("%w: asset %d not found", ErrInvalidLineItem, assetId)
+		}
+		if !seen[assetId] {
+			seen[assetId] = true
+			linked = append(linked, assetId)
+		}
+		return asset, nil
+	}
+	for _, item := range lineItems {
+		if item.AssetId == nil {
+			continue
+		}
+		if _, err := link(*item.AssetId); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, assetId := range assetIds {
+		if seen[assetId] {
+			continue
+		}
+		asset, err := link(assetId)
+		if err != nil {
+			return nil, nil, err
+		}
+		lineItems = append(lineItems, dto.BillLineItemRequest{
+			AssetId:     &asset.Id,
+			Description: asset.AssetName,
+			Quantity:    1,
+			UnitPrice:   asset.Cost,
+		})
+	}
+	if len(lineItems) == 0 {
+		return nil, nil, ErrEmptyBill
+	}
+	return lineItems, linked, nil
+}
+
 func (service *BillsService) GetByBillNumber(billNumber string) (*entity.Bill, error) {
 	bill, err := service.repo.GetByBillNumber(billNumber)
 	return bill, err
@@ -132,7 +243,20 @@ func (service *BillsService) GetAllBillUnpaid(userId int64) ([]*entity.Bill, err
 	bills, err := service.repo.GetAllBillUnpaid(user.CompanyId)
 	return bills, err
 }
-func (service *BillsService) UpdatePaid(billNumberStr string) error {
-	err := service.repo.UpdatePaid(billNumberStr)
+
+// UpdatePaid đánh dấu bill đã trả đủ bằng cách ghi 1 lần thanh toán cho toàn bộ số tiền còn nợ
+func (service *BillsService) UpdatePaid(userId int64, billNumberStr string) error {
+	bill, err := service.GetByBillNumber(billNumberStr)
+	if err != nil {
+		return err
+	}
+	if bill.Outstanding() == 0 {
+		return nil
+	}
+	_, err = service.RecordPayment(userId, billNumberStr, dto.CreateBillPaymentRequest{
+		Amount:    bill.Outstanding(),
+		Method:    entity.BillPaymentMethodOther,
+		PayerName: bill.BuyerName,
+	})
 	return err
 }
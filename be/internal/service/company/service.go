@@ -3,14 +3,16 @@ package service
 import (
 	"BE_Manage_device/internal/domain/entity"
 	company "BE_Manage_device/internal/repository/company"
+	user "BE_Manage_device/internal/repository/user"
 )
 
 type CompanyService struct {
-	repo company.CompanyRepository
+	repo     company.CompanyRepository
+	userRepo user.UserRepository
 }
 
-func NewCompanyService(repo company.CompanyRepository) *CompanyService {
-	return &CompanyService{repo: repo}
+func NewCompanyService(repo company.CompanyRepository, userRepo user.UserRepository) *CompanyService {
+	return &CompanyService{repo: repo, userRepo: userRepo}
 }
 
 func (service *CompanyService) Create(companyName, email string) (*entity.Company, error) {
@@ -26,3 +28,12 @@ func (service *CompanyService) GetCompanyById(id int64) (*entity.Company, error)
 	company, err := service.repo.GetCompanyById(id)
 	return company, err
 }
+
+// UpdateMfaPolicy bật/tắt bắt buộc MFA cho admin và assetManager của công ty user đang đăng nhập
+func (service *CompanyService) UpdateMfaPolicy(userId int64, requireMfa bool) (*entity.Company, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.UpdateRequireMfa(users.CompanyId, requireMfa)
+}
@@ -6,6 +6,7 @@ import (
 	department "BE_Manage_device/internal/repository/departments"
 	user "BE_Manage_device/internal/repository/user"
 	"BE_Manage_device/pkg/utils"
+	"errors"
 )
 
 type DepartmentsService struct {
@@ -56,6 +57,35 @@ func (service *DepartmentsService) Delete(id int64) error {
 	return err
 }
 
+// UpdateHead đặt trưởng phòng, người này phải thuộc chính phòng ban đó
+func (service *DepartmentsService) UpdateHead(userId int64, departmentId int64, headId *int64) (*entity.Departments, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	department, err := service.repo.GetDepartmentById(departmentId)
+	if err != nil {
+		return nil, err
+	}
+	if department.CompanyId != user.CompanyId {
+		return nil, errors.New("department not found")
+	}
+	if headId != nil {
+		head, err := service.userRepo.FindByUserId(*headId)
+		if err != nil {
+			return nil, err
+		}
+		if head.CompanyId != user.CompanyId || head.DepartmentId == nil || *head.DepartmentId != departmentId {
+			return nil, errors.New("department head must belong to the department")
+		}
+	}
+	if err := service.repo.UpdateHead(departmentId, headId); err != nil {
+		return nil, err
+	}
+	department.HeadId = headId
+	return department, nil
+}
+
 func (service *DepartmentsService) GetCompanyId(userId int64) (int64, error) {
 	user, err := service.userRepo.FindByUserId(userId)
 	return user.CompanyId, err
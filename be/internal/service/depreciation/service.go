@@ -0,0 +1,223 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	asset_log "BE_Manage_device/internal/repository/asset_log"
+	assetUsage "BE_Manage_device/internal/repository/asset_usages"
+	asset "BE_Manage_device/internal/repository/assets"
+	categories "BE_Manage_device/internal/repository/categories"
+	user "BE_Manage_device/internal/repository/user"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type DepreciationService struct {
+	assetRepo      asset.AssetsRepository
+	categoriesRepo categories.CategoriesRepository
+	usageRepo      assetUsage.AssetUsagesRepository
+	assetLogRepo   asset_log.AssetsLogRepository
+	userRepo       user.UserRepository
+}
+
+func NewDepreciationService(assetRepo asset.AssetsRepository, categoriesRepo categories.CategoriesRepository, usageRepo assetUsage.AssetUsagesRepository, assetLogRepo asset_log.AssetsLogRepository, userRepo user.UserRepository) *DepreciationService {
+	return &DepreciationService{assetRepo: assetRepo, categoriesRepo: categoriesRepo, usageRepo: usageRepo, assetLogRepo: assetLogRepo, userRepo: userRepo}
+}
+
+// GetSchedule trả về bảng khấu hao theo từng kỳ của tài sản
+func (service *DepreciationService) GetSchedule(userId int64, assetId int64) (*dto.DepreciationScheduleResponse, error) {
+	assetCheck, err := service.getAssetOfCompany(userId, assetId)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := service.usageRepo.GetUsagesByAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+	in, err := utils.ResolveDepreciationInput(assetCheck, usages)
+	if err != nil {
+		return nil, err
+	}
+	periods := utils.BuildDepreciationSchedule(in)
+	currentBookValue := utils.CurrentAssetValue(in, time.Now())
+	res := dto.DepreciationScheduleResponse{
+		AssetId:                 assetCheck.Id,
+		AssetName:               assetCheck.AssetName,
+		Method:                  in.Method,
+		Cost:                    in.Cost,
+		ResidualValue:           in.ResidualValue,
+		UsefulLife:              in.UsefulLife,
+		StartDate:               in.StartDate.Format("2006-01-02"),
+		CurrentBookValue:        currentBookValue,
+		AccumulatedDepreciation: in.Cost - currentBookValue,
+		Periods:                 periods,
+	}
+	if in.Method == utils.DepreciationDecliningBalance || in.Method == utils.DepreciationDoubleDecliningBalance {
+		res.DecliningFactor = &in.DecliningFactor
+	}
+	if in.Method == utils.DepreciationUnitsOfProduction {
+		res.TotalUnits = &in.TotalUnits
+	}
+	return &res, nil
+}
+
+// UpdateAssetDepreciation ghi đè cấu hình khấu hao của category cho riêng tài sản
+func (service *DepreciationService) UpdateAssetDepreciation(userId int64, assetId int64, request dto.UpdateAssetDepreciationRequest) (*dto.DepreciationScheduleResponse, error) {
+	var err error
+	assetCheck, err := service.getAssetOfCompany(userId, assetId)
+	if err != nil {
+		return nil, err
+	}
+	if request.DepreciationMethod != nil && !utils.ValidDepreciationMethod(*request.DepreciationMethod) {
+		return nil, fmt.Errorf("invalid depreciation method '%v'", *request.DepreciationMethod)
+	}
+	if request.ResidualValue != nil && *request.ResidualValue > assetCheck.Cost {
+		return nil, errors.New("residual value can't be greater than cost")
+	}
+	if request.ResetToCategory {
+		assetCheck.DepreciationMethod = nil
+		assetCheck.UsefulLife = nil
+		assetCheck.ResidualValue = nil
+		assetCheck.TotalUnits = nil
+	}
+	// Trường không gửi lên thì giữ nguyên giá trị đang ghi đè
+	if request.DepreciationMethod != nil {
+		assetCheck.DepreciationMethod = request.DepreciationMethod
+	}
+	if request.UsefulLife != nil {
+		assetCheck.UsefulLife = request.UsefulLife
+	}
+	if request.ResidualValue != nil {
+		assetCheck.ResidualValue = request.ResidualValue
+	}
+	if request.TotalUnits != nil {
+		assetCheck.TotalUnits = request.TotalUnits
+	}
+	// Kiểm tra cấu hình sau khi gộp với category trước khi lưu
+	if _, err = utils.ResolveDepreciationInput(assetCheck, nil); err != nil {
+		return nil, err
+	}
+	tx := service.assetRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if _, err = service.assetRepo.UpdateDepreciationSettings(assetCheck, tx); err != nil {
+		return nil, err
+	}
+	assetLog := entity.AssetLog{
+		Action:        "Update",
+		Event:         entity.WebhookEventAssetUpdated,
+		Timestamp:     time.Now(),
+		ByUserId:      &userId,
+		ChangeSummary: fmt.Sprintf("Updated depreciation settings: method %v, useful life %v, residual value %v, total units %v", derefOrDefault(assetCheck.DepreciationMethod), derefFloatOrDefault(assetCheck.UsefulLife), derefFloatOrDefault(assetCheck.ResidualValue), derefFloatOrDefault(assetCheck.TotalUnits)),
+		AssetId:       assetCheck.Id,
+		CompanyId:     assetCheck.CompanyId,
+	}
+	if _, err = service.assetLogRepo.Create(&assetLog, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.GetSchedule(userId, assetId)
+}
+
+// RecordUsage ghi sản lượng thực tế của 1 kỳ cho phương pháp units-of-production
+func (service *DepreciationService) RecordUsage(userId int64, assetId int64, period int, units float64) (*entity.AssetUsages, error) {
+	var err error
+	assetCheck, err := service.getAssetOfCompany(userId, assetId)
+	if err != nil {
+		return nil, err
+	}
+	tx := service.usageRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	usage := entity.AssetUsages{
+		AssetId:      assetId,
+		Period:       period,
+		Units:        units,
+		RecordedById: userId,
+		CompanyId:    assetCheck.CompanyId,
+		UpdatedAt:    time.Now(),
+	}
+	if _, err = service.usageRepo.Upsert(&usage, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return &usage, nil
+}
+
+// UpdateCategoryDepreciation cấu hình khấu hao mặc định cho category
+func (service *DepreciationService) UpdateCategoryDepreciation(userId int64, categoryId int64, request dto.UpdateCategoryDepreciationRequest) (*entity.Categories, error) {
+	userUpdate, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	category, err := service.categoriesRepo.GetById(categoryId)
+	if err != nil {
+		return nil, err
+	}
+	if category.CompanyId != userUpdate.CompanyId {
+		return nil, errors.New("category not found")
+	}
+	if request.DepreciationMethod != nil && !utils.ValidDepreciationMethod(*request.DepreciationMethod) {
+		return nil, fmt.Errorf("invalid depreciation method '%v'", *request.DepreciationMethod)
+	}
+	category.DepreciationMethod = request.DepreciationMethod
+	category.UsefulLife = request.UsefulLife
+	category.ResidualRate = request.ResidualRate
+	category.DecliningFactor = request.DecliningFactor
+	return service.categoriesRepo.UpdateDepreciation(category)
+}
+
+func (service *DepreciationService) GetCompanyId(userId int64) (int64, error) {
+	user, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return 0, err
+	}
+	return user.CompanyId, nil
+}
+
+func (service *DepreciationService) getAssetOfCompany(userId int64, assetId int64) (*entity.Assets, error) {
+	userCheck, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	assetCheck, err := service.assetRepo.GetAssetById(assetId)
+	if err != nil {
+		return nil, err
+	}
+	if assetCheck.CompanyId != userCheck.CompanyId {
+		return nil, errors.New("asset not found")
+	}
+	return assetCheck, nil
+}
+
+func derefOrDefault(value *string) string {
+	if value == nil {
+		return "category default"
+	}
+	return *value
+}
+
+func derefFloatOrDefault(value *float64) string {
+	if value == nil {
+		return "category default"
+	}
+	return fmt.Sprint(*value)
+}
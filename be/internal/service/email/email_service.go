@@ -2,88 +2,171 @@ package service
 
 import (
 	"BE_Manage_device/config"
-	"BE_Manage_device/pkg/utils"
-
+	"BE_Manage_device/internal/domain/entity"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	gomail "gopkg.in/mail.v2"
+	"gorm.io/gorm"
 )
 
+// ErrSkipMail do LinkBuilder trả về khi email không còn cần gửi (vd: tài khoản đã kích hoạt), message được bỏ qua thay vì retry
+var ErrSkipMail = errors.New("mail is no longer needed")
+
+// LinkBuilder tạo link chứa token lúc gửi để token không bị lưu trong outbox
+type LinkBuilder func(userId int64, redirectUrl string) (string, error)
+
 type EmailService struct {
-	Dialer *gomail.Dialer
-	Mutex  sync.Mutex
+	transport Transport
+	from      string
+	outbox    *outboxS.OutboxService
+	mu        sync.RWMutex
+	links     map[string]LinkBuilder
 }
 
-func NewEmailService(pw string) *EmailService {
-	dialer := gomail.NewDialer("smtp.gmail.com", 587, "thanhhaxuan02@gmail.com", pw)
-	return &EmailService{
-		Dialer: dialer,
+// emailMessage là payload outbox, mỗi message cho 1 người nhận. Body là bản HTML, Text là bản plain-text (có thể trống với message cũ).
+// Email chứa link bí mật chỉ lưu Template, UserId, RedirectUrl và được render lúc gửi
+type emailMessage struct {
+	To          string                 `json:"to"`
+	Subject     string                 `json:"subject"`
+	Body        string                 `json:"body"`
+	Text        string                 `json:"text,omitempty"`
+	Template    string                 `json:"template,omitempty"`
+	Language    string                 `json:"language,omitempty"`
+	UserId      int64                  `json:"userId,omitempty"`
+	RedirectUrl string                 `json:"redirectUrl,omitempty"`
+	Data        map[string]interface{} `json:"data,omitempty"`
+}
+
+func NewEmailService(transport Transport, outbox *outboxS.OutboxService) *EmailService {
+	service := &EmailService{
+		transport: transport,
+		from:      config.SMTP_FROM,
+		outbox:    outbox,
+		links:     map[string]LinkBuilder{},
 	}
+	outbox.RegisterHandler(entity.OutboxChannelEmail, service.deliver)
+	return service
 }
 
-func (service *EmailService) SendActivationEmail(email string, token string, redirectUrl string) error {
-	const maxRetry = 3
-	for attempt := 1; attempt <= maxRetry; attempt++ {
-		m := gomail.NewMessage()
-		m.SetHeader("From", "thanhhaxuan02@gmail.com")
-		m.SetHeader("To", email)
-		m.SetHeader("Subject", "Verify Account")
-		safeToken := url.QueryEscape(token)
-		safeRedirect := url.QueryEscape(redirectUrl)
-		actLink := config.BASE_URL_BACKEND + "api/activate?token=" + safeToken + "&redirectUrl=" + safeRedirect
-		m.SetBody("text/html", "Click link to activate account: <a href= '"+actLink+"'> Activate </a>")
-		service.Mutex.Lock()
-		sender, err := service.Dialer.Dial()
-		service.Mutex.Unlock()
-		if err != nil {
-			if attempt == maxRetry {
-				return err
-			}
-			continue
-		}
-		err = gomail.Send(sender, m)
-		sender.Close()
-		if err == nil {
-			utils.LogEmailSuccess("email", email)
+// RegisterLinkBuilder đăng ký hàm tạo link cho template chứa token, gọi lúc khởi tạo service
+func (service *EmailService) RegisterLinkBuilder(name string, builder LinkBuilder) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.links[name] = builder
+}
+
+func (service *EmailService) linkBuilder(name string) (LinkBuilder, bool) {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	builder, ok := service.links[name]
+	return builder, ok
+}
+
+// ActivationLink là link kích hoạt tài khoản gửi trong email
+func ActivationLink(token string, redirectUrl string) string {
+	return config.BASE_URL_BACKEND + "api/activate?token=" + url.QueryEscape(token) + "&redirectUrl=" + url.QueryEscape(redirectUrl)
+}
+
+// EnqueueActivationEmail ghi email kích hoạt tài khoản vào outbox trong transaction tx, link được tạo lúc gửi
+func (service *EmailService) EnqueueActivationEmail(tx *gorm.DB, companyId *int64, user *entity.Users, redirectUrl string) error {
+	data := map[string]interface{}{"Name": user.FirstName}
+	return service.EnqueueLinkTemplate(tx, companyId, user.Email, user.Language, TemplateActivation, user.Id, redirectUrl, data)
+}
+
+// EnqueueLinkTemplate ghi email chứa link bí mật: outbox chỉ lưu template, user và redirectUrl, Link được LinkBuilder tạo lúc gửi
+func (service *EmailService) EnqueueLinkTemplate(tx *gorm.DB, companyId *int64, to string, lang string, name string, userId int64, redirectUrl string, data map[string]interface{}) error {
+	if to == "" {
+		return nil
+	}
+	if _, ok := service.linkBuilder(name); !ok {
+		return fmt.Errorf("no link builder for template %q", name)
+	}
+	payload := emailMessage{To: to, Template: name, Language: lang, UserId: userId, RedirectUrl: redirectUrl, Data: data}
+	return service.outbox.EnqueueAt(tx, companyId, entity.OutboxChannelEmail, payload, time.Now())
+}
+
+// EnqueueTemplate render template theo ngôn ngữ của người nhận rồi ghi vào outbox, chỉ gửi từ thời điểm notBefore
+func (service *EmailService) EnqueueTemplate(tx *gorm.DB, companyId *int64, to string, lang string, name string, data interface{}, notBefore time.Time) error {
+	mail, err := Render(name, lang, data)
+	if err != nil {
+		return err
+	}
+	return service.EnqueueMail(tx, companyId, to, mail, notBefore)
+}
+
+// EnqueueMail ghi email đã render vào outbox trong transaction tx, dispatcher gửi và retry sau khi commit
+func (service *EmailService) EnqueueMail(tx *gorm.DB, companyId *int64, to string, mail *Mail, notBefore time.Time) error {
+	if to == "" {
+		return nil
+	}
+	payload := emailMessage{To: to, Subject: mail.Subject, Body: mail.HTML, Text: mail.Text}
+	return service.outbox.EnqueueAt(tx, companyId, entity.OutboxChannelEmail, payload, notBefore)
+}
+
+// deliver là handler của outbox cho kênh email
+func (service *EmailService) deliver(m *entity.OutboxMessages) error {
+	var payload emailMessage
+	if err := json.Unmarshal([]byte(m.Payload), &payload); err != nil {
+		return err
+	}
+	if payload.Template != "" {
+		mail, err := service.renderLink(&payload)
+		if errors.Is(err, ErrSkipMail) {
 			return nil
 		}
-		utils.LogEmailError("email", email, err)
-		if strings.Contains(err.Error(), "broken pipe") && attempt < maxRetry {
-			continue
-		} else {
+		if err != nil {
 			return err
 		}
+		payload.Subject, payload.Body, payload.Text = mail.Subject, mail.HTML, mail.Text
 	}
-	return fmt.Errorf("send email retry failed")
+	return service.SendEmail(payload.To, payload.Subject, payload.Body, payload.Text)
+}
+
+// renderLink tạo link bằng LinkBuilder của template rồi render email, token chỉ tồn tại trong bộ nhớ
+func (service *EmailService) renderLink(payload *emailMessage) (*Mail, error) {
+	builder, ok := service.linkBuilder(payload.Template)
+	if !ok {
+		return nil, fmt.Errorf("no link builder for template %q", payload.Template)
+	}
+	link, err := builder(payload.UserId, payload.RedirectUrl)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{}
+	for k, v := range payload.Data {
+		data[k] = v
+	}
+	data["Link"] = link
+	return Render(payload.Template, payload.Language, data)
 }
 
-func (service *EmailService) SendEmail(email string, subject string, body string) error {
+// SendEmail gửi multipart/alternative: bản plain-text trước, bản HTML sau để client ưu tiên HTML
+func (service *EmailService) SendEmail(email string, subject string, html string, text string) error {
 	const maxRetry = 3
 	for attempt := 1; attempt <= maxRetry; attempt++ {
 		msg := gomail.NewMessage()
-		msg.SetHeader("From", "thanhhaxuan02@gmail.com")
+		msg.SetHeader("From", service.from)
 		msg.SetHeader("To", email)
 		msg.SetHeader("Subject", subject)
-		msg.SetBody("text/html", body)
-		service.Mutex.Lock()
-		sender, err := service.Dialer.Dial()
-		service.Mutex.Unlock()
-		if err != nil {
-			if attempt == maxRetry {
-				return err
-			}
-			continue
+		if text != "" {
+			msg.SetBody("text/plain", text)
+			msg.AddAlternative("text/html", html)
+		} else {
+			msg.SetBody("text/html", html)
 		}
-		err = gomail.Send(sender, msg)
-		sender.Close()
+		err := service.transport.Send(msg)
 		if err == nil {
-			utils.LogEmailSuccess("email", email)
+			logEmailSuccess("email", email)
 			return nil
 		}
-		utils.LogEmailError("email", email, err)
+		logEmailError("email", email, err)
 		if strings.Contains(err.Error(), "broken pipe") && attempt < maxRetry {
 			continue
 		} else {
@@ -92,26 +175,3 @@ func (service *EmailService) SendEmail(email string, subject string, body string
 	}
 	return fmt.Errorf("send email retry failed")
 }
-
-func (service *EmailService) SendEmails(emails []string, subject string, body string) {
-	const workerCount = 10
-	type emailJob struct {
-		Email string
-	}
-	jobs := make(chan emailJob, len(emails))
-	var wg sync.WaitGroup
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for job := range jobs {
-				_ = service.SendEmail(job.Email, subject, body)
-			}
-		}()
-	}
-	for _, email := range emails {
-		jobs <- emailJob{Email: email}
-	}
-	close(jobs)
-	wg.Wait()
-}
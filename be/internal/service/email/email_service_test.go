@@ -0,0 +1,89 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	outbox "BE_Manage_device/internal/repository/outbox"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	"bytes"
+	"strings"
+	"testing"
+
+	gomail "gopkg.in/mail.v2"
+	"gorm.io/gorm"
+)
+
+type fakeOutboxRepo struct {
+	outbox.OutboxRepository
+	created []*entity.OutboxMessages
+}
+
+func (r *fakeOutboxRepo) Create(message *entity.OutboxMessages, tx *gorm.DB) error {
+	r.created = append(r.created, message)
+	return nil
+}
+
+type fakeTransport struct{ sent []string }
+
+func (t *fakeTransport) Send(msg *gomail.Message) error {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return err
+	}
+	t.sent = append(t.sent, buf.String())
+	return nil
+}
+
+// Token chỉ được tạo lúc gửi, payload trong outbox không chứa link
+func TestEnqueueLinkTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  LinkBuilder
+		wantSent bool
+	}{
+		{"render at send time", func(userId int64, redirectUrl string) (string, error) {
+			return redirectUrl + "?token=secret-token", nil
+		}, true},
+		{"skip obsolete mail", func(userId int64, redirectUrl string) (string, error) {
+			return "", ErrSkipMail
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOutboxRepo{}
+			transport := &fakeTransport{}
+			service := NewEmailService(transport, outboxS.NewOutboxService(repo))
+			service.RegisterLinkBuilder(TemplatePasswordReset, tt.builder)
+
+			data := map[string]interface{}{"Name": "Lan", "ExpiresInMinutes": 10}
+			if err := service.EnqueueLinkTemplate(nil, nil, "lan@example.com", LanguageEnglish, TemplatePasswordReset, 1, "https://example.com/reset", data); err != nil {
+				t.Fatalf("EnqueueLinkTemplate: %v", err)
+			}
+			if len(repo.created) != 1 {
+				t.Fatalf("created %d messages, want 1", len(repo.created))
+			}
+			if strings.Contains(repo.created[0].Payload, "token") {
+				t.Errorf("payload stores the token: %s", repo.created[0].Payload)
+			}
+			if err := service.deliver(repo.created[0]); err != nil {
+				t.Fatalf("deliver: %v", err)
+			}
+			if got := len(transport.sent) == 1; got != tt.wantSent {
+				t.Fatalf("sent %d mails, want sent = %v", len(transport.sent), tt.wantSent)
+			}
+			if tt.wantSent && !strings.Contains(transport.sent[0], "secret-token") {
+				t.Errorf("mail missing link:\n%s", transport.sent[0])
+			}
+		})
+	}
+}
+
+func TestEnqueueLinkTemplateWithoutBuilder(t *testing.T) {
+	repo := &fakeOutboxRepo{}
+	service := NewEmailService(&fakeTransport{}, outboxS.NewOutboxService(repo))
+	if err := service.EnqueueLinkTemplate(nil, nil, "lan@example.com", LanguageEnglish, TemplateActivation, 1, "", nil); err == nil {
+		t.Error("expected error for template without link builder")
+	}
+	if len(repo.created) != 0 {
+		t.Errorf("created %d messages, want 0", len(repo.created))
+	}
+}
@@ -1,8 +1,8 @@
-package utils
+package service
 
 import log "github.com/sirupsen/logrus"
 
-func LogEmailError(action string, to string, err error) {
+func logEmailError(action string, to string, err error) {
 	log.WithFields(log.Fields{
 		"action": action,
 		"to":     to,
@@ -10,7 +10,7 @@ func LogEmailError(action string, to string, err error) {
 	}).Error("❌ Gửi email thất bại")
 }
 
-func LogEmailSuccess(action string, to string) {
+func logEmailSuccess(action string, to string) {
 	log.WithFields(log.Fields{
 		"action": action,
 		"to":     to,
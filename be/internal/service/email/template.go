@@ -0,0 +1,116 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Tên template, mỗi ngôn ngữ có {name}.html trong <lang>.html và {name}.subject, {name}.text trong <lang>.txt.
+// Template có bản thông báo in-app thì thêm {name}.inapp trong <lang>.txt
+const (
+	TemplateActivation    = "activation"
+	TemplatePasswordReset = "password_reset"
+	TemplateMaintenance   = "maintenance"
+	TemplateWarranty      = "warranty"
+	TemplateAssignment    = "assignment"
+	TemplateLoanOverdue   = "loan_overdue"
+	TemplateNotification  = "notification"
+	TemplateDigest        = "digest"
+	TemplateBillOverdue   = "bill_overdue"
+)
+
+const (
+	LanguageEnglish    = "en"
+	LanguageVietnamese = "vi"
+)
+
+var Languages = []string{LanguageEnglish, LanguageVietnamese}
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Mail là email đã render, Text là bản plain-text gửi kèm bản HTML
+type Mail struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+type languageTemplates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var mailTemplates = mustLoadTemplates()
+
+// Định dạng ngày theo thói quen của từng ngôn ngữ
+var dateLayouts = map[string][2]string{
+	LanguageEnglish:    {"Jan 2, 2006", "Jan 2, 15:04"},
+	LanguageVietnamese: {"02/01/2006", "15:04 02/01"},
+}
+
+func mustLoadTemplates() map[string]languageTemplates {
+	result := map[string]languageTemplates{}
+	for _, lang := range Languages {
+		layouts := dateLayouts[lang]
+		funcs := map[string]interface{}{
+			"date":     func(t time.Time) string { return t.Format(layouts[0]) },
+			"datetime": func(t time.Time) string { return t.Format(layouts[1]) },
+		}
+		html := htmltemplate.Must(htmltemplate.New(lang).Funcs(funcs).ParseFS(templateFS, "templates/"+lang+".html"))
+		text := texttemplate.Must(texttemplate.New(lang).Funcs(funcs).ParseFS(templateFS, "templates/"+lang+".txt"))
+		result[lang] = languageTemplates{html: html, text: text}
+	}
+	return result
+}
+
+// NormalizeLanguage đưa "vi-VN", "EN"... về ngôn ngữ có template, không hỗ trợ thì dùng MAIL_DEFAULT_LANGUAGE
+func NormalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if slices.Contains(Languages, lang) {
+		return lang
+	}
+	if slices.Contains(Languages, config.MAIL_DEFAULT_LANGUAGE) {
+		return config.MAIL_DEFAULT_LANGUAGE
+	}
+	return LanguageEnglish
+}
+
+// Render dựng subject, bản HTML và bản plain-text của template name theo ngôn ngữ lang
+func Render(name, lang string, data interface{}) (*Mail, error) {
+	t := mailTemplates[NormalizeLanguage(lang)]
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := t.text.ExecuteTemplate(&text, name+".text", data); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", name, err)
+	}
+	return &Mail{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    strings.TrimSpace(html.String()),
+		Text:    strings.TrimSpace(text.String()),
+	}, nil
+}
+
+// RenderInApp dựng nội dung thông báo in-app ({name}.inapp) theo ngôn ngữ lang
+func RenderInApp(name, lang string, data interface{}) (string, error) {
+	var text bytes.Buffer
+	if err := mailTemplates[NormalizeLanguage(lang)].text.ExecuteTemplate(&text, name+".inapp", data); err != nil {
+		return "", fmt.Errorf("render %s in-app: %w", name, err)
+	}
+	return strings.TrimSpace(text.String()), nil
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/entity"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	defaultLanguage := config.MAIL_DEFAULT_LANGUAGE
+	defer func() { config.MAIL_DEFAULT_LANGUAGE = defaultLanguage }()
+
+	tests := []struct {
+		name            string
+		lang            string
+		defaultLanguage string
+		want            string
+	}{
+		{"english", "en", "en", LanguageEnglish},
+		{"upper case", "EN", "vi", LanguageEnglish},
+		{"region", "vi-VN", "en", LanguageVietnamese},
+		{"underscore region", "vi_VN", "en", LanguageVietnamese},
+		{"spaces", "  vi ", "en", LanguageVietnamese},
+		{"unsupported uses default", "fr", "vi", LanguageVietnamese},
+		{"empty uses default", "", "vi", LanguageVietnamese},
+		// MAIL_DEFAULT_LANGUAGE cấu hình sai thì về tiếng Anh
+		{"invalid default", "fr", "de", LanguageEnglish},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.MAIL_DEFAULT_LANGUAGE = tt.defaultLanguage
+			if got := NormalizeLanguage(tt.lang); got != tt.want {
+				t.Errorf("NormalizeLanguage(%q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func templateData() map[string]interface{} {
+	date := time.Date(2026, 3, 5, 14, 30, 0, 0, time.UTC)
+	return map[string]interface{}{
+		"Name":             "Lan",
+		"Link":             "https://example.com/activate?token=abc",
+		"ExpiresInMinutes": 15,
+		"AssetName":        "Laptop 01",
+		"Start":            date,
+		"End":              date.AddDate(0, 0, 1),
+		"ExpiryDate":       date,
+		"AssignedTo":       "Minh",
+		"AssignedBy":       "Lan",
+		"Borrower":         "Minh",
+		"DueDate":          date,
+		"DaysOverdue":      3,
+		"Message":          "Asset Laptop 01 was updated",
+		"Items": []*entity.NotificationDigestItems{
+			{Event: "asset.updated", Message: "Asset Laptop 01 was updated", CreatedAt: date},
+			{Event: "maintenance.due", Message: "Laptop 02 is due for maintenance", CreatedAt: date},
+		},
+		"BillNumber":  "B-0001",
+		"BuyerName":   "ACME",
+		"Outstanding": "1500.00",
+		"Currency":    "USD",
+	}
+}
+
+// Mọi template phải có đủ subject, text, html ở tất cả ngôn ngữ
+func TestRenderAllTemplates(t *testing.T) {
+	names := []string{TemplateActivation, TemplatePasswordReset, TemplateMaintenance, TemplateWarranty, TemplateAssignment,
+		TemplateLoanOverdue, TemplateNotification, TemplateDigest, TemplateBillOverdue}
+	for _, lang := range Languages {
+		for _, name := range names {
+			t.Run(lang+"/"+name, func(t *testing.T) {
+				mail, err := Render(name, lang, templateData())
+				if err != nil {
+					t.Fatalf("Render: %v", err)
+				}
+				if mail.Subject == "" || mail.Text == "" || mail.HTML == "" {
+					t.Errorf("empty part: %+v", mail)
+				}
+				if strings.Contains(mail.Text+mail.HTML+mail.Subject, "<no value>") {
+					t.Errorf("missing data in %+v", mail)
+				}
+			})
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		lang        string
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+	}{
+		{
+			name:        "maintenance english date",
+			template:    TemplateMaintenance,
+			lang:        LanguageEnglish,
+			wantSubject: "Asset Laptop 01 is scheduled for maintenance on Mar 5, 2026",
+			wantText:    []string{"Dear Lan,", "- Start Date: Mar 5, 2026", "- End Date: Mar 6, 2026"},
+			wantHTML:    []string{"<td>Mar 5, 2026</td>"},
+		},
+		{
+			name:        "maintenance vietnamese date",
+			template:    TemplateMaintenance,
+			lang:        "vi-VN",
+			wantSubject: "Tài sản Laptop 01 được lên lịch bảo trì ngày 05/03/2026",
+			wantText:    []string{"Xin chào Lan,"},
+			wantHTML:    []string{"05/03/2026"},
+		},
+		{
+			name:        "digest datetime",
+			template:    TemplateDigest,
+			lang:        LanguageEnglish,
+			wantSubject: "Your daily notification digest (2)",
+			wantText:    []string{"- [Mar 5, 14:30] asset.updated: Asset Laptop 01 was updated"},
+			wantHTML:    []string{"<td>Mar 5, 14:30</td>"},
+		},
+		{
+			name:        "digest vietnamese datetime",
+			template:    TemplateDigest,
+			lang:        LanguageVietnamese,
+			wantSubject: "Tổng hợp thông báo hằng ngày (2)",
+			wantText:    []string{"- [14:30 05/03] maintenance.due: Laptop 02 is due for maintenance"},
+		},
+		{
+			name:        "password reset link",
+			template:    TemplatePasswordReset,
+			lang:        LanguageEnglish,
+			wantSubject: "Reset Password",
+			wantText:    []string{"https://example.com/activate?token=abc", "expires in 15 minutes"},
+			wantHTML:    []string{`href="https://example.com/activate?token=abc"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mail, err := Render(tt.template, tt.lang, templateData())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if mail.Subject != tt.wantSubject {
+				t.Errorf("subject = %q, want %q", mail.Subject, tt.wantSubject)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(mail.Text, want) {
+					t.Errorf("text missing %q:\n%s", want, mail.Text)
+				}
+			}
+			for _, want := range tt.wantHTML {
+				if !strings.Contains(mail.HTML, want) {
+					t.Errorf("html missing %q:\n%s", want, mail.HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	data := templateData()
+	data["Message"] = "<script>alert(1)</script>\nnext line"
+	mail, err := Render(TemplateNotification, LanguageEnglish, data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if strings.Contains(mail.HTML, "<script>") {
+		t.Errorf("html not escaped: %s", mail.HTML)
+	}
+	if !strings.Contains(mail.Text, "<script>alert(1)</script>") {
+		t.Errorf("text must keep the raw message: %s", mail.Text)
+	}
+	// subject gộp về 1 dòng
+	if mail.Subject != "<script>alert(1)</script> next line" {
+		t.Errorf("subject = %q", mail.Subject)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("unknown", LanguageEnglish, templateData()); err == nil {
+		t.Error("expected error for unknown template")
+	}
+	if _, err := RenderInApp(TemplateActivation, LanguageEnglish, templateData()); err == nil {
+		t.Error("expected error for template without in-app version")
+	}
+}
+
+func TestRenderInApp(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{LanguageEnglish, "You have 2 new notification(s):\n- Asset Laptop 01 was updated\n- Laptop 02 is due for maintenance"},
+		{LanguageVietnamese, "Bạn có 2 thông báo mới:\n- Asset Laptop 01 was updated\n- Laptop 02 is due for maintenance"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			got, err := RenderInApp(TemplateDigest, tt.lang, templateData())
+			if err != nil {
+				t.Fatalf("RenderInApp: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderInApp = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	gomail "gopkg.in/mail.v2"
+)
+
+// Transport gửi 1 email đã dựng xong, SMTP khi chạy thật, file/console khi chạy local và test
+type Transport interface {
+	Send(msg *gomail.Message) error
+}
+
+const (
+	TransportSMTP    = "smtp"
+	TransportFile    = "file"
+	TransportConsole = "console"
+)
+
+// NewTransport khởi tạo transport theo biến môi trường MAIL_TRANSPORT
+func NewTransport() (Transport, error) {
+	switch config.MAIL_TRANSPORT {
+	case TransportSMTP, "":
+		return NewSMTPTransport(config.SMTP_HOST, config.SMTP_PORT, config.SMTP_USERNAME, config.SmtpPasswd, config.SMTP_TLS)
+	case TransportFile:
+		return NewFileTransport(config.MAIL_SINK_DIR)
+	case TransportConsole:
+		return &consoleTransport{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", config.MAIL_TRANSPORT)
+	}
+}
+
+type smtpTransport struct {
+	dialer *gomail.Dialer
+	mu     sync.Mutex
+}
+
+func NewSMTPTransport(host string, port int, username, password, tlsMode string) (Transport, error) {
+	dialer := gomail.NewDialer(host, port, username, password)
+	switch tlsMode {
+	case "starttls", "":
+		dialer.SSL = false
+		dialer.StartTLSPolicy = gomail.MandatoryStartTLS
+	case "ssl":
+		dialer.SSL = true
+	case "none":
+		dialer.SSL = false
+		dialer.StartTLSPolicy = gomail.NoStartTLS
+	default:
+		return nil, fmt.Errorf("unknown SMTP_TLS %q", tlsMode)
+	}
+	return &smtpTransport{dialer: dialer}, nil
+}
+
+func (t *smtpTransport) Send(msg *gomail.Message) error {
+	t.mu.Lock()
+	sender, err := t.dialer.Dial()
+	t.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	defer sender.Close()
+	return gomail.Send(sender, msg)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// fileTransport ghi mỗi email thành 1 file .eml trong dir, mở được bằng mail client để xem bản html/text
+type fileTransport struct {
+	dir string
+}
+
+func NewFileTransport(dir string) (Transport, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create mail sink dir: %w", err)
+	}
+	return &fileTransport{dir: dir}, nil
+}
+
+func (t *fileTransport) Send(msg *gomail.Message) error {
+	to := unsafeFileChars.ReplaceAllString(strings.Join(msg.GetHeader("To"), "_"), "_")
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), to)
+	f, err := os.Create(filepath.Join(t.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = msg.WriteTo(f)
+	return err
+}
+
+// consoleTransport chỉ log email ra stdout
+type consoleTransport struct{}
+
+func (t *consoleTransport) Send(msg *gomail.Message) error {
+	var b strings.Builder
+	if _, err := msg.WriteTo(&b); err != nil {
+		return err
+	}
+	log.Infof("📧 Mail to %v\n%s", msg.GetHeader("To"), b.String())
+	return nil
+}
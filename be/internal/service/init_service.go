@@ -2,21 +2,30 @@ package service
 
 import (
 	"BE_Manage_device/internal/repository"
+	asset_log "BE_Manage_device/internal/repository/asset_log"
+	apiTokenS "BE_Manage_device/internal/service/api_token"
 	assetS "BE_Manage_device/internal/service/asset"
+	assetLoanS "BE_Manage_device/internal/service/asset_loan"
 	assetLogS "BE_Manage_device/internal/service/asset_log"
 	assignmentS "BE_Manage_device/internal/service/assignment"
 	bill "BE_Manage_device/internal/service/bill"
 	categoriesS "BE_Manage_device/internal/service/categories"
 	company "BE_Manage_device/internal/service/company"
 	departmentS "BE_Manage_device/internal/service/departments"
+	depreciationS "BE_Manage_device/internal/service/depreciation"
 	emailS "BE_Manage_device/internal/service/email"
 	locationS "BE_Manage_device/internal/service/location"
 	maintenanceSchedulesS "BE_Manage_device/internal/service/maintenance_schedules"
 	MonthlySummary "BE_Manage_device/internal/service/monthly_summary"
 	notificationS "BE_Manage_device/internal/service/notification"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	purchaseOrderS "BE_Manage_device/internal/service/purchase_order"
 	requestTransferS "BE_Manage_device/internal/service/request_transfer"
 	roleS "BE_Manage_device/internal/service/role"
 	userS "BE_Manage_device/internal/service/user"
+	vendorS "BE_Manage_device/internal/service/vendor"
+	webhookS "BE_Manage_device/internal/service/webhook"
+	"BE_Manage_device/pkg/storage"
 )
 
 type Services struct {
@@ -35,11 +44,27 @@ type Services struct {
 	Company              *company.CompanyService
 	Bill                 *bill.BillsService
 	MonthlySummary       *MonthlySummary.MonthlySummaryService
+	AssetLoan            *assetLoanS.AssetLoanService
+	Depreciation         *depreciationS.DepreciationService
+	ApiToken             *apiTokenS.ApiTokenService
+	Webhook              *webhookS.WebhookService
+	Outbox               *outboxS.OutboxService
+	Vendor               *vendorS.VendorService
+	PurchaseOrder        *purchaseOrderS.PurchaseOrderService
 }
 
-func NewServices(repos *repository.Repository, emailPass string) *Services {
-	emailService := emailS.NewEmailService(emailPass)
-	notificationService := notificationS.NewNotificationService(repos.Notification)
+func NewServices(repos *repository.Repository, mailTransport emailS.Transport, store storage.Storage) *Services {
+	// Email, thông báo và sự kiện webhook đều đi qua outbox, các service dưới đây đăng ký handler cho kênh của mình
+	outboxService := outboxS.NewOutboxService(repos.Outbox)
+	emailService := emailS.NewEmailService(mailTransport, outboxService)
+	notificationService := notificationS.NewNotificationService(repos.Notification, repos.NotificationPreference, repos.User, outboxService, emailService)
+	webhookService := webhookS.NewWebhookService(repos.Webhook, outboxService)
+	// Mọi nơi ghi AssetLog (service và cron) đều ghi sự kiện webhook vào outbox trong cùng transaction
+	repos.AssetsLog = asset_log.NewPublishingAssetsLogRepository(repos.AssetsLog, webhookService.EnqueueAssetLog)
+	// Tài sản, bill và lịch bảo trì đều kiểm tra vendor được gắn qua vendorService
+	vendorService := vendorS.NewVendorService(repos.Vendor)
+	// Nhập kho từ đơn mua hàng tạo tài sản qua assetsService để giữ đúng log và bản giao như tạo tay
+	assetsService := assetS.NewAssetsService(repos.Assets, repos.AssetsLog, repos.Role, repos.AssetGrant, repos.User, repos.Assignment, repos.Department, notificationService, repos.Company, repos.Categories, store, vendorService, repos.AssetUsage)
 
 	assignmentService := assignmentS.NewAssignmentService(
 		repos.Assignment,
@@ -51,20 +76,27 @@ func NewServices(repos *repository.Repository, emailPass string) *Services {
 	)
 
 	return &Services{
-		User:                 userS.NewUserService(repos.User, emailService, repos.UserSession, repos.Role, repos.Assets, repos.UserRBAC, repos.Company),
+		User:                 userS.NewUserService(repos.User, emailService, repos.UserSession, repos.Role, repos.Assets, repos.Company, store, repos.UserMfa),
 		Location:             locationS.NewLocationService(repos.Location),
 		Categories:           categoriesS.NewCategoriesService(repos.Categories, repos.User, repos.Company),
 		Department:           departmentS.NewDepartmentsService(repos.Department, repos.User, repos.Company),
-		Assets:               assetS.NewAssetsService(repos.Assets, repos.AssetsLog, repos.Role, repos.UserRBAC, repos.User, repos.Assignment, repos.Department, notificationService, repos.Company),
-		Role:                 roleS.NewRoleService(repos.Role),
+		Assets:               assetsService,
+		Role:                 roleS.NewRoleService(repos.Role, repos.User),
 		Assignment:           assignmentService,
 		AssetLog:             assetLogS.NewAssetLogService(repos.AssetsLog, repos.User, repos.Role, repos.Assets),
-		RequestTransfer:      requestTransferS.NewRequestTransferService(repos.RequestTransfer, assignmentService, repos.User, repos.Assets),
-		MaintenanceSchedules: maintenanceSchedulesS.NewMaintenanceSchedulesService(repos.MaintenanceSchedules, repos.Assets, repos.User, notificationService),
+		RequestTransfer:      requestTransferS.NewRequestTransferService(repos.RequestTransfer, assignmentService, repos.User, repos.Assets, repos.AssetsLog, repos.AssetLoan, notificationService),
+		MaintenanceSchedules: maintenanceSchedulesS.NewMaintenanceSchedulesService(repos.MaintenanceSchedules, repos.Assets, repos.User, notificationService, vendorService),
 		Notification:         notificationService,
 		Email:                emailService,
-		Company:              company.NewCompanyService(repos.Company),
-		Bill:                 bill.NewBillService(repos.Bill, repos.Assets, repos.User),
+		Company:              company.NewCompanyService(repos.Company, repos.User),
+		Bill:                 bill.NewBillService(repos.Bill, repos.Assets, repos.User, repos.Company, store, notificationService, emailService, vendorService),
 		MonthlySummary:       MonthlySummary.NewMonthlySummaryService(repos.MonthlySummary, repos.Bill, repos.User),
+		AssetLoan:            assetLoanS.NewAssetLoanService(repos.AssetLoan, repos.Assets, repos.Assignment, repos.AssetsLog, repos.User, notificationService),
+		Depreciation:         depreciationS.NewDepreciationService(repos.Assets, repos.Categories, repos.AssetUsage, repos.AssetsLog, repos.User),
+		ApiToken:             apiTokenS.NewApiTokenService(repos.ApiToken),
+		Webhook:              webhookService,
+		Outbox:               outboxService,
+		Vendor:               vendorService,
+		PurchaseOrder:        purchaseOrderS.NewPurchaseOrderService(repos.PurchaseOrder, repos.Assets, repos.User, repos.Department, repos.Categories, assetsService, vendorService),
 	}
 }
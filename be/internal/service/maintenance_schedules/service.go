@@ -1,14 +1,25 @@
 package service
 
 import (
+	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	asset "BE_Manage_device/internal/repository/assets"
 	maintenanceSchedules "BE_Manage_device/internal/repository/maintenance_schedules"
 	user "BE_Manage_device/internal/repository/user"
 	notificationS "BE_Manage_device/internal/service/notification"
+	vendorS "BE_Manage_device/internal/service/vendor"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeAll       = "all"
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
 )
 
 type MaintenanceSchedulesService struct {
@@ -16,13 +27,15 @@ type MaintenanceSchedulesService struct {
 	assetRepo           asset.AssetsRepository
 	userRepository      user.UserRepository
 	NotificationService *notificationS.NotificationService
+	vendorService       *vendorS.VendorService
 }
 
-func NewMaintenanceSchedulesService(repo maintenanceSchedules.MaintenanceSchedulesRepository, assetRepo asset.AssetsRepository, userRepository user.UserRepository, NotificationService *notificationS.NotificationService) *MaintenanceSchedulesService {
-	return &MaintenanceSchedulesService{repo: repo, assetRepo: assetRepo, NotificationService: NotificationService, userRepository: userRepository}
+func NewMaintenanceSchedulesService(repo maintenanceSchedules.MaintenanceSchedulesRepository, assetRepo asset.AssetsRepository, userRepository user.UserRepository, NotificationService *notificationS.NotificationService, vendorService *vendorS.VendorService) *MaintenanceSchedulesService {
+	return &MaintenanceSchedulesService{repo: repo, assetRepo: assetRepo, NotificationService: NotificationService, userRepository: userRepository, vendorService: vendorService}
 }
 
-func (service *MaintenanceSchedulesService) Create(userId int64, assetId int64, startDate, endDate time.Time) (*entity.MaintenanceSchedules, error) {
+func (service *MaintenanceSchedulesService) Create(userId int64, assetId int64, startDate, endDate time.Time, recurrence *dto.MaintenanceRecurrenceRequest, vendorId *int64) (*entity.MaintenanceSchedules, error) {
+	var err error
 	loc, _ := time.LoadLocation("Asia/Bangkok") // GMT+7
 	startDate = startDate.In(loc)
 	endDate = endDate.In(loc)
@@ -37,44 +50,47 @@ func (service *MaintenanceSchedulesService) Create(userId int64, assetId int64,
 	if assetCheck.Status == "Disposed" || assetCheck.Status == "Retired" || assetCheck.Status == "Under Maintenance" {
 		return nil, errors.New("can't set maintenance schedules because status")
 	}
+	if err = service.vendorService.CheckVendor(userUpdate.CompanyId, vendorId); err != nil {
+		return nil, err
+	}
 	maintenance := entity.MaintenanceSchedules{
-		AssetId:   assetId,
-		StartDate: startDate,
-		EndDate:   endDate,
+		AssetId:        assetId,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		VendorId:       vendorId,
+		RepeatInterval: 1,
 	}
-	timeRange, err := service.repo.GetDateMaintenanceSchedulesInFuture(assetId)
-	if err != nil {
+	if err = applyRecurrence(&maintenance, recurrence); err != nil {
 		return nil, err
 	}
-	for _, r := range timeRange {
-		if !(endDate.Before(r.Start) || startDate.After(r.End)) {
-			return nil, errors.New("maintenance time overlaps with existing schedule")
-		}
-	}
-	maintenanceCreate, err := service.repo.Create(&maintenance)
+	existing, err := service.repo.GetActiveMaintenanceSchedulesByAssetId(assetId)
 	if err != nil {
 		return nil, err
 	}
-	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(assetCheck.DepartmentId)
-	usersToNotifications := []*entity.Users{}
-	usersToNotifications = append(usersToNotifications, assetCheck.OnwerUser)
-	usersToNotifications = append(usersToNotifications, userManagerAsset)
-	filteredUsers := []*entity.Users{}
-	for _, user := range usersToNotifications {
-		if user.Id != userUpdate.Id {
-			filteredUsers = append(filteredUsers, user)
+	if err = checkOverlap(&maintenance, existing); err != nil {
+		return nil, err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
 		}
+	}()
+	maintenanceCreate, err := service.repo.Create(&maintenance, tx)
+	if err != nil {
+		return nil, err
 	}
-	usersToNotifications = filteredUsers
 	message := fmt.Sprintf("The maintenance schedules (ID: %v) has just been created by %v", maintenance.Id, userUpdate.Email)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(usersToNotifications, message, *assetCheck)
-	}()
+	if err = service.notify(tx, userUpdate, *assetCheck, message); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	maintenanceCreate.Asset = *assetCheck
 	return maintenanceCreate, nil
 }
 
@@ -83,10 +99,36 @@ func (service *MaintenanceSchedulesService) GetAllMaintenanceSchedulesByAssetId(
 	if err != nil {
 		return nil, err
 	}
-	return maintenances, nil
+	return withUpcomingOccurrence(maintenances), nil
+}
+
+// GetOccurrencesByAssetId sinh các lần bảo trì của tài sản trong khoảng [from, to)
+func (service *MaintenanceSchedulesService) GetOccurrencesByAssetId(userId int64, assetId int64, from, to time.Time) ([]entity.MaintenanceOccurrence, error) {
+	if !to.After(from) {
+		return nil, errors.New("to must be after from")
+	}
+	if to.After(from.AddDate(1, 0, 0)) {
+		return nil, errors.New("range can't be longer than 1 year")
+	}
+	maintenances, err := service.repo.GetActiveMaintenanceSchedulesByAssetId(assetId)
+	if err != nil {
+		return nil, err
+	}
+	occurrences := []entity.MaintenanceOccurrence{}
+	for _, m := range maintenances {
+		list, err := m.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, list...)
+	}
+	return occurrences, nil
 }
 
-func (service *MaintenanceSchedulesService) Update(userId int64, id int64, startDate time.Time, endDate time.Time) (*entity.MaintenanceSchedules, error) {
+// Update sửa lịch bảo trì theo scope: all (cả series), this (chỉ 1 lần lặp), following (lần lặp này và các lần sau).
+// vendorId nil là giữ đơn vị bảo trì hiện tại, scope this không đổi được vendor vì exception không lưu vendor
+func (service *MaintenanceSchedulesService) Update(userId int64, id int64, startDate time.Time, endDate time.Time, scope string, occurrenceStart *time.Time, recurrence *dto.MaintenanceRecurrenceRequest, vendorId *int64) (*entity.MaintenanceSchedules, error) {
+	var err error
 	loc, _ := time.LoadLocation("Asia/Bangkok") // GMT+7
 	startDate = startDate.In(loc)
 	endDate = endDate.In(loc)
@@ -94,40 +136,132 @@ func (service *MaintenanceSchedulesService) Update(userId int64, id int64, start
 	if err != nil {
 		return nil, err
 	}
-	maintenaceUpdateOld, _ := service.repo.GetMaintenanceSchedulesById(id)
-	if maintenaceUpdateOld.StartDate.Before(time.Now()) {
-		return nil, errors.New("start date <= now")
+	maintenaceUpdateOld, err := service.repo.GetMaintenanceSchedulesById(id)
+	if err != nil {
+		return nil, err
 	}
-	maintenance, err := service.repo.Update(id, startDate, endDate)
+	occurrence, index, err := resolveScope(maintenaceUpdateOld, &scope, occurrenceStart)
 	if err != nil {
 		return nil, err
 	}
-	maintenaceUpdate, _ := service.repo.GetMaintenanceSchedulesById(id)
-
-	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(maintenaceUpdate.Asset.DepartmentId)
-	usersToNotifications := []*entity.Users{}
-	usersToNotifications = append(usersToNotifications, maintenaceUpdate.Asset.OnwerUser)
-	usersToNotifications = append(usersToNotifications, userManagerAsset)
-	filteredUsers := []*entity.Users{}
-	for _, user := range usersToNotifications {
-		if user.Id != userUpdate.Id {
-			filteredUsers = append(filteredUsers, user)
-		}
-	}
-	usersToNotifications = filteredUsers
-	message := fmt.Sprintf("The maintenance schedules (ID: %v) has just been updated by %v", maintenaceUpdate.Id, userUpdate.Email)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(usersToNotifications, message, maintenaceUpdate.Asset)
+	if err = service.vendorService.CheckVendor(userUpdate.CompanyId, vendorId); err != nil {
+		return nil, err
+	}
+	if vendorId == nil {
+		vendorId = maintenaceUpdateOld.VendorId
+	}
+	existing, err := service.repo.GetActiveMaintenanceSchedulesByAssetId(maintenaceUpdateOld.AssetId)
+	if err != nil {
+		return nil, err
+	}
+	resultId := id
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
 	}()
-	return maintenance, nil
+	switch scope {
+	case ScopeThis:
+		candidate := entity.MaintenanceSchedules{AssetId: maintenaceUpdateOld.AssetId, StartDate: startDate, EndDate: endDate}
+		// Bỏ lần lặp đang sửa khỏi series khi kiểm tra trùng lịch
+		existing = replaceSchedule(existing, withCancelledOccurrence(maintenaceUpdateOld, occurrence.OccurrenceStart))
+		if err = checkOverlap(&candidate, existing); err != nil {
+			return nil, err
+		}
+		exception := entity.MaintenanceScheduleExceptions{
+			ScheduleId:      id,
+			OccurrenceStart: occurrence.OccurrenceStart,
+			StartDate:       &startDate,
+			EndDate:         &endDate,
+		}
+		if _, err = service.repo.SaveException(&exception, tx); err != nil {
+			return nil, err
+		}
+	case ScopeFollowing:
+		truncated := truncateSeries(maintenaceUpdateOld, occurrence.OccurrenceStart, index)
+		newSeries := entity.MaintenanceSchedules{
+			AssetId:        maintenaceUpdateOld.AssetId,
+			StartDate:      startDate,
+			EndDate:        endDate,
+			Frequency:      maintenaceUpdateOld.Frequency,
+			RepeatInterval: maintenaceUpdateOld.RepeatInterval,
+			ByWeekdays:     maintenaceUpdateOld.ByWeekdays,
+			RepeatUntil:    maintenaceUpdateOld.RepeatUntil,
+			ParentId:       &maintenaceUpdateOld.Id,
+			VendorId:       vendorId,
+		}
+		if maintenaceUpdateOld.ParentId != nil {
+			newSeries.ParentId = maintenaceUpdateOld.ParentId
+		}
+		if maintenaceUpdateOld.RepeatCount != nil {
+			remaining := *maintenaceUpdateOld.RepeatCount - index
+			newSeries.RepeatCount = &remaining
+		}
+		if err = applyRecurrence(&newSeries, recurrence); err != nil {
+			return nil, err
+		}
+		existing = replaceSchedule(existing, truncated)
+		if err = checkOverlap(&newSeries, existing); err != nil {
+			return nil, err
+		}
+		if _, err = service.repo.Update(truncated, tx); err != nil {
+			return nil, err
+		}
+		if err = service.repo.DeleteExceptionsFrom(id, occurrence.OccurrenceStart, tx); err != nil {
+			return nil, err
+		}
+		if _, err = service.repo.Create(&newSeries, tx); err != nil {
+			return nil, err
+		}
+		resultId = newSeries.Id
+	default:
+		if maintenaceUpdateOld.StartDate.Before(time.Now()) {
+			err = errors.New("start date <= now")
+			return nil, err
+		}
+		updated := *maintenaceUpdateOld
+		updated.StartDate = startDate
+		updated.EndDate = endDate
+		updated.VendorId = vendorId
+		// Exception gắn với thời điểm gốc của rule cũ nên bỏ đi khi sửa cả series
+		updated.Exceptions = nil
+		if err = applyRecurrence(&updated, recurrence); err != nil {
+			return nil, err
+		}
+		if err = checkOverlap(&updated, existing); err != nil {
+			return nil, err
+		}
+		if _, err = service.repo.Update(&updated, tx); err != nil {
+			return nil, err
+		}
+		if err = service.repo.DeleteExceptionsFrom(id, time.Time{}, tx); err != nil {
+			return nil, err
+		}
+	}
+	message := fmt.Sprintf("The maintenance schedules (ID: %v) has just been updated by %v", maintenaceUpdateOld.Id, userUpdate.Email)
+	if scope != ScopeAll {
+		message = fmt.Sprintf("The maintenance schedules (ID: %v) occurrence on %v has just been updated by %v", maintenaceUpdateOld.Id, occurrence.OccurrenceStart.In(loc).Format("2006-01-02"), userUpdate.Email)
+	}
+	if err = service.notify(tx, userUpdate, maintenaceUpdateOld.Asset, message); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	maintenaceUpdate, err := service.repo.GetMaintenanceSchedulesById(resultId)
+	if err != nil {
+		return nil, err
+	}
+	return maintenaceUpdate, nil
 }
 
-func (service *MaintenanceSchedulesService) Delete(userId int64, id int64) error {
+// Delete xoá lịch bảo trì theo scope giống Update, lần lặp bị xoá lẻ được đánh dấu huỷ
+func (service *MaintenanceSchedulesService) Delete(userId int64, id int64, scope string, occurrenceStart *time.Time) error {
+	var err error
 	userUpdate, err := service.userRepository.FindByUserId(userId)
 	if err != nil {
 		return err
@@ -136,33 +270,97 @@ func (service *MaintenanceSchedulesService) Delete(userId int64, id int64) error
 	if err != nil {
 		return err
 	}
-	if maintenanceCheck.StartDate.After(time.Now()) {
-		return errors.New("start date <= now")
+	occurrence, index, err := resolveScope(maintenanceCheck, &scope, occurrenceStart)
+	if err != nil {
+		return err
 	}
-	err = service.repo.Delete(id)
-	maintenaceUpdate, _ := service.repo.GetMaintenanceSchedulesById(id)
-
-	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(maintenaceUpdate.Asset.DepartmentId)
-	usersToNotifications := []*entity.Users{}
-	usersToNotifications = append(usersToNotifications, maintenaceUpdate.Asset.OnwerUser)
-	usersToNotifications = append(usersToNotifications, userManagerAsset)
-	filteredUsers := []*entity.Users{}
-	for _, user := range usersToNotifications {
-		if user.Id != userUpdate.Id {
-			filteredUsers = append(filteredUsers, user)
-		}
-	}
-	usersToNotifications = filteredUsers
-	message := fmt.Sprintf("The maintenance schedules (ID: %v) has just been deleted by %v", maintenaceUpdate.Id, userUpdate.Email)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("SendNotificationToUsers panic:", r)
-			}
-		}()
-		service.NotificationService.SendNotificationToUsers(usersToNotifications, message, maintenaceUpdate.Asset)
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
 	}()
-	return err
+	switch scope {
+	case ScopeThis:
+		exception := entity.MaintenanceScheduleExceptions{
+			ScheduleId:      id,
+			OccurrenceStart: occurrence.OccurrenceStart,
+			Cancelled:       true,
+		}
+		if _, err = service.repo.SaveException(&exception, tx); err != nil {
+			return err
+		}
+	case ScopeFollowing:
+		if _, err = service.repo.Update(truncateSeries(maintenanceCheck, occurrence.OccurrenceStart, index), tx); err != nil {
+			return err
+		}
+		if err = service.repo.DeleteExceptionsFrom(id, occurrence.OccurrenceStart, tx); err != nil {
+			return err
+		}
+	default:
+		if maintenanceCheck.StartDate.Before(time.Now()) {
+			err = errors.New("start date <= now")
+			return err
+		}
+		if err = service.repo.Delete(id, tx); err != nil {
+			return err
+		}
+	}
+	message := fmt.Sprintf("The maintenance schedules (ID: %v) has just been deleted by %v", maintenanceCheck.Id, userUpdate.Email)
+	if scope != ScopeAll {
+		message = fmt.Sprintf("The maintenance schedules (ID: %v) occurrence on %v has just been cancelled by %v", maintenanceCheck.Id, occurrence.OccurrenceStart.Format("2006-01-02"), userUpdate.Email)
+	}
+	if err = service.notify(tx, userUpdate, maintenanceCheck.Asset, message); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// RecordJob ghi thời điểm thực tế giao tài sản cho đơn vị bảo trì và nhận lại, dùng để tính thời gian sửa chữa của vendor
+func (service *MaintenanceSchedulesService) RecordJob(userId int64, id int64, occurrenceStart time.Time, handedOverAt, returnedAt *time.Time) (*entity.MaintenanceJobs, error) {
+	user, err := service.userRepository.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	maintenance, err := service.repo.GetMaintenanceSchedulesById(id)
+	if err != nil {
+		return nil, err
+	}
+	if maintenance.Asset.CompanyId != user.CompanyId {
+		return nil, errors.New("maintenance schedule not found")
+	}
+	occurrence, _, ok, err := maintenance.OccurrenceAt(occurrenceStart)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("occurrence not found in schedule")
+	}
+	if returnedAt != nil && handedOverAt == nil {
+		return nil, errors.New("handedOverAt is required when returnedAt is set")
+	}
+	now := time.Now()
+	if (handedOverAt != nil && handedOverAt.After(now)) || (returnedAt != nil && returnedAt.After(now)) {
+		return nil, errors.New("handover and return time can't be in the future")
+	}
+	if returnedAt != nil && returnedAt.Before(*handedOverAt) {
+		return nil, errors.New("returnedAt must be after handedOverAt")
+	}
+	job := entity.MaintenanceJobs{
+		ScheduleId:      id,
+		OccurrenceStart: occurrence.OccurrenceStart,
+		HandedOverAt:    handedOverAt,
+		ReturnedAt:      returnedAt,
+		RecordedById:    userId,
+		UpdatedAt:       now,
+	}
+	return service.repo.SaveJob(&job, service.repo.GetDB())
 }
 
 func (service *MaintenanceSchedulesService) GetAllMaintenanceSchedules() ([]*entity.MaintenanceSchedules, error) {
@@ -170,5 +368,162 @@ func (service *MaintenanceSchedulesService) GetAllMaintenanceSchedules() ([]*ent
 	if err != nil {
 		return nil, err
 	}
-	return maintenances, nil
+	return withUpcomingOccurrence(maintenances), nil
+}
+
+func (service *MaintenanceSchedulesService) notify(tx *gorm.DB, byUser *entity.Users, asset entity.Assets, message string) error {
+	userManagerAsset, _ := service.userRepository.GetUserAssetManageOfDepartment(asset.DepartmentId)
+	usersToNotifications := []*entity.Users{}
+	for _, u := range []*entity.Users{asset.OnwerUser, userManagerAsset} {
+		if u != nil && u.Id != byUser.Id {
+			usersToNotifications = append(usersToNotifications, u)
+		}
+	}
+	return service.NotificationService.NotifyUsers(tx, usersToNotifications, entity.NotificationEventMaintenanceScheduled, message, asset)
+}
+
+// applyRecurrence gán rule lặp từ request vào lịch, rule = nil thì giữ nguyên
+func applyRecurrence(maintenance *entity.MaintenanceSchedules, rule *dto.MaintenanceRecurrenceRequest) error {
+	if rule == nil {
+		return nil
+	}
+	if !entity.ValidFrequency(rule.Frequency) {
+		return fmt.Errorf("invalid frequency '%v'", rule.Frequency)
+	}
+	weekdays := []string{}
+	for _, d := range rule.ByWeekdays {
+		if !entity.ValidWeekday(d) {
+			return fmt.Errorf("invalid weekday '%v'", d)
+		}
+		weekdays = append(weekdays, strings.ToUpper(d))
+	}
+	if len(weekdays) > 0 && rule.Frequency != entity.FrequencyWeekly {
+		return errors.New("byWeekdays is only supported for weekly frequency")
+	}
+	if rule.Until != nil && rule.Until.Before(maintenance.StartDate) {
+		return errors.New("until must be after start date")
+	}
+	if rule.Count != nil && *rule.Count < 1 {
+		return errors.New("count must be greater than 0")
+	}
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	frequency := rule.Frequency
+	maintenance.Frequency = &frequency
+	maintenance.RepeatInterval = interval
+	maintenance.ByWeekdays = strings.Join(weekdays, ",")
+	maintenance.RepeatUntil = rule.Until
+	maintenance.RepeatCount = rule.Count
+	return nil
+}
+
+// resolveScope kiểm tra lần lặp được chọn, "following" tại lần lặp đầu tiên tương đương sửa cả series
+func resolveScope(maintenance *entity.MaintenanceSchedules, scope *string, occurrenceStart *time.Time) (*entity.MaintenanceOccurrence, int, error) {
+	if *scope == "" {
+		*scope = ScopeAll
+	}
+	if *scope == ScopeAll {
+		return nil, 0, nil
+	}
+	if !maintenance.IsRecurring() {
+		return nil, 0, errors.New("schedule is not recurring, use scope 'all'")
+	}
+	if occurrenceStart == nil {
+		return nil, 0, errors.New("occurrenceStart is required for this scope")
+	}
+	occurrence, index, ok, err := maintenance.OccurrenceAt(*occurrenceStart)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, errors.New("occurrence not found in schedule")
+	}
+	if occurrence.Start.Before(time.Now()) {
+		return nil, 0, errors.New("occurrence has already started")
+	}
+	if *scope == ScopeFollowing && index == 0 {
+		*scope = ScopeAll
+	}
+	return occurrence, index, nil
+}
+
+// truncateSeries cắt series để lần lặp cuối nằm ngay trước occurrenceStart
+func truncateSeries(maintenance *entity.MaintenanceSchedules, occurrenceStart time.Time, index int) *entity.MaintenanceSchedules {
+	truncated := *maintenance
+	until := occurrenceStart.Add(-time.Second)
+	truncated.RepeatUntil = &until
+	if maintenance.RepeatCount != nil {
+		truncated.RepeatCount = &index
+	}
+	return &truncated
+}
+
+func withCancelledOccurrence(maintenance *entity.MaintenanceSchedules, occurrenceStart time.Time) *entity.MaintenanceSchedules {
+	copied := *maintenance
+	copied.Exceptions = append([]entity.MaintenanceScheduleExceptions{}, maintenance.Exceptions...)
+	for i := range copied.Exceptions {
+		if copied.Exceptions[i].OccurrenceStart.Unix() == occurrenceStart.Unix() {
+			copied.Exceptions = append(copied.Exceptions[:i], copied.Exceptions[i+1:]...)
+			break
+		}
+	}
+	copied.Exceptions = append(copied.Exceptions, entity.MaintenanceScheduleExceptions{ScheduleId: maintenance.Id, OccurrenceStart: occurrenceStart, Cancelled: true})
+	return &copied
+}
+
+func replaceSchedule(schedules []*entity.MaintenanceSchedules, replacement *entity.MaintenanceSchedules) []*entity.MaintenanceSchedules {
+	res := make([]*entity.MaintenanceSchedules, 0, len(schedules))
+	for _, s := range schedules {
+		if s.Id == replacement.Id {
+			res = append(res, replacement)
+		} else {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// checkOverlap so sánh các lần lặp sắp tới của candidate với các lịch khác của tài sản
+func checkOverlap(candidate *entity.MaintenanceSchedules, existing []*entity.MaintenanceSchedules) error {
+	now := time.Now()
+	to := now.AddDate(entity.OverlapHorizonYears, 0, 0)
+	end, ok, err := candidate.LastOccurrenceEnd()
+	if err != nil {
+		return err
+	}
+	if ok && end.Before(to) {
+		to = end.Add(time.Second)
+	}
+	candidateOccurrences, err := candidate.Occurrences(now, to)
+	if err != nil {
+		return err
+	}
+	for _, s := range existing {
+		if candidate.Id != 0 && s.Id == candidate.Id {
+			continue
+		}
+		occurrences, err := s.Occurrences(now, to)
+		if err != nil {
+			return err
+		}
+		if entity.OccurrencesOverlap(candidateOccurrences, occurrences) {
+			return errors.New("maintenance time overlaps with existing schedule")
+		}
+	}
+	return nil
+}
+
+// withUpcomingOccurrence bỏ các series lặp đã hết lần bảo trì
+func withUpcomingOccurrence(maintenances []*entity.MaintenanceSchedules) []*entity.MaintenanceSchedules {
+	res := []*entity.MaintenanceSchedules{}
+	now := time.Now()
+	for _, m := range maintenances {
+		// Series lỗi vượt giới hạn lần lặp vẫn được giữ lại để người dùng thấy và sửa
+		if _, ok, err := m.NextOccurrence(now); err != nil || ok || !m.IsRecurring() {
+			res = append(res, m)
+		}
+	}
+	return res
 }
@@ -8,8 +8,15 @@ import (
 	monthlySummary "BE_Manage_device/internal/repository/monthly_summary"
 	user "BE_Manage_device/internal/repository/user"
 	"BE_Manage_device/pkg/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
 )
 
+// Giới hạn số tháng mỗi lần regenerate để tránh request quá lâu
+const maxRegenerateMonths = 120
+
 type MonthlySummaryService struct {
 	repo     monthlySummary.MonthlySummaryRepository
 	billRepo bill.BillsRepository
@@ -40,3 +47,43 @@ func (service *MonthlySummaryService) Filter(userId int64, month, year int64) (*
 	MonthlySummaryRes := utils.ConvertMonthlySummaryToMonthlySummaryRes(MonthlySummary)
 	return MonthlySummaryRes, nil
 }
+
+// Regenerate tính lại summary cho công ty của user trong khoảng tháng from..to.
+// onlyMissing = true thì chỉ backfill các tháng chưa có summary
+func (service *MonthlySummaryService) Regenerate(userId int64, fromMonth, fromYear, toMonth, toYear int, onlyMissing bool) ([]*dto.MonthlySummaryResponse, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	from := time.Date(fromYear, time.Month(fromMonth), 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(toYear, time.Month(toMonth), 1, 0, 0, 0, 0, time.UTC)
+	if from.After(to) {
+		return nil, errors.New("from month must be before to month")
+	}
+	now := time.Now()
+	if to.After(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)) {
+		return nil, errors.New("can't generate summary for a future month")
+	}
+	if (to.Year()-from.Year())*12+int(to.Month()-from.Month())+1 > maxRegenerateMonths {
+		return nil, errors.New("range must not exceed 120 months")
+	}
+	res := []*dto.MonthlySummaryResponse{}
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		if onlyMissing {
+			existing, err := service.repo.GetByPeriod(users.CompanyId, int64(month.Month()), int64(month.Year()))
+			if err == nil {
+				res = append(res, utils.ConvertMonthlySummaryToMonthlySummaryRes(*existing))
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+		}
+		summary, err := utils.GenerateMonthlySummary(service.repo, service.billRepo, users.CompanyId, month.Month(), month.Year())
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, utils.ConvertMonthlySummaryToMonthlySummaryRes(*summary))
+	}
+	return res, nil
+}
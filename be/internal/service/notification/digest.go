@@ -0,0 +1,100 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	emailS "BE_Manage_device/internal/service/email"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// SendDigests chạy mỗi giờ, gửi digest cho các user có item đang chờ và giờ địa phương trùng giờ digest đã chọn
+func (service *NotificationService) SendDigests() {
+	userIds, err := service.preferenceRepository.GetUserIdsWithPendingDigest()
+	if err != nil {
+		log.Error("Happened error when get users with pending digest. Error", err)
+		return
+	}
+	if len(userIds) == 0 {
+		return
+	}
+	_, settings, err := service.loadPreferences(userIds, service.preferenceRepository.GetDB())
+	if err != nil {
+		log.Error("Happened error when get notification settings for digest. Error", err)
+		return
+	}
+	now := time.Now()
+	for _, userId := range userIds {
+		s := settings[userId]
+		if now.In(s.Location()).Hour() != s.DigestHour {
+			continue
+		}
+		if err := service.sendDigest(userId, s, now); err != nil {
+			log.Errorf("Happened error when send digest for user %v. Error %v", userId, err)
+		}
+	}
+}
+
+// sendDigest gom các item đang chờ theo kênh thành 1 thông báo / 1 email / 1 webhook và đánh dấu đã gửi trong cùng transaction
+func (service *NotificationService) sendDigest(userId int64, s *entity.UserNotificationSettings, now time.Time) error {
+	user, err := service.userRepository.FindByUserId(userId)
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	return service.preferenceRepository.GetDB().Transaction(func(tx *gorm.DB) error {
+		items, err := service.preferenceRepository.GetPendingDigestItems(userId, tx)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		byChannel := map[string][]*entity.NotificationDigestItems{}
+		var ids []int64
+		for _, item := range items {
+			byChannel[item.Channel] = append(byChannel[item.Channel], item)
+			ids = append(ids, item.Id)
+		}
+		companyId := &user.CompanyId
+		if list := byChannel[entity.NotificationChannelInApp]; len(list) > 0 {
+			// Nội dung theo ngôn ngữ của user, dùng chung bộ template với email
+			message, err := emailS.RenderInApp(emailS.TemplateDigest, user.Language, map[string]interface{}{"Items": list})
+			if err != nil {
+				return err
+			}
+			payload := notificationMessage{
+				UserId:  userId,
+				Event:   entity.NotificationEventDigest,
+				Message: message,
+			}
+			if err := service.outbox.Enqueue(tx, companyId, entity.OutboxChannelNotification, payload); err != nil {
+				return err
+			}
+		}
+		if list := byChannel[entity.NotificationChannelEmail]; len(list) > 0 {
+			items := make([]entity.NotificationDigestItems, 0, len(list))
+			for _, item := range list {
+				localized := *item
+				localized.CreatedAt = item.CreatedAt.In(s.Location())
+				items = append(items, localized)
+			}
+			data := map[string]interface{}{"Name": user.FirstName, "Items": items}
+			if err := service.emailService.EnqueueTemplate(tx, companyId, user.Email, user.Language, emailS.TemplateDigest, data, now); err != nil {
+				return err
+			}
+		}
+		if list := byChannel[entity.NotificationChannelWebhook]; len(list) > 0 && s.WebhookUrl != nil {
+			payload := dto.UserWebhookPayload{Event: entity.NotificationEventDigest, OccurredAt: now}
+			for _, item := range list {
+				payload.Items = append(payload.Items, dto.UserWebhookDigestItem{Event: item.Event, Message: item.Message, AssetId: item.AssetId, CreatedAt: item.CreatedAt})
+			}
+			if err := service.outbox.Enqueue(tx, companyId, entity.OutboxChannelUserWebhook, userWebhookMessage{UserId: userId, Payload: payload}); err != nil {
+				return err
+			}
+		}
+		return service.preferenceRepository.MarkDigested(ids, now, tx)
+	})
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/filter"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const notificationPageSize = 20
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+func toNotificationEvent(n *entity.Notifications) dto.NotificationEvent {
+	data := dto.NotificationEvent{Id: n.Id, AssetId: n.AssetId, NotifyDate: n.NotifyDate, ArchivedAt: n.ArchivedAt}
+	if n.Type != nil {
+		data.Type = *n.Type
+	}
+	if n.Content != nil {
+		data.Content = *n.Content
+	}
+	if n.Status != nil {
+		data.Status = *n.Status
+	}
+	return data
+}
+
+// GetInbox trả về 1 trang hộp thư của user, mới nhất trước
+func (service *NotificationService) GetInbox(userId int64, notificationFilter filter.NotificationFilter) (*dto.NotificationPage, error) {
+	notificationFilter.UserId = userId
+	limit := notificationFilter.Limit
+	if limit <= 0 {
+		limit = notificationPageSize
+	}
+	db := notificationFilter.ApplyFilter(service.notificationRepository.GetDB().Model(&entity.Notifications{}))
+	// Lấy dư 1 bản ghi để biết còn trang sau hay không
+	notifications, err := service.notificationRepository.GetNotificationsWithFilter(db, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &dto.NotificationPage{Items: []dto.NotificationEvent{}}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		nextCursor := notifications[limit-1].Id
+		page.NextCursor = &nextCursor
+	}
+	for _, n := range notifications {
+		page.Items = append(page.Items, toNotificationEvent(n))
+	}
+	return page, nil
+}
+
+func (service *NotificationService) GetUnreadCount(userId int64) (int64, error) {
+	return service.notificationRepository.CountUnread(userId)
+}
+
+// UpdateStatusToSeen đánh dấu đã đọc 1 thông báo của chính user
+func (service *NotificationService) UpdateStatusToSeen(userId int64, id int64) error {
+	updated, err := service.notificationRepository.MarkSeen(userId, []int64{id})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkSeen bỏ qua các id không thuộc user, trả về số thông báo được cập nhật
+func (service *NotificationService) MarkSeen(userId int64, ids []int64) (int64, error) {
+	return service.notificationRepository.MarkSeen(userId, ids)
+}
+
+func (service *NotificationService) MarkAllSeen(userId int64) (int64, error) {
+	return service.notificationRepository.MarkAllSeen(userId)
+}
+
+func (service *NotificationService) Archive(userId int64, ids []int64) (int64, error) {
+	return service.notificationRepository.Archive(userId, ids, time.Now())
+}
+
+func (service *NotificationService) Delete(userId int64, ids []int64) (int64, error) {
+	return service.notificationRepository.Delete(userId, ids)
+}
+
+// CleanupOldNotifications chạy hằng ngày, xoá thông báo đã đọc/lưu trữ quá hạn giữ lại
+func (service *NotificationService) CleanupOldNotifications(retentionDays int) {
+	before := time.Now().AddDate(0, 0, -retentionDays)
+	deleted, err := service.notificationRepository.DeleteOlderThan(before)
+	if err != nil {
+		log.Error("Happened error when clean up old notifications. Error", err)
+		return
+	}
+	log.Infof("Cleaned up %d notifications older than %s", deleted, before.Format(time.RFC3339))
+}
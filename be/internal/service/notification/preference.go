@@ -0,0 +1,287 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	emailS "BE_Manage_device/internal/service/email"
+	webhookS "BE_Manage_device/internal/service/webhook"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const userWebhookTimeout = 10 * time.Second
+
+// userWebhookMessage là payload outbox của kênh user_webhook, url và secret được đọc lại lúc gửi để user đổi cài đặt thì có hiệu lực ngay
+type userWebhookMessage struct {
+	UserId  int64                  `json:"userId"`
+	Payload dto.UserWebhookPayload `json:"payload"`
+}
+
+// preferenceMatrix: userId -> "category:channel" -> delivery, ô không có dùng DefaultNotificationDelivery
+type preferenceMatrix map[int64]map[string]string
+
+func preferenceKey(category, channel string) string {
+	return category + ":" + channel
+}
+
+func (m preferenceMatrix) delivery(userId int64, category, channel string) string {
+	if d, ok := m[userId][preferenceKey(category, channel)]; ok {
+		return d
+	}
+	return entity.DefaultNotificationDelivery(category, channel)
+}
+
+func defaultNotificationSettings(userId int64) *entity.UserNotificationSettings {
+	return &entity.UserNotificationSettings{UserId: userId, Timezone: entity.DefaultNotificationTimezone, DigestHour: 8}
+}
+
+func (service *NotificationService) loadPreferences(userIds []int64, tx *gorm.DB) (preferenceMatrix, map[int64]*entity.UserNotificationSettings, error) {
+	preferences, err := service.preferenceRepository.GetPreferencesByUserIds(userIds, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get notification preferences: %w", err)
+	}
+	matrix := preferenceMatrix{}
+	for _, p := range preferences {
+		if matrix[p.UserId] == nil {
+			matrix[p.UserId] = map[string]string{}
+		}
+		matrix[p.UserId][preferenceKey(p.Category, p.Channel)] = p.Delivery
+	}
+	settingList, err := service.preferenceRepository.GetSettingsByUserIds(userIds, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get notification settings: %w", err)
+	}
+	settings := map[int64]*entity.UserNotificationSettings{}
+	for _, s := range settingList {
+		settings[s.UserId] = s
+	}
+	for _, id := range userIds {
+		if settings[id] == nil {
+			settings[id] = defaultNotificationSettings(id)
+		}
+	}
+	return matrix, settings, nil
+}
+
+// route gửi thông báo tới từng user theo ma trận cài đặt: ngay lập tức, gom vào digest hoặc bỏ qua.
+// Email và webhook cá nhân rơi vào giờ yên lặng được dời tới lúc hết giờ yên lặng, in-app vẫn lưu ngay vào hộp thư
+func (service *NotificationService) route(tx *gorm.DB, companyId *int64, users []*entity.Users, n notice) error {
+	var recipients []*entity.Users
+	var userIds []int64
+	for _, u := range users {
+		if u == nil || slices.Contains(userIds, u.Id) {
+			continue
+		}
+		recipients = append(recipients, u)
+		userIds = append(userIds, u.Id)
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	matrix, settings, err := service.loadPreferences(userIds, tx)
+	if err != nil {
+		return err
+	}
+	category := entity.NotificationCategoryOf(n.event)
+	now := time.Now()
+	for _, u := range recipients {
+		s := settings[u.Id]
+		notBefore := now
+		if until, quiet := s.QuietUntil(now); quiet {
+			notBefore = until
+		}
+		for _, channel := range entity.NotificationChannels {
+			switch matrix.delivery(u.Id, category, channel) {
+			case entity.NotificationDeliveryOff:
+				continue
+			case entity.NotificationDeliveryDigest:
+				item := &entity.NotificationDigestItems{
+					UserId:    u.Id,
+					CompanyId: companyId,
+					Channel:   channel,
+					Event:     n.event,
+					Message:   n.message,
+					AssetId:   n.assetId,
+					CreatedAt: now,
+				}
+				if err := service.preferenceRepository.CreateDigestItem(item, tx); err != nil {
+					return fmt.Errorf("create digest item for user %v: %w", u.Id, err)
+				}
+			default:
+				if err := service.sendNow(tx, companyId, u, s, channel, n, notBefore); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (service *NotificationService) sendNow(tx *gorm.DB, companyId *int64, u *entity.Users, s *entity.UserNotificationSettings, channel string, n notice, notBefore time.Time) error {
+	switch channel {
+	case entity.NotificationChannelInApp:
+		payload := notificationMessage{UserId: u.Id, Event: n.event, Message: n.message, AssetId: n.assetId}
+		return service.outbox.Enqueue(tx, companyId, entity.OutboxChannelNotification, payload)
+	case entity.NotificationChannelEmail:
+		name, data := n.emailTemplate, map[string]interface{}{}
+		for k, v := range n.emailData {
+			data[k] = v
+		}
+		if name == "" {
+			name = emailS.TemplateNotification
+			data["Message"] = n.message
+		}
+		data["Name"] = u.FirstName
+		return service.emailService.EnqueueTemplate(tx, companyId, u.Email, u.Language, name, data, notBefore)
+	case entity.NotificationChannelWebhook:
+		if s.WebhookUrl == nil {
+			return nil
+		}
+		payload := userWebhookMessage{UserId: u.Id, Payload: dto.UserWebhookPayload{Event: n.event, OccurredAt: time.Now(), Message: n.message, AssetId: n.assetId}}
+		return service.outbox.EnqueueAt(tx, companyId, entity.OutboxChannelUserWebhook, payload, notBefore)
+	}
+	return nil
+}
+
+// deliverUserWebhook là handler của outbox cho kênh user_webhook, gửi qua cùng sender với webhook của công ty
+// (ký body, chỉ kết nối tới địa chỉ public)
+func (service *NotificationService) deliverUserWebhook(m *entity.OutboxMessages) error {
+	var message userWebhookMessage
+	if err := json.Unmarshal([]byte(m.Payload), &message); err != nil {
+		return err
+	}
+	settings, err := service.preferenceRepository.GetSettingsByUserIds([]int64{message.UserId}, service.preferenceRepository.GetDB())
+	if err != nil {
+		return err
+	}
+	// User đã gỡ webhook sau khi message được ghi thì bỏ qua
+	if len(settings) == 0 || settings[0].WebhookUrl == nil {
+		return nil
+	}
+	body, err := json.Marshal(message.Payload)
+	if err != nil {
+		return err
+	}
+	_, err = webhookS.PostSigned(service.client, *settings[0].WebhookUrl, settings[0].WebhookSecret, message.Payload.Event, "", body)
+	return err
+}
+
+// GetPreferences trả về đủ ma trận nhóm sự kiện x kênh (kể cả ô mặc định) cùng cài đặt chung của user
+func (service *NotificationService) GetPreferences(userId int64) (*dto.NotificationPreferencesResponse, error) {
+	matrix, settings, err := service.loadPreferences([]int64{userId}, service.preferenceRepository.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	res := &dto.NotificationPreferencesResponse{Settings: toNotificationSettingsResponse(settings[userId])}
+	for _, category := range entity.NotificationCategories {
+		for _, channel := range entity.NotificationChannels {
+			res.Preferences = append(res.Preferences, dto.NotificationPreference{
+				Category: category,
+				Channel:  channel,
+				Delivery: matrix.delivery(userId, category, channel),
+			})
+		}
+	}
+	return res, nil
+}
+
+// UpdatePreferences ghi đè các ô được gửi lên, các ô khác giữ nguyên
+func (service *NotificationService) UpdatePreferences(userId int64, preferences []dto.NotificationPreference) (*dto.NotificationPreferencesResponse, error) {
+	now := time.Now()
+	var rows []*entity.NotificationPreferences
+	for _, p := range preferences {
+		if !slices.Contains(entity.NotificationCategories, p.Category) {
+			return nil, fmt.Errorf("unknown category %q", p.Category)
+		}
+		if !slices.Contains(entity.NotificationChannels, p.Channel) {
+			return nil, fmt.Errorf("unknown channel %q", p.Channel)
+		}
+		if !slices.Contains(entity.NotificationDeliveries, p.Delivery) {
+			return nil, fmt.Errorf("unknown delivery %q", p.Delivery)
+		}
+		rows = append(rows, &entity.NotificationPreferences{UserId: userId, Category: p.Category, Channel: p.Channel, Delivery: p.Delivery, UpdatedAt: now})
+	}
+	if err := service.preferenceRepository.UpsertPreferences(rows, service.preferenceRepository.GetDB()); err != nil {
+		return nil, err
+	}
+	return service.GetPreferences(userId)
+}
+
+// UpdateSettings lưu múi giờ, giờ yên lặng, giờ gửi digest và webhook cá nhân.
+// Secret của webhook được sinh mới khi đặt hoặc đổi url và chỉ trả về trong lần đó
+func (service *NotificationService) UpdateSettings(userId int64, request dto.NotificationSettingsRequest) (*dto.NotificationSettingsResponse, error) {
+	if _, err := time.LoadLocation(request.Timezone); err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", request.Timezone)
+	}
+	quietStart, quietEnd := emptyToNil(request.QuietHoursStart), emptyToNil(request.QuietHoursEnd)
+	if (quietStart == nil) != (quietEnd == nil) {
+		return nil, errors.New("quietHoursStart and quietHoursEnd must be set together")
+	}
+	for _, v := range []*string{quietStart, quietEnd} {
+		if v == nil {
+			continue
+		}
+		if _, err := time.Parse("15:04", *v); err != nil {
+			return nil, fmt.Errorf("quiet hours must be HH:MM, got %q", *v)
+		}
+	}
+	webhookUrl := emptyToNil(request.WebhookUrl)
+	if webhookUrl != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), userWebhookTimeout)
+		defer cancel()
+		if err := webhookS.ValidateWebhookUrl(ctx, *webhookUrl); err != nil {
+			return nil, fmt.Errorf("webhookUrl: %w", err)
+		}
+	}
+	_, current, err := service.loadPreferences([]int64{userId}, service.preferenceRepository.GetDB())
+	if err != nil {
+		return nil, err
+	}
+	settings := current[userId]
+	var newSecret string
+	if webhookUrl == nil {
+		settings.WebhookSecret = ""
+	} else if settings.WebhookUrl == nil || *settings.WebhookUrl != *webhookUrl || settings.WebhookSecret == "" {
+		if newSecret, err = webhookS.GenerateWebhookSecret(); err != nil {
+			return nil, err
+		}
+		settings.WebhookSecret = newSecret
+	}
+	settings.Timezone = request.Timezone
+	settings.QuietHoursStart = quietStart
+	settings.QuietHoursEnd = quietEnd
+	settings.DigestHour = *request.DigestHour
+	settings.WebhookUrl = webhookUrl
+	settings.UpdatedAt = time.Now()
+	if err := service.preferenceRepository.SaveSettings(settings, service.preferenceRepository.GetDB()); err != nil {
+		return nil, err
+	}
+	res := toNotificationSettingsResponse(settings)
+	res.WebhookSecret = newSecret
+	return &res, nil
+}
+
+func toNotificationSettingsResponse(s *entity.UserNotificationSettings) dto.NotificationSettingsResponse {
+	return dto.NotificationSettingsResponse{
+		Timezone:        s.Timezone,
+		QuietHoursStart: s.QuietHoursStart,
+		QuietHoursEnd:   s.QuietHoursEnd,
+		DigestHour:      s.DigestHour,
+		WebhookUrl:      s.WebhookUrl,
+	}
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	return &v
+}
@@ -3,104 +3,125 @@ package service
 import (
 	"BE_Manage_device/internal/domain/entity"
 	notification "BE_Manage_device/internal/repository/noftifications"
+	notificationPreference "BE_Manage_device/internal/repository/notification_preference"
+	user "BE_Manage_device/internal/repository/user"
+	emailS "BE_Manage_device/internal/service/email"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	webhookS "BE_Manage_device/internal/service/webhook"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"sync"
-	"time"
+
+	"gorm.io/gorm"
 )
 
 type NotificationService struct {
-	clients                map[string][]chan string
+	clients                map[string][]*SSEClient
 	mu                     sync.RWMutex
 	notificationRepository notification.NotificationRepository
+	preferenceRepository   notificationPreference.NotificationPreferencesRepository
+	userRepository         user.UserRepository
+	outbox                 *outboxS.OutboxService
+	emailService           *emailS.EmailService
+	client                 *http.Client
 }
 
-func NewNotificationService(notificationRepository notification.NotificationRepository) *NotificationService {
-	return &NotificationService{
-		clients: make(map[string][]chan string), notificationRepository: notificationRepository,
-	}
+// notificationMessage là payload outbox, mỗi message cho 1 user để retry không tạo trùng thông báo cho user khác
+type notificationMessage struct {
+	UserId  int64  `json:"userId"`
+	Event   string `json:"event"`
+	Message string `json:"message"`
+	AssetId *int64 `json:"assetId"`
 }
 
-func (ns *NotificationService) Register(userId string) chan string {
-	ns.mu.Lock()
-	defer ns.mu.Unlock()
-	ch := make(chan string, 10)
-	ns.clients[userId] = append(ns.clients[userId], ch)
-	return ch
+// notice là nội dung 1 thông báo trước khi được định tuyến theo cài đặt của từng user
+type notice struct {
+	event   string
+	message string
+	assetId *int64
+	// template email riêng (emailS.Template...), để trống thì email dùng template notification với message
+	emailTemplate string
+	emailData     map[string]interface{}
 }
 
-func (ns *NotificationService) UnRegister(userId string, ch chan string) {
-	ns.mu.Lock()
-	defer ns.mu.Unlock()
-	chans := ns.clients[userId]
-	for i, c := range chans {
-		if c == ch {
-			ns.clients[userId] = append(chans[:i], chans[i+1:]...)
-			close(c)
-			break
-		}
-	}
-	// Xoá map khi không còn ai connect
-	if len(ns.clients[userId]) == 0 {
-		delete(ns.clients, userId)
+func NewNotificationService(notificationRepository notification.NotificationRepository, preferenceRepository notificationPreference.NotificationPreferencesRepository, userRepository user.UserRepository, outbox *outboxS.OutboxService, emailService *emailS.EmailService) *NotificationService {
+	service := &NotificationService{
+		clients: make(map[string][]*SSEClient), notificationRepository: notificationRepository, preferenceRepository: preferenceRepository,
+		userRepository: userRepository, outbox: outbox, emailService: emailService, client: webhookS.NewWebhookClient(userWebhookTimeout),
 	}
+	outbox.RegisterHandler(entity.OutboxChannelNotification, service.deliver)
+	outbox.RegisterHandler(entity.OutboxChannelUserWebhook, service.deliverUserWebhook)
+	return service
 }
 
-// Push notification cho 1 user
-func (ns *NotificationService) Push(userId, message string) {
-	ns.mu.RLock()
-	defer ns.mu.RUnlock()
-	for _, ch := range ns.clients[userId] {
-		ch <- message
-	}
+// NotifyUsers ghi thông báo vào outbox trong transaction tx, gửi thật sự sau khi commit
+func (service *NotificationService) NotifyUsers(tx *gorm.DB, users []*entity.Users, event, message string, asset entity.Assets) error {
+	return service.route(tx, &asset.CompanyId, users, notice{event: event, message: message, assetId: &asset.Id})
 }
 
-func (ns *NotificationService) IsOnline(userId string) bool {
-	ns.mu.RLock()
-	defer ns.mu.RUnlock()
-	chans, ok := ns.clients[userId]
-	return ok && len(chans) > 0
+// NotifyUsersWithEmail giống NotifyUsers nhưng user nhận qua email sẽ nhận email dựng từ template riêng, data được thêm Name của từng người nhận
+func (service *NotificationService) NotifyUsersWithEmail(tx *gorm.DB, users []*entity.Users, event, message, template string, data map[string]interface{}, asset entity.Assets) error {
+	return service.route(tx, &asset.CompanyId, users, notice{event: event, message: message, assetId: &asset.Id, emailTemplate: template, emailData: data})
 }
 
-func (service *NotificationService) SendNotificationToUsers(users []*entity.Users, message string, asset entity.Assets) error {
-	status := "pending"
-	typeNotify := "Info"
-	timeNotify := time.Now()
-	for _, u := range users {
-		if u == nil {
-			continue
-		}
-		notify := entity.Notifications{
-			Content:    &message,
-			Status:     &status,
-			Type:       &typeNotify,
-			UserId:     &u.Id,
-			AssetId:    &asset.Id,
-			NotifyDate: &timeNotify,
-		}
-		_, err := service.notificationRepository.Create(&notify)
-		if err != nil {
-			// log lỗi, tuỳ quyết định dừng hay tiếp tục
-			fmt.Printf("Lỗi lưu notification cho user %v: %v\n", u.Id, err)
-		}
-		isOnline := service.IsOnline(fmt.Sprintf("%v", u.Id))
-		if isOnline {
-			service.Push(fmt.Sprintf("%v", u.Id), message)
-		} else {
-			fmt.Printf("User %v đang offline, chỉ lưu notification DB\n", u.Id)
-		}
-	}
-	return nil
+// NotifyWithEmail giống NotifyUsersWithEmail cho thông báo không gắn với tài sản (bill...)
+func (service *NotificationService) NotifyWithEmail(tx *gorm.DB, companyId *int64, users []*entity.Users, event, message, template string, data map[string]interface{}) error {
+	return service.route(tx, companyId, users, notice{event: event, message: message, emailTemplate: template, emailData: data})
+}
+
+// Notify ghi thông báo vào outbox, assetId = nil khi thông báo không gắn với tài sản nào
+func (service *NotificationService) Notify(tx *gorm.DB, companyId *int64, users []*entity.Users, event, message string, assetId *int64) error {
+	return service.route(tx, companyId, users, notice{event: event, message: message, assetId: assetId})
 }
 
-func (service *NotificationService) GetNotificationsByUserId(userId int64) ([]*entity.Notifications, error) {
-	notifications, err := service.notificationRepository.GetNotificationsByUserId(userId)
+// deliver là handler của outbox: lưu Notifications (mỗi message 1 lần) rồi đẩy SSE nếu user đang online
+func (service *NotificationService) deliver(m *entity.OutboxMessages) error {
+	var payload notificationMessage
+	if err := json.Unmarshal([]byte(m.Payload), &payload); err != nil {
+		return err
+	}
+	status := entity.NotificationStatusUnread
+	typeNotify := payload.Event
+	timeNotify := m.CreatedAt
+	notify := entity.Notifications{
+		Content:         &payload.Message,
+		Status:          &status,
+		Type:            &typeNotify,
+		UserId:          &payload.UserId,
+		AssetId:         payload.AssetId,
+		NotifyDate:      &timeNotify,
+		OutboxMessageId: &m.Id,
+	}
+	created, err := service.notificationRepository.CreateFromOutbox(&notify)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("create notification for user %v: %w", payload.UserId, err)
+	}
+	// Message được gửi lại sau khi đã lưu thông báo thì không push SSE lần nữa
+	if !created {
+		return nil
+	}
+	userId := fmt.Sprintf("%v", payload.UserId)
+	if service.IsOnline(userId) {
+		service.Push(userId, NewNotificationSSEEvent(&notify))
 	}
-	return notifications, nil
+	return nil
 }
 
-func (service *NotificationService) UpdateStatusToSeen(id int64) error {
-	err := service.notificationRepository.UpdateStatus(id)
-	return err
+// GetMissedNotifications trả về thông báo của user có id lớn hơn lastEventId để replay khi client kết nối lại.
+// Lỡ nhiều hơn sseReplayLimit thì không replay mà trả về event resync để client tải lại hộp thư
+func (service *NotificationService) GetMissedNotifications(userId int64, lastEventId int64) ([]*entity.Notifications, *SSEEvent, error) {
+	missed, err := service.notificationRepository.GetNotificationsAfterId(userId, lastEventId, sseReplayLimit+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(missed) <= sseReplayLimit {
+		return missed, nil, nil
+	}
+	latestId, err := service.notificationRepository.GetLatestId(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Gắn id mới nhất để lần kết nối lại sau chỉ replay từ đây
+	return nil, &SSEEvent{Id: latestId, Event: entity.NotificationEventResync, Data: json.RawMessage(`{}`)}, nil
 }
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/entity"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// Message SSE được publish qua Redis để mọi instance đều nhận, mỗi instance chỉ đẩy cho client đang kết nối với mình
+const (
+	sseUserChannelPrefix      = "sse:user:"
+	sseCompanyChannelPrefix   = "sse:company:"
+	presenceUserKeyPrefix     = "presence:user:"    // zset connId -> hạn heartbeat
+	presenceCompanyKeyPrefix  = "presence:company:" // zset userId -> hạn heartbeat
+	PresenceHeartbeatInterval = 30 * time.Second
+	presenceTTL               = 3 * PresenceHeartbeatInterval
+	sseClientBuffer           = 64
+	sseReplayLimit            = 200
+)
+
+// SSEEvent là 1 frame SSE, Id = Notifications.Id (0 với event không lưu DB thì không gửi dòng id)
+type SSEEvent struct {
+	Id    int64           `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// SSEClient là 1 kết nối SSE trên instance này.
+// Buffer đầy thì client bị đánh dấu Overflow: handler đóng kết nối, client kết nối lại với Last-Event-ID và được replay từ DB
+type SSEClient struct {
+	Events    chan SSEEvent
+	Overflow  chan struct{}
+	connId    string
+	companyId string
+	once      sync.Once
+}
+
+func NewNotificationSSEEvent(n *entity.Notifications) SSEEvent {
+	data := toNotificationEvent(n)
+	body, _ := json.Marshal(data)
+	return SSEEvent{Id: n.Id, Event: data.Type, Data: body}
+}
+
+// Register đăng ký 1 kết nối SSE của user trên instance này và đánh dấu online
+func (ns *NotificationService) Register(userId, companyId string) *SSEClient {
+	client := &SSEClient{
+		Events:    make(chan SSEEvent, sseClientBuffer),
+		Overflow:  make(chan struct{}),
+		connId:    uuid.NewString(),
+		companyId: companyId,
+	}
+	ns.mu.Lock()
+	ns.clients[userId] = append(ns.clients[userId], client)
+	ns.mu.Unlock()
+	ns.touchPresence(userId, client)
+	return client
+}
+
+func (ns *NotificationService) UnRegister(userId string, client *SSEClient) {
+	ns.mu.Lock()
+	removed := false
+	clients := ns.clients[userId]
+	for i, c := range clients {
+		if c == client {
+			removed = true
+			ns.clients[userId] = append(clients[:i], clients[i+1:]...)
+			close(c.Events)
+			break
+		}
+	}
+	// Xoá map khi không còn ai connect
+	if len(ns.clients[userId]) == 0 {
+		delete(ns.clients, userId)
+	}
+	ns.mu.Unlock()
+	if removed {
+		ns.removePresence(userId, client)
+	}
+}
+
+// Heartbeat gia hạn presence của kết nối, gọi định kỳ theo PresenceHeartbeatInterval
+func (ns *NotificationService) Heartbeat(userId string, client *SSEClient) {
+	ns.touchPresence(userId, client)
+}
+
+// Push event cho 1 user, user có thể đang kết nối ở instance khác
+func (ns *NotificationService) Push(userId string, event SSEEvent) {
+	ns.publish(sseUserChannelPrefix+userId, event)
+}
+
+// PushToCompany gửi cho mọi user của công ty đang online
+func (ns *NotificationService) PushToCompany(companyId string, event SSEEvent) {
+	ns.publish(sseCompanyChannelPrefix+companyId, event)
+}
+
+func (ns *NotificationService) publish(channel string, event SSEEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error("Happened error when marshal sse event. Error", err)
+		return
+	}
+	if err := config.Rdb.Publish(config.Ctx, channel, body).Err(); err != nil {
+		log.Error("Happened error when publish sse event. Error", err)
+	}
+}
+
+// IsOnline kiểm tra user còn kết nối SSE nào chưa hết hạn heartbeat trên bất kỳ instance nào
+func (ns *NotificationService) IsOnline(userId string) bool {
+	count, err := config.Rdb.ZCount(config.Ctx, presenceUserKeyPrefix+userId, strconv.FormatInt(time.Now().Unix(), 10), "+inf").Result()
+	if err != nil {
+		log.Error("Happened error when check presence of user. Error", err)
+		return false
+	}
+	return count > 0
+}
+
+// OnlineUsers trả về id các user của công ty đang online
+func (ns *NotificationService) OnlineUsers(companyId int64) ([]int64, error) {
+	key := presenceCompanyKeyPrefix + strconv.FormatInt(companyId, 10)
+	members, err := config.Rdb.ZRangeByScore(config.Ctx, key, &redis.ZRangeBy{Min: strconv.FormatInt(time.Now().Unix(), 10), Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+	userIds := make([]int64, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+			userIds = append(userIds, id)
+		}
+	}
+	return userIds, nil
+}
+
+func (ns *NotificationService) touchPresence(userId string, client *SSEClient) {
+	now := time.Now()
+	expireAt := float64(now.Add(presenceTTL).Unix())
+	userKey := presenceUserKeyPrefix + userId
+	companyKey := presenceCompanyKeyPrefix + client.companyId
+	pipe := config.Rdb.TxPipeline()
+	pipe.ZRemRangeByScore(config.Ctx, userKey, "-inf", fmt.Sprintf("(%d", now.Unix()))
+	pipe.ZAdd(config.Ctx, userKey, redis.Z{Score: expireAt, Member: client.connId})
+	pipe.Expire(config.Ctx, userKey, presenceTTL)
+	pipe.ZRemRangeByScore(config.Ctx, companyKey, "-inf", fmt.Sprintf("(%d", now.Unix()))
+	pipe.ZAdd(config.Ctx, companyKey, redis.Z{Score: expireAt, Member: userId})
+	pipe.Expire(config.Ctx, companyKey, presenceTTL)
+	if _, err := pipe.Exec(config.Ctx); err != nil {
+		log.Error("Happened error when update presence. Error", err)
+	}
+}
+
+func (ns *NotificationService) removePresence(userId string, client *SSEClient) {
+	userKey := presenceUserKeyPrefix + userId
+	if err := config.Rdb.ZRem(config.Ctx, userKey, client.connId).Err(); err != nil {
+		log.Error("Happened error when remove presence. Error", err)
+		return
+	}
+	// User còn kết nối khác (có thể ở instance khác) thì vẫn giữ online trong công ty
+	if !ns.IsOnline(userId) {
+		config.Rdb.ZRem(config.Ctx, presenceCompanyKeyPrefix+client.companyId, userId)
+	}
+}
+
+// StartFanOut subscribe các kênh SSE trên Redis và đẩy event cho client kết nối với instance này
+func (ns *NotificationService) StartFanOut() {
+	pubsub := config.Rdb.PSubscribe(config.Ctx, sseUserChannelPrefix+"*", sseCompanyChannelPrefix+"*")
+	go func() {
+		defer pubsub.Close()
+		for msg := range pubsub.Channel() {
+			var event SSEEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				log.Error("Happened error when unmarshal sse event. Error", err)
+				continue
+			}
+			switch {
+			case strings.HasPrefix(msg.Channel, sseUserChannelPrefix):
+				ns.pushLocal(strings.TrimPrefix(msg.Channel, sseUserChannelPrefix), "", event)
+			case strings.HasPrefix(msg.Channel, sseCompanyChannelPrefix):
+				ns.pushLocal("", strings.TrimPrefix(msg.Channel, sseCompanyChannelPrefix), event)
+			}
+		}
+	}()
+}
+
+// pushLocal đẩy event cho client trên instance này theo user hoặc theo công ty, không bao giờ block
+func (ns *NotificationService) pushLocal(userId, companyId string, event SSEEvent) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	if userId != "" {
+		for _, c := range ns.clients[userId] {
+			c.offer(userId, event)
+		}
+		return
+	}
+	for uid, clients := range ns.clients {
+		for _, c := range clients {
+			if c.companyId == companyId {
+				c.offer(uid, event)
+			}
+		}
+	}
+}
+
+func (c *SSEClient) offer(userId string, event SSEEvent) {
+	select {
+	case c.Events <- event:
+	default:
+		c.once.Do(func() {
+			log.Warnf("SSE buffer of user %v is full, closing connection so the client replays from Last-Event-ID", userId)
+			close(c.Overflow)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	outbox "BE_Manage_device/internal/repository/outbox"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second // 30s, 1m, 2m, ... giữa các lần retry
+	outboxClaimLease  = 5 * time.Minute
+	outboxBatchSize   = 100
+	outboxListLimit   = 200
+)
+
+// maxErrorLength là độ dài tối đa của LastError trên outbox message và webhook delivery
+const maxErrorLength = 1000
+
+var ErrOutboxMessageNotFound = errors.New("outbox message not found")
+
+// Handler gửi 1 message ra kênh tương ứng, trả lỗi để dispatcher retry
+type Handler func(message *entity.OutboxMessages) error
+
+type OutboxService struct {
+	repo     outbox.OutboxRepository
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+func NewOutboxService(repo outbox.OutboxRepository) *OutboxService {
+	return &OutboxService{repo: repo, handlers: map[string]Handler{}}
+}
+
+// RegisterHandler đăng ký hàm gửi cho 1 kênh, gọi lúc khởi tạo service
+func (service *OutboxService) RegisterHandler(channel string, handler Handler) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.handlers[channel] = handler
+}
+
+// Enqueue ghi message trong transaction của nghiệp vụ, rollback thì message cũng mất theo
+func (service *OutboxService) Enqueue(tx *gorm.DB, companyId *int64, channel string, payload interface{}) error {
+	return service.EnqueueAt(tx, companyId, channel, payload, time.Now())
+}
+
+// EnqueueAt giống Enqueue nhưng message chỉ được gửi từ thời điểm notBefore (vd: hết giờ yên lặng của user)
+func (service *OutboxService) EnqueueAt(tx *gorm.DB, companyId *int64, channel string, payload interface{}, notBefore time.Time) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if notBefore.Before(now) {
+		notBefore = now
+	}
+	message := &entity.OutboxMessages{
+		CompanyId:     companyId,
+		Channel:       channel,
+		Payload:       string(body),
+		Status:        entity.OutboxStatusPending,
+		NextAttemptAt: &notBefore,
+		CreatedAt:     now,
+	}
+	return service.repo.Create(message, tx)
+}
+
+// DispatchDue xử lý các message tới hạn, chạy định kỳ bởi cron
+func (service *OutboxService) DispatchDue() {
+	messages, err := service.repo.ClaimDue(time.Now(), outboxClaimLease, outboxBatchSize)
+	if err != nil {
+		log.Error("Happened error when claim outbox messages. Error", err)
+		return
+	}
+	for _, m := range messages {
+		service.dispatch(m)
+	}
+}
+
+// dispatch gửi 1 lần và hẹn lần retry tiếp theo theo exponential backoff, hết lượt thì chuyển sang dead
+func (service *OutboxService) dispatch(m *entity.OutboxMessages) {
+	service.mu.RLock()
+	handler, ok := service.handlers[m.Channel]
+	service.mu.RUnlock()
+	var sendErr error
+	if !ok {
+		sendErr = fmt.Errorf("no handler for channel %q", m.Channel)
+	} else {
+		sendErr = safeHandle(handler, m)
+	}
+
+	m.Attempts++
+	if sendErr == nil {
+		now := time.Now()
+		m.Status = entity.OutboxStatusSent
+		m.LastError = ""
+		m.NextAttemptAt = nil
+		m.ProcessedAt = &now
+	} else {
+		m.LastError = ErrorText(sendErr)
+		if m.Attempts >= outboxMaxAttempts {
+			now := time.Now()
+			m.Status = entity.OutboxStatusDead
+			m.NextAttemptAt = nil
+			m.ProcessedAt = &now
+			log.Errorf("Outbox message %d (%s) moved to dead letter: %s", m.Id, m.Channel, m.LastError)
+		} else {
+			next := time.Now().Add(outboxBaseBackoff << (m.Attempts - 1))
+			m.Status = entity.OutboxStatusPending
+			m.NextAttemptAt = &next
+		}
+	}
+	if err := service.repo.Update(m, service.repo.GetDB()); err != nil {
+		log.Error("Happened error when update outbox message. Error", err)
+	}
+}
+
+func safeHandle(handler Handler, m *entity.OutboxMessages) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(m)
+}
+
+func (service *OutboxService) GetMessages(auth *policy.AuthContext, status, channel string) ([]*entity.OutboxMessages, error) {
+	if status != "" && !slices.Contains([]string{entity.OutboxStatusPending, entity.OutboxStatusSent, entity.OutboxStatusDead}, status) {
+		return nil, fmt.Errorf("unknown status %q", status)
+	}
+	if channel != "" && !slices.Contains(entity.OutboxChannels, channel) {
+		return nil, fmt.Errorf("unknown channel %q", channel)
+	}
+	return service.repo.GetByCompanyId(auth.CompanyId, status, channel, outboxListLimit)
+}
+
+func (service *OutboxService) GetMessage(auth *policy.AuthContext, id int64) (*entity.OutboxMessages, error) {
+	message, err := service.repo.GetById(id)
+	if err != nil || message.CompanyId == nil || *message.CompanyId != auth.CompanyId {
+		return nil, ErrOutboxMessageNotFound
+	}
+	return message, nil
+}
+
+// Replay đưa message dead về pending với số lần thử reset, dispatcher sẽ gửi ở lượt kế tiếp
+func (service *OutboxService) Replay(auth *policy.AuthContext, id int64) (*entity.OutboxMessages, error) {
+	message, err := service.GetMessage(auth, id)
+	if err != nil {
+		return nil, err
+	}
+	if message.Status != entity.OutboxStatusDead {
+		return nil, errors.New("only dead messages can be replayed")
+	}
+	if err := service.replay(message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// ReplayDead replay toàn bộ message dead của công ty (lọc theo kênh nếu có), trả về số message được replay
+func (service *OutboxService) ReplayDead(auth *policy.AuthContext, channel string) (int, error) {
+	messages, err := service.GetMessages(auth, entity.OutboxStatusDead, channel)
+	if err != nil {
+		return 0, err
+	}
+	for i, m := range messages {
+		if err := service.replay(m); err != nil {
+			return i, err
+		}
+	}
+	return len(messages), nil
+}
+
+func (service *OutboxService) replay(m *entity.OutboxMessages) error {
+	now := time.Now()
+	m.Status = entity.OutboxStatusPending
+	m.Attempts = 0
+	m.NextAttemptAt = &now
+	m.ProcessedAt = nil
+	m.ReplayCount++
+	return service.repo.Update(m, service.repo.GetDB())
+}
+
+// ErrorText cắt lỗi gửi về độ dài tối đa lưu ở LastError, dùng chung cho outbox và webhook delivery
+func ErrorText(err error) string {
+	text := err.Error()
+	if len(text) <= maxErrorLength {
+		return text
+	}
+	// Không cắt giữa 1 ký tự UTF-8
+	cut := maxErrorLength
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut]
+}
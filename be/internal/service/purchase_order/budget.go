@@ -0,0 +1,110 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	"BE_Manage_device/pkg/utils"
+	"sort"
+	"time"
+)
+
+func inRange(t time.Time, from, to *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+}
+
+// openAsOf: đơn đã được duyệt trước asOf và chưa bị huỷ tại asOf. Đơn huỷ trước khi có CancelledAt thì coi như đã đóng
+func openAsOf(order *entity.PurchaseOrders, asOf time.Time) bool {
+	if order.DecidedAt == nil || !order.DecidedAt.Before(asOf) {
+		return false
+	}
+	if order.Status == entity.PurchaseOrderStatusCancelled {
+		return order.CancelledAt != nil && !order.CancelledAt.Before(asOf)
+	}
+	return true
+}
+
+// receivedAsOf trả về số lượng đã nhận của từng dòng hàng tính tới trước asOf
+func receivedAsOf(order *entity.PurchaseOrders, asOf time.Time) map[int64]int {
+	received := map[int64]int{}
+	for _, receipt := range order.Receipts {
+		if !receipt.ReceivedAt.Before(asOf) {
+			continue
+		}
+		for _, line := range receipt.Lines {
+			received[line.PurchaseOrderItemId] += line.Quantity
+		}
+	}
+	return received
+}
+
+// GetBudget so sánh theo phòng ban: đã duyệt (tổng dự kiến của đơn được duyệt trong kỳ), đã cam kết (phần chưa nhận tại
+// thời điểm to, không có to thì tính tới hiện tại, của mọi đơn còn mở lúc đó dù được duyệt trước kỳ, theo giá dự kiến) và
+// đã chi (giá thực tế của các lần nhập kho trong kỳ). User chỉ có quyền trong phòng ban thì chỉ thấy phòng ban của mình
+func (service *PurchaseOrderService) GetBudget(auth *policy.AuthContext, from, to *time.Time) (*dto.PurchaseBudgetResponse, error) {
+	if err := utils.CheckRange(from, to); err != nil {
+		return nil, err
+	}
+	res := &dto.PurchaseBudgetResponse{From: from, To: to, Departments: []dto.PurchaseBudgetDepartmentResponse{}}
+	departmentId, restricted := viewScope(auth)
+	if restricted && departmentId == nil {
+		return res, nil
+	}
+	orders, err := service.repo.GetCommittedOrders(auth.CompanyId, departmentId)
+	if err != nil {
+		return nil, err
+	}
+	asOf := time.Now()
+	if to != nil {
+		asOf = *to
+	}
+	byDepartment := map[int64]*dto.PurchaseBudgetDepartmentResponse{}
+	for _, order := range orders {
+		d, ok := byDepartment[order.DepartmentId]
+		if !ok {
+			d = &dto.PurchaseBudgetDepartmentResponse{DepartmentId: order.DepartmentId, DepartmentName: order.Department.DepartmentName}
+			byDepartment[order.DepartmentId] = d
+		}
+		if order.DecidedAt != nil && inRange(*order.DecidedAt, from, to) {
+			d.Approved += order.EstimatedTotal
+		}
+		if openAsOf(order, asOf) {
+			received := receivedAsOf(order, asOf)
+			pending := 0
+			for _, item := range order.Items {
+				remaining := max(item.Quantity-received[item.Id], 0)
+				d.Committed += float64(remaining) * item.EstimatedUnitCost
+				pending += remaining
+			}
+			if pending > 0 {
+				d.OpenOrders++
+				d.PendingUnits += pending
+			}
+		}
+		for _, receipt := range order.Receipts {
+			if !inRange(receipt.ReceivedAt, from, to) {
+				continue
+			}
+			for _, line := range receipt.Lines {
+				d.Spent += float64(line.Quantity) * line.UnitCost
+				d.ReceivedUnits += line.Quantity
+			}
+		}
+	}
+	for _, d := range byDepartment {
+		d.Approved = utils.RoundMoney(d.Approved)
+		d.Committed = utils.RoundMoney(d.Committed)
+		d.Spent = utils.RoundMoney(d.Spent)
+		res.Approved += d.Approved
+		res.Committed += d.Committed
+		res.Spent += d.Spent
+		res.Departments = append(res.Departments, *d)
+	}
+	res.Approved = utils.RoundMoney(res.Approved)
+	res.Committed = utils.RoundMoney(res.Committed)
+	res.Spent = utils.RoundMoney(res.Spent)
+	sort.Slice(res.Departments, func(i, j int) bool {
+		return res.Departments[i].DepartmentName < res.Departments[j].DepartmentName
+	})
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidGoodsReceipt = errors.New("invalid goods receipt")
+	ErrNoAssetManager      = errors.New("department has no asset manager to receive the assets")
+)
+
+// receiptLine là 1 dòng nhập kho đã kiểm tra, item trỏ vào dòng hàng của đơn đang khoá
+type receiptLine struct {
+	item          *entity.PurchaseOrderItems
+	line          entity.GoodsReceiptLines
+	serialNumbers []string
+}
+
+// buildReceiptLines kiểm tra số lượng không vượt phần còn chờ nhận và chuẩn bị serial cho từng đơn vị.
+// Serial tự sinh có dạng <số đơn>-<id dòng hàng>-<thứ tự đơn vị>, mọi serial không được trùng tài sản đã có của công ty
+func (service *PurchaseOrderService) buildReceiptLines(order *entity.PurchaseOrders, requests []dto.GoodsReceiptLineRequest) ([]receiptLine, error) {
+	items := map[int64]*entity.PurchaseOrderItems{}
+	for i := range order.Items {
+		items[order.Items[i].Id] = &order.Items[i]
+	}
+	lines := make([]receiptLine, 0, len(requests))
+	seenItems := map[int64]bool{}
+	serialLines := map[string]int{}
+	allSerials := []string{}
+	for i, r := range requests {
+		item, ok := items[r.PurchaseOrderItemId]
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: item %d does not belong to the order", ErrInvalidGoodsReceipt, i+1, r.PurchaseOrderItemId)
+		}
+		if seenItems[item.Id] {
+			return nil, fmt.Errorf("%w: line %d: item %d is received twice", ErrInvalidGoodsReceipt, i+1, item.Id)
+		}
+		seenItems[item.Id] = true
+		if item.Remaining() == 0 {
+			return nil, fmt.Errorf("%w: line %d: item %d is already fully received", ErrInvalidGoodsReceipt, i+1, item.Id)
+		}
+		if r.Quantity <= 0 || r.Quantity > item.Remaining() {
+			return nil, fmt.Errorf("%w: line %d: quantity must be between 1 and %d", ErrInvalidGoodsReceipt, i+1, item.Remaining())
+		}
+		unitCost := item.EstimatedUnitCost
+		if r.UnitCost != nil {
+			unitCost = utils.RoundMoney(*r.UnitCost)
+		}
+		serials := make([]string, 0, r.Quantity)
+		switch len(r.SerialNumbers) {
+		case 0:
+			for n := 1; n <= r.Quantity; n++ {
+				serials = append(serials, fmt.Sprintf("%s-%d-%d", order.OrderNumber, item.Id, item.ReceivedQuantity+n))
+			}
+		case r.Quantity:
+			for _, s := range r.SerialNumbers {
+				serials = append(serials, strings.TrimSpace(s))
+			}
+		default:
+			return nil, fmt.Errorf("%w: line %d: expected %d serial numbers, got %d", ErrInvalidGoodsReceipt, i+1, r.Quantity, len(r.SerialNumbers))
+		}
+		for _, s := range serials {
+			if s == "" {
+				return nil, fmt.Errorf("%w: line %d: serial number must not be empty", ErrInvalidGoodsReceipt, i+1)
+			}
+			if first, ok := serialLines[s]; ok {
+				return nil, fmt.Errorf("%w: line %d: serial number %s is duplicated with line %d", ErrInvalidGoodsReceipt, i+1, s, first)
+			}
+			serialLines[s] = i + 1
+			allSerials = append(allSerials, s)
+		}
+		lines = append(lines, receiptLine{
+			item:          item,
+			line:          entity.GoodsReceiptLines{PurchaseOrderItemId: item.Id, Quantity: r.Quantity, UnitCost: unitCost},
+			serialNumbers: serials,
+		})
+	}
+	existing, err := service.assetRepo.GetAssetsBySerialNumbers(order.CompanyId, allSerials)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("%w: serial number %s already exists", ErrInvalidGoodsReceipt, existing[0].SerialNumber)
+	}
+	return lines, nil
+}
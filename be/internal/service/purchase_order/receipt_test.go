@@ -0,0 +1,146 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	asset "BE_Manage_device/internal/repository/assets"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeAssetRepo chỉ cài GetAssetsBySerialNumbers, serials là các serial đã có trong công ty
+type fakeAssetRepo struct {
+	asset.AssetsRepository
+	serials []string
+}
+
+func (r *fakeAssetRepo) GetAssetsBySerialNumbers(companyId int64, serialNumbers []string) ([]*entity.Assets, error) {
+	var result []*entity.Assets
+	for _, s := range serialNumbers {
+		if slices.Contains(r.serials, s) {
+			result = append(result, &entity.Assets{SerialNumber: s, CompanyId: companyId})
+		}
+	}
+	return result, nil
+}
+
+func receiptOrder() *entity.PurchaseOrders {
+	return &entity.PurchaseOrders{
+		Id:          1,
+		CompanyId:   1,
+		OrderNumber: "PO-0001",
+		Items: []entity.PurchaseOrderItems{
+			{Id: 11, Quantity: 3, ReceivedQuantity: 1, EstimatedUnitCost: 500},
+			{Id: 12, Quantity: 2, EstimatedUnitCost: 120.5},
+			{Id: 13, Quantity: 1, ReceivedQuantity: 1, EstimatedUnitCost: 80},
+		},
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestBuildReceiptLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		requests    []dto.GoodsReceiptLineRequest
+		wantSerials [][]string
+		wantCosts   []float64
+	}{
+		{
+			// serial tự sinh tiếp theo số đã nhận của dòng hàng
+			name:        "generated serials",
+			requests:    []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 11, Quantity: 2}},
+			wantSerials: [][]string{{"PO-0001-11-2", "PO-0001-11-3"}},
+			wantCosts:   []float64{500},
+		},
+		{
+			name:        "given serials are trimmed",
+			requests:    []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 2, SerialNumbers: []string{" SN-1 ", "SN-2"}}},
+			wantSerials: [][]string{{"SN-1", "SN-2"}},
+			wantCosts:   []float64{120.5},
+		},
+		{
+			name:        "actual unit cost is rounded",
+			requests:    []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 1, UnitCost: float64Ptr(99.999)}},
+			wantSerials: [][]string{{"PO-0001-12-1"}},
+			wantCosts:   []float64{100},
+		},
+		{
+			name: "several items",
+			requests: []dto.GoodsReceiptLineRequest{
+				{PurchaseOrderItemId: 12, Quantity: 1, UnitCost: float64Ptr(0)},
+				{PurchaseOrderItemId: 11, Quantity: 1, SerialNumbers: []string{"SN-9"}},
+			},
+			wantSerials: [][]string{{"PO-0001-12-1"}, {"SN-9"}},
+			wantCosts:   []float64{0, 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &PurchaseOrderService{assetRepo: &fakeAssetRepo{}}
+			lines, err := service.buildReceiptLines(receiptOrder(), tt.requests)
+			if err != nil {
+				t.Fatalf("buildReceiptLines: %v", err)
+			}
+			if len(lines) != len(tt.requests) {
+				t.Fatalf("got %d lines, want %d", len(lines), len(tt.requests))
+			}
+			for i, l := range lines {
+				if l.item.Id != tt.requests[i].PurchaseOrderItemId || l.line.PurchaseOrderItemId != l.item.Id || l.line.Quantity != tt.requests[i].Quantity {
+					t.Errorf("line %d = item %d, %+v", i, l.item.Id, l.line)
+				}
+				if !slices.Equal(l.serialNumbers, tt.wantSerials[i]) {
+					t.Errorf("line %d serials = %v, want %v", i, l.serialNumbers, tt.wantSerials[i])
+				}
+				if l.line.UnitCost != tt.wantCosts[i] {
+					t.Errorf("line %d unit cost = %v, want %v", i, l.line.UnitCost, tt.wantCosts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildReceiptLinesInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []string
+		requests []dto.GoodsReceiptLineRequest
+		wantMsg  string
+	}{
+		{"item of other order", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 99, Quantity: 1}}, "line 1: item 99 does not belong to the order"},
+		{"item twice", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 1}, {PurchaseOrderItemId: 12, Quantity: 1}}, "line 2: item 12 is received twice"},
+		{"fully received", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 13, Quantity: 1}}, "line 1: item 13 is already fully received"},
+		{"over remaining", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 11, Quantity: 3}}, "line 1: quantity must be between 1 and 2"},
+		{"zero quantity", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 11, Quantity: 0}}, "line 1: quantity must be between 1 and 2"},
+		{"serial count", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 2, SerialNumbers: []string{"SN-1"}}}, "line 1: expected 2 serial numbers, got 1"},
+		{"blank serial", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 2, SerialNumbers: []string{"SN-1", "  "}}}, "line 1: serial number must not be empty"},
+		{"duplicate in line", nil, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 2, SerialNumbers: []string{"SN-1", "SN-1"}}}, "line 1: serial number SN-1 is duplicated with line 1"},
+		{
+			"duplicate across lines", nil,
+			[]dto.GoodsReceiptLineRequest{
+				{PurchaseOrderItemId: 12, Quantity: 1, SerialNumbers: []string{"SN-1"}},
+				{PurchaseOrderItemId: 11, Quantity: 1, SerialNumbers: []string{"SN-1"}},
+			},
+			"line 2: serial number SN-1 is duplicated with line 1",
+		},
+		// serial tự sinh cũng phải kiểm tra trùng với tài sản đã có
+		{"existing generated serial", []string{"PO-0001-12-1"}, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 1}}, "serial number PO-0001-12-1 already exists"},
+		{"existing serial", []string{"SN-1"}, []dto.GoodsReceiptLineRequest{{PurchaseOrderItemId: 12, Quantity: 1, SerialNumbers: []string{"SN-1"}}}, "serial number SN-1 already exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &PurchaseOrderService{assetRepo: &fakeAssetRepo{serials: tt.existing}}
+			_, err := service.buildReceiptLines(receiptOrder(), tt.requests)
+			if !errors.Is(err, ErrInvalidGoodsReceipt) {
+				t.Fatalf("err = %v, want ErrInvalidGoodsReceipt", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("err = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	asset "BE_Manage_device/internal/repository/assets"
+	categories "BE_Manage_device/internal/repository/categories"
+	department "BE_Manage_device/internal/repository/departments"
+	purchaseOrder "BE_Manage_device/internal/repository/purchase_order"
+	user "BE_Manage_device/internal/repository/user"
+	assetS "BE_Manage_device/internal/service/asset"
+	vendorS "BE_Manage_device/internal/service/vendor"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPurchaseOrderNotFound  = errors.New("purchase order not found")
+	ErrInvalidPurchaseOrder   = errors.New("invalid purchase order")
+	ErrPurchaseOrderForbidden = errors.New("you are not allowed to manage purchase orders of this department")
+	ErrPurchaseOrderStatus    = errors.New("this action is not allowed in the current purchase order status")
+)
+
+type PurchaseOrderService struct {
+	repo           purchaseOrder.PurchaseOrdersRepository
+	assetRepo      asset.AssetsRepository
+	userRepo       user.UserRepository
+	departmentRepo department.DepartmentsRepository
+	categoriesRepo categories.CategoriesRepository
+	assetsService  *assetS.AssetsService
+	vendorService  *vendorS.VendorService
+}
+
+func NewPurchaseOrderService(repo purchaseOrder.PurchaseOrdersRepository, assetRepo asset.AssetsRepository, userRepo user.UserRepository, departmentRepo department.DepartmentsRepository, categoriesRepo categories.CategoriesRepository, assetsService *assetS.AssetsService, vendorService *vendorS.VendorService) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo, assetRepo: assetRepo, userRepo: userRepo, departmentRepo: departmentRepo, categoriesRepo: categoriesRepo, assetsService: assetsService, vendorService: vendorService}
+}
+
+// departmentScope: chỉ cần 1 permission không giới hạn phòng ban là xem được cả công ty
+func departmentScope(auth *policy.AuthContext, permSlugs ...string) (*int64, bool) {
+	for _, slug := range permSlugs {
+		if _, restricted := auth.DepartmentScope(slug); !restricted {
+			return nil, false
+		}
+	}
+	return auth.DepartmentId, true
+}
+
+// Người duyệt (manage-taxonomy) xem mọi đơn của công ty, người mua theo phạm vi manage-assets
+func viewScope(auth *policy.AuthContext) (*int64, bool) {
+	return departmentScope(auth, policy.PermissionManageTaxonomy, policy.PermissionManageAssets)
+}
+
+func (service *PurchaseOrderService) getOrderOfCompany(auth *policy.AuthContext, id int64) (*entity.PurchaseOrders, error) {
+	order, err := service.repo.GetById(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.CompanyId != auth.CompanyId) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if departmentId, restricted := viewScope(auth); restricted && (departmentId == nil || *departmentId != order.DepartmentId) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	return order, nil
+}
+
+// lockOrderOfCompany khoá đơn trong tx, dùng cho mọi thao tác đổi trạng thái
+func (service *PurchaseOrderService) lockOrderOfCompany(tx *gorm.DB, auth *policy.AuthContext, id int64) (*entity.PurchaseOrders, error) {
+	order, err := service.repo.LockById(id, auth.CompanyId, tx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	return order, err
+}
+
+// Create tạo đơn mua hàng ở trạng thái Pending cho phòng ban user được quản lý tài sản
+func (service *PurchaseOrderService) Create(auth *policy.AuthContext, request dto.PurchaseOrderRequest) (*entity.PurchaseOrders, error) {
+	dep, err := service.departmentRepo.GetDepartmentById(request.DepartmentId)
+	if err != nil || dep.CompanyId != auth.CompanyId {
+		return nil, fmt.Errorf("%w: department not found", ErrInvalidPurchaseOrder)
+	}
+	if !auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, dep.Id) {
+		return nil, ErrPurchaseOrderForbidden
+	}
+	if err := service.vendorService.CheckVendor(auth.CompanyId, request.VendorId); err != nil {
+		return nil, err
+	}
+	categoriesOfCompany, err := service.categoriesRepo.GetAll(auth.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	categoryIds := map[int64]bool{}
+	for _, c := range categoriesOfCompany {
+		categoryIds[c.Id] = true
+	}
+	order := entity.PurchaseOrders{
+		CompanyId:     auth.CompanyId,
+		DepartmentId:  dep.Id,
+		VendorId:      request.VendorId,
+		Status:        entity.PurchaseOrderStatusPending,
+		Description:   strings.TrimSpace(request.Description),
+		ExpectedDate:  request.ExpectedDate,
+		RequestedById: auth.UserId,
+	}
+	for i, r := range request.Items {
+		if !categoryIds[r.CategoryId] {
+			return nil, fmt.Errorf("%w: item %d has unknown category %d", ErrInvalidPurchaseOrder, i+1, r.CategoryId)
+		}
+		description := strings.TrimSpace(r.Description)
+		if description == "" {
+			return nil, fmt.Errorf("%w: item %d must have a description", ErrInvalidPurchaseOrder, i+1)
+		}
+		item := entity.PurchaseOrderItems{
+			CategoryId:        r.CategoryId,
+			Description:       description,
+			Quantity:          r.Quantity,
+			EstimatedUnitCost: utils.RoundMoney(r.EstimatedUnitCost),
+			WarrantyMonths:    r.WarrantyMonths,
+		}
+		order.EstimatedTotal += float64(item.Quantity) * item.EstimatedUnitCost
+		order.Items = append(order.Items, item)
+	}
+	order.EstimatedTotal = utils.RoundMoney(order.EstimatedTotal)
+	err = service.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		return service.repo.Create(&order, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.GetById(order.Id)
+}
+
+// GetPurchaseOrders liệt kê đơn trong phạm vi của user, lọc theo trạng thái nếu có
+func (service *PurchaseOrderService) GetPurchaseOrders(auth *policy.AuthContext, status string) ([]*entity.PurchaseOrders, error) {
+	departmentId, restricted := viewScope(auth)
+	if restricted && departmentId == nil {
+		return []*entity.PurchaseOrders{}, nil
+	}
+	return service.repo.GetByCompanyId(auth.CompanyId, departmentId, strings.TrimSpace(status))
+}
+
+func (service *PurchaseOrderService) GetPurchaseOrder(auth *policy.AuthContext, id int64) (*entity.PurchaseOrders, error) {
+	return service.getOrderOfCompany(auth, id)
+}
+
+// decide duyệt hoặc từ chối đơn đang Pending
+func (service *PurchaseOrderService) decide(auth *policy.AuthContext, id int64, status string, note string) (*entity.PurchaseOrders, error) {
+	err := service.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		order, err := service.lockOrderOfCompany(tx, auth, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != entity.PurchaseOrderStatusPending {
+			return ErrPurchaseOrderStatus
+		}
+		now := time.Now()
+		order.Status = status
+		order.DecidedById = &auth.UserId
+		order.DecidedAt = &now
+		order.DecisionNote = strings.TrimSpace(note)
+		return service.repo.UpdateDecision(order, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.GetById(id)
+}
+
+func (service *PurchaseOrderService) Approve(auth *policy.AuthContext, id int64, note string) (*entity.PurchaseOrders, error) {
+	return service.decide(auth, id, entity.PurchaseOrderStatusApproved, note)
+}
+
+func (service *PurchaseOrderService) Reject(auth *policy.AuthContext, id int64, note string) (*entity.PurchaseOrders, error) {
+	return service.decide(auth, id, entity.PurchaseOrderStatusRejected, note)
+}
+
+// Cancel huỷ đơn chưa nhận xong. Đơn đã nhận 1 phần thì phần đã nhận vẫn tính vào đã chi, phần còn lại không còn cam kết
+func (service *PurchaseOrderService) Cancel(auth *policy.AuthContext, id int64) (*entity.PurchaseOrders, error) {
+	err := service.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		order, err := service.lockOrderOfCompany(tx, auth, id)
+		if err != nil {
+			return err
+		}
+		if !auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, order.DepartmentId) && !auth.Allows(policy.PermissionManageTaxonomy, policy.OpWrite) {
+			return ErrPurchaseOrderForbidden
+		}
+		switch order.Status {
+		case entity.PurchaseOrderStatusPending, entity.PurchaseOrderStatusApproved, entity.PurchaseOrderStatusPartiallyReceived:
+		default:
+			return ErrPurchaseOrderStatus
+		}
+		return service.repo.Cancel(order.Id, time.Now(), tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.GetById(id)
+}
+
+// Receive nhập kho 1 phần hoặc toàn bộ số lượng còn chờ của đơn đã duyệt. Mỗi đơn vị nhận thành 1 tài sản của phòng ban
+// đặt hàng, giao cho asset manager của phòng ban giống như tạo tài sản bằng tay. QR được sinh sau khi commit
+func (service *PurchaseOrderService) Receive(auth *policy.AuthContext, id int64, request dto.GoodsReceiptRequest) (*entity.PurchaseOrders, error) {
+	now := time.Now()
+	receivedAt := now
+	if request.ReceivedAt != nil {
+		if request.ReceivedAt.After(now) {
+			return nil, fmt.Errorf("%w: received date must not be in the future", ErrInvalidGoodsReceipt)
+		}
+		receivedAt = *request.ReceivedAt
+	}
+	createdIds := []int64{}
+	err := service.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		order, err := service.lockOrderOfCompany(tx, auth, id)
+		if err != nil {
+			return err
+		}
+		if !auth.AllowsInDepartment(policy.PermissionManageAssets, policy.OpWrite, order.DepartmentId) {
+			return ErrPurchaseOrderForbidden
+		}
+		if order.Status != entity.PurchaseOrderStatusApproved && order.Status != entity.PurchaseOrderStatusPartiallyReceived {
+			return ErrPurchaseOrderStatus
+		}
+		manager, err := service.userRepo.GetUserAssetManageOfDepartment(order.DepartmentId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoAssetManager
+		}
+		if err != nil {
+			return err
+		}
+		lines, err := service.buildReceiptLines(order, request.Lines)
+		if err != nil {
+			return err
+		}
+		receipt := entity.GoodsReceipts{
+			PurchaseOrderId: order.Id,
+			ReceivedById:    auth.UserId,
+			ReceivedAt:      receivedAt,
+			Note:            strings.TrimSpace(request.Note),
+		}
+		for _, l := range lines {
+			receipt.Lines = append(receipt.Lines, l.line)
+		}
+		if err := service.repo.CreateReceipt(&receipt, tx); err != nil {
+			return err
+		}
+		changeSummary := fmt.Sprintf("Create asset (purchase order %s)", order.OrderNumber)
+		// Ảnh và file đính kèm để trống, bổ sung sau bằng PUT /assets/:id
+		noFile := ""
+		for i, l := range lines {
+			lineId := receipt.Lines[i].Id
+			for _, serial := range l.serialNumbers {
+				asset := entity.Assets{
+					AssetName:          l.item.Description,
+					PurchaseDate:       receivedAt,
+					Cost:               l.line.UnitCost,
+					WarrantExpiry:      receivedAt.AddDate(0, l.item.WarrantyMonths, 0),
+					Status:             "New",
+					SerialNumber:       serial,
+					ImageUpload:        &noFile,
+					FileAttachment:     &noFile,
+					CategoryId:         l.item.CategoryId,
+					DepartmentId:       order.DepartmentId,
+					Owner:              utils.PtrInt64(manager.Id),
+					CompanyId:          order.CompanyId,
+					VendorId:           order.VendorId,
+					GoodsReceiptLineId: &lineId,
+				}
+				assetCreate, err := service.assetsService.CreateInTx(tx, auth.UserId, &asset, changeSummary)
+				if err != nil {
+					return err
+				}
+				createdIds = append(createdIds, assetCreate.Id)
+			}
+			l.item.ReceivedQuantity += l.line.Quantity
+			if err := service.repo.UpdateReceivedQuantity(l.item.Id, l.item.ReceivedQuantity, tx); err != nil {
+				return err
+			}
+		}
+		status := entity.PurchaseOrderStatusReceived
+		for _, item := range order.Items {
+			if item.Remaining() > 0 {
+				status = entity.PurchaseOrderStatusPartiallyReceived
+				break
+			}
+		}
+		return service.repo.UpdateStatus(order.Id, status, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, assetId := range createdIds {
+		service.assetsService.GenQrAsync(assetId, request.RedirectUrl)
+	}
+	return service.repo.GetById(id)
+}
@@ -4,61 +4,225 @@ import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	"BE_Manage_device/internal/domain/filter"
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
+	asset_log "BE_Manage_device/internal/repository/asset_log"
 	asset "BE_Manage_device/internal/repository/assets"
 	request_transfer "BE_Manage_device/internal/repository/request_transfer"
 	user "BE_Manage_device/internal/repository/user"
 	assignmentS "BE_Manage_device/internal/service/assignment"
+	notificationS "BE_Manage_device/internal/service/notification"
 	"BE_Manage_device/pkg/utils"
-
 	"errors"
 	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RequestStatusPending   = "Pending"
+	RequestStatusConfirm   = "Confirm"
+	RequestStatusDeny      = "Deny"
+	RequestStatusCancelled = "Cancelled"
 )
 
+// Chuỗi duyệt mặc định khi công ty chưa cấu hình: asset manager phòng ban nguồn chọn tài sản và duyệt
+var defaultApprovalSteps = []*entity.TransferApprovalSteps{
+	{StepOrder: 1, ApproverType: entity.ApproverSourceDepartmentManager},
+}
+
 type RequestTransferService struct {
-	repo              request_transfer.RequestTransferRepository
-	assignmentService *assignmentS.AssignmentService
-	userRepo          user.UserRepository
-	assetRepo         asset.AssetsRepository
+	repo                request_transfer.RequestTransferRepository
+	assignmentService   *assignmentS.AssignmentService
+	userRepo            user.UserRepository
+	assetRepo           asset.AssetsRepository
+	assetLogRepo        asset_log.AssetsLogRepository
+	loanRepo            assetLoan.AssetLoansRepository
+	NotificationService *notificationS.NotificationService
 }
 
-func NewRequestTransferService(repo request_transfer.RequestTransferRepository, assignmentService *assignmentS.AssignmentService, userRepo user.UserRepository, assetRepo asset.AssetsRepository) *RequestTransferService {
-	return &RequestTransferService{repo: repo, assignmentService: assignmentService, userRepo: userRepo, assetRepo: assetRepo}
+func NewRequestTransferService(repo request_transfer.RequestTransferRepository, assignmentService *assignmentS.AssignmentService, userRepo user.UserRepository, assetRepo asset.AssetsRepository, assetLogRepo asset_log.AssetsLogRepository, loanRepo assetLoan.AssetLoansRepository, NotificationService *notificationS.NotificationService) *RequestTransferService {
+	return &RequestTransferService{repo: repo, assignmentService: assignmentService, userRepo: userRepo, assetRepo: assetRepo, assetLogRepo: assetLogRepo, loanRepo: loanRepo, NotificationService: NotificationService}
 }
 
 func (service *RequestTransferService) Create(userId int64, categoryId int64, description string) (*entity.RequestTransfer, error) {
+	var err error
 	user, err := service.userRepo.FindByUserId(userId)
 	if err != nil {
 		return nil, err
 	}
+	if user.DepartmentId == nil {
+		return nil, errors.New("user don't have department")
+	}
+	steps, err := service.getApprovalSteps(user.CompanyId)
+	if err != nil {
+		return nil, err
+	}
+	approvals := make([]entity.RequestTransferApprovals, 0, len(steps))
+	for _, step := range steps {
+		approvals = append(approvals, entity.RequestTransferApprovals{
+			StepOrder:    step.StepOrder,
+			ApproverType: step.ApproverType,
+			MinAssetCost: step.MinAssetCost,
+			Decision:     entity.ApprovalDecisionPending,
+		})
+	}
 	requestTransfer := entity.RequestTransfer{
 		UserId:      userId,
 		CategoryId:  categoryId,
-		Status:      "Pending",
+		Status:      RequestStatusPending,
 		Description: description,
+		CurrentStep: steps[0].StepOrder,
 		CompanyId:   user.CompanyId,
+		Approvals:   approvals,
 	}
-	requestTransferCreate, err := service.repo.Create(&requestTransfer)
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	requestTransferCreate, err := service.repo.Create(&requestTransfer, tx)
 	if err != nil {
 		return nil, err
 	}
-	return requestTransferCreate, err
+	current, err := service.advance(requestTransferCreate, nil, tx)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		err = errors.New("approval chain has no step that can select an asset")
+		return nil, err
+	}
+	if err = service.repo.UpdateCurrentStep(requestTransferCreate.Id, current.StepOrder, tx); err != nil {
+		return nil, err
+	}
+	changeSummary := fmt.Sprintf("Request transfer #%v for a '%v' asset created by %v", requestTransferCreate.Id, requestTransferCreate.Category.CategoryName, user.Email)
+	if err = service.log(userId, requestTransferCreate, nil, "Transfer Requested", changeSummary, tx); err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("%v requested a '%v' asset transfer (request #%v) and it is waiting for your approval", user.Email, requestTransferCreate.Category.CategoryName, requestTransferCreate.Id)
+	if err = service.notify(tx, userId, requestTransferCreate.CompanyId, entity.NotificationEventTransferRequested, service.approversOf(requestTransferCreate, current, nil), message, nil); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetRequestTransferById(requestTransferCreate.Id)
 }
 
-func (service *RequestTransferService) Accept(userId int64, id int64, assetId int64) (*entity.RequestTransfer, error) {
+// Accept duyệt bước hiện tại; khi bước cuối được duyệt thì chuyển tài sản sang phòng ban yêu cầu
+func (service *RequestTransferService) Accept(userId int64, id int64, assetId *int64, comment string) (*entity.RequestTransfer, error) {
 	var err error
-	requestCheck, err := service.repo.GetRequestTransferById(id)
+	byUser, err := service.userRepo.FindByUserId(userId)
 	if err != nil {
 		return nil, err
 	}
-	if requestCheck.Status == "Deny" {
-		return nil, errors.New("can't change request")
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	requestCheck, err := service.getPendingRequest(byUser, id, tx)
+	if err != nil {
+		return nil, err
+	}
+	assetCheck := requestCheck.Asset
+	selectAsset := false
+	if assetId != nil {
+		if assetCheck != nil && assetCheck.Id != *assetId {
+			err = errors.New("another asset was already selected for this request")
+			return nil, err
+		}
+		if assetCheck == nil {
+			assetCheck, err = service.assetRepo.GetAssetById(*assetId)
+			if err != nil {
+				return nil, err
+			}
+			if err = service.checkAsset(requestCheck, assetCheck); err != nil {
+				return nil, err
+			}
+			selectAsset = true
+		}
+	}
+	if selectAsset {
+		if err = service.checkNotLoaned(assetCheck.Id, tx); err != nil {
+			return nil, err
+		}
+		if err = service.repo.UpdateAsset(id, assetCheck.Id, tx); err != nil {
+			return nil, err
+		}
+	}
+	current, err := service.advance(requestCheck, assetCheck, tx)
+	if err != nil {
+		return nil, err
 	}
-	assetCheck, err := service.assetRepo.GetAssetById(assetId)
+	if current == nil {
+		err = errors.New("request has no pending approval step")
+		return nil, err
+	}
+	if err = service.checkApprover(byUser, requestCheck, current, assetCheck); err != nil {
+		return nil, err
+	}
+	if err = service.decide(current, userId, entity.ApprovalDecisionApproved, comment, tx); err != nil {
+		return nil, err
+	}
+	next, err := service.advance(requestCheck, assetCheck, tx)
 	if err != nil {
 		return nil, err
 	}
-	if assetCheck.DepartmentId == *requestCheck.User.DepartmentId {
-		return nil, errors.New("asset department same request department")
+	var changeSummary string
+	if next == nil {
+		if assetCheck == nil {
+			err = errors.New("an asset must be selected before the last approval")
+			return nil, err
+		}
+		if err = service.transfer(userId, requestCheck, assetCheck, tx); err != nil {
+			return nil, err
+		}
+		if err = service.repo.UpdateStatus(id, RequestStatusConfirm, tx); err != nil {
+			return nil, err
+		}
+		changeSummary = fmt.Sprintf("Request transfer #%v step %v (%v) approved by %v, request confirmed", id, current.StepOrder, current.ApproverType, byUser.Email)
+	} else {
+		if err = service.repo.UpdateCurrentStep(id, next.StepOrder, tx); err != nil {
+			return nil, err
+		}
+		changeSummary = fmt.Sprintf("Request transfer #%v step %v (%v) approved by %v", id, current.StepOrder, current.ApproverType, byUser.Email)
+	}
+	if comment != "" {
+		changeSummary += ": " + comment
+	}
+	if err = service.log(userId, requestCheck, assetCheck, "Transfer Approval", changeSummary, tx); err != nil {
+		return nil, err
+	}
+	usersToNotifications := []*entity.Users{&requestCheck.User}
+	if next != nil {
+		usersToNotifications = append(usersToNotifications, service.approversOf(requestCheck, next, assetCheck)...)
+	} else {
+		usersToNotifications = append(usersToNotifications, service.decidedApprovers(requestCheck)...)
+	}
+	if err = service.notify(tx, userId, requestCheck.CompanyId, entity.NotificationEventTransferUpdated, usersToNotifications, changeSummary, assetIdOf(assetCheck)); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetRequestTransferById(id)
+}
+
+func (service *RequestTransferService) Deny(userId int64, id int64, comment string) (*entity.RequestTransfer, error) {
+	var err error
+	byUser, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
 	}
 	tx := service.repo.GetDB().Begin()
 	defer func() {
@@ -69,40 +233,54 @@ func (service *RequestTransferService) Accept(userId int64, id int64, assetId in
 			tx.Rollback()
 		}
 	}()
-	request, err := service.repo.UpdateStatusConfirm(id, tx)
+	requestCheck, err := service.getPendingRequest(byUser, id, tx)
 	if err != nil {
 		return nil, err
 	}
-	assignment, err := service.assignmentService.Repo.GetAssignmentByAssetId(assetId)
+	current, err := service.advance(requestCheck, requestCheck.Asset, tx)
 	if err != nil {
 		return nil, err
 	}
-	if requestCheck.User.DepartmentId == nil {
-		return nil, errors.New("user don't have department")
+	if current == nil {
+		err = errors.New("request has no pending approval step")
+		return nil, err
 	}
-	userAssign, err := service.userRepo.GetUserAssetManageOfDepartment(*requestCheck.User.DepartmentId)
-	if err != nil {
+	if err = service.checkApprover(byUser, requestCheck, current, requestCheck.Asset); err != nil {
 		return nil, err
 	}
-	_, err = service.assignmentService.Update(userId, assignment.Id, &userAssign.Id, requestCheck.User.DepartmentId)
-	if err != nil {
+	if err = service.decide(current, userId, entity.ApprovalDecisionDenied, comment, tx); err != nil {
+		return nil, err
+	}
+	if err = service.repo.SkipPendingApprovals(id, tx); err != nil {
+		return nil, err
+	}
+	if err = service.repo.UpdateStatus(id, RequestStatusDeny, tx); err != nil {
+		return nil, err
+	}
+	changeSummary := fmt.Sprintf("Request transfer #%v denied at step %v (%v) by %v", id, current.StepOrder, current.ApproverType, byUser.Email)
+	if comment != "" {
+		changeSummary += ": " + comment
+	}
+	if err = service.log(userId, requestCheck, requestCheck.Asset, "Transfer Denied", changeSummary, tx); err != nil {
+		return nil, err
+	}
+	usersToNotifications := append([]*entity.Users{&requestCheck.User}, service.decidedApprovers(requestCheck)...)
+	if err = service.notify(tx, userId, requestCheck.CompanyId, entity.NotificationEventTransferUpdated, usersToNotifications, changeSummary, assetIdOf(requestCheck.Asset)); err != nil {
 		return nil, err
 	}
 	if err = tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("commit failed: %w", err)
 	}
-	return request, nil
+	return service.repo.GetRequestTransferById(id)
 }
 
-func (service *RequestTransferService) Deny(userId int64, id int64) (*entity.RequestTransfer, error) {
+// Cancel: chỉ người tạo request được huỷ khi request còn Pending
+func (service *RequestTransferService) Cancel(userId int64, id int64, comment string) (*entity.RequestTransfer, error) {
 	var err error
-	requestCheck, err := service.repo.GetRequestTransferById(id)
+	byUser, err := service.userRepo.FindByUserId(userId)
 	if err != nil {
 		return nil, err
 	}
-	if requestCheck.Status == "Confirm" {
-		return nil, errors.New("can't change request")
-	}
 	tx := service.repo.GetDB().Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -112,21 +290,54 @@ func (service *RequestTransferService) Deny(userId int64, id int64) (*entity.Req
 			tx.Rollback()
 		}
 	}()
-	request, err := service.repo.UpdateStatusDeny(id, tx)
+	requestCheck, err := service.getPendingRequest(byUser, id, tx)
 	if err != nil {
 		return nil, err
 	}
+	if requestCheck.UserId != userId {
+		err = errors.New("only the requester can cancel this request")
+		return nil, err
+	}
+	if err = service.repo.SkipPendingApprovals(id, tx); err != nil {
+		return nil, err
+	}
+	if err = service.repo.UpdateStatus(id, RequestStatusCancelled, tx); err != nil {
+		return nil, err
+	}
+	changeSummary := fmt.Sprintf("Request transfer #%v cancelled by %v", id, byUser.Email)
+	if comment != "" {
+		changeSummary += ": " + comment
+	}
+	if err = service.log(userId, requestCheck, requestCheck.Asset, "Transfer Cancelled", changeSummary, tx); err != nil {
+		return nil, err
+	}
+	usersToNotifications := service.decidedApprovers(requestCheck)
+	for i := range requestCheck.Approvals {
+		if requestCheck.Approvals[i].StepOrder == requestCheck.CurrentStep {
+			usersToNotifications = append(usersToNotifications, service.approversOf(requestCheck, &requestCheck.Approvals[i], requestCheck.Asset)...)
+		}
+	}
+	if err = service.notify(tx, userId, requestCheck.CompanyId, entity.NotificationEventTransferUpdated, usersToNotifications, changeSummary, assetIdOf(requestCheck.Asset)); err != nil {
+		return nil, err
+	}
 	if err = tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("commit failed: %w", err)
 	}
-	return request, nil
+	return service.repo.GetRequestTransferById(id)
 }
 
 func (service *RequestTransferService) GetRequestTransferById(userId int64, id int64) (*entity.RequestTransfer, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
 	request, err := service.repo.GetRequestTransferById(id)
 	if err != nil {
 		return nil, err
 	}
+	if request.CompanyId != users.CompanyId {
+		return nil, errors.New("request transfer not found")
+	}
 	return request, nil
 }
 
@@ -153,3 +364,309 @@ func (service *RequestTransferService) Filter(userId int64, status *string) ([]d
 
 	return requestRes, nil
 }
+
+func (service *RequestTransferService) GetApprovalChain(userId int64) ([]*entity.TransferApprovalSteps, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return service.getApprovalSteps(users.CompanyId)
+}
+
+// UpdateApprovalChain thay toàn bộ chuỗi duyệt của công ty, chỉ áp dụng cho request tạo sau đó
+func (service *RequestTransferService) UpdateApprovalChain(userId int64, request []dto.TransferApprovalStepRequest) ([]*entity.TransferApprovalSteps, error) {
+	var err error
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(request) == 0 {
+		return nil, errors.New("approval chain must have at least one step")
+	}
+	steps := make([]*entity.TransferApprovalSteps, 0, len(request))
+	// Phải có ít nhất 1 bước luôn áp dụng do người chọn được tài sản phụ trách
+	canSelectAsset := false
+	for i, step := range request {
+		if !entity.ValidApproverType(step.ApproverType) {
+			return nil, fmt.Errorf("invalid approver type '%v'", step.ApproverType)
+		}
+		if step.ApproverType != entity.ApproverRequesterDepartmentHead && step.MinAssetCost == nil {
+			canSelectAsset = true
+		}
+		steps = append(steps, &entity.TransferApprovalSteps{
+			CompanyId:    users.CompanyId,
+			StepOrder:    i + 1,
+			ApproverType: step.ApproverType,
+			MinAssetCost: step.MinAssetCost,
+		})
+	}
+	if !canSelectAsset {
+		return nil, errors.New("approval chain needs a source_department_manager or admin step without cost threshold")
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.ReplaceApprovalSteps(users.CompanyId, steps, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return steps, nil
+}
+
+func (service *RequestTransferService) getApprovalSteps(companyId int64) ([]*entity.TransferApprovalSteps, error) {
+	steps, err := service.repo.GetApprovalStepsByCompanyId(companyId)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return defaultApprovalSteps, nil
+	}
+	return steps, nil
+}
+
+// getPendingRequest khoá request trong tx rồi mới kiểm tra Pending, 2 lần duyệt song song không cùng qua được
+func (service *RequestTransferService) getPendingRequest(byUser *entity.Users, id int64, tx *gorm.DB) (*entity.RequestTransfer, error) {
+	request, err := service.repo.GetRequestTransferForUpdate(id, tx)
+	if err != nil {
+		return nil, err
+	}
+	if request.CompanyId != byUser.CompanyId {
+		return nil, errors.New("request transfer not found")
+	}
+	if request.Status != RequestStatusPending {
+		return nil, errors.New("can't change request")
+	}
+	if request.User.DepartmentId == nil {
+		return nil, errors.New("user don't have department")
+	}
+	return request, nil
+}
+
+// advance bỏ qua các bước không áp dụng (giá tài sản dưới ngưỡng) hoặc do chính người yêu cầu phụ trách,
+// trả về bước đang chờ duyệt, nil nếu đã duyệt hết
+func (service *RequestTransferService) advance(request *entity.RequestTransfer, asset *entity.Assets, tx *gorm.DB) (*entity.RequestTransferApprovals, error) {
+	for i := range request.Approvals {
+		step := &request.Approvals[i]
+		if step.Decision != entity.ApprovalDecisionPending {
+			continue
+		}
+		if !step.AppliesTo(asset) {
+			if err := service.decide(step, 0, entity.ApprovalDecisionSkipped, fmt.Sprintf("Asset cost is below %v", *step.MinAssetCost), tx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if step.ApproverType == entity.ApproverRequesterDepartmentHead {
+			headId := request.User.Department.HeadId
+			if headId != nil && *headId == request.UserId {
+				if err := service.decide(step, request.UserId, entity.ApprovalDecisionApproved, "Requester is the head of the department", tx); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		return step, nil
+	}
+	return nil, nil
+}
+
+func (service *RequestTransferService) decide(step *entity.RequestTransferApprovals, approverId int64, decision, comment string, tx *gorm.DB) error {
+	now := time.Now()
+	step.Decision = decision
+	step.Comment = comment
+	step.DecidedAt = &now
+	if approverId != 0 {
+		step.ApproverId = &approverId
+	}
+	return service.repo.UpdateApproval(step, tx)
+}
+
+func (service *RequestTransferService) checkApprover(byUser *entity.Users, request *entity.RequestTransfer, step *entity.RequestTransferApprovals, asset *entity.Assets) error {
+	permissionErrorMessage := errors.New("you are not allowed to decide this approval step")
+	switch step.ApproverType {
+	case entity.ApproverRequesterDepartmentHead:
+		// Phòng ban chưa có trưởng phòng thì admin duyệt thay
+		headId := request.User.Department.HeadId
+		if headId != nil && *headId == byUser.Id {
+			return nil
+		}
+		if headId == nil && byUser.Role.Slug == "admin" {
+			return nil
+		}
+	case entity.ApproverSourceDepartmentManager:
+		if asset == nil {
+			return errors.New("an asset from your department must be selected for this step")
+		}
+		if byUser.IsAssetManager && byUser.DepartmentId != nil && *byUser.DepartmentId == asset.DepartmentId {
+			return nil
+		}
+	case entity.ApproverAdmin:
+		if byUser.Role.Slug == "admin" {
+			return nil
+		}
+	}
+	return permissionErrorMessage
+}
+
+func (service *RequestTransferService) checkAsset(request *entity.RequestTransfer, asset *entity.Assets) error {
+	if asset.CompanyId != request.CompanyId {
+		return errors.New("asset not found")
+	}
+	if asset.CategoryId != request.CategoryId {
+		return errors.New("asset category doesn't match the request")
+	}
+	if asset.DepartmentId == *request.User.DepartmentId {
+		return errors.New("asset department same request department")
+	}
+	if asset.Status != "New" && asset.Status != "In Use" {
+		return fmt.Errorf("asset with status '%v' can't be transferred", asset.Status)
+	}
+	return nil
+}
+
+// checkNotLoaned khoá tài sản rồi kiểm tra khoản mượn, tài sản đang cho mượn thì không điều chuyển được
+func (service *RequestTransferService) checkNotLoaned(assetId int64, tx *gorm.DB) error {
+	if err := service.assetRepo.LockAsset(assetId, tx); err != nil {
+		return err
+	}
+	active, err := service.loanRepo.HasActiveLoan(assetId, tx)
+	if err != nil {
+		return err
+	}
+	if active {
+		return errors.New("asset is checked out on loan and can't be transferred")
+	}
+	return nil
+}
+
+// transfer giao tài sản cho asset manager của phòng ban yêu cầu
+func (service *RequestTransferService) transfer(userId int64, request *entity.RequestTransfer, asset *entity.Assets, tx *gorm.DB) error {
+	if err := service.checkNotLoaned(asset.Id, tx); err != nil {
+		return err
+	}
+	userAssign, err := service.userRepo.GetUserAssetManageOfDepartment(*request.User.DepartmentId)
+	if err != nil {
+		return err
+	}
+	assignment, err := service.assignmentService.Repo.GetAssignmentByAssetId(asset.Id)
+	if err != nil {
+		return err
+	}
+	if _, err := service.assignmentService.Repo.Update(assignment.Id, userId, asset.Id, &userAssign.Id, request.User.DepartmentId, tx); err != nil {
+		return err
+	}
+	if err := service.assetRepo.UpdateOwner(asset.Id, userAssign.Id, tx); err != nil {
+		return err
+	}
+	if _, err := service.assetRepo.UpdateAssetDepartment(asset.Id, *request.User.DepartmentId, tx); err != nil {
+		return err
+	}
+	changeSummary := fmt.Sprintf("Transfer from department %v to department %v by request transfer #%v",
+		asset.Department.DepartmentName, request.User.Department.DepartmentName, request.Id)
+	assetLog := entity.AssetLog{
+		Action:        "Transfer",
+		Event:         entity.WebhookEventAssetTransferred,
+		Timestamp:     time.Now(),
+		ByUserId:      &userId,
+		AssignUserId:  &userAssign.Id,
+		AssetId:       asset.Id,
+		ChangeSummary: changeSummary,
+		CompanyId:     request.CompanyId,
+	}
+	_, err = service.assetLogRepo.Create(&assetLog, tx)
+	return err
+}
+
+// log ghi lịch sử của request, nếu request đã chọn tài sản thì ghi thêm AssetLog
+func (service *RequestTransferService) log(userId int64, request *entity.RequestTransfer, asset *entity.Assets, action, changeSummary string, tx *gorm.DB) error {
+	now := time.Now()
+	requestLog := entity.RequestTransferLogs{
+		RequestTransferId: request.Id,
+		Action:            action,
+		ByUserId:          userId,
+		ChangeSummary:     changeSummary,
+		Timestamp:         now,
+		CompanyId:         request.CompanyId,
+	}
+	if err := service.repo.CreateLog(&requestLog, tx); err != nil {
+		return err
+	}
+	if asset == nil {
+		return nil
+	}
+	assetLog := entity.AssetLog{
+		Action:        action,
+		Timestamp:     now,
+		ByUserId:      &userId,
+		AssignUserId:  &request.UserId,
+		AssetId:       asset.Id,
+		ChangeSummary: changeSummary,
+		CompanyId:     request.CompanyId,
+	}
+	_, err := service.assetLogRepo.Create(&assetLog, tx)
+	return err
+}
+
+// approversOf trả về những người có thể duyệt bước step
+func (service *RequestTransferService) approversOf(request *entity.RequestTransfer, step *entity.RequestTransferApprovals, asset *entity.Assets) []*entity.Users {
+	if step == nil {
+		return nil
+	}
+	switch step.ApproverType {
+	case entity.ApproverRequesterDepartmentHead:
+		if headId := request.User.Department.HeadId; headId != nil {
+			head, _ := service.userRepo.FindByUserId(*headId)
+			return []*entity.Users{head}
+		}
+		admins, _ := service.userRepo.GetUserRoleAdminOfCompany(request.CompanyId)
+		return admins
+	case entity.ApproverSourceDepartmentManager:
+		if asset != nil {
+			manager, _ := service.userRepo.GetUserAssetManageOfDepartment(asset.DepartmentId)
+			return []*entity.Users{manager}
+		}
+		// Chưa chọn tài sản: báo cho asset manager của các phòng ban khác
+		managers, _ := service.userRepo.GetAllAssetManagerOfCompany(request.CompanyId)
+		res := []*entity.Users{}
+		for _, manager := range managers {
+			if manager.DepartmentId != nil && *manager.DepartmentId != *request.User.DepartmentId {
+				res = append(res, manager)
+			}
+		}
+		return res
+	case entity.ApproverAdmin:
+		admins, _ := service.userRepo.GetUserRoleAdminOfCompany(request.CompanyId)
+		return admins
+	}
+	return nil
+}
+
+func (service *RequestTransferService) decidedApprovers(request *entity.RequestTransfer) []*entity.Users {
+	res := []*entity.Users{}
+	for _, step := range request.Approvals {
+		if step.Decision == entity.ApprovalDecisionApproved && step.Approver != nil {
+			res = append(res, step.Approver)
+		}
+	}
+	return res
+}
+
+func (service *RequestTransferService) notify(tx *gorm.DB, userId int64, companyId int64, event string, usersToNotifications []*entity.Users, message string, assetId *int64) error {
+	userNotificationUnique := utils.ConvertUsersToNotificationsToMap(userId, usersToNotifications)
+	return service.NotificationService.Notify(tx, &companyId, userNotificationUnique, event, message, assetId)
+}
+
+func assetIdOf(asset *entity.Assets) *int64 {
+	if asset == nil {
+		return nil
+	}
+	return &asset.Id
+}
@@ -3,17 +3,278 @@ package service
 import (
 	"BE_Manage_device/internal/domain/entity"
 	role "BE_Manage_device/internal/repository/role"
+	user "BE_Manage_device/internal/repository/user"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLastUserManagementHolder = errors.New("the company must keep at least one active user with full user-management permission")
+	slugInvalidChars            = regexp.MustCompile(`[^a-z0-9]+`)
 )
 
 type RoleService struct {
-	repo role.RoleRepository
+	repo     role.RoleRepository
+	userRepo user.UserRepository
+}
+
+func NewRoleService(repo role.RoleRepository, userRepo user.UserRepository) *RoleService {
+	return &RoleService{repo: repo, userRepo: userRepo}
+}
+
+// GetAllRole trả về role hệ thống và role riêng của công ty user
+func (service *RoleService) GetAllRole(userId int64) ([]*entity.Roles, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.GetRolesOfCompany(users.CompanyId)
+}
+
+func (service *RoleService) GetRoleById(userId, id int64) (*entity.Roles, int64, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, 0, err
+	}
+	roles, err := service.repo.GetRoleById(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !roles.IsSystem() && *roles.CompanyId != users.CompanyId {
+		return nil, 0, errors.New("role not found")
+	}
+	count, err := service.repo.CountUsersOfRole(id, service.repo.GetDB())
+	if err != nil {
+		return nil, 0, err
+	}
+	return roles, count, nil
+}
+
+func (service *RoleService) GetAllPermissions() ([]*entity.Permission, error) {
+	return service.repo.GetAllPermissions()
+}
+
+func (service *RoleService) Create(userId int64, title, slug, description string) (*entity.Roles, error) {
+	var err error
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	if slug == "" {
+		slug = title
+	}
+	slug = strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(slug), "-"), "-")
+	if slug == "" {
+		return nil, errors.New("slug is invalid")
+	}
+	// Không cho trùng slug với role hệ thống vì nhiều chỗ vẫn tìm role theo slug
+	if service.repo.GetRoleBySlug(slug).Id != 0 {
+		return nil, fmt.Errorf("slug %s is reserved", slug)
+	}
+	if _, err := service.repo.GetRoleBySlugOfCompany(slug, users.CompanyId); err == nil {
+		return nil, fmt.Errorf("role %s already exists", slug)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	roles := &entity.Roles{
+		Title:       title,
+		Slug:        slug,
+		Description: description,
+		Activated:   true,
+		CompanyId:   &users.CompanyId,
+		Created_at:  time.Now(),
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.Create(roles, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return roles, nil
+}
+
+func (service *RoleService) Update(userId, id int64, title, description string, activated *bool) (*entity.Roles, error) {
+	var err error
+	users, roles, err := service.getCompanyRole(userId, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	roles.Title = title
+	roles.Description = description
+	if activated != nil {
+		roles.Activated = *activated
+	}
+	roles.Updated_at = &now
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.Update(roles, tx); err != nil {
+		return nil, err
+	}
+	if err = service.checkUserManagementHolders(users.CompanyId, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetRoleById(id)
+}
+
+func (service *RoleService) Delete(userId, id int64) error {
+	var err error
+	_, roles, err := service.getCompanyRole(userId, id)
+	if err != nil {
+		return err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.LockRole(roles.Id, tx); err != nil {
+		return err
+	}
+	count, err := service.repo.CountUsersOfRole(roles.Id, tx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		err = fmt.Errorf("role is assigned to %d user(s), reassign them before deleting", count)
+		return err
+	}
+	if err = service.repo.Delete(roles.Id, tx); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// SetPermission gắn quyền vào role hoặc đổi access level nếu đã có
+func (service *RoleService) SetPermission(userId, roleId, permissionId int64, accessLevel string) (*entity.Roles, error) {
+	var err error
+	if !entity.ValidAccessLevel(accessLevel) {
+		return nil, fmt.Errorf("access level %s is invalid", accessLevel)
+	}
+	users, roles, err := service.getCompanyRole(userId, roleId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = service.repo.GetPermissionById(permissionId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("permission not found")
+		}
+		return nil, err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	rolePermission := &entity.RolePermission{
+		RoleId:       roles.Id,
+		PermissionId: permissionId,
+		AccessLevel:  accessLevel,
+		Created_at:   time.Now(),
+	}
+	if err = service.repo.UpsertRolePermission(rolePermission, tx); err != nil {
+		return nil, err
+	}
+	if err = service.checkUserManagementHolders(users.CompanyId, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetRoleById(roleId)
+}
+
+func (service *RoleService) RemovePermission(userId, roleId, permissionId int64) (*entity.Roles, error) {
+	var err error
+	users, roles, err := service.getCompanyRole(userId, roleId)
+	if err != nil {
+		return nil, err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.DeleteRolePermission(roles.Id, permissionId, tx); err != nil {
+		return nil, err
+	}
+	if err = service.checkUserManagementHolders(users.CompanyId, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return service.repo.GetRoleById(roleId)
 }
 
-func NewRoleService(repo role.RoleRepository) *RoleService {
-	return &RoleService{repo: repo}
+// getCompanyRole chỉ cho thao tác trên role riêng của công ty user
+func (service *RoleService) getCompanyRole(userId, roleId int64) (*entity.Users, *entity.Roles, error) {
+	users, err := service.userRepo.FindByUserId(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	roles, err := service.repo.GetRoleById(roleId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("role not found")
+		}
+		return nil, nil, err
+	}
+	if roles.IsSystem() {
+		return nil, nil, errors.New("system roles can not be modified")
+	}
+	if *roles.CompanyId != users.CompanyId {
+		return nil, nil, errors.New("role not found")
+	}
+	return users, roles, nil
 }
 
-func (service *RoleService) GetAllRole() []*entity.Roles {
-	roles := service.repo.GetAllRole()
-	return roles
+func (service *RoleService) checkUserManagementHolders(companyId int64, tx *gorm.DB) error {
+	count, err := service.repo.CountUserManagementHolders(companyId, 0, tx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrLastUserManagementHolder
+	}
+	return nil
 }
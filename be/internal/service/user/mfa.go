@@ -0,0 +1,395 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/pkg/utils"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaMaxFailedAttempts = 5
+	mfaLockDuration      = 15 * time.Minute
+)
+
+// Các role bị bắt buộc MFA khi công ty bật RequireMfa
+var mfaEnforcedRoles = map[string]bool{"admin": true, "assetManager": true}
+
+func (service *UserService) isMfaEnforced(user *entity.Users) (bool, error) {
+	if !mfaEnforcedRoles[user.Role.Slug] {
+		return false, nil
+	}
+	company, err := service.CompanyRepo.GetCompanyById(user.CompanyId)
+	if err != nil {
+		return false, err
+	}
+	return company.RequireMfa, nil
+}
+
+func (service *UserService) getMfa(userId int64) (*entity.UserMfa, error) {
+	mfa, err := service.mfaRepo.GetByUserId(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return mfa, err
+}
+
+// mfaChallenge trả về nil nếu user không cần bước MFA
+func (service *UserService) mfaChallenge(user *entity.Users) (*dto.MfaChallengeResponse, error) {
+	mfa, err := service.getMfa(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	enabled := mfa != nil && mfa.Enabled
+	enforced, err := service.isMfaEnforced(user)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !enforced {
+		return nil, nil
+	}
+	token, expiresAt, err := utils.GenerateMfaChallengeToken(user.Id, user.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MfaChallengeResponse{
+		MfaRequired:      true,
+		MfaSetupRequired: !enabled,
+		MfaToken:         token,
+		ExpiresAt:        expiresAt,
+	}, nil
+}
+
+// VerifyMfaLogin: bước 2 của login, đổi challenge token + mã TOTP (hoặc recovery code) lấy access/refresh token
+func (service *UserService) VerifyMfaLogin(mfaToken, code, recoveryCode string, device dto.SessionDevice) (*entity.Users, string, string, error) {
+	userId, err := utils.ParseMfaChallengeToken(mfaToken)
+	if err != nil {
+		return nil, "", "", err
+	}
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, "", "", errors.New("mfa is not enabled, complete the enrollment first")
+	}
+	if err := service.checkMfaCode(mfa, code, recoveryCode); err != nil {
+		return nil, "", "", err
+	}
+	accessToken, refreshToken, err := service.issueTokens(user, device)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return user, accessToken, refreshToken, nil
+}
+
+// BeginMfaEnrollment tạo secret mới (chưa bật) và trả về URI để quét QR
+func (service *UserService) BeginMfaEnrollment(userId int64) (*dto.MfaEnrollmentResponse, error) {
+	var err error
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, errors.New("mfa is already enabled")
+	}
+	key, err := utils.GenerateTotpKey(user.Email)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.EncryptMfaSecret(key.Secret())
+	if err != nil {
+		return nil, err
+	}
+	png, err := qrcode.Encode(key.URL(), qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	tx := service.mfaRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.mfaRepo.Save(&entity.UserMfa{UserId: userId, Secret: secret, CreatedAt: time.Now()}, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return &dto.MfaEnrollmentResponse{
+		Secret:     key.Secret(),
+		OtpauthUrl: key.URL(),
+		QrCode:     base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmMfaEnrollment bật MFA khi mã đầu tiên đúng, trả về recovery codes (chỉ hiển thị 1 lần)
+func (service *UserService) ConfirmMfaEnrollment(userId int64, code string) ([]string, error) {
+	var err error
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.New("mfa enrollment was not started")
+	}
+	if mfa.Enabled {
+		return nil, errors.New("mfa is already enabled")
+	}
+	secret, err := utils.DecryptMfaSecret(mfa.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := utils.ValidateTotpCode(secret, code, mfa.LastUsedStep, time.Now())
+	if !ok {
+		return nil, errors.New("invalid mfa code")
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	tx := service.mfaRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.mfaRepo.Enable(userId, time.Now(), step, tx); err != nil {
+		return nil, err
+	}
+	if err = service.mfaRepo.ReplaceRecoveryCodes(userId, hashes, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return codes, nil
+}
+
+// BeginMfaEnrollmentWithChallenge dùng cho user bị bắt buộc MFA nhưng chưa enroll, lúc này chưa có access token
+func (service *UserService) BeginMfaEnrollmentWithChallenge(mfaToken string) (*dto.MfaEnrollmentResponse, error) {
+	userId, err := utils.ParseMfaChallengeToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return service.BeginMfaEnrollment(userId)
+}
+
+// ConfirmMfaEnrollmentWithChallenge xác nhận enroll và hoàn tất login
+func (service *UserService) ConfirmMfaEnrollmentWithChallenge(mfaToken, code string, device dto.SessionDevice) (*entity.Users, string, string, []string, error) {
+	userId, err := utils.ParseMfaChallengeToken(mfaToken)
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	codes, err := service.ConfirmMfaEnrollment(userId, code)
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	accessToken, refreshToken, err := service.issueTokens(user, device)
+	if err != nil {
+		return nil, "", "", nil, err
+	}
+	return user, accessToken, refreshToken, codes, nil
+}
+
+func (service *UserService) DisableMfa(userId int64, password, code, recoveryCode string) error {
+	var err error
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("invalid password")
+	}
+	enforced, err := service.isMfaEnforced(user)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return errors.New("mfa is required for your role by the company policy")
+	}
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return err
+	}
+	if mfa == nil {
+		return errors.New("mfa is not enabled")
+	}
+	if mfa.Enabled {
+		if err = service.checkMfaCode(mfa, code, recoveryCode); err != nil {
+			return err
+		}
+	}
+	tx := service.mfaRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.mfaRepo.Delete(userId, tx); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes huỷ toàn bộ recovery code cũ
+func (service *UserService) RegenerateRecoveryCodes(userId int64, code string) ([]string, error) {
+	var err error
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, errors.New("mfa is not enabled")
+	}
+	if err = service.checkMfaCode(mfa, code, ""); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	tx := service.mfaRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.mfaRepo.ReplaceRecoveryCodes(userId, hashes, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return codes, nil
+}
+
+func (service *UserService) GetMfaStatus(userId int64) (*dto.MfaStatusResponse, error) {
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	enforced, err := service.isMfaEnforced(user)
+	if err != nil {
+		return nil, err
+	}
+	res := &dto.MfaStatusResponse{Required: enforced}
+	mfa, err := service.getMfa(userId)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		codes, err := service.mfaRepo.GetUnusedRecoveryCodes(userId)
+		if err != nil {
+			return nil, err
+		}
+		res.Enabled = true
+		res.ConfirmedAt = mfa.ConfirmedAt
+		res.RecoveryCodesRemaining = len(codes)
+	}
+	return res, nil
+}
+
+// checkMfaCode kiểm tra mã TOTP hoặc recovery code, khoá tạm sau nhiều lần sai
+func (service *UserService) checkMfaCode(mfa *entity.UserMfa, code, recoveryCode string) error {
+	if mfa.LockedUntil != nil && mfa.LockedUntil.After(time.Now()) {
+		return fmt.Errorf("too many failed attempts, try again after %v", mfa.LockedUntil.Format(time.RFC3339))
+	}
+	if code == "" && recoveryCode == "" {
+		return errors.New("code or recovery code is required")
+	}
+	if code != "" {
+		secret, err := utils.DecryptMfaSecret(mfa.Secret)
+		if err != nil {
+			return err
+		}
+		if step, ok := utils.ValidateTotpCode(secret, code, mfa.LastUsedStep, time.Now()); ok {
+			// 2 request cùng mã chạy song song thì chỉ 1 request ghi được step
+			updated, err := service.mfaRepo.UpdateLastUsedStep(mfa.UserId, step, time.Now())
+			if err != nil {
+				return err
+			}
+			if updated {
+				return nil
+			}
+		}
+	} else {
+		codes, err := service.mfaRepo.GetUnusedRecoveryCodes(mfa.UserId)
+		if err != nil {
+			return err
+		}
+		normalized := utils.NormalizeRecoveryCode(recoveryCode)
+		for _, c := range codes {
+			if bcrypt.CompareHashAndPassword([]byte(c.CodeHash), []byte(normalized)) != nil {
+				continue
+			}
+			used, err := service.mfaRepo.MarkRecoveryCodeUsed(c.Id)
+			if err != nil {
+				return err
+			}
+			if used {
+				return service.mfaRepo.ResetFailures(mfa.UserId)
+			}
+		}
+	}
+	// Kiểm tra khoá theo dòng vừa cập nhật, không theo mfa đọc lúc đầu
+	lockedUntil, err := service.mfaRepo.RecordFailure(mfa.UserId, mfaMaxFailedAttempts, time.Now().Add(mfaLockDuration))
+	if err != nil {
+		return err
+	}
+	if lockedUntil != nil && lockedUntil.After(time.Now()) {
+		return fmt.Errorf("too many failed attempts, try again after %v", lockedUntil.Format(time.RFC3339))
+	}
+	return errors.New("invalid mfa code")
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, nil, err
+		}
+		hashes = append(hashes, string(hash))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	userMfa "BE_Manage_device/internal/repository/user_mfa"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMfaRepo trả locked_until như câu UPDATE ... RETURNING sau khi request khác đã tăng số lần sai
+type fakeMfaRepo struct {
+	userMfa.UserMfaRepository
+	lockedUntil *time.Time
+	failures    int
+}
+
+func (r *fakeMfaRepo) GetUnusedRecoveryCodes(userId int64) ([]*entity.UserMfaRecoveryCodes, error) {
+	return nil, nil
+}
+
+func (r *fakeMfaRepo) RecordFailure(userId int64, maxAttempts int, lockedUntil time.Time) (*time.Time, error) {
+	r.failures++
+	return r.lockedUntil, nil
+}
+
+func TestCheckMfaCodeUsesFreshLock(t *testing.T) {
+	future := time.Now().Add(10 * time.Minute)
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name         string
+		stored       *time.Time
+		returned     *time.Time
+		wantErr      string
+		wantFailures int
+	}{
+		{"not locked", nil, nil, "invalid mfa code", 1},
+		{"lock expired", &past, &past, "invalid mfa code", 1},
+		// mfa đọc lúc đầu chưa khoá nhưng request song song vừa khoá
+		{"locked by parallel request", nil, &future, "too many failed attempts", 1},
+		{"already locked", &future, &future, "too many failed attempts", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMfaRepo{lockedUntil: tt.returned}
+			service := &UserService{mfaRepo: repo}
+			err := service.checkMfaCode(&entity.UserMfa{UserId: 1, LockedUntil: tt.stored}, "", "aaaaa-bbbbb")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if repo.failures != tt.wantFailures {
+				t.Errorf("failures = %d, want %d", repo.failures, tt.wantFailures)
+			}
+		})
+	}
+}
@@ -2,19 +2,22 @@ package service
 
 import (
 	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
 	asset "BE_Manage_device/internal/repository/assets"
 	company "BE_Manage_device/internal/repository/company"
 	role "BE_Manage_device/internal/repository/role"
 	user "BE_Manage_device/internal/repository/user"
-	userRBAC "BE_Manage_device/internal/repository/user_rbac"
+	userMfa "BE_Manage_device/internal/repository/user_mfa"
 	userSession "BE_Manage_device/internal/repository/user_session"
 	emailS "BE_Manage_device/internal/service/email"
+	"BE_Manage_device/pkg/storage"
 	"BE_Manage_device/pkg/utils"
 
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -24,20 +27,25 @@ import (
 )
 
 type UserService struct {
-	repo               user.UserRepository
-	emailService       *emailS.EmailService
-	userSessionRepo    userSession.UsersSessionRepository
-	roleRepository     role.RoleRepository
-	assetRepo          asset.AssetsRepository
-	userRBACRepository userRBAC.UserRBACRepository
-	CompanyRepo        company.CompanyRepository
+	repo            user.UserRepository
+	emailService    *emailS.EmailService
+	userSessionRepo userSession.UsersSessionRepository
+	roleRepository  role.RoleRepository
+	assetRepo       asset.AssetsRepository
+	CompanyRepo     company.CompanyRepository
+	storage         storage.Storage
+	mfaRepo         userMfa.UserMfaRepository
 }
 
-func NewUserService(repo user.UserRepository, emailService *emailS.EmailService, userSessionRepo userSession.UsersSessionRepository, roleRepository role.RoleRepository, assetRepo asset.AssetsRepository, userRBACRepository userRBAC.UserRBACRepository, CompanyRepo company.CompanyRepository) *UserService {
-	return &UserService{repo: repo, emailService: emailService, userSessionRepo: userSessionRepo, roleRepository: roleRepository, assetRepo: assetRepo, userRBACRepository: userRBACRepository, CompanyRepo: CompanyRepo}
+func NewUserService(repo user.UserRepository, emailService *emailS.EmailService, userSessionRepo userSession.UsersSessionRepository, roleRepository role.RoleRepository, assetRepo asset.AssetsRepository, CompanyRepo company.CompanyRepository, storage storage.Storage, mfaRepo userMfa.UserMfaRepository) *UserService {
+	service := &UserService{repo: repo, emailService: emailService, userSessionRepo: userSessionRepo, roleRepository: roleRepository, assetRepo: assetRepo, CompanyRepo: CompanyRepo, storage: storage, mfaRepo: mfaRepo}
+	emailService.RegisterLinkBuilder(emailS.TemplateActivation, service.activationLink)
+	emailService.RegisterLinkBuilder(emailS.TemplatePasswordReset, service.passwordResetLink)
+	return service
 }
 
-func (service *UserService) Register(firstName, lastName, password, email, redirectUrl string) (*entity.Users, error) {
+func (service *UserService) Register(firstName, lastName, password, email, redirectUrl, language string) (*entity.Users, error) {
+	var err error
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
@@ -57,53 +65,52 @@ func (service *UserService) Register(firstName, lastName, password, email, redir
 		IsActive:  false,
 		Token:     token,
 		CompanyId: company.Id,
+		Language:  emailS.NormalizeLanguage(language),
 	}
-	err = service.repo.Create(users)
-	if err != nil {
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.Create(users, tx); err != nil {
+		return nil, err
+	}
+	if err = service.emailService.EnqueueActivationEmail(tx, &company.Id, users, redirectUrl); err != nil {
 		return nil, err
 	}
-	go service.emailService.SendActivationEmail(email, token, redirectUrl)
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
 	return users, nil
 }
 
-func (service *UserService) Login(email string, password string) (*entity.Users, string, string, error) {
+// Login kiểm tra mật khẩu; nếu user cần MFA thì trả về challenge thay vì access/refresh token
+func (service *UserService) Login(email string, password string, device dto.SessionDevice) (*entity.Users, string, string, *dto.MfaChallengeResponse, error) {
 	user, err := service.repo.FindByEmail(email)
 	if err != nil {
-		return nil, "", "", errors.New("email dont; have")
+		return nil, "", "", nil, errors.New("email dont; have")
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, "", "", errors.New("invalid email or password")
+		return nil, "", "", nil, errors.New("invalid email or password")
 	}
-
-	accessToken, refreshToken, err := utils.GenerateTokens(user.Id, email)
-	if err != nil {
-		return nil, "", "", err
-	}
-	if service.userSessionRepo.CheckUserInSession(user.Id) {
-		userSession, err := service.userSessionRepo.FindByUserIdInSession(user.Id)
+	if user.IsActive {
+		challenge, err := service.mfaChallenge(user)
 		if err != nil {
-			return nil, "", "", err
+			return nil, "", "", nil, err
 		}
-		err = service.userSessionRepo.UpdateIsRevoked(userSession)
-		if err != nil {
-			return nil, "", "", err
+		if challenge != nil {
+			return user, "", "", challenge, nil
 		}
 	}
-	userSession := entity.UsersSessions{
-		UserId:       user.Id,
-		CreatedAt:    time.Now(),
-		RefreshToken: refreshToken,
-		AccessToken:  accessToken,
-		ExpiresAt:    time.Now().Add(5 * time.Minute),
-	}
-	tx := service.repo.GetDB().Begin()
-	err = service.userSessionRepo.Create(&userSession, tx)
+	accessToken, refreshToken, err := service.issueTokens(user, device)
 	if err != nil {
-		tx.Rollback()
-		return nil, "", "", err
+		return nil, "", "", nil, err
 	}
-	tx.Commit()
-	return user, accessToken, refreshToken, nil
+	return user, accessToken, refreshToken, nil, nil
 }
 
 func (service *UserService) Activate(token string) error {
@@ -112,35 +119,9 @@ func (service *UserService) Activate(token string) error {
 		return err
 	}
 	err = service.repo.Update(users)
-	go service.SetRole(users.Id, users.RoleId)
 	return err
 }
 
-func (service *UserService) SetRole(userId int64, roleId int64) {
-	tx := service.repo.GetDB().Begin()
-	var err error
-	user, err := service.repo.FindByUserId(userId)
-	if err != nil {
-		return
-	}
-
-	assets, _ := service.assetRepo.GetAllAsset(user.CompanyId)
-	for _, asset := range assets {
-		userRbac := entity.UserRbac{
-			AssetId: asset.Id,
-			UserId:  userId,
-			RoleId:  roleId,
-		}
-		err := service.userRBACRepository.Create(&userRbac, tx)
-		if err != nil {
-			tx.Rollback()
-		}
-	}
-	if err = tx.Commit().Error; err != nil {
-		return
-	}
-}
-
 func (service *UserService) FindUserByEmail(email string) (*entity.Users, error) {
 	user, err := service.repo.FindByEmail(email)
 	if err != nil {
@@ -162,63 +143,107 @@ func (service *UserService) ResetPassword(user *entity.Users, newPassword string
 	return err
 }
 
-func (service *UserService) CheckPasswordReset(email string, redirectUrl string) error {
+const passwordResetExpiry = 10 * time.Minute
 
-	tokenPW := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": email,
-		"exp":   time.Now().Add(10 * time.Minute).Unix(),
-	})
-	tokenPWstring, err := tokenPW.SignedString([]byte(config.PasswordSecret))
-	if err != nil {
-		return err
-	}
-	_, err = service.repo.FindByEmail(email)
+func (service *UserService) CheckPasswordReset(email string, redirectUrl string) error {
+	user, err := service.repo.FindByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("email don't register")
 		}
 		return err
 	}
-	body := "Click link to reset password account: <a href='" + redirectUrl + "?token=" + tokenPWstring + "'>reset</a>"
-	go service.emailService.SendEmail(email, "Reset Password", body)
-	return err
-}
-
-func (service *UserService) DeleteUser(email string) error {
-	err := service.repo.DeleteUser(email)
-	return err
+	data := map[string]interface{}{
+		"Name":             user.FirstName,
+		"ExpiresInMinutes": int(passwordResetExpiry.Minutes()),
+	}
+	return service.emailService.EnqueueLinkTemplate(service.repo.GetDB(), &user.CompanyId, email, user.Language, emailS.TemplatePasswordReset, user.Id, redirectUrl, data)
 }
 
-func (service *UserService) CheckRefreshToken(token string) bool {
-	userSession, err := service.userSessionRepo.FindByRefreshToken(token)
+// passwordResetLink ký token đặt lại mật khẩu lúc gửi email, token không được lưu trong outbox
+func (service *UserService) passwordResetLink(userId int64, redirectUrl string) (string, error) {
+	user, err := service.repo.FindByUserId(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", emailS.ErrSkipMail
+	}
 	if err != nil {
-		return false
+		return "", err
 	}
-	if userSession.IsRevoked {
-		return false
+	tokenPW := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": user.Email,
+		"exp":   time.Now().Add(passwordResetExpiry).Unix(),
+	})
+	tokenPWstring, err := tokenPW.SignedString([]byte(config.PasswordSecret))
+	if err != nil {
+		return "", err
 	}
-	return true
+	return redirectUrl + "?token=" + tokenPWstring, nil
 }
 
-func (service *UserService) FindByUserId(userId int64) (*entity.Users, error) {
+// activationLink đọc token kích hoạt của user lúc gửi email, bỏ qua nếu tài khoản đã kích hoạt
+func (service *UserService) activationLink(userId int64, redirectUrl string) (string, error) {
 	user, err := service.repo.FindByUserId(userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", emailS.ErrSkipMail
+	}
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	return user, err
+	if user.IsActive {
+		return "", emailS.ErrSkipMail
+	}
+	return emailS.ActivationLink(user.Token, redirectUrl), nil
 }
 
-func (service *UserService) FindSessionById(userId int64) (*entity.UsersSessions, error) {
-	session, err := service.userSessionRepo.FindByUserIdInSession(userId)
+// DeleteUser xoá user cùng công ty, không cho xoá người cuối cùng còn quyền user-management
+func (service *UserService) DeleteUser(userId int64, email string) (*entity.Users, error) {
+	var err error
+	byUser, err := service.repo.FindByUserId(userId)
 	if err != nil {
 		return nil, err
 	}
-	return session, nil
+	target, err := service.repo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if target.CompanyId != byUser.CompanyId {
+		return nil, errors.New("user not found")
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.DeleteUser(target.Email, byUser.CompanyId, tx); err != nil {
+		return nil, err
+	}
+	count, err := service.roleRepository.CountUserManagementHolders(byUser.CompanyId, 0, tx)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		err = errors.New("the company must keep at least one active user with full user-management permission")
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	return target, nil
 }
 
-func (service *UserService) UpdateInvoked(userSession entity.UsersSessions) error {
-	err := service.userSessionRepo.UpdateIsRevoked(&userSession)
-	return err
+func (service *UserService) FindByUserId(userId int64) (*entity.Users, error) {
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return user, err
 }
 
 func (service *UserService) GetAllUser(userId int64) []*entity.Users {
@@ -230,7 +255,10 @@ func (service *UserService) GetAllUser(userId int64) []*entity.Users {
 	return users
 }
 
-func (service *UserService) UpdateInformation(id int64, firstName, lastName string, image *multipart.FileHeader) (*entity.Users, error) {
+func (service *UserService) UpdateInformation(id int64, firstName, lastName, language string, image *multipart.FileHeader) (*entity.Users, error) {
+	if language != "" && !slices.Contains(emailS.Languages, language) {
+		return nil, fmt.Errorf("unsupported language %q", language)
+	}
 	var imageUrl string
 	if image != nil {
 		imgFile, err := image.Open()
@@ -240,13 +268,12 @@ func (service *UserService) UpdateInformation(id int64, firstName, lastName stri
 		defer imgFile.Close()
 		uniqueName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), image.Filename)
 		imagePath := "avatar/" + uniqueName
-		uploader := utils.NewSupabaseUploader()
-		imageUrl, err = uploader.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
+		imageUrl, err = service.storage.Upload(imagePath, imgFile, image.Header.Get("Content-Type"))
 		if err != nil {
 			return nil, err
 		}
 	}
-	user := entity.Users{Id: id, FirstName: firstName, LastName: lastName, Avatar: imageUrl}
+	user := entity.Users{Id: id, FirstName: firstName, LastName: lastName, Avatar: imageUrl, Language: language}
 	userUpdated, err := service.repo.UpdateUser(&user)
 	if err != nil {
 		return nil, err
@@ -255,12 +282,59 @@ func (service *UserService) UpdateInformation(id int64, firstName, lastName stri
 }
 
 func (service *UserService) UpdateRole(userId int64, setRoleUserId int64, slug string) (*entity.Users, error) {
-	roles := service.roleRepository.GetRoleBySlug(slug)
-	if roles == nil {
-		return nil, errors.New("something went wrong ")
+	userUpdate, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return nil, err
 	}
-	user := entity.Users{Id: setRoleUserId, RoleId: roles.Id}
-	userUpdated, err := service.repo.UpdateUser(&user)
+	target, err := service.repo.FindByUserId(setRoleUserId)
+	if err != nil {
+		return nil, err
+	}
+	if target.CompanyId != userUpdate.CompanyId {
+		return nil, errors.New("user not found")
+	}
+	roles, err := service.roleRepository.GetRoleBySlugOfCompany(slug, userUpdate.CompanyId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+	if !roles.Activated {
+		return nil, errors.New("role is deactivated")
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	// Khoá role để không gán vào role đang bị xoá
+	if err = service.roleRepository.LockRole(roles.Id, tx); err != nil {
+		return nil, err
+	}
+	// Không cho đổi role của người cuối cùng còn quyền user-management
+	if !roleHasFullPermission(roles, "user-management") {
+		var count int64
+		count, err = service.roleRepository.CountUserManagementHolders(userUpdate.CompanyId, setRoleUserId, tx)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			err = errors.New("the company must keep at least one active user with full user-management permission")
+			return nil, err
+		}
+	}
+	if err = service.repo.UpdateRole(setRoleUserId, roles.Id, tx); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("commit failed: %w", err)
+	}
+	userUpdated, err := service.repo.FindByUserId(setRoleUserId)
 	if err != nil {
 		return nil, err
 	}
@@ -275,6 +349,15 @@ func (service *UserService) UpdateRole(userId int64, setRoleUserId int64, slug s
 	return userUpdated, nil
 }
 
+func roleHasFullPermission(roles *entity.Roles, permissionSlug string) bool {
+	for _, rp := range roles.RolePermissions {
+		if rp.Permission.Slug == permissionSlug && rp.AccessLevel == entity.AccessLevelFull {
+			return true
+		}
+	}
+	return false
+}
+
 func (service *UserService) GetAllUserOfDepartment(userId int64, departmentId int64) ([]*entity.Users, error) {
 	user, err := service.repo.FindByUserId(userId)
 	if err != nil {
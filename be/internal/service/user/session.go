@@ -0,0 +1,206 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/pkg/utils"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenRevoked = errors.New("refresh token was revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, the session has been signed out")
+)
+
+// issueTokens tạo session mới cho thiết bị, các session khác của user vẫn giữ nguyên
+func (service *UserService) issueTokens(user *entity.Users, device dto.SessionDevice) (string, string, error) {
+	var err error
+	accessToken, refreshToken, err := utils.GenerateTokens(user.Id, user.Email)
+	if err != nil {
+		return "", "", err
+	}
+	if device.DeviceLabel == "" {
+		device.DeviceLabel = utils.DeviceLabelFromUserAgent(device.UserAgent)
+	}
+	now := time.Now()
+	userSession := entity.UsersSessions{
+		UserId:       user.Id,
+		RefreshToken: refreshToken,
+		AccessToken:  accessToken,
+		DeviceLabel:  device.DeviceLabel,
+		UserAgent:    device.UserAgent,
+		IpAddress:    device.IpAddress,
+		LastSeenAt:   &now,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(utils.RefreshTokenTTL),
+	}
+	tx := service.userSessionRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.userSessionRepo.Create(&userSession, tx); err != nil {
+		return "", "", err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return "", "", fmt.Errorf("commit failed: %w", err)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// RefreshSession rotate refresh token: token cũ chỉ dùng được 1 lần, dùng lại token đã rotate thì huỷ cả session
+func (service *UserService) RefreshSession(refreshToken string, device dto.SessionDevice) (string, string, error) {
+	var err error
+	userId, err := utils.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	userSession, err := service.userSessionRepo.FindByRefreshToken(refreshToken)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", "", err
+		}
+		return "", "", service.detectRefreshTokenReuse(refreshToken)
+	}
+	if userSession.IsRevoked || userSession.ExpiresAt.Before(time.Now()) {
+		return "", "", ErrRefreshTokenRevoked
+	}
+	if userSession.UserId != userId {
+		return "", "", ErrRefreshTokenInvalid
+	}
+	user, err := service.repo.FindByUserId(userId)
+	if err != nil {
+		return "", "", err
+	}
+	newAccessToken, newRefreshToken, err := utils.GenerateTokens(user.Id, user.Email)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	userSession.AccessToken = newAccessToken
+	userSession.RefreshToken = newRefreshToken
+	userSession.ExpiresAt = now.Add(utils.RefreshTokenTTL)
+	userSession.LastSeenAt = &now
+	if device.IpAddress != "" {
+		userSession.IpAddress = device.IpAddress
+	}
+	if device.UserAgent != "" {
+		userSession.UserAgent = device.UserAgent
+	}
+
+	tx := service.userSessionRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	rotated, err := service.userSessionRepo.Rotate(userSession, refreshToken, utils.HashToken(refreshToken), tx)
+	if err != nil {
+		return "", "", err
+	}
+	if !rotated {
+		// Request khác đã rotate token này trước => token bị dùng 2 lần
+		if err = service.userSessionRepo.Revoke(userSession.Id, tx); err != nil {
+			return "", "", err
+		}
+		if err = tx.Commit().Error; err != nil {
+			return "", "", fmt.Errorf("commit failed: %w", err)
+		}
+		log.Warnf("Refresh token reuse detected for session %d of user %d", userSession.Id, userSession.UserId)
+		err = ErrRefreshTokenReused
+		return "", "", err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return "", "", fmt.Errorf("commit failed: %w", err)
+	}
+	return newAccessToken, newRefreshToken, nil
+}
+
+// detectRefreshTokenReuse: token không còn là token hiện tại của session nào, nếu nằm trong danh sách đã dùng thì revoke cả family
+func (service *UserService) detectRefreshTokenReuse(refreshToken string) error {
+	sessionId, err := service.userSessionRepo.FindSessionIdByUsedToken(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+		return err
+	}
+	tx := service.userSessionRepo.GetDB().Begin()
+	if err = service.userSessionRepo.Revoke(sessionId, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	log.Warnf("Refresh token reuse detected, revoked session %d", sessionId)
+	return ErrRefreshTokenReused
+}
+
+func (service *UserService) GetSessions(userId, currentSessionId int64) ([]*dto.UserSessionResponse, error) {
+	userSessions, err := service.userSessionRepo.GetActiveByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*dto.UserSessionResponse, 0, len(userSessions))
+	for _, s := range userSessions {
+		res = append(res, &dto.UserSessionResponse{
+			Id:          s.Id,
+			DeviceLabel: s.DeviceLabel,
+			UserAgent:   s.UserAgent,
+			IpAddress:   s.IpAddress,
+			CreatedAt:   s.CreatedAt,
+			LastSeenAt:  s.LastSeenAt,
+			ExpiresAt:   s.ExpiresAt,
+			Current:     s.Id == currentSessionId,
+		})
+	}
+	return res, nil
+}
+
+// RevokeSession đăng xuất 1 thiết bị của chính user
+func (service *UserService) RevokeSession(userId, sessionId int64) error {
+	var err error
+	userSession, err := service.userSessionRepo.FindById(sessionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		return err
+	}
+	if userSession.UserId != userId {
+		return errors.New("session not found")
+	}
+	if userSession.IsRevoked {
+		return nil
+	}
+	tx := service.userSessionRepo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.userSessionRepo.Revoke(sessionId, tx); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"BE_Manage_device/config"
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	user "BE_Manage_device/internal/repository/user"
+	userSession "BE_Manage_device/internal/repository/user_session"
+	"BE_Manage_device/pkg/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// txPool cho phép Begin/Commit/Rollback mà không cần database, các repository fake không chạy SQL
+type txPool struct{}
+
+func (*txPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+func (*txPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+func (p *txPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+func (*txPool) Commit() error   { return nil }
+func (*txPool) Rollback() error { return nil }
+
+type fakeUserRepo struct {
+	user.UserRepository
+	user *entity.Users
+}
+
+func (r *fakeUserRepo) FindByUserId(id int64) (*entity.Users, error) {
+	return r.user, nil
+}
+
+// fakeSessionRepo giữ session theo id và hash các refresh token đã rotate giống bảng users_sessions_used_tokens
+type fakeSessionRepo struct {
+	userSession.UsersSessionRepository
+	db       *gorm.DB
+	sessions map[int64]*entity.UsersSessions
+	used     map[string]int64
+	// loseRace giả lập request khác đã rotate token trước khi Rotate chạy
+	loseRace bool
+}
+
+func (r *fakeSessionRepo) GetDB() *gorm.DB { return r.db }
+
+func (r *fakeSessionRepo) FindByRefreshToken(refreshToken string) (*entity.UsersSessions, error) {
+	for _, s := range r.sessions {
+		if s.RefreshToken == refreshToken {
+			copied := *s
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSessionRepo) FindSessionIdByUsedToken(tokenHash string) (int64, error) {
+	if id, ok := r.used[tokenHash]; ok {
+		return id, nil
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSessionRepo) Rotate(s *entity.UsersSessions, oldRefreshToken string, usedTokenHash string, tx *gorm.DB) (bool, error) {
+	current := r.sessions[s.Id]
+	if r.loseRace || current.RefreshToken != oldRefreshToken {
+		return false, nil
+	}
+	copied := *s
+	r.sessions[s.Id] = &copied
+	r.used[usedTokenHash] = s.Id
+	return true, nil
+}
+
+func (r *fakeSessionRepo) Revoke(id int64, tx *gorm.DB) error {
+	r.sessions[id].IsRevoked = true
+	return nil
+}
+
+func newSessionTestService(t *testing.T) (*UserService, *fakeSessionRepo, string) {
+	t.Helper()
+	savedAccess, savedRefresh := config.AccessSecret, config.RefreshSecret
+	t.Cleanup(func() { config.AccessSecret, config.RefreshSecret = savedAccess, savedRefresh })
+	config.AccessSecret, config.RefreshSecret = "access-secret", "refresh-secret"
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: &txPool{}}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, refreshToken, err := utils.GenerateTokens(1, "a@b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := &fakeSessionRepo{
+		db:       db,
+		sessions: map[int64]*entity.UsersSessions{10: {Id: 10, UserId: 1, RefreshToken: refreshToken, ExpiresAt: time.Now().Add(time.Hour)}},
+		used:     map[string]int64{},
+	}
+	service := &UserService{repo: &fakeUserRepo{user: &entity.Users{Id: 1, Email: "a@b.c"}}, userSessionRepo: sessions}
+	return service, sessions, refreshToken
+}
+
+func TestRefreshSessionRotation(t *testing.T) {
+	service, sessions, first := newSessionTestService(t)
+	_, second, err := service.RefreshSession(first, dto.SessionDevice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first || sessions.sessions[10].RefreshToken != second {
+		t.Fatal("refresh token was not rotated")
+	}
+	_, third, err := service.RefreshSession(second, dto.SessionDevice{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessions.sessions[10].IsRevoked || sessions.sessions[10].RefreshToken != third {
+		t.Fatal("session must stay active while tokens are used in order")
+	}
+}
+
+func TestRefreshSessionRejects(t *testing.T) {
+	tests := []struct {
+		name        string
+		prepare     func(sessions *fakeSessionRepo, current string) string
+		wantErr     error
+		wantRevoked bool
+	}{
+		{"reused rotated token revokes the session", func(sessions *fakeSessionRepo, current string) string {
+			sessions.used[utils.HashToken(current)] = 10
+			sessions.sessions[10].RefreshToken = "newer"
+			return current
+		}, ErrRefreshTokenReused, true},
+		{"concurrent rotation revokes the session", func(sessions *fakeSessionRepo, current string) string {
+			sessions.loseRace = true
+			return current
+		}, ErrRefreshTokenReused, true},
+		{"unknown token", func(sessions *fakeSessionRepo, current string) string {
+			_, other, _ := utils.GenerateTokens(1, "a@b.c")
+			return other
+		}, ErrRefreshTokenInvalid, false},
+		{"revoked session", func(sessions *fakeSessionRepo, current string) string {
+			sessions.sessions[10].IsRevoked = true
+			return current
+		}, ErrRefreshTokenRevoked, true},
+		{"expired session", func(sessions *fakeSessionRepo, current string) string {
+			sessions.sessions[10].ExpiresAt = time.Now().Add(-time.Minute)
+			return current
+		}, ErrRefreshTokenRevoked, false},
+		{"token of another user", func(sessions *fakeSessionRepo, current string) string {
+			_, other, _ := utils.GenerateTokens(2, "x@b.c")
+			sessions.sessions[10].RefreshToken = other
+			return other
+		}, ErrRefreshTokenInvalid, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, sessions, current := newSessionTestService(t)
+			token := tt.prepare(sessions, current)
+			_, _, err := service.RefreshSession(token, dto.SessionDevice{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if sessions.sessions[10].IsRevoked != tt.wantRevoked {
+				t.Errorf("revoked = %v, want %v", sessions.sessions[10].IsRevoked, tt.wantRevoked)
+			}
+		})
+	}
+}
+
+func TestRefreshSessionInvalidSignature(t *testing.T) {
+	service, _, current := newSessionTestService(t)
+	config.RefreshSecret = "rotated-secret"
+	if _, _, err := service.RefreshSession(current, dto.SessionDevice{}); err == nil {
+		t.Fatal("token signed with another secret must be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrWebhookUrlInvalid      = errors.New("url must be an absolute http(s) url")
+	ErrWebhookHostUnresolved  = errors.New("url host cannot be resolved")
+	ErrWebhookAddressNotAllow = errors.New("url must point to a public address")
+)
+
+// Dải địa chỉ không phải internet công cộng mà net.IP chưa có hàm kiểm tra riêng
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this network"
+	"100.64.0.0/10", // CGNAT, RFC 6598
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmark, RFC 2544
+	"64:ff9b::/96",  // NAT64 có thể trỏ về IPv4 nội bộ
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// IsPublicIP: webhook chỉ được gửi tới địa chỉ public, chặn loopback, mạng nội bộ (RFC 1918, fc00::/7),
+// link-local (gồm metadata 169.254.169.254), multicast và các dải đặc biệt
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupIP là resolver dùng khi kiểm tra url, tách ra biến để test thay được
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+// ValidateWebhookUrl kiểm tra url tuyệt đối http(s) và mọi địa chỉ của host đều public.
+// Lúc gửi địa chỉ được kiểm tra lại ở dial (DNS có thể đổi sau khi lưu)
+func ValidateWebhookUrl(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrWebhookUrlInvalid
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrWebhookAddressNotAllow
+		}
+		return nil
+	}
+	addrs, err := lookupIP(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return ErrWebhookHostUnresolved
+	}
+	for _, a := range addrs {
+		if !IsPublicIP(a.IP) {
+			return ErrWebhookAddressNotAllow
+		}
+	}
+	return nil
+}
+
+// NewWebhookClient tạo http client chỉ kết nối tới địa chỉ public: Control của dialer kiểm tra IP thực sự được dial
+// nên DNS rebinding không vượt qua được. Không dùng proxy và không theo redirect
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrWebhookAddressNotAllow
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// PostSigned gửi body đã ký tới webhook (của công ty hoặc của user). Lỗi trả về chỉ có mã phản hồi hoặc mô tả chung,
+// không chứa nội dung phản hồi để không lộ dữ liệu của bên nhận qua log delivery
+func PostSigned(client *http.Client, rawUrl, secret, event, deliveryId string, body []byte) (*int, error) {
+	req, err := http.NewRequest(http.MethodPost, rawUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, ErrWebhookUrlInvalid
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "BE_Manage_device-Webhook/1.0")
+	req.Header.Set("X-Webhook-Event", event)
+	if deliveryId != "" {
+		req.Header.Set("X-Webhook-Delivery", deliveryId)
+	}
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(secret, time.Now().Unix(), body))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, sendError(err)
+	}
+	defer resp.Body.Close()
+	code := resp.StatusCode
+	if code < 200 || code >= 300 {
+		return &code, fmt.Errorf("unexpected status %d", code)
+	}
+	return &code, nil
+}
+
+// sendError đổi lỗi kết nối thành mô tả chung
+func sendError(err error) error {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrWebhookAddressNotAllow):
+		return ErrWebhookAddressNotAllow
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errors.New("request timed out")
+	}
+	return errors.New("connection failed")
+}
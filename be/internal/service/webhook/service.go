@@ -0,0 +1,352 @@
+package service
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/internal/domain/policy"
+	webhook "BE_Manage_device/internal/repository/webhook"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookMaxAttempts     = 8
+	webhookBaseBackoff     = time.Minute // 1, 2, 4, ... phút giữa các lần retry
+	webhookTimeout         = 10 * time.Second
+	webhookClaimLease      = 5 * time.Minute
+	webhookBatchSize       = 50
+	webhookDeliveryHistory = 100
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookService struct {
+	repo   webhook.WebhooksRepository
+	client *http.Client
+	outbox *outboxS.OutboxService
+}
+
+func NewWebhookService(repo webhook.WebhooksRepository, outbox *outboxS.OutboxService) *WebhookService {
+	service := &WebhookService{repo: repo, client: NewWebhookClient(webhookTimeout), outbox: outbox}
+	outbox.RegisterHandler(entity.OutboxChannelWebhook, service.fanOut)
+	return service
+}
+
+// SignWebhookPayload ký body theo dạng "t=<unix>,v1=<hex>", v1 = HMAC-SHA256(secret, "<unix>.<body>").
+// Bên nhận tính lại chữ ký và kiểm tra t để chống replay
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func GenerateWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+func validateWebhook(rawUrl string, events []string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := ValidateWebhookUrl(ctx, rawUrl); err != nil {
+		return nil, err
+	}
+	uniqueEvents := []string{}
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if !slices.Contains(entity.WebhookEvents, e) {
+			return nil, fmt.Errorf("unknown event %q, supported events: %s", e, strings.Join(entity.WebhookEvents, ", "))
+		}
+		if !slices.Contains(uniqueEvents, e) {
+			uniqueEvents = append(uniqueEvents, e)
+		}
+	}
+	return uniqueEvents, nil
+}
+
+func (service *WebhookService) getWebhookOfCompany(auth *policy.AuthContext, id int64) (*entity.Webhooks, error) {
+	hook, err := service.repo.GetById(id)
+	if err != nil || hook.CompanyId != auth.CompanyId {
+		return nil, ErrWebhookNotFound
+	}
+	return hook, nil
+}
+
+func (service *WebhookService) Create(auth *policy.AuthContext, name, rawUrl string, events []string) (*entity.Webhooks, error) {
+	events, err := validateWebhook(rawUrl, events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := GenerateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	hook := &entity.Webhooks{
+		CompanyId: auth.CompanyId,
+		Name:      strings.TrimSpace(name),
+		Url:       rawUrl,
+		Secret:    secret,
+		Events:    events,
+		Active:    true,
+		CreatedBy: auth.UserId,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := service.repo.Create(hook, service.repo.GetDB()); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (service *WebhookService) GetWebhooks(auth *policy.AuthContext) ([]*entity.Webhooks, error) {
+	return service.repo.GetByCompanyId(auth.CompanyId)
+}
+
+func (service *WebhookService) GetWebhook(auth *policy.AuthContext, id int64) (*entity.Webhooks, error) {
+	return service.getWebhookOfCompany(auth, id)
+}
+
+func (service *WebhookService) Update(auth *policy.AuthContext, id int64, name, rawUrl string, events []string, active bool) (*entity.Webhooks, error) {
+	hook, err := service.getWebhookOfCompany(auth, id)
+	if err != nil {
+		return nil, err
+	}
+	events, err = validateWebhook(rawUrl, events)
+	if err != nil {
+		return nil, err
+	}
+	hook.Name = strings.TrimSpace(name)
+	hook.Url = rawUrl
+	hook.Events = events
+	hook.Active = active
+	hook.UpdatedAt = time.Now()
+	if err := service.repo.Update(hook, service.repo.GetDB()); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (service *WebhookService) Delete(auth *policy.AuthContext, id int64) error {
+	var err error
+	hook, err := service.getWebhookOfCompany(auth, id)
+	if err != nil {
+		return err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = service.repo.Delete(hook.Id, tx); err != nil {
+		return err
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (service *WebhookService) GetDeliveries(auth *policy.AuthContext, webhookId int64) ([]*entity.WebhookDeliveries, error) {
+	hook, err := service.getWebhookOfCompany(auth, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	return service.repo.GetDeliveriesOfWebhook(hook.Id, webhookDeliveryHistory)
+}
+
+// Redeliver tạo 1 delivery mới cùng payload (giữ nguyên event id để bên nhận chống trùng), log cũ được giữ lại
+func (service *WebhookService) Redeliver(auth *policy.AuthContext, webhookId, deliveryId int64) (*entity.WebhookDeliveries, error) {
+	hook, err := service.getWebhookOfCompany(auth, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	original, err := service.repo.GetDeliveryById(deliveryId)
+	if err != nil || original.WebhookId != hook.Id {
+		return nil, errors.New("delivery not found")
+	}
+	now := time.Now()
+	// Gửi ngay ở goroutine nên giữ lease để cron không claim trùng
+	leaseUntil := now.Add(webhookClaimLease)
+	delivery := &entity.WebhookDeliveries{
+		WebhookId:     hook.Id,
+		CompanyId:     hook.CompanyId,
+		EventId:       original.EventId,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        entity.WebhookDeliveryPending,
+		NextAttemptAt: &leaseUntil,
+		RedeliveryOf:  &original.Id,
+		CreatedAt:     now,
+	}
+	if err := service.repo.CreateDelivery(delivery, service.repo.GetDB()); err != nil {
+		return nil, err
+	}
+	go service.attempt(delivery, hook)
+	return delivery, nil
+}
+
+// EnqueueAssetLog được gọi trong transaction ghi AssetLog, ghi sự kiện vào outbox để fan-out tới các webhook sau khi commit
+func (service *WebhookService) EnqueueAssetLog(assetLog *entity.AssetLog, tx *gorm.DB) error {
+	if assetLog.Event == "" {
+		return nil
+	}
+	payload := dto.WebhookEventPayload{
+		Id:         uuid.NewString(),
+		Type:       assetLog.Event,
+		OccurredAt: assetLog.Timestamp,
+		Data: dto.WebhookEventData{
+			AssetLogId:    assetLog.Id,
+			AssetId:       assetLog.AssetId,
+			Action:        assetLog.Action,
+			ChangeSummary: assetLog.ChangeSummary,
+			ByUserId:      assetLog.ByUserId,
+			AssignUserId:  assetLog.AssignUserId,
+		},
+	}
+	return service.outbox.Enqueue(tx, &assetLog.CompanyId, entity.OutboxChannelWebhook, payload)
+}
+
+// fanOut là handler của outbox: tạo delivery cho các webhook đang đăng ký sự kiện, việc gửi và retry do DeliverDue đảm nhận
+func (service *WebhookService) fanOut(m *entity.OutboxMessages) error {
+	var err error
+	if m.CompanyId == nil {
+		return errors.New("webhook event without company")
+	}
+	var payload dto.WebhookEventPayload
+	if err = json.Unmarshal([]byte(m.Payload), &payload); err != nil {
+		return err
+	}
+	tx := service.repo.GetDB().Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		} else if err != nil {
+			tx.Rollback()
+		}
+	}()
+	hooks, err := service.repo.GetActiveByCompanyId(*m.CompanyId, tx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, hook := range hooks {
+		if !hook.Subscribes(payload.Type) {
+			continue
+		}
+		delivery := &entity.WebhookDeliveries{
+			WebhookId:     hook.Id,
+			CompanyId:     hook.CompanyId,
+			EventId:       payload.Id,
+			EventType:     payload.Type,
+			Payload:       m.Payload,
+			Status:        entity.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		}
+		if err = service.repo.CreateDelivery(delivery, tx); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit().Error; err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// DeliverDue gửi các delivery tới hạn, chạy định kỳ bởi cron
+func (service *WebhookService) DeliverDue() {
+	deliveries, err := service.repo.ClaimDueDeliveries(time.Now(), webhookClaimLease, webhookBatchSize)
+	if err != nil {
+		log.Error("Happened error when claim webhook deliveries. Error", err)
+		return
+	}
+	hooks := map[int64]*entity.Webhooks{}
+	for _, d := range deliveries {
+		hook, ok := hooks[d.WebhookId]
+		if !ok {
+			hook, err = service.repo.GetById(d.WebhookId)
+			if err != nil {
+				log.Error("Happened error when get webhook of delivery. Error", err)
+				continue
+			}
+			hooks[d.WebhookId] = hook
+		}
+		service.attempt(d, hook)
+	}
+}
+
+// attempt gửi 1 lần, ghi log mã phản hồi + độ trễ và hẹn lần retry tiếp theo theo exponential backoff
+func (service *WebhookService) attempt(d *entity.WebhookDeliveries, hook *entity.Webhooks) {
+	start := time.Now()
+	responseCode, sendErr := service.send(hook, d)
+	latency := time.Since(start).Milliseconds()
+
+	d.Attempts++
+	d.LatencyMs = &latency
+	d.ResponseCode = responseCode
+	d.LastError = ""
+	attemptLog := &entity.WebhookDeliveryAttempts{
+		DeliveryId:   d.Id,
+		Attempt:      d.Attempts,
+		ResponseCode: responseCode,
+		LatencyMs:    latency,
+		AttemptedAt:  start,
+	}
+	if sendErr == nil {
+		now := time.Now()
+		d.Status = entity.WebhookDeliverySucceeded
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+	} else {
+		d.LastError = outboxS.ErrorText(sendErr)
+		attemptLog.Error = d.LastError
+		if d.Attempts >= webhookMaxAttempts {
+			d.Status = entity.WebhookDeliveryFailed
+			d.NextAttemptAt = nil
+		} else {
+			next := time.Now().Add(webhookBaseBackoff << (d.Attempts - 1))
+			d.Status = entity.WebhookDeliveryPending
+			d.NextAttemptAt = &next
+		}
+	}
+	if err := service.repo.CreateAttempt(attemptLog, service.repo.GetDB()); err != nil {
+		log.Error("Happened error when create webhook attempt log. Error", err)
+	}
+	if err := service.repo.UpdateDelivery(d, service.repo.GetDB()); err != nil {
+		log.Error("Happened error when update webhook delivery. Error", err)
+	}
+}
+
+func (service *WebhookService) send(hook *entity.Webhooks, d *entity.WebhookDeliveries) (*int, error) {
+	if !hook.Active {
+		return nil, errors.New("webhook is disabled")
+	}
+	return PostSigned(service.client, hook.Url, hook.Secret, d.EventType, d.EventId, []byte(d.Payload))
+}
@@ -1,16 +1,19 @@
 package cronjob
 
 import (
+	"BE_Manage_device/config"
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
 	asset "BE_Manage_device/internal/repository/assets"
 	bill "BE_Manage_device/internal/repository/bill"
 	company "BE_Manage_device/internal/repository/company"
 	monthlySummary "BE_Manage_device/internal/repository/monthly_summary"
 	user "BE_Manage_device/internal/repository/user"
-	emailS "BE_Manage_device/internal/service/email"
+	billS "BE_Manage_device/internal/service/bill"
 	notificationS "BE_Manage_device/internal/service/notification"
+	outboxS "BE_Manage_device/internal/service/outbox"
+	webhookS "BE_Manage_device/internal/service/webhook"
 	"BE_Manage_device/pkg/utils"
-	"fmt"
 	"log"
 	"time"
 
@@ -18,12 +21,12 @@ import (
 	"gorm.io/gorm"
 )
 
-func InitCronJobs(db *gorm.DB, emailService *emailS.EmailService, assetsRepository asset.AssetsRepository, userRepository user.UserRepository, notificationsService *notificationS.NotificationService, assetsLogRepository asset_log.AssetsLogRepository, billRepository bill.BillsRepository, monthlySummaryRepository monthlySummary.MonthlySummaryRepository, companyRepository company.CompanyRepository) {
+func InitCronJobs(db *gorm.DB, assetsRepository asset.AssetsRepository, userRepository user.UserRepository, notificationsService *notificationS.NotificationService, assetsLogRepository asset_log.AssetsLogRepository, billRepository bill.BillsRepository, monthlySummaryRepository monthlySummary.MonthlySummaryRepository, companyRepository company.CompanyRepository, assetLoanRepository assetLoan.AssetLoansRepository, webhookService *webhookS.WebhookService, outboxService *outboxS.OutboxService, billService *billS.BillsService) {
 	c := cron.New(cron.WithLocation(time.FixedZone("Asia/Ho_Chi_Minh", 7*3600)))
 
 	_, err := c.AddFunc("0 8 * * *", func() {
 		log.Println("🔔 Running maintenance notification check at 8:00 AM")
-		utils.CheckAndSenMaintenanceNotification(db, emailService, assetsRepository, userRepository, notificationsService, assetsLogRepository)
+		utils.CheckAndSenMaintenanceNotification(db, assetsRepository, userRepository, notificationsService, assetsLogRepository)
 	})
 
 	if err != nil {
@@ -32,12 +35,29 @@ func InitCronJobs(db *gorm.DB, emailService *emailS.EmailService, assetsReposito
 
 	_, err = c.AddFunc("1 8 * * *", func() {
 		log.Println("🔔 Running warranty notification check at 8:00 AM")
-		utils.SendEmailsForWarrantyExpiry(db, emailService, notificationsService, assetsRepository, userRepository)
+		utils.SendEmailsForWarrantyExpiry(db, notificationsService, assetsRepository, userRepository)
 	})
 	if err != nil {
 		log.Fatalf("❌ Failed to schedule warranty cron job: %v", err)
 	}
 
+	_, err = c.AddFunc("2 8 * * *", func() {
+		log.Println("🔔 Running overdue loan notification check at 8:02 AM")
+		utils.SendOverdueLoanNotifications(notificationsService, assetLoanRepository, userRepository)
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule overdue loan cron job: %v", err)
+	}
+
+	// Nhắc bill quá hạn cho người mua và người tạo bill
+	_, err = c.AddFunc("3 8 * * *", func() {
+		log.Println("🔔 Running overdue bill reminder at 8:03 AM")
+		billService.SendOverdueReminders()
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule overdue bill cron job: %v", err)
+	}
+
 	_, err = c.AddFunc("0 9 * * *", func() {
 		log.Println("🔔 Running update status when finish maintenance at 9:00 AM")
 		utils.UpdateStatusWhenFinishMaintenance(db, assetsRepository, userRepository, notificationsService, assetsLogRepository)
@@ -54,20 +74,47 @@ func InitCronJobs(db *gorm.DB, emailService *emailS.EmailService, assetsReposito
 		log.Fatalf("❌ Failed to schedule kill session cron job: %v", err)
 	}
 
-	_, err = c.AddFunc("0 0 * * *", func() {
-		now := time.Now()
-		// Get tomorrow's date
-		tomorrow := now.AddDate(0, 0, 1)
-		// If tomorrow is the first, today is the last day
-		if tomorrow.Day() == 1 {
-			utils.CreateSummary(monthlySummaryRepository, billRepository, companyRepository)
-		} else {
-			fmt.Println("Not the last day of the month. Skipping task...")
-		}
+	// Chạy lúc 00:05 ngày đầu tháng, tổng hợp tháng vừa kết thúc
+	_, err = c.AddFunc("5 0 1 * *", func() {
+		log.Println("🔔 Running monthly summary generator")
+		utils.CreateSummary(monthlySummaryRepository, billRepository, companyRepository)
 	})
 	if err != nil {
 		log.Fatalf("❌ Failed to schedule create monthly summary cron job: %v", err)
 	}
 
+	// Gửi digest thông báo, mỗi user nhận lúc giờ digest theo múi giờ của mình
+	_, err = c.AddFunc("5 * * * *", func() {
+		notificationsService.SendDigests()
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule notification digest cron job: %v", err)
+	}
+
+	// Dọn thông báo đã đọc/lưu trữ quá hạn lúc 03:00
+	_, err = c.AddFunc("0 3 * * *", func() {
+		log.Println("🔔 Running notification retention cleanup")
+		notificationsService.CleanupOldNotifications(config.NOTIFICATION_RETENTION_DAYS)
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule notification cleanup cron job: %v", err)
+	}
+
+	// Gửi webhook tới hạn (lần đầu và các lần retry)
+	_, err = c.AddFunc("* * * * *", func() {
+		webhookService.DeliverDue()
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule webhook delivery cron job: %v", err)
+	}
+
+	// Gửi các message trong outbox (thông báo, email, sự kiện webhook) đã commit
+	_, err = c.AddFunc("@every 5s", func() {
+		outboxService.DispatchDue()
+	})
+	if err != nil {
+		log.Fatalf("❌ Failed to schedule outbox dispatcher cron job: %v", err)
+	}
+
 	c.Start()
 }
@@ -1,7 +1,12 @@
 package interfaces
 
-import "BE_Manage_device/internal/domain/entity"
+import (
+	"BE_Manage_device/internal/domain/entity"
+
+	"gorm.io/gorm"
+)
 
 type Notification interface {
-	SendNotificationToUsers(users []*entity.Users, message string, asset entity.Assets) error
+	NotifyUsers(tx *gorm.DB, users []*entity.Users, event string, message string, asset entity.Assets) error
+	NotifyUsersWithEmail(tx *gorm.DB, users []*entity.Users, event string, message string, template string, data map[string]interface{}, asset entity.Assets) error
 }
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route phục vụ file của local driver, xem api/router/file_routes.go
+const LocalFileRoute = "/api/files/"
+
+type LocalStorage struct {
+	RootDir string
+	BaseURL string
+	Secret  string
+	Public  bool
+}
+
+func NewLocalStorage(rootDir, baseURL, secret string, public bool) (*LocalStorage, error) {
+	if secret == "" {
+		return nil, errors.New("STORAGE_SIGNING_SECRET is required for local storage")
+	}
+	if err := os.MkdirAll(rootDir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create storage dir: %w", err)
+	}
+	return &LocalStorage{RootDir: rootDir, BaseURL: strings.TrimRight(baseURL, "/"), Secret: secret, Public: public}, nil
+}
+
+func (s *LocalStorage) Upload(objectPath string, file multipart.File, contentType string) (string, error) {
+	defer file.Close()
+	return s.UploadReader(objectPath, file, contentType)
+}
+
+func (s *LocalStorage) UploadReader(objectPath string, reader io.Reader, contentType string) (string, error) {
+	fullPath, err := s.FilePath(objectPath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", fmt.Errorf("cannot create dir: %w", err)
+	}
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot create file: %w", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, reader); err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	return objectPath, nil
+}
+
+func (s *LocalStorage) Delete(objectPath string) error {
+	fullPath, err := s.FilePath(objectPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) SignedURL(objectPath string, expiry time.Duration) (string, error) {
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(objectPath, expires))
+	return s.BaseURL + LocalFileRoute + objectPath + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) ObjectPath(url string) (string, bool) {
+	if isObjectKey(url) {
+		return url, true
+	}
+	idx := strings.Index(url, LocalFileRoute)
+	if idx == -1 {
+		return "", false
+	}
+	path := url[idx+len(LocalFileRoute):]
+	if q := strings.Index(path, "?"); q != -1 {
+		path = path[:q]
+	}
+	return path, true
+}
+
+// URL: private thì ký lại mỗi lần dựng response, public thì trả link cố định
+func (s *LocalStorage) URL(stored string) (string, error) {
+	objectPath, ok := s.ObjectPath(stored)
+	if !ok {
+		return stored, nil
+	}
+	if s.Public {
+		return s.BaseURL + LocalFileRoute + objectPath, nil
+	}
+	return s.SignedURL(objectPath, FileURLExpiry)
+}
+
+// FilePath trả về đường dẫn thật trên đĩa, chặn path traversal ra ngoài RootDir
+func (s *LocalStorage) FilePath(objectPath string) (string, error) {
+	clean := filepath.Clean("/" + objectPath)
+	if clean == "/" {
+		return "", errors.New("invalid object path")
+	}
+	return filepath.Join(s.RootDir, filepath.FromSlash(clean)), nil
+}
+
+// VerifySignature kiểm tra chữ ký do SignedURL sinh ra
+func (s *LocalStorage) VerifySignature(objectPath, expires, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(s.sign(objectPath, expires)))
+}
+
+func (s *LocalStorage) sign(objectPath, expires string) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(objectPath + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage dùng được với AWS S3 và các dịch vụ tương thích như MinIO
+type S3Storage struct {
+	client    *minio.Client
+	Bucket    string
+	PublicURL string
+}
+
+func NewS3Storage(endpoint, accessKey, secretKey, bucket, region string, useSSL bool, publicURL string) (*S3Storage, error) {
+	if endpoint == "" {
+		return nil, errors.New("S3_ENDPOINT is required for s3 storage")
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create s3 client: %w", err)
+	}
+	if publicURL == "" {
+		scheme := "http"
+		if useSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, endpoint, bucket)
+	}
+	return &S3Storage{client: client, Bucket: bucket, PublicURL: strings.TrimRight(publicURL, "/")}, nil
+}
+
+func (s *S3Storage) Upload(objectPath string, file multipart.File, contentType string) (string, error) {
+	defer file.Close()
+	return s.UploadReader(objectPath, file, contentType)
+}
+
+func (s *S3Storage) UploadReader(objectPath string, reader io.Reader, contentType string) (string, error) {
+	_, err := s.client.PutObject(context.Background(), s.Bucket, objectPath, reader, -1, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	return objectPath, nil
+}
+
+func (s *S3Storage) Delete(objectPath string) error {
+	if err := s.client.RemoveObject(context.Background(), s.Bucket, objectPath, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) SignedURL(objectPath string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(context.Background(), s.Bucket, objectPath, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("cannot sign url: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3Storage) ObjectPath(url string) (string, bool) {
+	if isObjectKey(url) {
+		return url, true
+	}
+	prefix := s.PublicURL + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(url, prefix), true
+}
+
+func (s *S3Storage) URL(stored string) (string, error) {
+	if isObjectKey(stored) {
+		return s.PublicURL + "/" + stored, nil
+	}
+	return stored, nil
+}
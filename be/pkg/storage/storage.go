@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"BE_Manage_device/config"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Storage là interface chung cho các backend lưu trữ file (local, S3/MinIO, Supabase)
+type Storage interface {
+	// Upload lưu file multipart và trả về object key để lưu vào DB, link cho client dựng bằng URL
+	Upload(objectPath string, file multipart.File, contentType string) (string, error)
+	UploadReader(objectPath string, reader io.Reader, contentType string) (string, error)
+	Delete(objectPath string) error
+	// SignedURL trả về URL tạm thời có hạn dùng expiry
+	SignedURL(objectPath string, expiry time.Duration) (string, error)
+	// ObjectPath lấy lại object path từ giá trị đã lưu: object key hoặc URL do chính backend này trả về (dữ liệu cũ)
+	ObjectPath(url string) (string, bool)
+	// URL dựng link trả cho client từ giá trị đã lưu, backend private thì trả URL đã ký
+	URL(stored string) (string, error)
+}
+
+// FileURLExpiry là hạn của URL đã ký trả trong response
+const FileURLExpiry = time.Hour
+
+const (
+	DriverLocal    = "local"
+	DriverS3       = "s3"
+	DriverSupabase = "supabase"
+)
+
+// NewStorage khởi tạo backend theo biến môi trường STORAGE_DRIVER
+func NewStorage() (Storage, error) {
+	switch config.STORAGE_DRIVER {
+	case DriverLocal:
+		// Secret ký URL phải riêng, không dùng lại secret của JWT
+		if config.STORAGE_SIGNING_SECRET != "" && config.STORAGE_SIGNING_SECRET == config.AccessSecret {
+			return nil, fmt.Errorf("STORAGE_SIGNING_SECRET must differ from AccessSecret")
+		}
+		return NewLocalStorage(config.STORAGE_LOCAL_DIR, config.BASE_URL_BACKEND, config.STORAGE_SIGNING_SECRET, config.STORAGE_LOCAL_PUBLIC)
+	case DriverS3:
+		return NewS3Storage(config.S3_ENDPOINT, config.S3_ACCESS_KEY, config.S3_SECRET_KEY, config.S3_BUCKET, config.S3_REGION, config.S3_USE_SSL, config.S3_PUBLIC_URL)
+	case DriverSupabase, "":
+		return NewSupabaseStorage(config.SUPABASE_URL, config.SupabaseKey, config.SUPABASE_BUCKET)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.STORAGE_DRIVER)
+	}
+}
+
+var current Storage
+
+// SetDefault đặt backend dùng cho FileURL, gọi 1 lần lúc khởi động
+func SetDefault(s Storage) {
+	current = s
+}
+
+// FileURL dựng link trả cho client từ giá trị đã lưu trong DB, dùng khi build response
+func FileURL(stored string) string {
+	if stored == "" || current == nil {
+		return stored
+	}
+	url, err := current.URL(stored)
+	if err != nil {
+		log.Errorf("Happened error when build file url of %v. Error %v", stored, err)
+		return ""
+	}
+	return url
+}
+
+// isObjectKey phân biệt object key với URL đầy đủ đã lưu từ trước
+func isObjectKey(stored string) bool {
+	return stored != "" && !strings.Contains(stored, "://")
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"BE_Manage_device/config"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) *LocalStorage {
+	t.Helper()
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8080/", "signing-secret", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNewLocalStorageRequiresSecret(t *testing.T) {
+	if _, err := NewLocalStorage(t.TempDir(), "http://localhost:8080", "", false); err == nil {
+		t.Fatal("expected error without signing secret")
+	}
+}
+
+func TestNewStorageDriver(t *testing.T) {
+	saved := []string{config.STORAGE_DRIVER, config.STORAGE_SIGNING_SECRET, config.AccessSecret, config.STORAGE_LOCAL_DIR, config.SUPABASE_URL}
+	defer func() {
+		config.STORAGE_DRIVER, config.STORAGE_SIGNING_SECRET, config.AccessSecret, config.STORAGE_LOCAL_DIR, config.SUPABASE_URL = saved[0], saved[1], saved[2], saved[3], saved[4]
+	}()
+	config.STORAGE_LOCAL_DIR = t.TempDir()
+	config.AccessSecret = "access-secret"
+	config.SUPABASE_URL = "https://project.supabase.co"
+	tests := []struct {
+		name    string
+		driver  string
+		secret  string
+		want    string
+		wantErr bool
+	}{
+		{"default is supabase", "", "", "*storage.SupabaseStorage", false},
+		{"supabase", DriverSupabase, "", "*storage.SupabaseStorage", false},
+		{"local", DriverLocal, "signing-secret", "*storage.LocalStorage", false},
+		{"local without secret", DriverLocal, "", "", true},
+		{"local reusing jwt secret", DriverLocal, "access-secret", "", true},
+		{"unknown driver", "ftp", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.STORAGE_DRIVER = tt.driver
+			config.STORAGE_SIGNING_SECRET = tt.secret
+			s, err := NewStorage()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if got := typeName(s); got != tt.want {
+					t.Errorf("got %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func typeName(s Storage) string {
+	switch s.(type) {
+	case *LocalStorage:
+		return "*storage.LocalStorage"
+	case *SupabaseStorage:
+		return "*storage.SupabaseStorage"
+	case *S3Storage:
+		return "*storage.S3Storage"
+	}
+	return ""
+}
+
+func TestLocalStorageFilePath(t *testing.T) {
+	s := newTestLocalStorage(t)
+	tests := []struct {
+		name       string
+		objectPath string
+		want       string
+		wantErr    bool
+	}{
+		{"nested path", "assets/1/image.png", filepath.Join(s.RootDir, "assets", "1", "image.png"), false},
+		{"traversal stays in root", "../../etc/passwd", filepath.Join(s.RootDir, "etc", "passwd"), false},
+		{"traversal in the middle", "assets/../../secret", filepath.Join(s.RootDir, "secret"), false},
+		{"empty", "", "", true},
+		{"root only", "/", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FilePath(tt.objectPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalStorageUploadAndDelete(t *testing.T) {
+	s := newTestLocalStorage(t)
+	key, err := s.UploadReader("assets/1/file.txt", strings.NewReader("hello"), "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DB lưu object key, không lưu link chưa ký
+	if key != "assets/1/file.txt" {
+		t.Errorf("unexpected key %q", key)
+	}
+	objectPath, ok := s.ObjectPath(key)
+	if !ok || objectPath != "assets/1/file.txt" {
+		t.Fatalf("ObjectPath(%q) = %q, %v", key, objectPath, ok)
+	}
+	fullPath, _ := s.FilePath(objectPath)
+	f, err := os.Open(fullPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := io.ReadAll(f)
+	f.Close()
+	if string(content) != "hello" {
+		t.Errorf("content = %q", content)
+	}
+	if err := s.Delete(objectPath); err != nil {
+		t.Fatal(err)
+	}
+	// Xoá lần 2 không lỗi
+	if err := s.Delete(objectPath); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalStorageSignature(t *testing.T) {
+	s := newTestLocalStorage(t)
+	signed, err := s.SignedURL("assets/1/file.txt", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	other := &LocalStorage{RootDir: s.RootDir, BaseURL: s.BaseURL, Secret: "other-secret"}
+	tests := []struct {
+		name       string
+		storage    *LocalStorage
+		objectPath string
+		expires    string
+		signature  string
+		want       bool
+	}{
+		{"valid", s, "assets/1/file.txt", expires, signature, true},
+		{"other object", s, "assets/2/file.txt", expires, signature, false},
+		{"extended expiry", s, "assets/1/file.txt", expires + "0", signature, false},
+		{"expired", s, "assets/1/file.txt", past, s.sign("assets/1/file.txt", past), false},
+		{"invalid expiry", s, "assets/1/file.txt", "soon", signature, false},
+		{"other secret", other, "assets/1/file.txt", expires, signature, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.storage.VerifySignature(tt.objectPath, tt.expires, tt.signature); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectPath(t *testing.T) {
+	supabase := &SupabaseStorage{Bucket: "assets"}
+	s3 := &S3Storage{PublicURL: "https://cdn.example.com/assets"}
+	local := &LocalStorage{BaseURL: "http://localhost:8080"}
+	tests := []struct {
+		name    string
+		storage Storage
+		url     string
+		want    string
+		wantOk  bool
+	}{
+		{"supabase public url", supabase, "https://x.supabase.co/storage/v1/object/public/assets/1/a.png", "1/a.png", true},
+		{"supabase other bucket", supabase, "https://x.supabase.co/storage/v1/object/public/other/1/a.png", "", false},
+		{"s3 public url", s3, "https://cdn.example.com/assets/1/a.png", "1/a.png", true},
+		{"s3 foreign url", s3, "https://evil.example.com/assets/1/a.png", "", false},
+		{"local with query", local, "http://localhost:8080/api/files/1/a.png?expires=1&signature=x", "1/a.png", true},
+		{"local foreign url", local, "http://localhost:8080/static/1/a.png", "", false},
+		{"supabase key", supabase, "1/a.png", "1/a.png", true},
+		{"s3 key", s3, "1/a.png", "1/a.png", true},
+		{"local key", local, "1/a.png", "1/a.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.storage.ObjectPath(tt.url)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestURL(t *testing.T) {
+	private := newTestLocalStorage(t)
+	public := &LocalStorage{BaseURL: "http://localhost:8080", Public: true}
+	supabase := &SupabaseStorage{BaseURL: "https://x.supabase.co/storage/v1", Bucket: "assets"}
+	s3 := &S3Storage{PublicURL: "https://cdn.example.com/assets"}
+	tests := []struct {
+		name       string
+		storage    Storage
+		stored     string
+		wantPrefix string
+		wantSigned bool
+	}{
+		{"local private key", private, "1/a.png", "http://localhost:8080/api/files/1/a.png?expires=", true},
+		// link cũ đã lưu trong DB được ký lại
+		{"local private legacy url", private, "http://localhost:8080/api/files/1/a.png", "http://localhost:8080/api/files/1/a.png?expires=", true},
+		{"local public key", public, "1/a.png", "http://localhost:8080/api/files/1/a.png", false},
+		{"local foreign url", private, "https://example.com/a.png", "https://example.com/a.png", false},
+		{"supabase key", supabase, "1/a.png", "https://x.supabase.co/storage/v1/object/public/assets/1/a.png", false},
+		{"supabase legacy url", supabase, "https://x.supabase.co/storage/v1/object/public/assets/1/a.png", "https://x.supabase.co/storage/v1/object/public/assets/1/a.png", false},
+		{"s3 key", s3, "1/a.png", "https://cdn.example.com/assets/1/a.png", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.storage.URL(tt.stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("got %q, want prefix %q", got, tt.wantPrefix)
+			}
+			if strings.Contains(got, "signature=") != tt.wantSigned {
+				t.Errorf("got %q, signed want %v", got, tt.wantSigned)
+			}
+		})
+	}
+}
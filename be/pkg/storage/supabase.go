@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type SupabaseStorage struct {
+	BaseURL string
+	ApiKey  string
+	Bucket  string
+	client  *http.Client
+}
+
+func NewSupabaseStorage(baseURL, apiKey, bucket string) (*SupabaseStorage, error) {
+	if baseURL == "" {
+		return nil, errors.New("SUPABASE_URL or SUPABASE_PROJECT_REF is required for supabase storage")
+	}
+	return &SupabaseStorage{
+		BaseURL: strings.TrimRight(baseURL, "/") + "/storage/v1",
+		ApiKey:  apiKey,
+		Bucket:  bucket,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *SupabaseStorage) Upload(objectPath string, file multipart.File, contentType string) (string, error) {
+	defer file.Close()
+	return s.UploadReader(objectPath, file, contentType)
+}
+
+func (s *SupabaseStorage) UploadReader(objectPath string, reader io.Reader, contentType string) (string, error) {
+	// Đọc toàn bộ nội dung từ reader vào buffer
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, reader); err != nil {
+		return "", fmt.Errorf("failed to read content: %w", err)
+	}
+	url := fmt.Sprintf("%s/object/%s/%s", s.BaseURL, s.Bucket, objectPath)
+	req, err := http.NewRequest("POST", url, &buf)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Length", fmt.Sprint(buf.Len()))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("upload failed: %s", string(body))
+	}
+	return objectPath, nil
+}
+
+func (s *SupabaseStorage) Delete(objectPath string) error {
+	// Supabase yêu cầu mảng path
+	body, err := json.Marshal(map[string]interface{}{
+		"prefixes": []string{objectPath},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	url := fmt.Sprintf("%s/object/%s", s.BaseURL, s.Bucket)
+	req, err := http.NewRequest("DELETE", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call supabase: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete failed: %s", string(b))
+	}
+	return nil
+}
+
+func (s *SupabaseStorage) SignedURL(objectPath string, expiry time.Duration) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"expiresIn": int(expiry.Seconds()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	url := fmt.Sprintf("%s/object/sign/%s/%s", s.BaseURL, s.Bucket, objectPath)
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call supabase: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("sign failed: %s", string(b))
+	}
+	var result struct {
+		SignedURL string `json:"signedURL"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	return s.BaseURL + result.SignedURL, nil
+}
+
+func (s *SupabaseStorage) ObjectPath(url string) (string, bool) {
+	if isObjectKey(url) {
+		return url, true
+	}
+	sep := "/public/" + s.Bucket + "/"
+	idx := strings.Index(url, sep)
+	if idx == -1 {
+		return "", false
+	}
+	return url[idx+len(sep):], true
+}
+
+// URL trả về URL public (bucket là public)
+func (s *SupabaseStorage) URL(stored string) (string, error) {
+	if isObjectKey(stored) {
+		return fmt.Sprintf("%s/object/public/%s/%s", s.BaseURL, s.Bucket, stored), nil
+	}
+	return stored, nil
+}
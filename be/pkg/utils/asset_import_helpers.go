@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"BE_Manage_device/internal/domain/dto"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Các tên cột được chấp nhận trong file import, so khớp không phân biệt hoa thường
+var assetImportColumns = map[string]string{
+	"assetname":     "assetName",
+	"name":          "assetName",
+	"serialnumber":  "serialNumber",
+	"serial":        "serialNumber",
+	"purchasedate":  "purchaseDate",
+	"warrantexpiry": "warrantExpiry",
+	"cost":          "cost",
+	"category":      "category",
+	"department":    "department",
+	"owner":         "owner",
+}
+
+var importDateLayouts = []string{time.RFC3339, "2006-01-02", "02/01/2006"}
+
+func ParseAssetImportFile(file *multipart.FileHeader) ([]dto.AssetImportRow, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("cannot open import file: %w", err)
+	}
+	defer f.Close()
+	var records [][]string
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".csv":
+		reader := csv.NewReader(f)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err = reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read csv: %w", err)
+		}
+	case ".xlsx":
+		records, err = readXLSXRecords(f)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("unsupported file type, only .csv and .xlsx are allowed")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("import file is empty")
+	}
+	header := map[string]int{}
+	for i, col := range records[0] {
+		key := strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.TrimSpace(col)))
+		if name, ok := assetImportColumns[key]; ok {
+			header[name] = i
+		}
+	}
+	for _, required := range []string{"assetName", "serialNumber", "purchaseDate", "warrantExpiry", "cost", "category", "department"} {
+		if _, ok := header[required]; !ok {
+			return nil, fmt.Errorf("missing required column %s", required)
+		}
+	}
+	cell := func(record []string, name string) string {
+		i, ok := header[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	rows := []dto.AssetImportRow{}
+	for i, record := range records[1:] {
+		if isBlankRecord(record) {
+			continue
+		}
+		rows = append(rows, dto.AssetImportRow{
+			Row:           i + 2,
+			AssetName:     cell(record, "assetName"),
+			SerialNumber:  cell(record, "serialNumber"),
+			PurchaseDate:  cell(record, "purchaseDate"),
+			WarrantExpiry: cell(record, "warrantExpiry"),
+			Cost:          cell(record, "cost"),
+			Category:      cell(record, "category"),
+			Department:    cell(record, "department"),
+			Owner:         cell(record, "owner"),
+		})
+	}
+	return rows, nil
+}
+
+func readXLSXRecords(r io.Reader) ([][]string, error) {
+	book, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read xlsx: %w", err)
+	}
+	defer book.Close()
+	sheets := book.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("xlsx file has no sheet")
+	}
+	records, err := book.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("cannot read xlsx rows: %w", err)
+	}
+	return records, nil
+}
+
+func isBlankRecord(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func ParseImportDate(value string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
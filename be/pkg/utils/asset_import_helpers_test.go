@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// importFileHeader dựng FileHeader như khi upload qua multipart form
+func importFileHeader(t *testing.T, filename string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	return req.MultipartForm.File["file"][0]
+}
+
+func TestParseAssetImportFileCSV(t *testing.T) {
+	const header = "Asset Name,serial_number,Purchase-Date,warrantExpiry,COST,category,department,owner\n"
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		wantErr  bool
+		wantRows []int
+	}{
+		{"header aliases", "assets.csv", header + "Laptop,SN1,2024-01-02,2026-01-02,1000,IT,Sales,a@b.c\n", false, []int{2}},
+		{"upper case extension", "ASSETS.CSV", header + "Laptop,SN1,2024-01-02,2026-01-02,1000,IT,Sales,\n", false, []int{2}},
+		{"blank rows are skipped but keep row numbers", "assets.csv", header + "Laptop,SN1,2024-01-02,2026-01-02,1000,IT,Sales,\n , , , , , , , \nPhone,SN2,2024-01-02,2026-01-02,500,IT,Sales,\n", false, []int{2, 4}},
+		{"short record", "assets.csv", "name,serial,purchaseDate,warrantExpiry,cost,category,department\nLaptop,SN1\n", false, []int{2}},
+		{"missing required column", "assets.csv", "name,serial,purchaseDate,warrantExpiry,cost,category\nLaptop,SN1,2024-01-02,2026-01-02,1000,IT\n", true, nil},
+		{"empty file", "assets.csv", "", true, nil},
+		{"unsupported extension", "assets.txt", header, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseAssetImportFile(importFileHeader(t, tt.filename, []byte(tt.content)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.wantRows))
+			}
+			for i, row := range rows {
+				if row.Row != tt.wantRows[i] {
+					t.Errorf("rows[%d].Row = %d, want %d", i, row.Row, tt.wantRows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseAssetImportFileCells(t *testing.T) {
+	content := "name,serial,purchaseDate,warrantExpiry,cost,category,department,owner\n Laptop , SN1 ,2024-01-02,2026-01-02,1000,IT,Sales,a@b.c\n"
+	rows, err := ParseAssetImportFile(importFileHeader(t, "assets.csv", []byte(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := rows[0]
+	if row.AssetName != "Laptop" || row.SerialNumber != "SN1" || row.Cost != "1000" || row.Owner != "a@b.c" {
+		t.Errorf("unexpected row %+v", row)
+	}
+}
+
+func TestParseAssetImportFileXLSX(t *testing.T) {
+	book := excelize.NewFile()
+	sheet := book.GetSheetName(0)
+	records := [][]interface{}{
+		{"Asset Name", "Serial Number", "Purchase Date", "Warrant Expiry", "Cost", "Category", "Department"},
+		{"Laptop", "SN1", "2024-01-02", "2026-01-02", "1000", "IT", "Sales"},
+	}
+	for i, record := range records {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := book.SetSheetRow(sheet, cell, &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := book.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := ParseAssetImportFile(importFileHeader(t, "assets.xlsx", buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].SerialNumber != "SN1" || rows[0].Department != "Sales" {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"2024-03-05", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"05/03/2024", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), false},
+		{"2024-03-05T10:00:00Z", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), false},
+		{"03-05-2024", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseImportDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
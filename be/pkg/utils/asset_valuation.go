@@ -1,32 +1,199 @@
 package utils
 
 import (
+	"BE_Manage_device/internal/domain/dto"
+	"BE_Manage_device/internal/domain/entity"
+	"errors"
+	"fmt"
 	"math"
 	"time"
 )
 
-// Hàm tính giá trị còn lại của tài sản
-func CurrentAssetValue(
-	originalCost float64,
-	salvageValue float64,
-	usefulLifeYears float64,
-	startDate, currentDate time.Time,
-) float64 {
+const (
+	DepreciationStraightLine           = "straight_line"
+	DepreciationDecliningBalance       = "declining_balance"
+	DepreciationDoubleDecliningBalance = "double_declining_balance"
+	DepreciationSumOfYearsDigits       = "sum_of_years_digits"
+	DepreciationUnitsOfProduction      = "units_of_production"
+	DefaultDepreciationDecliningFactor = 1.5
+	doubleDecliningDepreciationFactor  = 2.0
+	depreciationPeriodDateLayout       = "2006-01-02"
+)
 
-	// Tính số năm đã sử dụng (dạng float, có thể lẻ tháng)
-	yearsUsed := currentDate.Sub(startDate).Hours() / (24 * 365)
+func ValidDepreciationMethod(method string) bool {
+	switch method {
+	case DepreciationStraightLine, DepreciationDecliningBalance, DepreciationDoubleDecliningBalance, DepreciationSumOfYearsDigits, DepreciationUnitsOfProduction:
+		return true
+	}
+	return false
+}
 
-	if yearsUsed < 0 {
-		yearsUsed = 0 // chưa đến ngày bắt đầu sử dụng
+// DepreciationInput là cấu hình khấu hao đã gộp giữa category và tài sản
+type DepreciationInput struct {
+	Method          string
+	Cost            float64
+	ResidualValue   float64
+	UsefulLife      float64 // số năm, có thể lẻ
+	DecliningFactor float64
+	TotalUnits      float64
+	UnitsByPeriod   map[int]float64
+	StartDate       time.Time
+}
+
+// ResolveDepreciationInput ưu tiên giá trị ghi đè trên tài sản, sau đó tới cấu hình của category (asset.Category phải được preload)
+func ResolveDepreciationInput(asset *entity.Assets, usages []*entity.AssetUsages) (*DepreciationInput, error) {
+	in := DepreciationInput{
+		Method:          DepreciationStraightLine,
+		Cost:            asset.Cost,
+		DecliningFactor: DefaultDepreciationDecliningFactor,
+		UnitsByPeriod:   map[int]float64{},
+		StartDate:       asset.PurchaseDate,
+	}
+	if asset.AcquisitionDate != nil {
+		in.StartDate = *asset.AcquisitionDate
+	}
+	category := asset.Category
+	if category.DepreciationMethod != nil {
+		in.Method = *category.DepreciationMethod
+	}
+	if asset.DepreciationMethod != nil {
+		in.Method = *asset.DepreciationMethod
+	}
+	if !ValidDepreciationMethod(in.Method) {
+		return nil, fmt.Errorf("invalid depreciation method '%v'", in.Method)
+	}
+	switch {
+	case asset.UsefulLife != nil:
+		in.UsefulLife = *asset.UsefulLife
+	case category.UsefulLife != nil:
+		in.UsefulLife = *category.UsefulLife
+	default:
+		return nil, errors.New("useful life is not configured for this asset or its category")
+	}
+	if in.UsefulLife <= 0 {
+		return nil, errors.New("useful life must be greater than 0")
+	}
+	if asset.ResidualValue != nil {
+		in.ResidualValue = *asset.ResidualValue
+	} else if category.ResidualRate != nil {
+		in.ResidualValue = asset.Cost * *category.ResidualRate
+	}
+	if in.ResidualValue < 0 || in.ResidualValue > in.Cost {
+		return nil, errors.New("residual value must be between 0 and cost")
+	}
+	if category.DecliningFactor != nil {
+		in.DecliningFactor = *category.DecliningFactor
 	}
-	if yearsUsed > usefulLifeYears {
-		yearsUsed = usefulLifeYears // không vượt quá thời gian sử dụng
+	if in.Method == DepreciationDoubleDecliningBalance {
+		in.DecliningFactor = doubleDecliningDepreciationFactor
 	}
+	if in.Method == DepreciationUnitsOfProduction {
+		if asset.TotalUnits == nil || *asset.TotalUnits <= 0 {
+			return nil, errors.New("total units is required for units-of-production depreciation")
+		}
+		in.TotalUnits = *asset.TotalUnits
+	}
+	for _, u := range usages {
+		in.UnitsByPeriod[u.Period] = u.Units
+	}
+	return &in, nil
+}
+
+// BuildDepreciationSchedule tính khấu hao theo từng năm kể từ ngày bắt đầu sử dụng.
+// Kỳ cuối của useful life lẻ được tính theo tỉ lệ, sum-of-years-digits làm tròn lên số năm.
+func BuildDepreciationSchedule(in *DepreciationInput) []dto.DepreciationPeriodResponse {
+	periods := int(math.Ceil(in.UsefulLife))
+	if in.Method == DepreciationUnitsOfProduction {
+		for p := range in.UnitsByPeriod {
+			if p > periods {
+				periods = p
+			}
+		}
+	}
+	depreciable := in.Cost - in.ResidualValue
+	sumOfYears := float64(periods*(periods+1)) / 2
+	bookValue := in.Cost
+	accumulated := 0.0
+	res := make([]dto.DepreciationPeriodResponse, 0, periods)
+	for i := 0; i < periods; i++ {
+		fraction := 1.0
+		isLast := i == periods-1
+		if isLast && in.Method != DepreciationUnitsOfProduction {
+			if f := in.UsefulLife - float64(periods-1); f > 0 && f < 1 {
+				fraction = f
+			}
+		}
+		var charge float64
+		var units *float64
+		switch in.Method {
+		case DepreciationDecliningBalance, DepreciationDoubleDecliningBalance:
+			charge = bookValue * in.DecliningFactor / in.UsefulLife * fraction
+		case DepreciationSumOfYearsDigits:
+			charge = depreciable * float64(periods-i) / sumOfYears
+		case DepreciationUnitsOfProduction:
+			u := in.UnitsByPeriod[i+1]
+			units = &u
+			charge = depreciable * u / in.TotalUnits
+		default:
+			charge = depreciable / in.UsefulLife * fraction
+		}
+		// Kỳ cuối khấu hao hết phần còn lại về giá trị thu hồi (trừ units-of-production phụ thuộc sản lượng thực tế)
+		if isLast && in.Method != DepreciationUnitsOfProduction {
+			charge = bookValue - in.ResidualValue
+		}
+		charge = RoundMoney(math.Max(0, math.Min(charge, bookValue-in.ResidualValue)))
+		opening := bookValue
+		bookValue = RoundMoney(bookValue - charge)
+		accumulated = RoundMoney(accumulated + charge)
+		periodStart := in.StartDate.AddDate(i, 0, 0)
+		periodEnd := in.StartDate.AddDate(i+1, 0, 0)
+		if fraction < 1 {
+			periodEnd = periodStart.Add(time.Duration(float64(periodEnd.Sub(periodStart)) * fraction))
+		}
+		res = append(res, dto.DepreciationPeriodResponse{
+			Period:                  i + 1,
+			StartDate:               periodStart.Format(depreciationPeriodDateLayout),
+			EndDate:                 periodEnd.Format(depreciationPeriodDateLayout),
+			OpeningValue:            opening,
+			Charge:                  charge,
+			AccumulatedDepreciation: accumulated,
+			ClosingValue:            bookValue,
+			Units:                   units,
+		})
+	}
+	return res
+}
 
-	annualDepreciation := (originalCost - salvageValue) / usefulLifeYears
-	accumulatedDepreciation := yearsUsed * annualDepreciation
+// CurrentAssetValue trả về giá trị còn lại của tài sản tại currentDate, kỳ đang chạy được phân bổ theo số ngày đã qua
+func CurrentAssetValue(in *DepreciationInput, currentDate time.Time) float64 {
+	periods := BuildDepreciationSchedule(in)
+	if !currentDate.After(in.StartDate) || len(periods) == 0 {
+		return in.Cost
+	}
+	for _, p := range periods {
+		periodStart, _ := time.ParseInLocation(depreciationPeriodDateLayout, p.StartDate, in.StartDate.Location())
+		periodEnd, _ := time.ParseInLocation(depreciationPeriodDateLayout, p.EndDate, in.StartDate.Location())
+		if currentDate.Before(periodEnd) && periodEnd.After(periodStart) {
+			elapsed := currentDate.Sub(periodStart).Hours() / periodEnd.Sub(periodStart).Hours()
+			return RoundMoney(p.OpeningValue - p.Charge*math.Min(elapsed, 1))
+		}
+	}
+	return periods[len(periods)-1].ClosingValue
+}
+
+// DepreciationChargeAt trả về mức khấu hao của kỳ chứa currentDate, ngoài thời gian khấu hao thì trả về 0
+func DepreciationChargeAt(in *DepreciationInput, currentDate time.Time) float64 {
+	for _, p := range BuildDepreciationSchedule(in) {
+		periodStart, _ := time.ParseInLocation(depreciationPeriodDateLayout, p.StartDate, in.StartDate.Location())
+		periodEnd, _ := time.ParseInLocation(depreciationPeriodDateLayout, p.EndDate, in.StartDate.Location())
+		if !currentDate.Before(periodStart) && currentDate.Before(periodEnd) {
+			return p.Charge
+		}
+	}
+	return 0
+}
 
-	// Giá trị còn lại không thấp hơn salvage value
-	currentValue := math.Max(originalCost-accumulatedDepreciation, salvageValue)
-	return currentValue
+// RoundMoney làm tròn tiền tới 2 chữ số lẻ, dùng chung cho giá trị tài sản và các báo cáo
+func RoundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
 }
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"BE_Manage_device/internal/domain/entity"
+	"testing"
+	"time"
+)
+
+func TestBuildDepreciationSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		in          DepreciationInput
+		wantCharges []float64
+		wantClosing []float64
+		wantLastEnd string
+	}{
+		{"straight line", DepreciationInput{Method: DepreciationStraightLine, Cost: 1000, ResidualValue: 100, UsefulLife: 3},
+			[]float64{300, 300, 300}, []float64{700, 400, 100}, "2027-01-01"},
+		{"straight line with fractional life", DepreciationInput{Method: DepreciationStraightLine, Cost: 600, ResidualValue: 100, UsefulLife: 2.5},
+			[]float64{200, 200, 100}, []float64{400, 200, 100}, "2026-07-02"},
+		{"declining balance", DepreciationInput{Method: DepreciationDecliningBalance, Cost: 1000, ResidualValue: 100, UsefulLife: 3, DecliningFactor: 1.5},
+			[]float64{500, 250, 150}, []float64{500, 250, 100}, "2027-01-01"},
+		{"double declining balance", DepreciationInput{Method: DepreciationDoubleDecliningBalance, Cost: 1000, UsefulLife: 4, DecliningFactor: 2},
+			[]float64{500, 250, 125, 125}, []float64{500, 250, 125, 0}, "2028-01-01"},
+		{"declining balance never goes below residual", DepreciationInput{Method: DepreciationDecliningBalance, Cost: 1000, ResidualValue: 600, UsefulLife: 2, DecliningFactor: 2},
+			[]float64{400, 0}, []float64{600, 600}, "2026-01-01"},
+		{"sum of years digits", DepreciationInput{Method: DepreciationSumOfYearsDigits, Cost: 700, ResidualValue: 100, UsefulLife: 3},
+			[]float64{300, 200, 100}, []float64{400, 200, 100}, "2027-01-01"},
+		{"units of production", DepreciationInput{Method: DepreciationUnitsOfProduction, Cost: 1100, ResidualValue: 100, UsefulLife: 3, TotalUnits: 1000, UnitsByPeriod: map[int]float64{1: 200, 2: 500}},
+			[]float64{200, 500, 0}, []float64{900, 400, 400}, "2027-01-01"},
+		{"units of production past useful life", DepreciationInput{Method: DepreciationUnitsOfProduction, Cost: 1100, ResidualValue: 100, UsefulLife: 1, TotalUnits: 1000, UnitsByPeriod: map[int]float64{1: 600, 2: 600}},
+			[]float64{600, 400}, []float64{500, 100}, "2026-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.in
+			in.StartDate = start
+			periods := BuildDepreciationSchedule(&in)
+			if len(periods) != len(tt.wantCharges) {
+				t.Fatalf("got %d periods, want %d", len(periods), len(tt.wantCharges))
+			}
+			accumulated := 0.0
+			for i, p := range periods {
+				accumulated += p.Charge
+				if p.Charge != tt.wantCharges[i] || p.ClosingValue != tt.wantClosing[i] || p.AccumulatedDepreciation != accumulated {
+					t.Errorf("period %d = charge %v closing %v accumulated %v, want %v %v %v", p.Period, p.Charge, p.ClosingValue, p.AccumulatedDepreciation, tt.wantCharges[i], tt.wantClosing[i], accumulated)
+				}
+			}
+			if last := periods[len(periods)-1]; last.EndDate != tt.wantLastEnd {
+				t.Errorf("last period ends %v, want %v", last.EndDate, tt.wantLastEnd)
+			}
+		})
+	}
+}
+
+func TestCurrentAssetValueAndChargeAt(t *testing.T) {
+	in := &DepreciationInput{Method: DepreciationStraightLine, Cost: 1000, UsefulLife: 2, StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		name       string
+		date       time.Time
+		wantValue  float64
+		wantCharge float64
+	}{
+		{"before start", time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), 1000, 0},
+		{"half of first period", time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), 750, 500},
+		{"start of second period", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 500, 500},
+		{"after useful life", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CurrentAssetValue(in, tt.date); got != tt.wantValue {
+				t.Errorf("CurrentAssetValue = %v, want %v", got, tt.wantValue)
+			}
+			if got := DepreciationChargeAt(in, tt.date); got != tt.wantCharge {
+				t.Errorf("DepreciationChargeAt = %v, want %v", got, tt.wantCharge)
+			}
+		})
+	}
+}
+
+func TestResolveDepreciationInput(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	s := func(v string) *string { return &v }
+	purchase := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	acquired := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	category := entity.Categories{DepreciationMethod: s(DepreciationSumOfYearsDigits), UsefulLife: f(5), ResidualRate: f(0.1), DecliningFactor: f(1.8)}
+	tests := []struct {
+		name    string
+		asset   entity.Assets
+		want    DepreciationInput
+		wantErr bool
+	}{
+		{"category defaults", entity.Assets{Cost: 1000, PurchaseDate: purchase, Category: category},
+			DepreciationInput{Method: DepreciationSumOfYearsDigits, Cost: 1000, ResidualValue: 100, UsefulLife: 5, DecliningFactor: 1.8, StartDate: purchase}, false},
+		{"asset overrides", entity.Assets{Cost: 1000, PurchaseDate: purchase, AcquisitionDate: &acquired, Category: category, DepreciationMethod: s(DepreciationDoubleDecliningBalance), UsefulLife: f(4), ResidualValue: f(0)},
+			DepreciationInput{Method: DepreciationDoubleDecliningBalance, Cost: 1000, ResidualValue: 0, UsefulLife: 4, DecliningFactor: 2, StartDate: acquired}, false},
+		{"no category method uses straight line", entity.Assets{Cost: 1000, PurchaseDate: purchase, Category: entity.Categories{UsefulLife: f(2)}},
+			DepreciationInput{Method: DepreciationStraightLine, Cost: 1000, UsefulLife: 2, DecliningFactor: DefaultDepreciationDecliningFactor, StartDate: purchase}, false},
+		{"missing useful life", entity.Assets{Cost: 1000, Category: entity.Categories{}}, DepreciationInput{}, true},
+		{"invalid method", entity.Assets{Cost: 1000, Category: category, DepreciationMethod: s("linear")}, DepreciationInput{}, true},
+		{"residual above cost", entity.Assets{Cost: 1000, Category: category, ResidualValue: f(1001)}, DepreciationInput{}, true},
+		{"units without total units", entity.Assets{Cost: 1000, Category: category, DepreciationMethod: s(DepreciationUnitsOfProduction)}, DepreciationInput{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDepreciationInput(&tt.asset, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Method != tt.want.Method || got.Cost != tt.want.Cost || got.ResidualValue != tt.want.ResidualValue || got.UsefulLife != tt.want.UsefulLife || got.DecliningFactor != tt.want.DecliningFactor || !got.StartDate.Equal(tt.want.StartDate) {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -4,15 +4,25 @@ import (
 	"BE_Manage_device/config"
 	"BE_Manage_device/constant"
 	"BE_Manage_device/pkg"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	AccessTokenTTL  = 10 * time.Hour
+	RefreshTokenTTL = 50 * time.Hour
+)
+
 func GetUserIdFromContext(c *gin.Context) int64 {
 	userID, exists := c.Get("userID")
 	if exists {
@@ -31,12 +41,27 @@ func GetUserIdFromContext(c *gin.Context) int64 {
 	return userIdConvert
 }
 
+// GetSessionIdFromContext trả về id session của access token hiện tại (AuthMiddleware set)
+func GetSessionIdFromContext(c *gin.Context) int64 {
+	sessionId, exists := c.Get("sessionID")
+	if !exists {
+		log.Error("Happened error when get sessionId from gin Context")
+		pkg.PanicExeption(constant.UnknownError)
+	}
+	id, ok := sessionId.(int64)
+	if !ok {
+		pkg.PanicExeption(constant.UnknownError)
+	}
+	return id
+}
+
 func GenerateTokens(userId int64, email string) (string, string, error) {
 	// Access Token (15 phút)
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userId": userId,
 		"email":  email,
-		"exp":    time.Now().Add(10 * time.Hour).Unix(),
+		"exp":    time.Now().Add(AccessTokenTTL).Unix(),
+		"jti":    uuid.NewString(),
 	})
 	accessString, err := accessToken.SignedString([]byte(config.AccessSecret))
 	if err != nil {
@@ -47,7 +72,8 @@ func GenerateTokens(userId int64, email string) (string, string, error) {
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"userId": userId,
 		"email":  email,
-		"exp":    time.Now().Add(50 * time.Hour).Unix(),
+		"exp":    time.Now().Add(RefreshTokenTTL).Unix(),
+		"jti":    uuid.NewString(),
 	})
 	refreshString, err := refreshToken.SignedString([]byte(config.RefreshSecret))
 	if err != nil {
@@ -56,3 +82,99 @@ func GenerateTokens(userId int64, email string) (string, string, error) {
 
 	return accessString, refreshString, nil
 }
+
+// ParseRefreshToken kiểm tra chữ ký, hạn của refresh token và trả về userId
+func ParseRefreshToken(tokenString string) (int64, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.RefreshSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, fmt.Errorf("refresh token was expired")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, fmt.Errorf("invalid refresh token")
+	}
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("invalid refresh token")
+	}
+	return int64(userId), nil
+}
+
+// HashToken dùng để lưu refresh token đã dùng và API token mà không giữ bản gốc
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApiTokenPrefix giúp AuthMiddleware phân biệt API token với JWT
+const ApiTokenPrefix = "bmd_pat_"
+
+// GenerateApiToken sinh API token ngẫu nhiên 32 byte
+func GenerateApiToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return ApiTokenPrefix + hex.EncodeToString(b), nil
+}
+
+func IsApiToken(token string) bool {
+	return strings.HasPrefix(token, ApiTokenPrefix)
+}
+
+// GetApiTokenIdFromContext trả về 0 nếu request không xác thực bằng API token
+func GetApiTokenIdFromContext(c *gin.Context) int64 {
+	v, exists := c.Get("apiTokenID")
+	if !exists {
+		return 0
+	}
+	id, _ := v.(int64)
+	return id
+}
+
+// DeviceLabelFromUserAgent đoán tên thiết bị khi FE không gửi deviceLabel, vd "Chrome on Windows"
+func DeviceLabelFromUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+	browser := "Unknown browser"
+	for _, b := range []struct{ key, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"chrome/", "Chrome"},
+		{"firefox/", "Firefox"},
+		{"safari/", "Safari"},
+		{"postman", "Postman"},
+		{"okhttp", "Android app"},
+		{"dart", "Mobile app"},
+	} {
+		if strings.Contains(ua, b.key) {
+			browser = b.name
+			break
+		}
+	}
+	os := ""
+	for _, o := range []struct{ key, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.key) {
+			os = o.name
+			break
+		}
+	}
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
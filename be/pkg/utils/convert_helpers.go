@@ -3,6 +3,9 @@ package utils
 import (
 	"BE_Manage_device/internal/domain/dto"
 	"BE_Manage_device/internal/domain/entity"
+	"BE_Manage_device/pkg/storage"
+	"strings"
+	"time"
 )
 
 func ConvertUserToUserResponse(user *entity.Users) dto.UserResponse {
@@ -12,7 +15,8 @@ func ConvertUserToUserResponse(user *entity.Users) dto.UserResponse {
 		LastName:  user.LastName,
 		Email:     user.Email,
 		IsActive:  user.IsActive,
-		Avatar:    user.Avatar,
+		Avatar:    storage.FileURL(user.Avatar),
+		Language:  user.Language,
 		Role: dto.UserRoleResponse{
 			Id:   user.RoleId,
 			Slug: user.Role.Slug,
@@ -52,9 +56,63 @@ func ConvertRequestTransferToResponse(rt *entity.RequestTransfer) dto.RequestTra
 			Id:           rt.CategoryId,
 			CategoryName: rt.Category.CategoryName,
 		},
+		Asset:       convertAssetToRequestTransferAsset(rt.Asset),
+		CurrentStep: rt.CurrentStep,
+		CreatedAt:   rt.CreatedAt,
+		UpdatedAt:   rt.UpdatedAt,
+		Approvals:   ConvertRequestTransferApprovalsToResponses(rt.Approvals),
 	}
 }
 
+func convertAssetToRequestTransferAsset(asset *entity.Assets) *dto.AssetResponseInRequestTransfer {
+	if asset == nil {
+		return nil
+	}
+	return &dto.AssetResponseInRequestTransfer{
+		Id:           asset.Id,
+		AssetName:    asset.AssetName,
+		Cost:         asset.Cost,
+		DepartmentId: asset.DepartmentId,
+	}
+}
+
+func ConvertRequestTransferApprovalsToResponses(approvals []entity.RequestTransferApprovals) []dto.RequestTransferApprovalResponse {
+	res := make([]dto.RequestTransferApprovalResponse, 0, len(approvals))
+	for _, approval := range approvals {
+		item := dto.RequestTransferApprovalResponse{
+			StepOrder:    approval.StepOrder,
+			ApproverType: approval.ApproverType,
+			MinAssetCost: approval.MinAssetCost,
+			Decision:     approval.Decision,
+			Comment:      approval.Comment,
+			DecidedAt:    approval.DecidedAt,
+		}
+		if approval.Approver != nil {
+			item.Approver = &dto.UserResponseInRequestTransfer{
+				Id:           approval.Approver.Id,
+				FirstName:    approval.Approver.FirstName,
+				LastName:     approval.Approver.LastName,
+				Email:        approval.Approver.Email,
+				DepartmentId: derefInt64(approval.Approver.DepartmentId),
+			}
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func ConvertTransferApprovalStepsToResponses(steps []*entity.TransferApprovalSteps) []dto.TransferApprovalStepResponse {
+	res := make([]dto.TransferApprovalStepResponse, 0, len(steps))
+	for _, step := range steps {
+		res = append(res, dto.TransferApprovalStepResponse{
+			StepOrder:    step.StepOrder,
+			ApproverType: step.ApproverType,
+			MinAssetCost: step.MinAssetCost,
+		})
+	}
+	return res
+}
+
 func ConvertRequestTransfersToResponses(rts []entity.RequestTransfer) []dto.RequestTransferResponse {
 	res := make([]dto.RequestTransferResponse, 0, len(rts))
 	for _, rt := range rts {
@@ -82,8 +140,8 @@ func ConvertAssignmentToResponse(assignment *entity.Assignments) dto.AssignmentR
 			Id:             assignment.Asset.Id,
 			AssetName:      assignment.Asset.AssetName,
 			Status:         assignment.Asset.Status,
-			FileAttachment: derefString(assignment.Asset.FileAttachment),
-			ImageUpload:    derefString(assignment.Asset.ImageUpload),
+			FileAttachment: storage.FileURL(derefString(assignment.Asset.FileAttachment)),
+			ImageUpload:    storage.FileURL(derefString(assignment.Asset.ImageUpload)),
 		},
 		Department: dto.DepartmentResponse{
 			ID:             derefInt64(assignment.DepartmentId),
@@ -119,20 +177,63 @@ func ConvertAssignmentsToResponses(assignments []entity.Assignments) []dto.Assig
 }
 
 func ConvertMaintenanceSchedulesToResponses(maintenanceSchedules *entity.MaintenanceSchedules) dto.MaintenanceSchedulesResponse {
+	var recurrence *dto.MaintenanceRecurrenceResponse
+	if maintenanceSchedules.IsRecurring() {
+		recurrence = &dto.MaintenanceRecurrenceResponse{
+			Frequency:  *maintenanceSchedules.Frequency,
+			Interval:   maintenanceSchedules.RepeatInterval,
+			ByWeekdays: []string{},
+			Count:      maintenanceSchedules.RepeatCount,
+		}
+		if maintenanceSchedules.ByWeekdays != "" {
+			recurrence.ByWeekdays = strings.Split(maintenanceSchedules.ByWeekdays, ",")
+		}
+		if maintenanceSchedules.RepeatUntil != nil {
+			until := maintenanceSchedules.RepeatUntil.Format("2006-01-02")
+			recurrence.Until = &until
+		}
+	}
+	var nextOccurrence *dto.MaintenanceOccurrenceResponse
+	// Series quá nhiều lần lặp thì bỏ trống lần tiếp theo, lỗi được trả về ở các API liệt kê lần lặp
+	if occ, ok, err := maintenanceSchedules.NextOccurrence(time.Now()); err == nil && ok {
+		res := ConvertMaintenanceOccurrenceToResponse(*occ)
+		nextOccurrence = &res
+	}
 	return dto.MaintenanceSchedulesResponse{
-		Id:        maintenanceSchedules.Id,
-		StartDate: maintenanceSchedules.StartDate.Format("2006-01-02"),
-		EndDate:   maintenanceSchedules.EndDate.Format("2006-01-02"),
+		Id:             maintenanceSchedules.Id,
+		StartDate:      maintenanceSchedules.StartDate.Format("2006-01-02"),
+		EndDate:        maintenanceSchedules.EndDate.Format("2006-01-02"),
+		Recurrence:     recurrence,
+		ParentId:       maintenanceSchedules.ParentId,
+		VendorId:       maintenanceSchedules.VendorId,
+		NextOccurrence: nextOccurrence,
 		Asset: dto.AssetResponseInMaintenanceSchedules{
 			Id:             maintenanceSchedules.AssetId,
 			AssetName:      maintenanceSchedules.Asset.AssetName,
 			Status:         maintenanceSchedules.Asset.Status,
-			FileAttachment: derefString(maintenanceSchedules.Asset.FileAttachment),
-			ImageUpload:    derefString(maintenanceSchedules.Asset.ImageUpload),
+			FileAttachment: storage.FileURL(derefString(maintenanceSchedules.Asset.FileAttachment)),
+			ImageUpload:    storage.FileURL(derefString(maintenanceSchedules.Asset.ImageUpload)),
 		},
 	}
 }
 
+func ConvertMaintenanceOccurrenceToResponse(occurrence entity.MaintenanceOccurrence) dto.MaintenanceOccurrenceResponse {
+	return dto.MaintenanceOccurrenceResponse{
+		ScheduleId:      occurrence.ScheduleId,
+		OccurrenceStart: occurrence.OccurrenceStart,
+		Start:           occurrence.Start,
+		End:             occurrence.End,
+	}
+}
+
+func ConvertMaintenanceOccurrencesToResponses(occurrences []entity.MaintenanceOccurrence) []dto.MaintenanceOccurrenceResponse {
+	res := make([]dto.MaintenanceOccurrenceResponse, 0, len(occurrences))
+	for _, occ := range occurrences {
+		res = append(res, ConvertMaintenanceOccurrenceToResponse(occ))
+	}
+	return res
+}
+
 func ConvertMaintenanceSchedulesToResponsesArray(maintenanceSchedules []*entity.MaintenanceSchedules) []dto.MaintenanceSchedulesResponse {
 	res := make([]dto.MaintenanceSchedulesResponse, 0, len(maintenanceSchedules))
 	for _, as := range maintenanceSchedules {
@@ -144,11 +245,44 @@ func ConvertMaintenanceSchedulesToResponsesArray(maintenanceSchedules []*entity.
 func ConvertRoleToResponse(role *entity.Roles) dto.RoleResponse {
 	return dto.RoleResponse{
 		Id:          role.Id,
+		Title:       role.Title,
 		Slug:        role.Slug,
 		Description: role.Description,
+		Activated:   role.Activated,
+		IsSystem:    role.IsSystem(),
 	}
 }
 
+func ConvertRoleToDetailResponse(role *entity.Roles, userCount int64) dto.RoleDetailResponse {
+	permissions := make([]dto.RolePermissionResponse, 0, len(role.RolePermissions))
+	for _, rp := range role.RolePermissions {
+		permissions = append(permissions, dto.RolePermissionResponse{
+			PermissionId: rp.PermissionId,
+			Slug:         rp.Permission.Slug,
+			Title:        rp.Permission.Title,
+			AccessLevel:  rp.AccessLevel,
+		})
+	}
+	return dto.RoleDetailResponse{
+		RoleResponse: ConvertRoleToResponse(role),
+		UserCount:    userCount,
+		Permissions:  permissions,
+	}
+}
+
+func ConvertPermissionsToResponses(permissions []*entity.Permission) []dto.PermissionResponse {
+	res := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, p := range permissions {
+		res = append(res, dto.PermissionResponse{
+			Id:          p.Id,
+			Title:       p.Title,
+			Slug:        p.Slug,
+			Description: p.Description,
+		})
+	}
+	return res
+}
+
 func ConvertRolesToResponsesArray(role []*entity.Roles) []dto.RoleResponse {
 	res := make([]dto.RoleResponse, 0, len(role))
 	for _, as := range role {
@@ -179,7 +313,7 @@ func ConvertUsersToNotificationsToMap(userId int64, usersToNotifications []*enti
 func ConvertAssetToResponse(asset entity.Assets) dto.AssetResponse {
 	var qrURL string
 	if asset.QrUrl != nil {
-		qrURL = *asset.QrUrl
+		qrURL = storage.FileURL(*asset.QrUrl)
 	}
 	assetResponse := dto.AssetResponse{
 		ID:             asset.Id,
@@ -189,9 +323,10 @@ func ConvertAssetToResponse(asset entity.Assets) dto.AssetResponse {
 		WarrantExpiry:  asset.WarrantExpiry.Format("2006-01-02"),
 		Status:         asset.Status,
 		SerialNumber:   asset.SerialNumber,
-		FileAttachment: *asset.FileAttachment,
-		ImageUpload:    *asset.ImageUpload,
+		FileAttachment: storage.FileURL(*asset.FileAttachment),
+		ImageUpload:    storage.FileURL(*asset.ImageUpload),
 		QrURL:          qrURL,
+		VendorId:       asset.VendorId,
 		Category: dto.CategoryResponse{
 			ID:           asset.Category.Id,
 			CategoryName: asset.Category.CategoryName,
@@ -220,12 +355,12 @@ func ConvertBillToResponse(bill *entity.Bill) dto.BillResponse {
 	var fileAttachmentBill string
 	var imageUploadBill string
 	if bill.FileAttachmentBill != nil {
-		fileAttachmentBill = *bill.FileAttachmentBill
+		fileAttachmentBill = storage.FileURL(*bill.FileAttachmentBill)
 	} else {
 		fileAttachmentBill = ""
 	}
 	if bill.ImageUploadBill != nil {
-		imageUploadBill = *bill.ImageUploadBill
+		imageUploadBill = storage.FileURL(*bill.ImageUploadBill)
 	} else {
 		imageUploadBill = ""
 	}
@@ -235,11 +370,68 @@ func ConvertBillToResponse(bill *entity.Bill) dto.BillResponse {
 		CreateAt:           bill.CreateAt,
 		Asset:              ConvertBillToAssetsResponse(bill),
 		CreateBy:           ConvertUserToUserResponse(&bill.CreateBy),
-		StatusBill:         bill.StatusBill,
+		StatusBill:         bill.DeriveStatus(time.Now()),
 		FileAttachmentBill: fileAttachmentBill,
 		ImageUploadBill:    imageUploadBill,
 		Buyer:              ConvertBillToBuyerResponse(bill),
+		VendorId:           bill.VendorId,
+		Currency:           bill.Currency,
+		LineItems:          ConvertBillToLineItemsResponse(bill),
+		Subtotal:           bill.Subtotal,
+		DiscountTotal:      bill.DiscountTotal,
+		TaxTotal:           bill.TaxTotal,
+		GrandTotal:         bill.GrandTotal,
+		PaidAmount:         bill.PaidAmount,
+		Outstanding:        bill.Outstanding(),
+		DueDate:            bill.DueDate,
+		Payments:           ConvertBillPaymentsToResponses(bill.Payments),
+	}
+}
+
+func ConvertBillPaymentsToResponses(payments []entity.BillPayments) []dto.BillPaymentResponse {
+	res := make([]dto.BillPaymentResponse, 0, len(payments))
+	for i := range payments {
+		res = append(res, ConvertBillPaymentToResponse(&payments[i]))
+	}
+	return res
+}
+
+func ConvertBillPaymentToResponse(payment *entity.BillPayments) dto.BillPaymentResponse {
+	res := dto.BillPaymentResponse{
+		Id:        payment.Id,
+		Amount:    payment.Amount,
+		Method:    payment.Method,
+		Reference: payment.Reference,
+		PayerName: payment.PayerName,
+		PaidAt:    payment.PaidAt,
+	}
+	if payment.RecordedBy.Id != 0 {
+		res.RecordedBy = convertUserToAssignmentUser(&payment.RecordedBy)
 	}
+	return res
+}
+
+func ConvertBillToLineItemsResponse(bill *entity.Bill) []dto.BillLineItemResponse {
+	res := make([]dto.BillLineItemResponse, 0, len(bill.LineItems))
+	for _, item := range bill.LineItems {
+		line := dto.BillLineItemResponse{
+			Id:           item.Id,
+			AssetId:      item.AssetId,
+			Description:  item.Description,
+			Quantity:     item.Quantity,
+			UnitPrice:    item.UnitPrice,
+			TaxRate:      item.TaxRate,
+			Discount:     item.Discount,
+			LineSubtotal: item.LineSubtotal,
+			LineTax:      item.LineTax,
+			LineTotal:    item.LineTotal,
+		}
+		if item.Asset != nil {
+			line.AssetName = item.Asset.AssetName
+		}
+		res = append(res, line)
+	}
+	return res
 }
 
 func ConvertBillToBuyerResponse(bill *entity.Bill) dto.BuyerResponse {
@@ -268,3 +460,107 @@ func ConvertBillsToResponsesArray(bills []*entity.Bill) []dto.BillResponse {
 	}
 	return res
 }
+
+func convertUserToAssignmentUser(user *entity.Users) *dto.UsersAssignmentResponse {
+	if user == nil {
+		return nil
+	}
+	return &dto.UsersAssignmentResponse{
+		Id:        user.Id,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+	}
+}
+
+func ConvertAssetLoanToResponse(loan *entity.AssetLoans) dto.AssetLoanResponse {
+	res := dto.AssetLoanResponse{
+		Id: loan.Id,
+		Asset: dto.UserAssignmentAssetResponse{
+			Id:             loan.Asset.Id,
+			AssetName:      loan.Asset.AssetName,
+			Status:         loan.Asset.Status,
+			FileAttachment: storage.FileURL(derefString(loan.Asset.FileAttachment)),
+			ImageUpload:    storage.FileURL(derefString(loan.Asset.ImageUpload)),
+		},
+		Borrower:          *convertUserToAssignmentUser(&loan.Borrower),
+		PreviousOwner:     convertUserToAssignmentUser(loan.PreviousOwner),
+		CheckedOutBy:      *convertUserToAssignmentUser(&loan.CheckedOutBy),
+		CheckedInBy:       convertUserToAssignmentUser(loan.CheckedInBy),
+		CheckOutDate:      loan.CheckOutDate.Format(time.RFC3339),
+		DueDate:           loan.DueDate.Format(time.RFC3339),
+		CheckOutCondition: loan.CheckOutCondition,
+		CheckInCondition:  loan.CheckInCondition,
+		Note:              loan.Note,
+		Status:            loan.Status,
+		Overdue:           loan.Status == "Checked Out" && loan.DueDate.Before(time.Now()),
+	}
+	if loan.CheckInDate != nil {
+		checkInDate := loan.CheckInDate.Format(time.RFC3339)
+		res.CheckInDate = &checkInDate
+	}
+	return res
+}
+
+func ConvertAssetLoansToResponses(loans []*entity.AssetLoans) []dto.AssetLoanResponse {
+	res := []dto.AssetLoanResponse{}
+	for _, loan := range loans {
+		res = append(res, ConvertAssetLoanToResponse(loan))
+	}
+	return res
+}
+
+func ConvertAssetGrantsToResponses(grants []*entity.AssetPermissionGrants) []dto.AssetGrantResponse {
+	res := make([]dto.AssetGrantResponse, 0, len(grants))
+	for _, g := range grants {
+		grant := dto.AssetGrantResponse{
+			Id:             g.Id,
+			AssetId:        g.AssetId,
+			PermissionSlug: g.PermissionSlug,
+			GrantedBy:      g.GrantedBy,
+			CreatedAt:      g.CreatedAt,
+		}
+		if g.User != nil {
+			grant.User = dto.OwnerResponse{
+				ID:        g.User.Id,
+				FirstName: g.User.FirstName,
+				LastName:  g.User.LastName,
+				Email:     g.User.Email,
+			}
+		}
+		res = append(res, grant)
+	}
+	return res
+}
+
+func ConvertApiTokenToResponse(t *entity.ApiTokens) dto.ApiTokenResponse {
+	res := dto.ApiTokenResponse{
+		Id:         t.Id,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.Scopes,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		LastUsedIp: t.LastUsedIp,
+		CreatedAt:  t.CreatedAt,
+		RevokedAt:  t.RevokedAt,
+		Active:     t.IsActive(time.Now()),
+	}
+	if t.User != nil {
+		res.User = &dto.OwnerResponse{
+			ID:        t.User.Id,
+			FirstName: t.User.FirstName,
+			LastName:  t.User.LastName,
+			Email:     t.User.Email,
+		}
+	}
+	return res
+}
+
+func ConvertApiTokensToResponses(tokens []*entity.ApiTokens) []dto.ApiTokenResponse {
+	res := make([]dto.ApiTokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, ConvertApiTokenToResponse(t))
+	}
+	return res
+}
@@ -1,26 +1,23 @@
 package utils
 
 import (
-	"BE_Manage_device/config"
 	"BE_Manage_device/internal/domain/entity"
 	"strconv"
 
 	"BE_Manage_device/pkg/interfaces"
+	"BE_Manage_device/pkg/storage"
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/http"
 	"strings"
-	"sync"
 	"time"
 
+	assetLoan "BE_Manage_device/internal/repository/asset_loans"
 	asset_log "BE_Manage_device/internal/repository/asset_log"
 	repository "BE_Manage_device/internal/repository/asset_log"
 	asset "BE_Manage_device/internal/repository/assets"
 	user "BE_Manage_device/internal/repository/user"
+	emailS "BE_Manage_device/internal/service/email"
 
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
@@ -28,140 +25,7 @@ import (
 	"gorm.io/gorm"
 )
 
-type SupabaseUploader struct {
-	ProjectRef string
-	ApiKey     string
-	Bucket     string
-}
-
-func NewSupabaseUploader() *SupabaseUploader {
-	return &SupabaseUploader{
-		ProjectRef: config.SUPABASE_PROJECT_REF, // hoặc gán thẳng chuỗi
-		ApiKey:     config.SupabaseKey,          // hoặc gán thẳng
-		Bucket:     "images",                    // Tên bucket
-	}
-}
-
-func (s *SupabaseUploader) Upload(objectPath string, file multipart.File, contentType string) (string, error) {
-	defer file.Close()
-
-	// Tạo buffer để đọc toàn bộ file
-	var buf bytes.Buffer
-	_, err := io.Copy(&buf, file)
-	if err != nil {
-		return "", err
-	}
-
-	// Gửi request PUT (Upload file)
-	url := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/%s/%s", s.ProjectRef, s.Bucket, objectPath)
-
-	req, err := http.NewRequest("POST", url, &buf)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Content-Length", fmt.Sprint(buf.Len()))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed: %s", string(body))
-	}
-
-	// Trả về URL public nếu bucket public
-	publicURL := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/public/%s/%s", s.ProjectRef, s.Bucket, objectPath)
-	return publicURL, nil
-}
-
-func (s *SupabaseUploader) UploadReader(objectPath string, reader io.Reader, contentType string) (string, error) {
-	// Đọc toàn bộ nội dung từ reader vào buffer
-	var buf bytes.Buffer
-	_, err := io.Copy(&buf, reader)
-	if err != nil {
-		return "", fmt.Errorf("failed to read content: %w", err)
-	}
-
-	// Tạo URL Supabase Storage
-	url := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/%s/%s", s.ProjectRef, s.Bucket, objectPath)
-
-	// Tạo request POST (upload)
-	req, err := http.NewRequest("POST", url, &buf)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Content-Length", fmt.Sprint(buf.Len()))
-
-	// Gửi request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to upload: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Kiểm tra trạng thái phản hồi
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed: %s", string(body))
-	}
-
-	// Trả về URL public (nếu bucket là public)
-	publicURL := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/public/%s/%s", s.ProjectRef, s.Bucket, objectPath)
-	return publicURL, nil
-}
-
-func (s *SupabaseUploader) Delete(objectPath string) error {
-	// Chuẩn bị body (Supabase yêu cầu mảng path)
-	paths := []string{objectPath}
-	body, err := json.Marshal(map[string]interface{}{
-		"prefixes": paths,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %w", err)
-	}
-
-	url := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/%s", s.ProjectRef, paths[0])
-
-	req, err := http.NewRequest("DELETE", url, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+s.ApiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to call supabase: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete failed: %s", string(b))
-	}
-
-	return nil
-}
-
-func ExtractFilePath(url string) (string, bool) {
-	sep := "/public/"
-	idx := strings.Index(url, sep)
-	if idx != -1 {
-		return url[idx+len(sep):], true
-	}
-	return "", false
-}
-
-func GenerateAssetQR(assetID int64, urlFrontend string) (string, error) {
+func GenerateAssetQR(store storage.Storage, assetID int64, urlFrontend string) (string, error) {
 	url := fmt.Sprintf("%s/%d", urlFrontend, assetID)
 	png, err := qrcode.Encode(url, qrcode.Medium, 256)
 	if err != nil {
@@ -173,8 +37,7 @@ func GenerateAssetQR(assetID int64, urlFrontend string) (string, error) {
 	// Tạo reader để upload
 	reader := bytes.NewReader(png)
 	contentType := "image/png"
-	uploader := NewSupabaseUploader()
-	qrURL, err := uploader.UploadReader(path, reader, contentType)
+	qrURL, err := store.UploadReader(path, reader, contentType)
 	if err != nil {
 		return "", fmt.Errorf("upload failed: %w", err)
 	}
@@ -182,8 +45,8 @@ func GenerateAssetQR(assetID int64, urlFrontend string) (string, error) {
 	return qrURL, nil
 }
 
-func GenQrAndUpdate(repo asset.AssetsRepository, assetId int64, url string) {
-	qrUrl, err := GenerateAssetQR(assetId, url)
+func GenQrAndUpdate(repo asset.AssetsRepository, store storage.Storage, assetId int64, url string) {
+	qrUrl, err := GenerateAssetQR(store, assetId, url)
 	if err != nil {
 		logrus.Info("Error when create qrurl")
 		return
@@ -194,35 +57,54 @@ func GenQrAndUpdate(repo asset.AssetsRepository, assetId int64, url string) {
 	}
 }
 
-type notificationJob struct {
-	Emails  []string
-	Subject string
-	Body    string
-}
-
-func CheckAndSenMaintenanceNotification(db *gorm.DB, emailNotifier interfaces.EmailNotifier, assetRepo asset.AssetsRepository, userRepo user.UserRepository, notification interfaces.Notification, assetLogRepo repository.AssetsLogRepository) {
+func CheckAndSenMaintenanceNotification(db *gorm.DB, assetRepo asset.AssetsRepository, userRepo user.UserRepository, notification interfaces.Notification, assetLogRepo repository.AssetsLogRepository) {
 	loc, _ := time.LoadLocation("Asia/Bangkok")
 
 	now := time.Now().In(loc)
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	endOfDay := startOfDay.Add(24 * time.Hour)
 	var schedules []entity.MaintenanceSchedules
-	err := db.Where("start_date <= ? and start_date >= ?", endOfDay, startOfDay).Preload("Asset").Preload("Asset.OnwerUser").Find(&schedules).Error
+	err := db.Where("start_date <= ?", endOfDay).
+		Where("(frequency IS NULL AND start_date >= ?) OR (frequency IS NOT NULL AND (repeat_until IS NULL OR repeat_until >= ?))", startOfDay, startOfDay).
+		Preload("Asset").Preload("Asset.OnwerUser").Preload("Exceptions").Find(&schedules).Error
 	if err != nil {
 		log.Printf("Error fetching maintenance schedules: %v", err)
 		return
 	}
-	var jobs []notificationJob
+	// Mỗi lần lặp của lịch là 1 cửa sổ bảo trì riêng, chỉ lấy các lần bắt đầu trong hôm nay
+	type dueOccurrence struct {
+		schedule   entity.MaintenanceSchedules
+		occurrence entity.MaintenanceOccurrence
+	}
+	var dueOccurrences []dueOccurrence
 	for _, s := range schedules {
-		// Check nếu đã thông báo rồi
+		occurrences, err := s.Occurrences(startOfDay, endOfDay)
+		if err != nil {
+			log.Printf("❌ Error expanding maintenance schedule %d: %v", s.Id, err)
+			continue
+		}
+		for _, occ := range occurrences {
+			if !occ.Start.Before(startOfDay) {
+				dueOccurrences = append(dueOccurrences, dueOccurrence{schedule: s, occurrence: occ})
+			}
+		}
+	}
+	for _, d := range dueOccurrences {
+		s, occ := d.schedule, d.occurrence
+		// Check nếu đã thông báo rồi, bản ghi cũ của lịch 1 lần không có occurrence_start
 		var noti entity.MaintenanceNotifications
-		err := db.Where("schedule_id = ?", s.Id).First(&noti).Error
+		notiQuery := db.Where("schedule_id = ?", s.Id)
+		if s.IsRecurring() {
+			notiQuery = notiQuery.Where("occurrence_start = ?", occ.OccurrenceStart)
+		} else {
+			notiQuery = notiQuery.Where("occurrence_start = ? OR occurrence_start IS NULL", occ.OccurrenceStart)
+		}
+		err := notiQuery.First(&noti).Error
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("✅ Already notified for schedule ID %d today", s.Id)
+			log.Printf("✅ Already notified for schedule ID %d occurrence %v", s.Id, occ.OccurrenceStart)
 			continue
 		}
-		var job notificationJob
-		// Nếu chưa có thông báo thì tiến hành
+		// Nếu chưa có thông báo thì tiến hành, email và thông báo được ghi vào outbox cùng transaction
 		err = db.Transaction(func(tx *gorm.DB) error {
 			// 1. Lấy user nhận email
 			var userManagerAsset *entity.Users
@@ -241,36 +123,8 @@ func CheckAndSenMaintenanceNotification(db *gorm.DB, emailNotifier interfaces.Em
 				return fmt.Errorf("error fetching asset: %w", err)
 			}
 
-			// 3. Chuẩn bị email
-			var emails []string
-			for _, u := range users {
-				emails = append(emails, u.Email)
-			}
-			subject := fmt.Sprintf("Asset %s is scheduled for maintenance on %s", asset.AssetName, s.StartDate.Format("Jan 2, 2006"))
-			body := fmt.Sprintf(`
-			<html>
-				<body>
-					<p>Dear team,</p>
-					<p>Please be informed that the following asset is scheduled for maintenance:</p>
-					<table border="1" cellpadding="6" cellspacing="0" style="border-collapse: collapse;">
-						<tr>
-							<th align="left">Asset</th>
-							<td>%s</td>
-						</tr>
-						<tr>
-							<th align="left">Start Date</th>
-							<td>%s</td>
-						</tr>
-						<tr>
-							<th align="left">End Date</th>
-							<td>%s</td>
-						</tr>
-					</table>
-					<p>Kindly plan accordingly.</p>
-					<p>Best regards,<br>Your Maintenance Team</p>
-				</body>
-			</html>
-		`, asset.AssetName, s.StartDate.Format("Jan 2, 2006"), s.EndDate.Format("Jan 2, 2006"))
+			// 3. Chuẩn bị email, ai nhận email do cài đặt thông báo của từng user quyết định
+			emailData := map[string]interface{}{"AssetName": asset.AssetName, "Start": occ.Start, "End": occ.End}
 
 			// 5. Cập nhật lifecycle
 			if _, err := assetRepo.UpdateAssetLifeCycleStage(asset.Id, "Under Maintenance", tx); err != nil {
@@ -279,8 +133,9 @@ func CheckAndSenMaintenanceNotification(db *gorm.DB, emailNotifier interfaces.Em
 
 			// 6. Ghi log notification
 			notify := entity.MaintenanceNotifications{
-				ScheduleId: s.Id,
-				NotifyDate: time.Now(),
+				ScheduleId:      s.Id,
+				OccurrenceStart: &occ.OccurrenceStart,
+				NotifyDate:      time.Now(),
 			}
 			if err := tx.Create(&notify).Error; err != nil {
 				return fmt.Errorf("error inserting notification: %w", err)
@@ -290,50 +145,22 @@ func CheckAndSenMaintenanceNotification(db *gorm.DB, emailNotifier interfaces.Em
 				ChangeSummary: fmt.Sprintf("Asset %d has started maintenance", asset.Id),
 				Timestamp:     time.Now(),
 				Action:        "Maintenance",
+				Event:         entity.WebhookEventAssetMaintenanceStarted,
 				CompanyId:     asset.CompanyId,
 			}
 			if _, err := assetLogRepo.Create(&assetLog, tx); err != nil {
 				return fmt.Errorf("error create asset log: %w", err)
 			}
-			job.Emails = emails
-			job.Subject = subject
-			job.Body = body
 			message := fmt.Sprintf("The asset (ID: %v) moved to 'Under Maintenance'", s.AssetId)
-			go func() {
-				defer func() {
-					if r := recover(); r != nil {
-						fmt.Println("SendNotificationToUsers panic:", r)
-					}
-				}()
-				notification.SendNotificationToUsers(users, message, s.Asset)
-			}()
+			if err := notification.NotifyUsersWithEmail(tx, users, entity.NotificationEventMaintenanceStarted, message, emailS.TemplateMaintenance, emailData, *asset); err != nil {
+				return fmt.Errorf("error enqueue notifications: %w", err)
+			}
 			return nil
 		})
-		if len(job.Emails) > 0 {
-			jobs = append(jobs, job)
-		}
 		if err != nil {
 			log.Printf("❌ Transaction failed for schedule %d: %v", s.Id, err)
 		}
 	}
-	const workerCount = 10
-	jobsQueue := make(chan notificationJob, len(jobs))
-	var wg sync.WaitGroup
-
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for job := range jobsQueue {
-				emailNotifier.SendEmails(job.Emails, job.Subject, job.Body)
-			}
-		}()
-	}
-	for _, job := range jobs {
-		jobsQueue <- job
-	}
-	close(jobsQueue)
-	wg.Wait()
 }
 
 func UpdateStatusWhenFinishMaintenance(db *gorm.DB, assetRepo asset.AssetsRepository, userRepo user.UserRepository, notification interfaces.Notification, assetLogRepo asset_log.AssetsLogRepository) {
@@ -350,11 +177,10 @@ func UpdateStatusWhenFinishMaintenance(db *gorm.DB, assetRepo asset.AssetsReposi
 		}
 
 		if finished {
-			_, err := assetRepo.UpdateAssetLifeCycleStage(a.Id, "In Use", db)
-			if err != nil {
-				log.Printf("❌ Error updating asset %d to 'In Use': %v", a.Id, err)
-			} else {
-				log.Printf("✅ Asset %d moved to 'In Use'", a.Id)
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if _, err := assetRepo.UpdateAssetLifeCycleStage(a.Id, "In Use", tx); err != nil {
+					return fmt.Errorf("error updating asset stage: %w", err)
+				}
 				userManagerAsset, _ := userRepo.GetUserAssetManageOfDepartment(a.DepartmentId)
 				usersToNotifications := []*entity.Users{}
 				if a.OnwerUser != nil {
@@ -364,38 +190,38 @@ func UpdateStatusWhenFinishMaintenance(db *gorm.DB, assetRepo asset.AssetsReposi
 					usersToNotifications = append(usersToNotifications, userManagerAsset)
 				}
 				message := fmt.Sprintf("The asset (ID: %v) moved to 'In Use'", a.Id)
-				go func() {
-					defer func() {
-						if r := recover(); r != nil {
-							fmt.Println("SendNotificationToUsers panic:", r)
-						}
-					}()
-					notification.SendNotificationToUsers(usersToNotifications, message, *a)
-				}()
-			}
-			assetLog := entity.AssetLog{
-				AssetId:       a.Id,
-				ChangeSummary: fmt.Sprintf("Asset %d has started maintenance", a.Id),
-				Timestamp:     time.Now(),
-				Action:        "Maintenance",
-				CompanyId:     a.CompanyId,
-			}
-			if _, err := assetLogRepo.Create(&assetLog, assetLogRepo.GetDB()); err != nil {
-				log.Printf("❌ Error create asset log ")
+				if err := notification.NotifyUsers(tx, usersToNotifications, entity.NotificationEventMaintenanceFinished, message, *a); err != nil {
+					return fmt.Errorf("error enqueue notifications: %w", err)
+				}
+				assetLog := entity.AssetLog{
+					AssetId:       a.Id,
+					ChangeSummary: fmt.Sprintf("Asset %d has started maintenance", a.Id),
+					Timestamp:     time.Now(),
+					Action:        "Maintenance",
+					Event:         entity.WebhookEventAssetUpdated,
+					CompanyId:     a.CompanyId,
+				}
+				if _, err := assetLogRepo.Create(&assetLog, tx); err != nil {
+					return fmt.Errorf("error create asset log: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				log.Printf("❌ Error updating asset %d to 'In Use': %v", a.Id, err)
+			} else {
+				log.Printf("✅ Asset %d moved to 'In Use'", a.Id)
 			}
 		}
 	}
 }
 
-func SendEmailsForWarrantyExpiry(db *gorm.DB, emailNotifier interfaces.EmailNotifier, notification interfaces.Notification, assetRepo asset.AssetsRepository, userRepo user.UserRepository) {
+func SendEmailsForWarrantyExpiry(db *gorm.DB, notification interfaces.Notification, assetRepo asset.AssetsRepository, userRepo user.UserRepository) {
 	assets, err := assetRepo.GetAssetsWasWarrantyExpiry()
 	if err != nil {
 		log.Printf("❌ Error fetching assets : %v", err)
 		return
 	}
-	var jobs []notificationJob
 	for _, a := range assets {
-		var job notificationJob
 		var userHeadDepart *entity.Users
 		var userManagerAsset *entity.Users
 		userManagerAsset, _ = userRepo.GetUserAssetManageOfDepartment(a.DepartmentId)
@@ -406,31 +232,7 @@ func SendEmailsForWarrantyExpiry(db *gorm.DB, emailNotifier interfaces.EmailNoti
 			log.Printf("⚠️ No users with notification permission for asset ID %d", a.Id)
 			continue
 		}
-		var emails []string
-		for _, u := range users {
-			emails = append(emails, u.Email)
-		}
-		subject := fmt.Sprintf("Asset %s is expired on %s", a.AssetName, a.WarrantExpiry.Format("Jan 2, 2006"))
-		body := fmt.Sprintf(`
-			<html>
-				<body>
-					<p>Dear team,</p>
-					<p>Please be informed that the following asset is expired:</p>
-					<table border="1" cellpadding="6" cellspacing="0" style="border-collapse: collapse;">
-						<tr>
-							<th align="left">Asset</th>
-							<td>%s</td>
-						</tr>
-						<tr>
-							<th align="left">Expiry Date</th>
-							<td>%s</td>
-						</tr>
-					</table>
-					<p>Kindly plan accordingly.</p>
-					<p>Best regards,<br>Your Manager Asset Team</p>
-				</body>
-			</html>
-		`, a.AssetName, a.WarrantExpiry.Format("Jan 2, 2006"))
+		emailData := map[string]interface{}{"AssetName": a.AssetName, "ExpiryDate": a.WarrantExpiry}
 		now := time.Now()
 		typ := "Expired"
 		assetId := a.Id
@@ -442,45 +244,52 @@ func SendEmailsForWarrantyExpiry(db *gorm.DB, emailNotifier interfaces.EmailNoti
 			AssetId:    &assetId,
 			Status:     &status,
 		}
-		result := db.Create(&notify)
-		if result.Error != nil {
-			log.Infof("Happen error when create notify type %v AssetId %v", typ, assetId)
-		}
-		job.Emails = emails
-		job.Subject = subject
-		job.Body = body
-		if len(emails) > 0 {
-			jobs = append(jobs, job)
-		}
-
 		message := fmt.Sprintf("The asset (ID: %v) has just been Expired", a.Id)
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					fmt.Println("SendNotificationToUsers panic:", r)
-				}
-			}()
-			notification.SendNotificationToUsers(users, message, *a)
-		}()
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&notify).Error; err != nil {
+				return fmt.Errorf("error create notify type %v: %w", typ, err)
+			}
+			return notification.NotifyUsersWithEmail(tx, users, entity.NotificationEventWarrantyExpired, message, emailS.TemplateWarranty, emailData, *a)
+		})
+		if err != nil {
+			log.Printf("❌ Transaction failed for warranty expiry of asset %d: %v", a.Id, err)
+		}
 	}
-	const workerCount = 10
-	jobsQueue := make(chan notificationJob, len(jobs))
-	var wg sync.WaitGroup
+}
 
-	for i := 0; i < workerCount; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for job := range jobsQueue {
-				emailNotifier.SendEmails(job.Emails, job.Subject, job.Body)
-			}
-		}()
+func SendOverdueLoanNotifications(notification interfaces.Notification, loanRepo assetLoan.AssetLoansRepository, userRepo user.UserRepository) {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(loc)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	loans, err := loanRepo.GetOverdueLoans(now)
+	if err != nil {
+		log.Printf("❌ Error fetching overdue loans: %v", err)
+		return
 	}
-	for _, job := range jobs {
-		jobsQueue <- job
+	for _, l := range loans {
+		// Mỗi ngày chỉ nhắc 1 lần
+		if l.LastOverdueNotifiedAt != nil && !l.LastOverdueNotifiedAt.Before(startOfDay) {
+			continue
+		}
+		userManagerAsset, _ := userRepo.GetUserAssetManageOfDepartment(l.Asset.DepartmentId)
+		users := []*entity.Users{&l.Borrower, userManagerAsset}
+		daysOverdue := int(now.Sub(l.DueDate).Hours() / 24)
+		emailData := map[string]interface{}{"AssetName": l.Asset.AssetName, "Borrower": l.Borrower.Email, "DueDate": l.DueDate, "DaysOverdue": daysOverdue}
+		message := fmt.Sprintf("The asset '%v' (ID: %v) borrowed by %v is overdue since %v", l.Asset.AssetName, l.AssetId, l.Borrower.Email, l.DueDate.Format("2006-01-02"))
+		err := loanRepo.GetDB().Transaction(func(tx *gorm.DB) error {
+			// Chỉ đánh dấu đã nhắc khi gửi thành công, lỗi thì lần chạy sau nhắc lại
+			if err := notification.NotifyUsersWithEmail(tx, users, entity.NotificationEventLoanOverdue, message, emailS.TemplateLoanOverdue, emailData, l.Asset); err != nil {
+				return err
+			}
+			if err := loanRepo.UpdateLastOverdueNotifiedAt(l.Id, now, tx); err != nil {
+				return fmt.Errorf("error update overdue notified time: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("❌ Transaction failed for overdue loan %d: %v", l.Id, err)
+		}
 	}
-	close(jobsQueue)
-	wg.Wait()
 }
 
 func PtrInt64(i int64) *int64 {
@@ -507,6 +316,18 @@ func ParseStrToInt64(s string) (int64, error) {
 	return i, err
 }
 
+// ParseOptionalInt64 đọc id không bắt buộc từ form, chuỗi rỗng trả về nil
+func ParseOptionalInt64(s string) (*int64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
 func ParseStrToBool(s string) (bool, error) {
 	i, err := strconv.ParseBool(s)
 	return i, err
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"BE_Manage_device/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	totpPeriod            = 30
+	mfaChallengePurpose   = "mfa_challenge"
+	mfaChallengeExpiry    = 5 * time.Minute
+	mfaRecoveryCodeCount  = 10
+	mfaRecoveryCodeLength = 10
+)
+
+var ErrMfaNotConfigured = errors.New("mfa is not configured on this server")
+
+// GenerateTotpKey tạo secret mới và URI otpauth:// để app authenticator quét
+func GenerateTotpKey(email string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      config.MFA_ISSUER,
+		AccountName: email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+}
+
+// ValidateTotpCode cho phép lệch 1 chu kỳ, bỏ qua các step <= lastUsedStep để 1 mã không dùng được 2 lần.
+// Trả về step đã khớp
+func ValidateTotpCode(secret, code string, lastUsedStep int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current - 1, current, current + 1} {
+		if step <= lastUsedStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes sinh các mã dạng xxxxx-xxxxx, chỉ trả về cho user 1 lần, DB lưu bản hash
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		buf := make([]byte, mfaRecoveryCodeLength)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:mfaRecoveryCodeLength]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	return codes, nil
+}
+
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	if len(code) != mfaRecoveryCodeLength {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+func mfaEncryptionKey() ([]byte, error) {
+	if config.MFA_ENCRYPTION_KEY == "" {
+		return nil, ErrMfaNotConfigured
+	}
+	key := sha256.Sum256([]byte(config.MFA_ENCRYPTION_KEY))
+	return key[:], nil
+}
+
+// EncryptMfaSecret mã hoá secret TOTP bằng AES-GCM trước khi lưu DB
+func EncryptMfaSecret(secret string) (string, error) {
+	key, err := mfaEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptMfaSecret(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	key, err := mfaEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("invalid mfa secret")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// GenerateMfaChallengeToken cấp token ngắn hạn sau khi đúng mật khẩu, ký bằng key riêng nên không dùng được như access token
+func GenerateMfaChallengeToken(userId int64, email string) (string, time.Time, error) {
+	if config.MFA_CHALLENGE_SECRET == "" {
+		return "", time.Time{}, ErrMfaNotConfigured
+	}
+	expiresAt := time.Now().Add(mfaChallengeExpiry)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId":  userId,
+		"email":   email,
+		"purpose": mfaChallengePurpose,
+		"exp":     expiresAt.Unix(),
+	})
+	tokenString, err := token.SignedString([]byte(config.MFA_CHALLENGE_SECRET))
+	return tokenString, expiresAt, err
+}
+
+func ParseMfaChallengeToken(tokenString string) (int64, error) {
+	if config.MFA_CHALLENGE_SECRET == "" {
+		return 0, ErrMfaNotConfigured
+	}
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(config.MFA_CHALLENGE_SECRET), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, errors.New("mfa challenge token is invalid or expired")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != mfaChallengePurpose {
+		return 0, errors.New("mfa challenge token is invalid or expired")
+	}
+	userId, ok := claims["userId"].(float64)
+	if !ok {
+		return 0, errors.New("mfa challenge token is invalid or expired")
+	}
+	return int64(userId), nil
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"BE_Manage_device/config"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const testTotpSecret = "JBSWY3DPEHPK3PXP"
+
+func totpCodeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(testTotpSecret, at, totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestValidateTotpCode(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	step := now.Unix() / totpPeriod
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		wantStep     int64
+		wantOk       bool
+	}{
+		{"current step", totpCodeAt(t, now), 0, step, true},
+		{"code with spaces", " " + totpCodeAt(t, now) + " ", 0, step, true},
+		{"previous step is tolerated", totpCodeAt(t, now.Add(-totpPeriod*time.Second)), 0, step - 1, true},
+		{"next step is tolerated", totpCodeAt(t, now.Add(totpPeriod*time.Second)), 0, step + 1, true},
+		{"two steps old", totpCodeAt(t, now.Add(-2*totpPeriod*time.Second)), 0, 0, false},
+		{"replayed step", totpCodeAt(t, now), step, 0, false},
+		{"older than last used step", totpCodeAt(t, now.Add(-totpPeriod*time.Second)), step - 1, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+		{"empty code", "", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTotpCode(testTotpSecret, tt.code, tt.lastUsedStep, now)
+			// Mã sai có thể trùng ngẫu nhiên với 1 step lân cận, chỉ kiểm tra step khi mong đợi khớp
+			if ok != tt.wantOk || (ok && gotStep != tt.wantStep) {
+				t.Errorf("got %d, %v, want %d, %v", gotStep, ok, tt.wantStep, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != mfaRecoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), mfaRecoveryCodeCount)
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{5}-[a-z2-7]{5}$`)
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q has unexpected format", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+		if NormalizeRecoveryCode(code) != code {
+			t.Errorf("generated code %q is not normalized", code)
+		}
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"abcde-fghij", "abcde-fghij"},
+		{"ABCDEFGHIJ", "abcde-fghij"},
+		{"  abcde-fghij\n", "abcde-fghij"},
+		{"ab-cde-fgh-ij", "abcde-fghij"},
+		{"abc", "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMfaSecretEncryption(t *testing.T) {
+	saved := config.MFA_ENCRYPTION_KEY
+	defer func() { config.MFA_ENCRYPTION_KEY = saved }()
+	config.MFA_ENCRYPTION_KEY = "encryption-key"
+	encrypted, err := EncryptMfaSecret(testTotpSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := EncryptMfaSecret(testTotpSecret)
+	if encrypted == again {
+		t.Error("encryption must use a random nonce")
+	}
+	tests := []struct {
+		name      string
+		key       string
+		encrypted string
+		wantErr   bool
+	}{
+		{"same key", "encryption-key", encrypted, false},
+		{"other key", "other-key", encrypted, true},
+		{"not base64", "encryption-key", "%%%", true},
+		{"too short", "encryption-key", "AAAA", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.MFA_ENCRYPTION_KEY = tt.key
+			got, err := DecryptMfaSecret(tt.encrypted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != testTotpSecret {
+				t.Errorf("got %q", got)
+			}
+		})
+	}
+}
+
+func TestMfaChallengeToken(t *testing.T) {
+	saved := config.MFA_CHALLENGE_SECRET
+	defer func() { config.MFA_CHALLENGE_SECRET = saved }()
+	config.MFA_CHALLENGE_SECRET = "challenge-secret"
+	token, _, err := GenerateMfaChallengeToken(42, "a@b.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userId, err := ParseMfaChallengeToken(token)
+	if err != nil || userId != 42 {
+		t.Fatalf("got %d, %v", userId, err)
+	}
+	config.MFA_CHALLENGE_SECRET = "other-secret"
+	if _, err := ParseMfaChallengeToken(token); err == nil {
+		t.Error("token signed with another secret must be rejected")
+	}
+}
+
+func TestMfaNotConfigured(t *testing.T) {
+	savedKey, savedSecret := config.MFA_ENCRYPTION_KEY, config.MFA_CHALLENGE_SECRET
+	defer func() { config.MFA_ENCRYPTION_KEY, config.MFA_CHALLENGE_SECRET = savedKey, savedSecret }()
+	config.MFA_ENCRYPTION_KEY, config.MFA_CHALLENGE_SECRET = "", ""
+	if _, err := EncryptMfaSecret(testTotpSecret); !errors.Is(err, ErrMfaNotConfigured) {
+		t.Errorf("EncryptMfaSecret err = %v", err)
+	}
+	if _, err := DecryptMfaSecret("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"); !errors.Is(err, ErrMfaNotConfigured) {
+		t.Errorf("DecryptMfaSecret err = %v", err)
+	}
+	if _, _, err := GenerateMfaChallengeToken(42, "a@b.c"); !errors.Is(err, ErrMfaNotConfigured) {
+		t.Errorf("GenerateMfaChallengeToken err = %v", err)
+	}
+	if _, err := ParseMfaChallengeToken("token"); !errors.Is(err, ErrMfaNotConfigured) {
+		t.Errorf("ParseMfaChallengeToken err = %v", err)
+	}
+}
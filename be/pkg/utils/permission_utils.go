@@ -1,30 +1,43 @@
 package utils
 
 import (
-	"BE_Manage_device/internal/domain/entity"
-	"slices"
+	"BE_Manage_device/constant"
+	"BE_Manage_device/internal/domain/policy"
+	"BE_Manage_device/pkg"
 
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
-func UserHasPermission(db *gorm.DB, userId int64, permSlug []string, accessLevel []string) (bool, error) {
-	var user entity.Users
-	err := db.Preload("Role.RolePermissions.Permission").
-		First(&user, userId).Error
+const authContextKey = "authContext"
+
+// ResolveAuthContext nạp quyền của user 1 lần cho mỗi request rồi lưu vào gin context,
+// các middleware/handler sau dùng lại thay vì đọc lại user/role
+func ResolveAuthContext(c *gin.Context, db *gorm.DB, userId int64) (*policy.AuthContext, error) {
+	if v, ok := c.Get(authContextKey); ok {
+		if auth, ok := v.(*policy.AuthContext); ok && auth.UserId == userId {
+			return auth, nil
+		}
+	}
+	// Đọc quyền trực tiếp từ DB mỗi request nên thay đổi role có hiệu lực ngay
+	auth, err := policy.LoadAuthContext(db, userId)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	c.Set(authContextKey, auth)
+	return auth, nil
+}
 
-	for _, rolePerm := range user.Role.RolePermissions {
-		if accessLevel == nil {
-			if slices.Contains(permSlug, rolePerm.Permission.Slug) && rolePerm.AccessLevel == "full" {
-				return true, nil
-			}
-		} else {
-			if slices.Contains(permSlug, rolePerm.Permission.Slug) && slices.Contains(accessLevel, rolePerm.AccessLevel) {
-				return true, nil
-			}
-		}
+func GetAuthContextFromContext(c *gin.Context) *policy.AuthContext {
+	v, exists := c.Get(authContextKey)
+	if !exists {
+		log.Error("Happened error when get auth context from gin Context")
+		pkg.PanicExeption(constant.Unauthorized, "Unauthorized Access Token")
+	}
+	auth, ok := v.(*policy.AuthContext)
+	if !ok {
+		pkg.PanicExeption(constant.UnknownError)
 	}
-	return false, nil
+	return auth
 }
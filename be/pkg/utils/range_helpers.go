@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// ReportMaxRangeYears giới hạn độ dài kỳ của các báo cáo theo khoảng thời gian
+const ReportMaxRangeYears = 2
+
+var ErrInvalidRange = errors.New("to must be after from and the range can't be longer than 2 years")
+
+// CheckRange kiểm tra kỳ báo cáo [from, to), đầu mút nil là không giới hạn phía đó
+func CheckRange(from, to *time.Time) error {
+	if from == nil || to == nil {
+		return nil
+	}
+	if !to.After(*from) || to.After(from.AddDate(ReportMaxRangeYears, 0, 0)) {
+		return ErrInvalidRange
+	}
+	return nil
+}
@@ -6,99 +6,176 @@ import (
 	bill "BE_Manage_device/internal/repository/bill"
 	company "BE_Manage_device/internal/repository/company"
 	monthlySummary "BE_Manage_device/internal/repository/monthly_summary"
-	"fmt"
-	"strconv"
-	"strings"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
-func ConvertTCAToStr(TCA map[string]float64) string {
-	var str string
-	for k, v := range TCA {
-		vStr := fmt.Sprintf("%f", v)
-		str += k + ":" + vStr + " "
+type breakdownKey struct {
+	id       int64
+	currency string
+}
+
+// breakdownAccumulator cộng dồn tiền theo từng nhóm và loại tiền tệ, đếm tài sản khác nhau của mỗi nhóm
+type breakdownAccumulator struct {
+	groups map[breakdownKey]*entity.SummaryBreakdown
+	assets map[breakdownKey]map[int64]bool
+}
+
+func newBreakdownAccumulator() *breakdownAccumulator {
+	return &breakdownAccumulator{groups: map[breakdownKey]*entity.SummaryBreakdown{}, assets: map[breakdownKey]map[int64]bool{}}
+}
+
+// add cộng amount vào nhóm id, assetId = nil với dòng không gắn tài sản
+func (acc *breakdownAccumulator) add(id int64, name, currency string, amount float64, assetId *int64) {
+	key := breakdownKey{id: id, currency: currency}
+	item, ok := acc.groups[key]
+	if !ok {
+		item = &entity.SummaryBreakdown{Id: id, Name: name, Currency: currency}
+		acc.groups[key] = item
+		acc.assets[key] = map[int64]bool{}
+	}
+	item.Amount += amount
+	if assetId != nil && !acc.assets[key][*assetId] {
+		acc.assets[key][*assetId] = true
+		item.AssetCount++
 	}
-	return str
 }
 
-func ConvertStrToTCA(TCAStr string) []*dto.TotalCategoryAmountResponse {
-	var TCARes []*dto.TotalCategoryAmountResponse
-	TCA := strings.Split(TCAStr, ` `)
-	for _, tca := range TCA {
-		tcaSplit := strings.Split(tca, `:`)
-		amountParse, _ := strconv.ParseFloat(tcaSplit[1], 64)
-		tcaRes := dto.TotalCategoryAmountResponse{
-			CategoryName: tcaSplit[0],
-			Amount:       amountParse,
-		}
-		TCARes = append(TCARes, &tcaRes)
+// unassignedName là nhóm cho các dòng không gắn tài sản (phí ship, gia hạn bảo hành...)
+const unassignedName = "Unassigned"
+
+func (acc *breakdownAccumulator) items() []entity.SummaryBreakdown {
+	items := make([]entity.SummaryBreakdown, 0, len(acc.groups))
+	for _, item := range acc.groups {
+		items = append(items, *item)
 	}
-	return TCARes
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].Currency < items[j].Currency
+	})
+	return items
 }
 
-func ConvertMonthlySummaryToMonthlySummaryRes(MonthlySummary entity.MonthlySummary) *dto.MonthlySummaryResponse {
-	var TotalCategoryAmountRes []*dto.TotalCategoryAmountResponse
-	if MonthlySummary.TotalCategoryAmount != "" {
-		TotalCategoryAmountRes = ConvertStrToTCA(MonthlySummary.TotalCategoryAmount)
-	} else {
-		TotalCategoryAmountRes = []*dto.TotalCategoryAmountResponse{}
+// BuildMonthlySummary tổng hợp số tiền đã bill (LineTotal, gồm thuế, sau chiết khấu) trong tháng theo category, department và location.
+// Tiền được cộng riêng theo Currency của bill. Dòng gắn tài sản được tính vào nhóm của tài sản đó, dòng còn lại vào nhóm Unassigned (id 0)
+func BuildMonthlySummary(bills []*entity.Bill, companyId int64, month time.Month, year int) entity.MonthlySummary {
+	categories := newBreakdownAccumulator()
+	departments := newBreakdownAccumulator()
+	locations := newBreakdownAccumulator()
+	totals := map[string]*entity.CurrencyTotal{}
+	assetIds := map[int64]bool{}
+	summary := entity.MonthlySummary{
+		Month:       int64(month),
+		Year:        int64(year),
+		GeneratedAt: time.Now(),
+		CompanyId:   companyId,
 	}
-	return &dto.MonthlySummaryResponse{
-		Month:               MonthlySummary.Month,
-		Year:                MonthlySummary.Year,
-		TotalAmount:         MonthlySummary.TotalAmount,
-		BillCount:           MonthlySummary.BillCount,
-		AssetCount:          MonthlySummary.AssetCount,
-		TotalCategoryAmount: TotalCategoryAmountRes,
-		GeneratedAt:         MonthlySummary.GeneratedAt,
+	for _, b := range bills {
+		summary.BillCount++
+		total, ok := totals[b.Currency]
+		if !ok {
+			total = &entity.CurrencyTotal{Currency: b.Currency}
+			totals[b.Currency] = total
+		}
+		total.BillCount++
+		for _, billAsset := range b.BillAssets {
+			assetIds[billAsset.AssetId] = true
+		}
+		for _, item := range b.LineItems {
+			total.Amount += item.LineTotal
+			asset := item.Asset
+			if asset == nil {
+				categories.add(0, unassignedName, b.Currency, item.LineTotal, nil)
+				departments.add(0, unassignedName, b.Currency, item.LineTotal, nil)
+				locations.add(0, unassignedName, b.Currency, item.LineTotal, nil)
+				continue
+			}
+			assetIds[asset.Id] = true
+			categories.add(asset.CategoryId, asset.Category.CategoryName, b.Currency, item.LineTotal, &asset.Id)
+			departments.add(asset.DepartmentId, asset.Department.DepartmentName, b.Currency, item.LineTotal, &asset.Id)
+			locations.add(asset.Department.LocationId, asset.Department.Location.LocationName, b.Currency, item.LineTotal, &asset.Id)
+		}
 	}
+	summary.AssetCount = int64(len(assetIds))
+	summary.Totals = make([]entity.CurrencyTotal, 0, len(totals))
+	for _, total := range totals {
+		summary.Totals = append(summary.Totals, *total)
+	}
+	sort.Slice(summary.Totals, func(i, j int) bool { return summary.Totals[i].Currency < summary.Totals[j].Currency })
+	summary.CategoryBreakdown = categories.items()
+	summary.DepartmentBreakdown = departments.items()
+	summary.LocationBreakdown = locations.items()
+	return summary
+}
+
+// GenerateMonthlySummary tính lại và lưu (ghi đè) summary của 1 công ty cho tháng month/year
+func GenerateMonthlySummary(MonthlySummaryRepo monthlySummary.MonthlySummaryRepository, billRepo bill.BillsRepository, companyId int64, month time.Month, year int) (*entity.MonthlySummary, error) {
+	bills, err := billRepo.GetAllBillOfMonth(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), companyId)
+	if err != nil {
+		return nil, err
+	}
+	summary := BuildMonthlySummary(bills, companyId, month, year)
+	return MonthlySummaryRepo.Upsert(&summary)
 }
 
+// CreateSummary chạy đầu tháng, tổng hợp tháng vừa kết thúc cho tất cả công ty
 func CreateSummary(MonthlySummaryRepo monthlySummary.MonthlySummaryRepository, billRepo bill.BillsRepository, companyRepo company.CompanyRepository) {
-	// company, err := companyRepo.GetAllCompany()
-	// if err != nil {
-	// 	logrus.Infof("Happen error when create summary at: %v", time.Now())
-	// 	return
-	// }
-	// time := time.Now()
-	// month := time.Month()
-	// year := time.Year()
-	// for _, c := range company {
-	// 	var totalAmount float64
-	// 	var BillCount int64
-	// 	var AssetCount int64
-	// 	TotalCategoryAmount := make(map[string]float64)
-	// 	// bills, err := billRepo.GetAllBillOfMonth(time, c.Id)
-	// 	// if err != nil {
-	// 	// 	logrus.Infof("Happen error get bill of company: %v", c.CompanyName)
-	// 	// 	continue
-	// 	// }
-	// 	// for _, b := range bills {
-	// 	// 	BillCount += 1
-	// 	// 	AssetCount += 1
-	// 	// 	// val, ok := TotalCategoryAmount[b.Asset.Category.CategoryName]
-	// 	// 	// if ok {
-	// 	// 	// 	TotalCategoryAmount[b.Asset.Category.CategoryName] = (val + b.Asset.Cost)
-	// 	// 	// } else {
-	// 	// 	// 	TotalCategoryAmount[b.Asset.Category.CategoryName] = b.Asset.Cost
-	// 	// 	// }
-	// 	// 	totalAmount += b.Asset.Cost
-	// 	// }
-	// 	TotalCategoryAmountStr := ConvertTCAToStr(TotalCategoryAmount)
+	companies, err := companyRepo.GetAllCompany()
+	if err != nil {
+		logrus.Infof("Happen error when create summary at: %v", time.Now())
+		return
+	}
+	previousMonth := time.Now().AddDate(0, 0, -time.Now().Day()+1).AddDate(0, -1, 0)
+	for _, c := range companies {
+		if _, err := GenerateMonthlySummary(MonthlySummaryRepo, billRepo, c.Id, previousMonth.Month(), previousMonth.Year()); err != nil {
+			logrus.Infof("Happen error when create summary for company: %v. Error: %v", c.CompanyName, err)
+			continue
+		}
+	}
+}
 
-	// 	monthlySummary := entity.MonthlySummary{
-	// 		Month:               int64(month),
-	// 		Year:                int64(year),
-	// 		GeneratedAt:         time,
-	// 		TotalCategoryAmount: TotalCategoryAmountStr,
-	// 		AssetCount:          AssetCount,
-	// 		BillCount:           BillCount,
-	// 		TotalAmount:         totalAmount,
-	// 		CompanyId:           c.Id,
-	// 	}
-	// 	_, err = MonthlySummaryRepo.Create(&monthlySummary)
-	// 	if err != nil {
-	// 		logrus.Infof("Happen error when create summary for company: %v", c.CompanyName)
-	// 		continue
-	// 	}
-	// }
+func convertBreakdownToResponse(items []entity.SummaryBreakdown) []*dto.SummaryBreakdownResponse {
+	res := make([]*dto.SummaryBreakdownResponse, 0, len(items))
+	for _, item := range items {
+		res = append(res, &dto.SummaryBreakdownResponse{
+			Id:         item.Id,
+			Name:       item.Name,
+			Currency:   item.Currency,
+			Amount:     item.Amount,
+			AssetCount: item.AssetCount,
+		})
+	}
+	return res
+}
+
+func ConvertMonthlySummaryToMonthlySummaryRes(MonthlySummary entity.MonthlySummary) *dto.MonthlySummaryResponse {
+	TotalCategoryAmountRes := make([]*dto.TotalCategoryAmountResponse, 0, len(MonthlySummary.CategoryBreakdown))
+	for _, item := range MonthlySummary.CategoryBreakdown {
+		TotalCategoryAmountRes = append(TotalCategoryAmountRes, &dto.TotalCategoryAmountResponse{
+			CategoryId:   item.Id,
+			CategoryName: item.Name,
+			Currency:     item.Currency,
+			Amount:       item.Amount,
+			AssetCount:   item.AssetCount,
+		})
+	}
+	totals := make([]*dto.CurrencyTotalResponse, 0, len(MonthlySummary.Totals))
+	for _, total := range MonthlySummary.Totals {
+		totals = append(totals, &dto.CurrencyTotalResponse{Currency: total.Currency, Amount: total.Amount, BillCount: total.BillCount})
+	}
+	return &dto.MonthlySummaryResponse{
+		Month:                 MonthlySummary.Month,
+		Year:                  MonthlySummary.Year,
+		Totals:                totals,
+		BillCount:             MonthlySummary.BillCount,
+		AssetCount:            MonthlySummary.AssetCount,
+		TotalCategoryAmount:   TotalCategoryAmountRes,
+		TotalDepartmentAmount: convertBreakdownToResponse(MonthlySummary.DepartmentBreakdown),
+		TotalLocationAmount:   convertBreakdownToResponse(MonthlySummary.LocationBreakdown),
+		GeneratedAt:           MonthlySummary.GeneratedAt,
+	}
 }
@@ -0,0 +1,5 @@
+import { httpRequest } from '@/utils'
+
+export const getUnreadCount = async () => {
+  return await httpRequest.get('/notifications/unread-count')
+}
@@ -1,2 +1,4 @@
 export * from './get-all-notifications'
+export * from './get-unread-count'
+export * from './mark-all-read'
 export * from './update-read-notification'
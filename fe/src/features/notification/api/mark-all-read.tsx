@@ -0,0 +1,5 @@
+import { httpRequest } from '@/utils'
+
+export const markAllNotificationsRead = async () => {
+  return await httpRequest.put('/notifications/read-all')
+}
@@ -1,9 +1,13 @@
 export type NotificationType = {
   id: number
-  assetId: number
+  assetId: number | null
   content: string
   notifyDate: string
   status: string
   type: string
-  userId: number
+}
+
+export type NotificationPage = {
+  items: NotificationType[]
+  nextCursor: number | null
 }
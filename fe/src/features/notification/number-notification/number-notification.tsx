@@ -9,9 +9,9 @@ import {
   LoadingSpinner,
 } from '@/components/ui'
 import { tryCatch } from '@/utils'
-import { getAllNotifications, updateReadNotification } from '../api'
+import { getAllNotifications, getUnreadCount, markAllNotificationsRead, updateReadNotification } from '../api'
 import { toast } from 'sonner'
-import type { NotificationType } from './model'
+import type { NotificationPage, NotificationType } from './model'
 import { useNavigate } from 'react-router-dom'
 import { cn } from '@/lib/utils'
 import Cookies from 'js-cookie'
@@ -44,55 +44,44 @@ const NumberNotification = () => {
   }
   const getNotifications = async () => {
     setIsPending(true)
-    const response = await tryCatch(getAllNotifications())
+    const [response, countResponse] = await Promise.all([
+      tryCatch(getAllNotifications()),
+      tryCatch(getUnreadCount()),
+    ])
+    setIsPending(false)
     if (response.error) {
       toast.error(response.error.message || 'Failed to fetch notifications')
       return
     }
-    const sortedNotifications = response.data.data.sort((a: NotificationType, b: NotificationType) => {
-      return new Date(b.notifyDate).getTime() - new Date(a.notifyDate).getTime()
-    })
-
-    setNotifications(sortedNotifications)
-    setUnreadCount(
-      sortedNotifications.filter((notification: NotificationType) => notification.status === 'pending').length
-    )
-    setIsPending(false)
+    if (countResponse.error) {
+      toast.error(countResponse.error.message || 'Failed to fetch unread notifications')
+      return
+    }
+    // API trả trang đầu của hộp thư, đã sắp xếp mới nhất trước
+    const page: NotificationPage = response.data.data
+    setNotifications(page.items)
+    setUnreadCount(countResponse.data.data.count)
   }
   const markAllAsRead = async () => {
-    const unreadNotifications = notifications.filter((notification) => notification.status === 'pending')
-
-    if (unreadNotifications.length === 0) {
+    if (unreadCount === 0) {
       toast.info('All notifications are already read')
       return
     }
 
     setIsMarkingAllRead(true)
+    const response = await tryCatch(markAllNotificationsRead())
+    setIsMarkingAllRead(false)
 
-    try {
-      const updatePromises = unreadNotifications.map((notification) =>
-        updateReadNotification(notification.id.toString())
-      )
-
-      const results = await Promise.allSettled(updatePromises)
-
-      const failedCount = results.filter((result) => result.status === 'rejected').length
-
-      if (failedCount === 0) {
-        toast.success('All notifications marked as read')
-        await getNotifications()
-      } else {
-        toast.warning(`${unreadNotifications.length - failedCount} notifications marked as read, ${failedCount} failed`)
-        await getNotifications()
-      }
-    } catch (error) {
-      toast.error('Failed to mark notifications as read')
-    } finally {
-      setIsMarkingAllRead(false)
+    if (response.error) {
+      toast.error(response.error.message || 'Failed to mark notifications as read')
+      return
     }
+
+    toast.success('All notifications marked as read')
+    await getNotifications()
   }
 
-  const clickNotification = async (assetId: string, id: string) => {
+  const clickNotification = async (assetId: number | null, id: string) => {
     const response = await tryCatch(updateReadNotification(id))
 
     if (response.error) {
@@ -101,7 +90,9 @@ const NumberNotification = () => {
     }
 
     await getNotifications()
-    navigate(`assets/${assetId}`)
+    if (assetId !== null) {
+      navigate(`assets/${assetId}`)
+    }
   }
 
   const token = Cookies.get('accessToken')
@@ -115,6 +106,10 @@ const NumberNotification = () => {
     eventSource.onmessage = async () => {
       await getNotifications()
     }
+    // Lỡ quá nhiều thông báo khi mất kết nối, server yêu cầu tải lại hộp thư
+    eventSource.addEventListener('notification.resync', async () => {
+      await getNotifications()
+    })
     return () => eventSource.close()
   }, [])
 
@@ -180,7 +175,7 @@ const NumberNotification = () => {
               <DropdownMenuItem
                 key={notification.id}
                 className={cn('focus:bg-accent p-0', 'cursor-pointer')}
-                onClick={() => clickNotification(notification.assetId.toString(), notification.id.toString())}
+                onClick={() => clickNotification(notification.assetId, notification.id.toString())}
               >
                 <div
                   className={cn(